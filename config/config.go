@@ -1,37 +1,218 @@
 package config
 
 import (
+	"errors"
+	"flag"
+	"fmt"
 	"log"
 	"os"
-
-	"github.com/joho/godotenv"
+	"strings"
+	"time"
 )
 
-type config struct {
-	DBHost     string
-	DBPort     string
-	DBUser     string
-	DBPassword string
-	DBName     string
-	DBSSLMode  string
-	JWTSecret  string
+// Config 按 默认值 < 配置文件 < 环境变量 < 命令行参数 的优先级加载。
+// key 为配置文件中的键，也是命令行参数名（如 -server.port）；env 为对应的环境变量。
+type Config struct {
+	Server   ServerConfig   `key:"server"`
+	Database DatabaseConfig `key:"database"`
+	Redis    RedisConfig    `key:"redis"`
+	JWT      JWTConfig      `key:"jwt"`
+	CORS     CORSConfig     `key:"cors"`
+	Cache    CacheConfig    `key:"cache"`
+	Metrics  MetricsConfig  `key:"metrics"`
+	Tracing  TracingConfig  `key:"tracing"`
+	Log      LogConfig      `key:"log"`
+	Trash    TrashConfig    `key:"trash"`
+	Account  AccountConfig  `key:"account"`
+	Webhook  WebhookConfig  `key:"webhook"`
+	Static   StaticConfig   `key:"static"`
+
+	// EditorialWorkflow 开启后文章必须经过审核，且只有编辑可以发布
+	EditorialWorkflow bool `key:"editorial_workflow" env:"EDITORIAL_WORKFLOW" usage:"开启编辑审稿流程"`
+}
+
+type ServerConfig struct {
+	Host            string        `key:"host" env:"SERVER_HOST" usage:"监听地址"`
+	Port            int           `key:"port" env:"SERVER_PORT" usage:"监听端口"`
+	Mode            string        `key:"mode" env:"GIN_MODE" usage:"gin 运行模式 debug/release/test"`
+	ShutdownTimeout time.Duration `key:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT" usage:"收到退出信号后等待请求处理完的最长时间"`
+}
+
+type DatabaseConfig struct {
+	// Driver 为 postgres、mysql 或 sqlite，sqlite 只需要 Path，适合本地开发
+	Driver   string `key:"driver" env:"DB_DRIVER" usage:"数据库驱动 postgres/mysql/sqlite"`
+	Path     string `key:"path" env:"DB_PATH" usage:"SQLite 数据库文件路径"`
+	Host     string `key:"host" env:"DB_HOST" usage:"数据库地址"`
+	Port     int    `key:"port" env:"DB_PORT" usage:"数据库端口"`
+	User     string `key:"user" env:"DB_USER" usage:"数据库用户"`
+	Password string `key:"password" env:"DB_PASSWORD" usage:"数据库密码"`
+	Name     string `key:"name" env:"DB_NAME" usage:"数据库名"`
+	SSLMode  string `key:"sslmode" env:"DB_SSLMODE" usage:"PostgreSQL sslmode"`
+	TimeZone string `key:"timezone" env:"DB_TIMEZONE" usage:"连接时区，如 Asia/Shanghai"`
+
+	AutoMigrate bool `key:"auto_migrate" env:"DB_AUTO_MIGRATE" usage:"启动时自动执行未应用的数据库迁移"`
+}
+
+type RedisConfig struct {
+	Host     string `key:"host" env:"REDIS_HOST" usage:"Redis 地址"`
+	Port     int    `key:"port" env:"REDIS_PORT" usage:"Redis 端口"`
+	Password string `key:"password" env:"REDIS_PASSWORD" usage:"Redis 密码"`
+	DB       int    `key:"db" env:"REDIS_DB" usage:"Redis 库编号"`
+
+	// 连续失败 FailureThreshold 次后熔断，之后每隔 RetryInterval 尝试重连，熔断期间缓存只使用本地一级
+	FailureThreshold int           `key:"failure_threshold" env:"REDIS_FAILURE_THRESHOLD" usage:"连续失败多少次后熔断 Redis"`
+	RetryInterval    time.Duration `key:"retry_interval" env:"REDIS_RETRY_INTERVAL" usage:"熔断后尝试重连 Redis 的间隔"`
 }
 
-var AppConfig *config
+type JWTConfig struct {
+	Secret string        `key:"secret" env:"JWT_SECRET" usage:"JWT 签名密钥"`
+	Expire time.Duration `key:"expire" env:"JWT_EXPIRE" usage:"token 有效期"`
+}
+
+type CORSConfig struct {
+	AllowOrigins     []string      `key:"allow_origins" env:"CORS_ALLOW_ORIGINS" usage:"允许的来源，逗号分隔，为空时不启用 CORS"`
+	AllowMethods     []string      `key:"allow_methods" env:"CORS_ALLOW_METHODS" usage:"允许的方法，逗号分隔"`
+	AllowHeaders     []string      `key:"allow_headers" env:"CORS_ALLOW_HEADERS" usage:"允许的请求头，逗号分隔"`
+	AllowCredentials bool          `key:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS" usage:"是否允许携带凭证"`
+	MaxAge           time.Duration `key:"max_age" env:"CORS_MAX_AGE" usage:"预检请求缓存时间"`
+}
+
+// CacheConfig 是读接口的两级缓存配置：进程内 LRU 在前，Redis 在后
+type CacheConfig struct {
+	PostListTTL time.Duration `key:"post_list_ttl" env:"CACHE_POST_LIST_TTL" usage:"文章列表缓存时间"`
+	ReadTTL     time.Duration `key:"read_ttl" env:"CACHE_READ_TTL" usage:"文章详情、标签文章、评论和点赞数的缓存时间"`
+	LocalTTL    time.Duration `key:"local_ttl" env:"CACHE_LOCAL_TTL" usage:"本地缓存的最长时间，其他副本的失效消息丢失时最多读到这么久的旧数据"`
+	LocalSize   int           `key:"local_size" env:"CACHE_LOCAL_SIZE" usage:"本地缓存的最大条数，0 表示不使用本地缓存"`
+}
 
-func LoadConfig() {
-	err := godotenv.Load()
+type MetricsConfig struct {
+	Enabled bool `key:"enabled" env:"METRICS_ENABLED" usage:"是否开放 /metrics 供 Prometheus 抓取"`
+}
+
+// TracingConfig 是 OpenTelemetry 链路追踪配置，Exporter 为 none 时不导出
+type TracingConfig struct {
+	Exporter    string  `key:"exporter" env:"TRACING_EXPORTER" usage:"链路追踪导出方式 none/stdout/otlp"`
+	Endpoint    string  `key:"endpoint" env:"TRACING_ENDPOINT" usage:"OTLP/HTTP collector 地址"`
+	ServiceName string  `key:"service_name" env:"TRACING_SERVICE_NAME" usage:"上报的服务名"`
+	SampleRatio float64 `key:"sample_ratio" env:"TRACING_SAMPLE_RATIO" usage:"采样比例 0-1，上游已采样的请求总是记录"`
+}
+
+type LogConfig struct {
+	Level  string `key:"level" env:"LOG_LEVEL" usage:"日志级别 debug/info/warn/error"`
+	Format string `key:"format" env:"LOG_FORMAT" usage:"日志格式 json/text"`
+	// SlowQuery 为 0 时不记录慢查询
+	SlowQuery time.Duration `key:"slow_query" env:"LOG_SLOW_QUERY" usage:"超过该时间的 SQL 记为慢查询"`
+}
+
+type TrashConfig struct {
+	// Retention 为 0 时不自动清理回收站
+	Retention time.Duration `key:"retention" env:"TRASH_RETENTION" usage:"删除的文章在回收站中保留的时间，过期后永久删除"`
+}
+
+type WebhookConfig struct {
+	// AllowPrivate 允许投递到回环、内网和链路本地地址，只应在本地调试时开启
+	AllowPrivate bool `key:"allow_private" env:"WEBHOOK_ALLOW_PRIVATE" usage:"允许 webhook 投递到内网地址"`
+}
+
+type AccountConfig struct {
+	// DeletionGracePeriod 内用户可以撤销注销申请，为 0 时后台任务下一次检查就执行
+	DeletionGracePeriod time.Duration `key:"deletion_grace_period" env:"ACCOUNT_DELETION_GRACE_PERIOD" usage:"申请注销后多久执行，期间可以撤销"`
+	ExportRetention     time.Duration `key:"export_retention" env:"ACCOUNT_EXPORT_RETENTION" usage:"生成的数据导出文件保留多久"`
+}
+
+// StaticConfig 是 export 子命令生成静态站点的配置
+type StaticConfig struct {
+	// BaseURL 是静态站点的访问地址，feed 和 sitemap 中的链接是绝对地址，导出时必须设置
+	BaseURL     string `key:"base_url" env:"STATIC_BASE_URL" usage:"静态站点的访问地址，如 https://blog.example.com/"`
+	Title       string `key:"title" env:"STATIC_TITLE" usage:"站点标题"`
+	Description string `key:"description" env:"STATIC_DESCRIPTION" usage:"站点简介"`
+	OutDir      string `key:"out_dir" env:"STATIC_OUT_DIR" usage:"静态站点的输出目录"`
+	Templates   string `key:"templates" env:"STATIC_TEMPLATES" usage:"自定义模板目录，其中的同名文件覆盖内置模板"`
+	PageSize    int    `key:"page_size" env:"STATIC_PAGE_SIZE" usage:"列表页每页的文章数"`
+	// RawHTML 开启后保留正文中的 HTML（从 WordPress 导入的文章需要），只应在信任所有作者时开启
+	RawHTML bool `key:"raw_html" env:"STATIC_RAW_HTML" usage:"保留文章正文中的 HTML"`
+}
+
+var AppConfig *Config
+
+// Default 返回默认配置
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{Port: 8080, Mode: "debug", ShutdownTimeout: 15 * time.Second},
+		Database: DatabaseConfig{
+			Driver:      "postgres",
+			Path:        "goblog.db",
+			Host:        "localhost",
+			Port:        5432,
+			User:        "postgres",
+			Name:        "goblog",
+			SSLMode:     "disable",
+			AutoMigrate: true,
+		},
+		Redis: RedisConfig{
+			Host:             "localhost",
+			Port:             6379,
+			FailureThreshold: 5,
+			RetryInterval:    5 * time.Second,
+		},
+		JWT: JWTConfig{Expire: 72 * time.Hour},
+		CORS: CORSConfig{
+			AllowMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+			AllowHeaders: []string{"Authorization", "Content-Type", "Accept-Language"},
+			MaxAge:       12 * time.Hour,
+		},
+		Cache: CacheConfig{
+			PostListTTL: 30 * time.Second,
+			ReadTTL:     time.Minute,
+			LocalTTL:    5 * time.Second,
+			LocalSize:   1000,
+		},
+		Metrics: MetricsConfig{Enabled: true},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			Endpoint:    "http://localhost:4318",
+			ServiceName: "goblog",
+			SampleRatio: 1,
+		},
+		Log:   LogConfig{Level: "info", Format: "json", SlowQuery: 200 * time.Millisecond},
+		Trash: TrashConfig{Retention: 30 * 24 * time.Hour},
+		Account: AccountConfig{
+			DeletionGracePeriod: 14 * 24 * time.Hour,
+			ExportRetention:     7 * 24 * time.Hour,
+		},
+		Static: StaticConfig{Title: "GoBlog", OutDir: "public", PageSize: 10},
+	}
+}
+
+// LoadConfig 从命令行参数、环境变量和配置文件加载配置，有问题时列出全部错误后退出。
+// 返回参数之后的子命令，如 migrate up
+func LoadConfig() []string {
+	conf, args, err := Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
 	if err != nil {
-		log.Fatal("Error loading .env file")
+		log.Fatalf("配置加载失败:\n%v", err)
 	}
+	AppConfig = conf
+	return args
+}
 
-	AppConfig = &config{
-		DBHost:     os.Getenv("DB_HOST"),
-		DBPort:     os.Getenv("DB_PORT"),
-		DBUser:     os.Getenv("DB_USER"),
-		DBPassword: os.Getenv("DB_PASSWORD"),
-		DBName:     os.Getenv("DB_NAME"),
-		DBSSLMode:  os.Getenv("DB_SSLMODE"),
-		JWTSecret:  os.Getenv("JWT_SECRET"),
+func (s ServerConfig) Addr() string {
+	return fmt.Sprintf("%s:%d", s.Host, s.Port)
+}
+
+// DSN 生成 PostgreSQL 连接串，值都加引号，避免空密码或含空格的值被错误解析
+func (d DatabaseConfig) DSN() string {
+	quote := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace
+	dsn := fmt.Sprintf("host='%s' user='%s' password='%s' dbname='%s' port=%d sslmode='%s'",
+		quote(d.Host), quote(d.User), quote(d.Password), quote(d.Name), d.Port, quote(d.SSLMode))
+	if d.TimeZone != "" {
+		dsn += fmt.Sprintf(" TimeZone='%s'", quote(d.TimeZone))
 	}
+	return dsn
+}
+
+func (r RedisConfig) Addr() string {
+	return fmt.Sprintf("%s:%d", r.Host, r.Port)
 }
@@ -0,0 +1,254 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Errors 汇总加载和校验过程中发现的所有问题，便于一次性修正
+type Errors []string
+
+func (e Errors) Error() string {
+	return "  - " + strings.Join(e, "\n  - ")
+}
+
+// defaultFiles 未指定 -config 时在工作目录中依次查找的配置文件，都不存在时只使用默认值和环境变量
+var defaultFiles = []string{"config.yaml", "config.yml", "config.toml"}
+
+type field struct {
+	key   string
+	env   string
+	usage string
+	value reflect.Value
+}
+
+// Load 依次应用默认值、配置文件、环境变量和命令行参数，然后校验结果。
+// 参数需要写在子命令之前，第一个非参数之后的内容原样返回
+func Load(args []string) (*Config, []string, error) {
+	// .env 只是本地开发的便利，不存在时忽略，也不会覆盖已有的环境变量
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, fmt.Errorf(".env 解析失败: %w", err)
+	}
+
+	conf := Default()
+	fields := collectFields(reflect.ValueOf(conf).Elem(), "")
+	byKey := make(map[string]field, len(fields))
+
+	flags := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+	configFile := flags.String("config", os.Getenv("GOBLOG_CONFIG"), "配置文件路径，支持 .yaml/.yml/.toml (env GOBLOG_CONFIG)")
+	for _, f := range fields {
+		byKey[f.key] = f
+		usage := f.usage
+		if f.env != "" {
+			usage += " (env " + f.env + ")"
+		}
+		if f.value.Kind() == reflect.Bool {
+			flags.Var(new(boolFlag), f.key, usage)
+		} else {
+			flags.String(f.key, "", usage)
+		}
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	var problems Errors
+
+	path, required := *configFile, *configFile != ""
+	if !required {
+		for _, name := range defaultFiles {
+			if _, err := os.Stat(name); err == nil {
+				path = name
+				break
+			}
+		}
+	}
+	if path != "" {
+		values, err := readFile(path)
+		if err != nil && (required || !errors.Is(err, fs.ErrNotExist)) {
+			problems = append(problems, fmt.Sprintf("读取配置文件 %s 失败: %v", path, err))
+		}
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			f, ok := byKey[key]
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s: 未知的配置项 %s", path, key))
+				continue
+			}
+			if err := setValue(f.value, values[key]); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %s: %v", path, key, err))
+			}
+		}
+	}
+
+	for _, f := range fields {
+		if f.env == "" {
+			continue
+		}
+		if v, ok := os.LookupEnv(f.env); ok {
+			if err := setString(f.value, v); err != nil {
+				problems = append(problems, fmt.Sprintf("环境变量 %s: %v", f.env, err))
+			}
+		}
+	}
+
+	flags.Visit(func(fl *flag.Flag) {
+		if f, ok := byKey[fl.Name]; ok {
+			if err := setString(f.value, fl.Value.String()); err != nil {
+				problems = append(problems, fmt.Sprintf("参数 -%s: %v", fl.Name, err))
+			}
+		}
+	})
+
+	problems = append(problems, conf.check()...)
+	if len(problems) > 0 {
+		return nil, nil, problems
+	}
+	return conf, flags.Args(), nil
+}
+
+// boolFlag 让布尔配置项可以只写 -key 表示 true，值和其他参数一样在最后统一校验
+type boolFlag string
+
+func (b *boolFlag) String() string     { return string(*b) }
+func (b *boolFlag) Set(s string) error { *b = boolFlag(s); return nil }
+func (b *boolFlag) IsBoolFlag() bool   { return true }
+
+// collectFields 展开嵌套的配置结构体，key 用 . 连接
+func collectFields(v reflect.Value, prefix string) []field {
+	var fields []field
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		key := sf.Tag.Get("key")
+		if key == "" {
+			continue
+		}
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if sf.Type.Kind() == reflect.Struct {
+			fields = append(fields, collectFields(v.Field(i), key)...)
+			continue
+		}
+		fields = append(fields, field{key: key, env: sf.Tag.Get("env"), usage: sf.Tag.Get("usage"), value: v.Field(i)})
+	}
+	return fields
+}
+
+// readFile 读取配置文件并展开为 server.port 形式的键
+func readFile(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := map[string]any{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("不支持的配置文件格式 %q", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]any{}
+	flatten("", raw, values)
+	return values, nil
+}
+
+func flatten(prefix string, in map[string]any, out map[string]any) {
+	for k, v := range in {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if nested, ok := v.(map[string]any); ok {
+			flatten(key, nested, out)
+			continue
+		}
+		out[key] = v
+	}
+}
+
+func setValue(v reflect.Value, raw any) error {
+	list, ok := raw.([]any)
+	if !ok {
+		return setString(v, fmt.Sprint(raw))
+	}
+	if v.Kind() != reflect.Slice {
+		return errors.New("不能是列表")
+	}
+	items := make([]string, len(list))
+	for i, item := range list {
+		items[i] = fmt.Sprint(item)
+	}
+	v.Set(reflect.ValueOf(items))
+	return nil
+}
+
+func setString(v reflect.Value, s string) error {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("%q 不是有效的时长，例如 30s、5m、72h", s)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("%q 不是整数", s)
+		}
+		v.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("%q 不是数字", s)
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("%q 不是布尔值", s)
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("不支持的配置类型 %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,278 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// isolate 在空的临时目录中运行，并清掉所有配置项对应的环境变量，避免读到开发机上的 .env 和 config.yaml
+func isolate(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+	for _, f := range collectFields(reflect.ValueOf(Default()).Elem(), "") {
+		if f.env != "" {
+			t.Setenv(f.env, "")
+			os.Unsetenv(f.env)
+		}
+	}
+	t.Setenv("GOBLOG_CONFIG", "")
+	os.Unsetenv("GOBLOG_CONFIG")
+	return dir
+}
+
+func TestLoad(t *testing.T) {
+	const secret = "0123456789abcdef"
+
+	for _, c := range []struct {
+		name  string
+		files map[string]string
+		env   map[string]string
+		args  []string
+		rest  []string
+		check func(t *testing.T, conf *Config)
+	}{
+		{
+			name: "默认值",
+			env:  map[string]string{"JWT_SECRET": secret},
+			check: func(t *testing.T, conf *Config) {
+				want := Default()
+				want.JWT.Secret = secret
+				if !reflect.DeepEqual(conf, want) {
+					t.Errorf("得到 %+v，期望默认值", conf)
+				}
+			},
+		},
+		{
+			name: "YAML 文件",
+			files: map[string]string{"config.yaml": `
+server:
+  port: 9000
+  shutdown_timeout: 30s
+jwt:
+  secret: ` + secret + `
+cors:
+  allow_origins: [https://a.example.com, https://b.example.com]
+tracing:
+  sample_ratio: 0.25
+metrics:
+  enabled: false
+`},
+			check: func(t *testing.T, conf *Config) {
+				if conf.Server.Port != 9000 || conf.Server.ShutdownTimeout != 30*time.Second || conf.JWT.Secret != secret {
+					t.Errorf("server = %+v, jwt = %+v", conf.Server, conf.JWT)
+				}
+				if want := []string{"https://a.example.com", "https://b.example.com"}; !slices.Equal(conf.CORS.AllowOrigins, want) {
+					t.Errorf("cors.allow_origins = %q，期望 %q", conf.CORS.AllowOrigins, want)
+				}
+				if conf.Tracing.SampleRatio != 0.25 || conf.Metrics.Enabled {
+					t.Errorf("tracing.sample_ratio = %v, metrics.enabled = %v", conf.Tracing.SampleRatio, conf.Metrics.Enabled)
+				}
+				// 文件中没有的项保持默认值
+				if conf.Redis.Port != 6379 {
+					t.Errorf("redis.port = %d，期望默认值 6379", conf.Redis.Port)
+				}
+			},
+		},
+		{
+			name: "TOML 文件",
+			files: map[string]string{"config.toml": `
+editorial_workflow = true
+
+[server]
+port = 9000
+shutdown_timeout = "30s"
+
+[jwt]
+secret = "` + secret + `"
+
+[cors]
+allow_origins = ["https://a.example.com"]
+`},
+			check: func(t *testing.T, conf *Config) {
+				if conf.Server.Port != 9000 || conf.Server.ShutdownTimeout != 30*time.Second || conf.JWT.Secret != secret {
+					t.Errorf("server = %+v, jwt = %+v", conf.Server, conf.JWT)
+				}
+				if !slices.Equal(conf.CORS.AllowOrigins, []string{"https://a.example.com"}) || !conf.EditorialWorkflow {
+					t.Errorf("cors.allow_origins = %q, editorial_workflow = %v", conf.CORS.AllowOrigins, conf.EditorialWorkflow)
+				}
+			},
+		},
+		{
+			name: "-config 指定的文件优先于工作目录中的默认文件",
+			files: map[string]string{
+				"config.yaml":      "server:\n  port: 9000\n",
+				"conf/custom.yaml": "server:\n  port: 9100\n",
+			},
+			env:  map[string]string{"JWT_SECRET": secret},
+			args: []string{"-config", "conf/custom.yaml"},
+			check: func(t *testing.T, conf *Config) {
+				if conf.Server.Port != 9100 {
+					t.Errorf("server.port = %d，期望 9100", conf.Server.Port)
+				}
+			},
+		},
+		{
+			name:  "环境变量覆盖配置文件",
+			files: map[string]string{"config.yaml": "server:\n  port: 9000\n  mode: release\ncors:\n  allow_origins: [https://a.example.com]\n"},
+			env: map[string]string{
+				"JWT_SECRET":         secret,
+				"SERVER_PORT":        "9100",
+				"CORS_ALLOW_ORIGINS": "https://b.example.com, https://c.example.com,",
+			},
+			check: func(t *testing.T, conf *Config) {
+				if conf.Server.Port != 9100 || conf.Server.Mode != "release" {
+					t.Errorf("server = %+v，期望端口来自环境变量、模式来自文件", conf.Server)
+				}
+				if want := []string{"https://b.example.com", "https://c.example.com"}; !slices.Equal(conf.CORS.AllowOrigins, want) {
+					t.Errorf("cors.allow_origins = %q，期望 %q", conf.CORS.AllowOrigins, want)
+				}
+			},
+		},
+		{
+			name:  "命令行参数覆盖环境变量和配置文件",
+			files: map[string]string{"config.yaml": "server:\n  port: 9000\n  mode: release\n"},
+			env:   map[string]string{"JWT_SECRET": secret, "SERVER_PORT": "9100", "LOG_LEVEL": "warn"},
+			args:  []string{"-server.port", "9200", "-log.level=debug", "migrate", "up", "-server.port", "1"},
+			rest:  []string{"migrate", "up", "-server.port", "1"},
+			check: func(t *testing.T, conf *Config) {
+				if conf.Server.Port != 9200 || conf.Server.Mode != "release" || conf.Log.Level != "debug" {
+					t.Errorf("server = %+v, log.level = %q", conf.Server, conf.Log.Level)
+				}
+			},
+		},
+		{
+			name: "只写参数名的布尔参数为 true",
+			env:  map[string]string{"JWT_SECRET": secret, "METRICS_ENABLED": "false"},
+			args: []string{"-editorial_workflow", "-metrics.enabled", "-database.auto_migrate=false", "export"},
+			rest: []string{"export"},
+			check: func(t *testing.T, conf *Config) {
+				if !conf.EditorialWorkflow || !conf.Metrics.Enabled || conf.Database.AutoMigrate {
+					t.Errorf("editorial_workflow = %v, metrics.enabled = %v, database.auto_migrate = %v",
+						conf.EditorialWorkflow, conf.Metrics.Enabled, conf.Database.AutoMigrate)
+				}
+			},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			dir := isolate(t)
+			for name, content := range c.files {
+				path := filepath.Join(dir, name)
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for k, v := range c.env {
+				t.Setenv(k, v)
+			}
+
+			conf, rest, err := Load(c.args)
+			if err != nil {
+				t.Fatalf("加载失败:\n%v", err)
+			}
+			if !slices.Equal(rest, c.rest) {
+				t.Errorf("剩余参数 = %q，期望 %q", rest, c.rest)
+			}
+			c.check(t, conf)
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		files map[string]string
+		env   map[string]string
+		args  []string
+		want  []string
+	}{
+		{
+			name: "各来源的问题一次性全部列出",
+			files: map[string]string{"config.yaml": `
+server:
+  port: abc
+  prot: 80
+redis:
+  host: [a, b]
+`},
+			env:  map[string]string{"JWT_EXPIRE": "3 days"},
+			args: []string{"-editorial_workflow=maybe", "-tracing.exporter", "jaeger"},
+			want: []string{
+				"config.yaml: redis.host: 不能是列表",
+				"config.yaml: server.port: \"abc\" 不是整数",
+				"config.yaml: 未知的配置项 server.prot",
+				"环境变量 JWT_EXPIRE: \"3 days\" 不是有效的时长，例如 30s、5m、72h",
+				"参数 -editorial_workflow: \"maybe\" 不是布尔值",
+				"jwt.secret 不能为空 (JWT_SECRET)",
+				"tracing.exporter 只能是 none、stdout 或 otlp，当前为 \"jaeger\"",
+			},
+		},
+		{
+			name: "校验合并后的配置",
+			env:  map[string]string{"JWT_SECRET": "short", "GIN_MODE": "release", "DB_DRIVER": "sqlite", "DB_PATH": ""},
+			args: []string{"-cors.allow_origins", "*", "-cors.allow_credentials"},
+			want: []string{
+				"database.path 不能为空 (DB_PATH)",
+				"release 模式下 jwt.secret 至少需要 16 个字符",
+				"cors.allow_credentials 开启时 cors.allow_origins 不能包含 *",
+			},
+		},
+		{
+			name: "-config 指定的文件不存在",
+			env:  map[string]string{"JWT_SECRET": "0123456789abcdef"},
+			args: []string{"-config", "missing.yaml"},
+			want: []string{"读取配置文件 missing.yaml 失败"},
+		},
+		{
+			name:  "不支持的文件格式",
+			files: map[string]string{"config.json": "{}"},
+			env:   map[string]string{"JWT_SECRET": "0123456789abcdef", "GOBLOG_CONFIG": "config.json"},
+			want:  []string{"读取配置文件 config.json 失败: 不支持的配置文件格式 \".json\""},
+		},
+		{
+			name:  "YAML 语法错误",
+			files: map[string]string{"config.yml": "server: [port: 1\n"},
+			env:   map[string]string{"JWT_SECRET": "0123456789abcdef"},
+			want:  []string{"读取配置文件 config.yml 失败"},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			dir := isolate(t)
+			for name, content := range c.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for k, v := range c.env {
+				t.Setenv(k, v)
+			}
+
+			conf, _, err := Load(c.args)
+			if conf != nil {
+				t.Fatal("有问题时不应返回配置")
+			}
+			var problems Errors
+			if !errors.As(err, &problems) {
+				t.Fatalf("返回 %T: %v，期望 Errors", err, err)
+			}
+			for _, want := range c.want {
+				if !slices.ContainsFunc(problems, func(p string) bool { return strings.HasPrefix(p, want) }) {
+					t.Errorf("缺少问题 %q，得到:\n%v", want, problems)
+				}
+			}
+			if len(problems) != len(c.want) {
+				t.Errorf("得到 %d 个问题，期望 %d 个:\n%v", len(problems), len(c.want), problems)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+)
+
+var (
+	serverModes  = []string{"debug", "release", "test"}
+	sslModes     = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+	exporters    = []string{"none", "stdout", "otlp"}
+	logLevels    = []string{"debug", "info", "warn", "error"}
+	logFormats   = []string{"json", "text"}
+	minSecretLen = 16
+)
+
+// Validate 检查配置是否合法，返回的 Errors 包含全部问题
+func (c *Config) Validate() error {
+	if problems := c.check(); len(problems) > 0 {
+		return Errors(problems)
+	}
+	return nil
+}
+
+func (c *Config) check() []string {
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if !validPort(c.Server.Port) {
+		add("server.port 必须在 1-65535 之间，当前为 %d", c.Server.Port)
+	}
+	if !slices.Contains(serverModes, c.Server.Mode) {
+		add("server.mode 只能是 debug、release 或 test，当前为 %q", c.Server.Mode)
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		add("server.shutdown_timeout 必须大于 0")
+	}
+
+	switch c.Database.Driver {
+	case "sqlite":
+		if c.Database.Path == "" {
+			add("database.path 不能为空 (DB_PATH)")
+		}
+	case "postgres", "mysql":
+		if c.Database.Host == "" {
+			add("database.host 不能为空 (DB_HOST)")
+		}
+		if !validPort(c.Database.Port) {
+			add("database.port 必须在 1-65535 之间，当前为 %d", c.Database.Port)
+		}
+		if c.Database.User == "" {
+			add("database.user 不能为空 (DB_USER)")
+		}
+		if c.Database.Name == "" {
+			add("database.name 不能为空 (DB_NAME)")
+		}
+		if c.Database.Driver == "postgres" && !slices.Contains(sslModes, c.Database.SSLMode) {
+			add("database.sslmode 无效: %q", c.Database.SSLMode)
+		}
+	default:
+		add("database.driver 只能是 postgres、mysql 或 sqlite，当前为 %q", c.Database.Driver)
+	}
+	if c.Database.TimeZone != "" {
+		if _, err := time.LoadLocation(c.Database.TimeZone); err != nil {
+			add("database.timezone 无效: %q", c.Database.TimeZone)
+		}
+	}
+
+	if c.Redis.Host == "" {
+		add("redis.host 不能为空 (REDIS_HOST)")
+	}
+	if !validPort(c.Redis.Port) {
+		add("redis.port 必须在 1-65535 之间，当前为 %d", c.Redis.Port)
+	}
+	if c.Redis.DB < 0 {
+		add("redis.db 不能为负数")
+	}
+	if c.Redis.FailureThreshold <= 0 {
+		add("redis.failure_threshold 必须大于 0")
+	}
+	if c.Redis.RetryInterval <= 0 {
+		add("redis.retry_interval 必须大于 0")
+	}
+
+	if c.JWT.Secret == "" {
+		add("jwt.secret 不能为空 (JWT_SECRET)")
+	} else if c.Server.Mode == "release" && len(c.JWT.Secret) < minSecretLen {
+		add("release 模式下 jwt.secret 至少需要 %d 个字符", minSecretLen)
+	}
+	if c.JWT.Expire <= 0 {
+		add("jwt.expire 必须大于 0")
+	}
+
+	if c.CORS.AllowCredentials && slices.Contains(c.CORS.AllowOrigins, "*") {
+		add("cors.allow_credentials 开启时 cors.allow_origins 不能包含 *")
+	}
+	if c.CORS.MaxAge < 0 {
+		add("cors.max_age 不能为负数")
+	}
+
+	if c.Cache.PostListTTL < 0 {
+		add("cache.post_list_ttl 不能为负数")
+	}
+	if c.Cache.ReadTTL < 0 {
+		add("cache.read_ttl 不能为负数")
+	}
+	if c.Cache.LocalTTL < 0 {
+		add("cache.local_ttl 不能为负数")
+	}
+	if c.Cache.LocalSize < 0 {
+		add("cache.local_size 不能为负数")
+	}
+
+	if !slices.Contains(exporters, c.Tracing.Exporter) {
+		add("tracing.exporter 只能是 none、stdout 或 otlp，当前为 %q", c.Tracing.Exporter)
+	}
+	if c.Tracing.Exporter == "otlp" {
+		if u, err := url.Parse(c.Tracing.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("tracing.endpoint 必须是 http:// 或 https:// 开头的地址，当前为 %q", c.Tracing.Endpoint)
+		}
+	}
+	if c.Tracing.ServiceName == "" {
+		add("tracing.service_name 不能为空")
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		add("tracing.sample_ratio 必须在 0-1 之间，当前为 %v", c.Tracing.SampleRatio)
+	}
+
+	if !slices.Contains(logLevels, c.Log.Level) {
+		add("log.level 只能是 debug、info、warn 或 error，当前为 %q", c.Log.Level)
+	}
+	if !slices.Contains(logFormats, c.Log.Format) {
+		add("log.format 只能是 json 或 text，当前为 %q", c.Log.Format)
+	}
+	if c.Log.SlowQuery < 0 {
+		add("log.slow_query 不能为负数")
+	}
+	if c.Trash.Retention < 0 {
+		add("trash.retention 不能为负数")
+	}
+	if c.Account.DeletionGracePeriod < 0 {
+		add("account.deletion_grace_period 不能为负数")
+	}
+	if c.Account.ExportRetention <= 0 {
+		add("account.export_retention 必须大于 0")
+	}
+	if c.Static.BaseURL != "" {
+		if u, err := url.Parse(c.Static.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("static.base_url 必须是 http:// 或 https:// 开头的地址，当前为 %q", c.Static.BaseURL)
+		}
+	}
+	if c.Static.OutDir == "" {
+		add("static.out_dir 不能为空 (STATIC_OUT_DIR)")
+	}
+	if c.Static.PageSize <= 0 {
+		add("static.page_size 必须大于 0")
+	}
+	return problems
+}
+
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
@@ -0,0 +1,243 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"goblog/config"
+	"goblog/database"
+	"goblog/models"
+	"goblog/pkg/account"
+	"goblog/pkg/apperror"
+	"goblog/pkg/audit"
+	"goblog/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RequestExport godoc
+// @Summary 申请导出个人数据
+// @Description 后台生成 ZIP，包含资料、文章（带 front matter 的 Markdown）、评论和点赞。已有排队中的导出时直接返回它
+// @Tags 账号
+// @Accept json
+// @Produce json
+// @Success 202 {object} map[string]interface{}
+// @Router /account/exports [post]
+// @Security ApiKeyAuth
+func RequestExport(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	var export models.DataExport
+	err := database.DB.Omit("data").Where("user_id = ? AND status = ?", userID, models.ExportPending).First(&export).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		export = models.DataExport{UserID: userID, Status: models.ExportPending}
+		err = database.DB.Create(&export).Error
+	}
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	account.Notify()
+	c.JSON(http.StatusAccepted, gin.H{"export": export})
+}
+
+// GetExports godoc
+// @Summary 获取我的数据导出
+// @Description 新的在前，status 为 done 后可以下载，过期（expires_at）后自动删除
+// @Tags 账号
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /account/exports [get]
+// @Security ApiKeyAuth
+func GetExports(c *gin.Context) {
+	var exports []models.DataExport
+	if err := database.DB.Omit("data").Where("user_id = ?", c.MustGet("user_id").(uint)).
+		Order("id desc").Find(&exports).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"exports": exports})
+}
+
+// DownloadExport godoc
+// @Summary 下载数据导出
+// @Tags 账号
+// @Produce application/zip
+// @Param id path int true "导出 ID"
+// @Success 200 {file} file
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Router /account/exports/{id}/download [get]
+// @Security ApiKeyAuth
+func DownloadExport(c *gin.Context) {
+	exportID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidExportID))
+		return
+	}
+
+	var export models.DataExport
+	err = database.DB.Where("id = ? AND user_id = ?", exportID, c.MustGet("user_id").(uint)).First(&export).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.Error(apperror.NotFound(apperror.CodeExportNotFound))
+		return
+	}
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	if export.Status != models.ExportDone {
+		c.Error(apperror.Conflict(apperror.CodeExportNotReady))
+		return
+	}
+
+	filename := fmt.Sprintf("goblog-export-%d-%s.zip", export.ID, export.CreatedAt.Format("20060102"))
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, "application/zip", export.Data)
+}
+
+type DeleteAccountInput struct {
+	Password string `json:"password" binding:"required"`
+	// Posts 为 transfer 时文章和系列转给 TransferTo，为 delete 时永久删除
+	Posts      string `json:"posts" binding:"required,oneof=delete transfer"`
+	TransferTo uint   `json:"transfer_to"`
+}
+
+// RequestAccountDeletion godoc
+// @Summary 申请注销账号
+// @Description 需要输入密码确认，account.deletion_grace_period 之后执行，之前可以撤销。
+// @Description 注销时删除点赞、收藏、关注和 webhook，评论保留并匿名化，文章按 posts 转给其他用户或永久删除
+// @Tags 账号
+// @Accept json
+// @Produce json
+// @Param deletion body DeleteAccountInput true "注销选项"
+// @Success 202 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Router /account/deletion [post]
+// @Security ApiKeyAuth
+func RequestAccountDeletion(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	var input DeleteAccountInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperror.FromBind(err))
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	if !utils.CheckPasswordHash(input.Password, user.Password) {
+		c.Error(apperror.Forbidden(apperror.CodeWrongPassword))
+		return
+	}
+
+	deletion := models.AccountDeletion{
+		UserID:      userID,
+		PostAction:  input.Posts,
+		RequestedAt: time.Now(),
+		ExecuteAt:   time.Now().Add(config.AppConfig.Account.DeletionGracePeriod),
+	}
+	if input.Posts == models.TransferPosts {
+		var target models.User
+		err := database.DB.Select("id", "deleted_at").First(&target, input.TransferTo).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) || err == nil && (target.ID == userID || !target.DeletedAt.IsZero()) {
+			c.Error(apperror.BadRequest(apperror.CodeInvalidTransferTo))
+			return
+		}
+		if err != nil {
+			c.Error(apperror.Internal(err))
+			return
+		}
+		deletion.TransferToID = &target.ID
+	}
+
+	var count int64
+	if err := database.DB.Model(&models.AccountDeletion{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	if count > 0 {
+		c.Error(apperror.Conflict(apperror.CodeDeletionPending))
+		return
+	}
+	if err := database.DB.Create(&deletion).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	recordAudit(c, audit.Entry{Action: audit.ActionAccountDeletionRequest, TargetType: "user", TargetID: userID, After: deletion})
+
+	account.Notify()
+	c.JSON(http.StatusAccepted, gin.H{"message": "已申请注销", "deletion": deletion})
+}
+
+// GetAccountDeletion godoc
+// @Summary 查看注销申请
+// @Tags 账号
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} apperror.Problem
+// @Router /account/deletion [get]
+// @Security ApiKeyAuth
+func GetAccountDeletion(c *gin.Context) {
+	var deletion models.AccountDeletion
+	err := database.DB.Where("user_id = ?", c.MustGet("user_id").(uint)).First(&deletion).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.Error(apperror.NotFound(apperror.CodeDeletionNotFound))
+		return
+	}
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deletion": deletion})
+}
+
+// CancelAccountDeletion godoc
+// @Summary 撤销注销申请
+// @Description 注销开始执行后不能撤销
+// @Tags 账号
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Router /account/deletion [delete]
+// @Security ApiKeyAuth
+func CancelAccountDeletion(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	var deletion models.AccountDeletion
+	err := database.DB.Where("user_id = ?", userID).First(&deletion).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.Error(apperror.NotFound(apperror.CodeDeletionNotFound))
+		return
+	}
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	// 与 worker 领取任务竞争，只删除还没开始执行的申请
+	result := database.DB.Where("started_at IS NULL").Delete(&deletion)
+	if result.Error != nil {
+		c.Error(apperror.Internal(result.Error))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.Error(apperror.Conflict(apperror.CodeDeletionStarted))
+		return
+	}
+	recordAudit(c, audit.Entry{Action: audit.ActionAccountDeletionCancel, TargetType: "user", TargetID: userID, Before: deletion})
+	c.JSON(http.StatusOK, gin.H{"message": "已撤销注销申请"})
+}
@@ -0,0 +1,95 @@
+package controllers_test
+
+import (
+	"goblog/database"
+	"goblog/models"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestExports(t *testing.T) {
+	user, other := newUser(t), newUser(t)
+
+	res := call(t, http.MethodPost, "/api/account/exports", user.Token, nil)
+	expect(t, res, http.StatusAccepted, "export.id", "export.status")
+	export := number(t, res, "export.id")
+
+	// 已有排队中的导出时不重复创建
+	res = call(t, http.MethodPost, "/api/account/exports", user.Token, nil)
+	expect(t, res, http.StatusAccepted, "export.id")
+	if number(t, res, "export.id") != export {
+		t.Fatalf("应返回排队中的导出 %d: %s", export, res.Raw)
+	}
+
+	res = call(t, http.MethodGet, "/api/account/exports", user.Token, nil)
+	expect(t, res, http.StatusOK, "exports")
+	if len(list(t, res, "exports")) != 1 {
+		t.Fatalf("应有 1 个导出: %s", res.Raw)
+	}
+
+	res = call(t, http.MethodGet, path("/api/account/exports/%d/download", export), user.Token, nil)
+	expectError(t, res, http.StatusConflict, "export.not_ready")
+
+	if err := database.DB.Model(&models.DataExport{ID: export}).Updates(map[string]any{"status": models.ExportDone, "data": []byte("PK")}).Error; err != nil {
+		t.Fatal(err)
+	}
+	res = call(t, http.MethodGet, path("/api/account/exports/%d/download", export), user.Token, nil)
+	if res.Code != http.StatusOK || string(res.Raw) != "PK" {
+		t.Fatalf("下载导出失败: %d %s", res.Code, res.Raw)
+	}
+
+	res = call(t, http.MethodGet, path("/api/account/exports/%d/download", export), other.Token, nil)
+	expectError(t, res, http.StatusNotFound, "export.not_found")
+
+	res = call(t, http.MethodGet, "/api/account/exports/abc/download", user.Token, nil)
+	expectError(t, res, http.StatusBadRequest, "export.invalid_id")
+
+	res = call(t, http.MethodGet, "/api/account/exports", "", nil)
+	expectError(t, res, http.StatusUnauthorized, "auth.token_missing")
+}
+
+func TestAccountDeletion(t *testing.T) {
+	user, heir := newUser(t), newUser(t)
+
+	res := call(t, http.MethodGet, "/api/account/deletion", user.Token, nil)
+	expectError(t, res, http.StatusNotFound, "account.deletion_not_found")
+
+	res = call(t, http.MethodPost, "/api/account/deletion", user.Token, gin.H{"password": "wrong-password", "posts": models.DeletePosts})
+	expectError(t, res, http.StatusForbidden, "auth.wrong_password")
+
+	res = call(t, http.MethodPost, "/api/account/deletion", user.Token, gin.H{"password": "secret1", "posts": "keep"})
+	expectInvalid(t, res, "posts")
+
+	res = call(t, http.MethodPost, "/api/account/deletion", user.Token, gin.H{"password": "secret1", "posts": models.TransferPosts, "transfer_to": user.ID})
+	expectError(t, res, http.StatusBadRequest, "account.invalid_transfer_target")
+
+	res = call(t, http.MethodPost, "/api/account/deletion", user.Token, gin.H{"password": "secret1", "posts": models.TransferPosts, "transfer_to": heir.ID})
+	expect(t, res, http.StatusAccepted, "message", "deletion.id", "deletion.execute_at", "deletion.transfer_to_id")
+
+	res = call(t, http.MethodPost, "/api/account/deletion", user.Token, gin.H{"password": "secret1", "posts": models.DeletePosts})
+	expectError(t, res, http.StatusConflict, "account.deletion_pending")
+
+	res = call(t, http.MethodGet, "/api/account/deletion", user.Token, nil)
+	expect(t, res, http.StatusOK, "deletion.id", "deletion.post_action")
+
+	res = call(t, http.MethodDelete, "/api/account/deletion", user.Token, nil)
+	expect(t, res, http.StatusOK, "message")
+
+	res = call(t, http.MethodDelete, "/api/account/deletion", user.Token, nil)
+	expectError(t, res, http.StatusNotFound, "account.deletion_not_found")
+
+	// 已开始执行的注销不能撤销
+	res = call(t, http.MethodPost, "/api/account/deletion", user.Token, gin.H{"password": "secret1", "posts": models.DeletePosts})
+	expect(t, res, http.StatusAccepted, "deletion.id")
+	if err := database.DB.Model(&models.AccountDeletion{}).Where("user_id = ?", user.ID).Update("started_at", time.Now()).Error; err != nil {
+		t.Fatal(err)
+	}
+	res = call(t, http.MethodDelete, "/api/account/deletion", user.Token, nil)
+	expectError(t, res, http.StatusConflict, "account.deletion_started")
+
+	res = call(t, http.MethodPost, "/api/account/deletion", "", gin.H{"password": "secret1", "posts": models.DeletePosts})
+	expectError(t, res, http.StatusUnauthorized, "auth.token_missing")
+}
@@ -0,0 +1,571 @@
+package controllers
+
+import (
+	"goblog/database"
+	"goblog/models"
+	"goblog/pkg/apperror"
+	"goblog/pkg/audit"
+	"goblog/pkg/cache"
+	"goblog/repository"
+	"goblog/service"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AdminUser 是管理后台看到的用户信息，比公开接口多出邮箱和封禁状态
+type AdminUser struct {
+	ID        uint       `json:"id"`
+	Username  string     `json:"username"`
+	Email     string     `json:"email"`
+	Role      string     `json:"role"`
+	BannedAt  *time.Time `json:"banned_at"`
+	BanReason string     `json:"ban_reason,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func adminUser(user models.User) AdminUser {
+	return AdminUser{
+		ID:        user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		Role:      user.Role,
+		BannedAt:  user.BannedAt,
+		BanReason: user.BanReason,
+		CreatedAt: user.CreatedAt,
+	}
+}
+
+type SetRoleInput struct {
+	Role string `json:"role" binding:"required,oneof=user editor admin"`
+}
+
+type BanUserInput struct {
+	Reason string `json:"reason" binding:"max=255"`
+}
+
+type AdminUpdatePostInput struct {
+	UserID      *uint `json:"user_id"`
+	IsTop       *bool `json:"is_top"`
+	IsRecommend *bool `json:"is_recommend"`
+}
+
+type AdminUpdateCommentInput struct {
+	UserID uint `json:"user_id" binding:"required"`
+}
+
+type RenameTagInput struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// RequireAdmin 只允许站点管理员继续访问，需要放在 JWTAuthMiddleware 之后
+func (h *Handler) RequireAdmin(c *gin.Context) {
+	if h.svc.Users.Role(c, c.MustGet("user_id").(uint)) != models.RoleAdmin {
+		c.Error(apperror.Forbidden(apperror.CodeAdminRequired))
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+// AdminListUsers godoc
+// @Summary 查询用户列表
+// @Description 按用户名或邮箱搜索，可按角色和封禁状态筛选，新注册的在前
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Param q query string false "用户名或邮箱包含的内容"
+// @Param role query string false "角色：user / editor / admin"
+// @Param banned query bool false "true 只看已封禁，false 只看未封禁"
+// @Param page query int false "页码，默认 1"
+// @Param limit query int false "每页数量，1-100，默认 20"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Router /admin/users [get]
+// @Security ApiKeyAuth
+func (h *Handler) AdminListUsers(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.Error(apperror.BadRequest(apperror.CodeBadRequest).With("param", "page"))
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		c.Error(apperror.BadRequest(apperror.CodeBadRequest).With("param", "limit"))
+		return
+	}
+
+	query := repository.UserQuery{
+		Search: c.Query("q"),
+		Role:   c.Query("role"),
+		Offset: (page - 1) * limit,
+		Limit:  limit,
+	}
+	if s := c.Query("banned"); s != "" {
+		banned, err := strconv.ParseBool(s)
+		if err != nil {
+			c.Error(apperror.BadRequest(apperror.CodeBadRequest).With("param", "banned"))
+			return
+		}
+		query.Banned = &banned
+	}
+
+	users, total, err := h.svc.Users.List(c, query)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	result := make([]AdminUser, len(users))
+	for i, user := range users {
+		result[i] = adminUser(user)
+	}
+	c.JSON(http.StatusOK, gin.H{"users": result, "total": total, "page": page, "limit": limit})
+}
+
+// AdminSetUserRole godoc
+// @Summary 修改用户角色
+// @Description 管理员不能修改自己的角色
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Param id path int true "用户 ID"
+// @Param role body SetRoleInput true "新角色"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /admin/users/{id}/role [put]
+// @Security ApiKeyAuth
+func (h *Handler) AdminSetUserRole(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidUserID))
+		return
+	}
+
+	var input SetRoleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperror.FromBind(err))
+		return
+	}
+
+	before, err := h.svc.Users.Get(c, uint(id))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	user, err := h.svc.Users.SetRole(c, c.MustGet("user_id").(uint), uint(id), input.Role)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	recordAudit(c, audit.Entry{Action: audit.ActionUserRole, TargetType: "user", TargetID: user.ID, Before: adminUser(before), After: adminUser(user)})
+	c.JSON(http.StatusOK, gin.H{"message": "角色修改成功", "user": adminUser(user)})
+}
+
+// AdminBanUser godoc
+// @Summary 封禁用户
+// @Description 被封禁的用户不能登录，已签发的 token 也会失效；管理员需要先降级才能封禁
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Param id path int true "用户 ID"
+// @Param ban body BanUserInput false "封禁原因"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /admin/users/{id}/ban [post]
+// @Security ApiKeyAuth
+func (h *Handler) AdminBanUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidUserID))
+		return
+	}
+
+	var input BanUserInput
+	// 请求体可以省略
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.Error(apperror.FromBind(err))
+			return
+		}
+	}
+
+	before, err := h.svc.Users.Get(c, uint(id))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	user, err := h.svc.Users.Ban(c, uint(id), input.Reason)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	cache.Del(service.UserStatusKey(user.ID))
+	recordAudit(c, audit.Entry{Action: audit.ActionUserBan, TargetType: "user", TargetID: user.ID, Before: adminUser(before), After: adminUser(user)})
+	c.JSON(http.StatusOK, gin.H{"message": "用户已封禁", "user": adminUser(user)})
+}
+
+// AdminUnbanUser godoc
+// @Summary 解除封禁
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Param id path int true "用户 ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} apperror.Problem
+// @Router /admin/users/{id}/ban [delete]
+// @Security ApiKeyAuth
+func (h *Handler) AdminUnbanUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidUserID))
+		return
+	}
+
+	before, err := h.svc.Users.Get(c, uint(id))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	user, err := h.svc.Users.Unban(c, uint(id))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	cache.Del(service.UserStatusKey(user.ID))
+	recordAudit(c, audit.Entry{Action: audit.ActionUserUnban, TargetType: "user", TargetID: user.ID, Before: adminUser(before), After: adminUser(user)})
+	c.JSON(http.StatusOK, gin.H{"message": "已解除封禁", "user": adminUser(user)})
+}
+
+// AdminUpdatePost godoc
+// @Summary 修改任意文章的作者、置顶和推荐
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Param id path int true "文章 ID"
+// @Param post body AdminUpdatePostInput true "更新数据"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} apperror.Problem
+// @Router /admin/posts/{id} [put]
+// @Security ApiKeyAuth
+func (h *Handler) AdminUpdatePost(c *gin.Context) {
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidPostID))
+		return
+	}
+
+	var input AdminUpdatePostInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperror.FromBind(err))
+		return
+	}
+
+	post, err := h.svc.Posts.Find(c, uint(postID))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// 审计日志只记录修改的字段
+	fields, before := map[string]any{}, map[string]any{}
+	if input.UserID != nil {
+		author, err := h.svc.Users.Get(c, *input.UserID)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		fields["user_id"], before["user_id"] = author.ID, post.UserID
+		post.User = author
+	}
+	if input.IsTop != nil {
+		fields["is_top"], before["is_top"] = *input.IsTop, post.IsTop
+	}
+	if input.IsRecommend != nil {
+		fields["is_recommend"], before["is_recommend"] = *input.IsRecommend, post.IsRecommend
+	}
+
+	if len(fields) > 0 {
+		if err := h.svc.Posts.Update(c, &post, fields); err != nil {
+			c.Error(err)
+			return
+		}
+		recordAudit(c, audit.Entry{Action: audit.ActionPostUpdate, TargetType: "post", TargetID: post.ID, Before: before, After: fields})
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "文章更新成功", "post": post})
+}
+
+// AdminDeletePost godoc
+// @Summary 删除任意文章
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Param id path int true "文章 ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} apperror.Problem
+// @Router /admin/posts/{id} [delete]
+// @Security ApiKeyAuth
+func (h *Handler) AdminDeletePost(c *gin.Context) {
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidPostID))
+		return
+	}
+
+	post, err := h.svc.Posts.DeleteAny(c, uint(postID))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	recordAudit(c, audit.Entry{Action: audit.ActionPostDelete, TargetType: "post", TargetID: post.ID, Before: post})
+	c.JSON(http.StatusOK, gin.H{"message": "文章删除成功"})
+}
+
+// AdminUpdateComment godoc
+// @Summary 修改评论的作者
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Param id path int true "评论 ID"
+// @Param comment body AdminUpdateCommentInput true "新作者"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} apperror.Problem
+// @Router /admin/comments/{id} [put]
+// @Security ApiKeyAuth
+func (h *Handler) AdminUpdateComment(c *gin.Context) {
+	commentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidCommentID))
+		return
+	}
+
+	var input AdminUpdateCommentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperror.FromBind(err))
+		return
+	}
+
+	comment, err := h.svc.Comments.Find(c, uint(commentID))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	author, err := h.svc.Users.Get(c, input.UserID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	before := gin.H{"user_id": comment.UserID}
+	if err := h.svc.Comments.Update(c, &comment, map[string]any{"user_id": author.ID}); err != nil {
+		c.Error(err)
+		return
+	}
+	comment.User = author
+	recordAudit(c, audit.Entry{Action: audit.ActionCommentUpdate, TargetType: "comment", TargetID: comment.ID, Before: before, After: gin.H{"user_id": author.ID}})
+	c.JSON(http.StatusOK, gin.H{"message": "评论更新成功", "comment": comment})
+}
+
+// AdminDeleteComment godoc
+// @Summary 删除评论
+// @Description 评论的回复会一起删除
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Param id path int true "评论 ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} apperror.Problem
+// @Router /admin/comments/{id} [delete]
+// @Security ApiKeyAuth
+func (h *Handler) AdminDeleteComment(c *gin.Context) {
+	commentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidCommentID))
+		return
+	}
+
+	comment, err := h.svc.Comments.Delete(c, uint(commentID))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	recordAudit(c, audit.Entry{Action: audit.ActionCommentDelete, TargetType: "comment", TargetID: comment.ID, Before: comment})
+	c.JSON(http.StatusOK, gin.H{"message": "评论删除成功"})
+}
+
+// AdminListTags godoc
+// @Summary 查询标签及其文章数
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Param q query string false "标签名包含的内容"
+// @Success 200 {object} map[string]interface{}
+// @Router /admin/tags [get]
+// @Security ApiKeyAuth
+func (h *Handler) AdminListTags(c *gin.Context) {
+	tags, err := h.svc.Tags.List(c, c.Query("q"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+// AdminRenameTag godoc
+// @Summary 重命名标签
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Param id path int true "标签 ID"
+// @Param tag body RenameTagInput true "新名称"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Router /admin/tags/{id} [put]
+// @Security ApiKeyAuth
+func (h *Handler) AdminRenameTag(c *gin.Context) {
+	tagID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidTagID))
+		return
+	}
+
+	var input RenameTagInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperror.FromBind(err))
+		return
+	}
+
+	old, err := h.svc.Tags.Rename(c, uint(tagID), input.Name)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	tag := old
+	tag.Name = input.Name
+	recordAudit(c, audit.Entry{Action: audit.ActionTagRename, TargetType: "tag", TargetID: tag.ID, Before: old, After: tag})
+	c.JSON(http.StatusOK, gin.H{"message": "标签修改成功", "tag": tag})
+}
+
+// AdminDeleteTag godoc
+// @Summary 删除标签
+// @Description 同时移除文章上的该标签，文章本身不受影响
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Param id path int true "标签 ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} apperror.Problem
+// @Router /admin/tags/{id} [delete]
+// @Security ApiKeyAuth
+func (h *Handler) AdminDeleteTag(c *gin.Context) {
+	tagID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidTagID))
+		return
+	}
+
+	tag, err := h.svc.Tags.Delete(c, uint(tagID))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	recordAudit(c, audit.Entry{Action: audit.ActionTagDelete, TargetType: "tag", TargetID: tag.ID, Before: tag})
+	c.JSON(http.StatusOK, gin.H{"message": "标签删除成功"})
+}
+
+// SiteStats 是站点的内容总量
+type SiteStats struct {
+	Users       int64 `json:"users"`
+	BannedUsers int64 `json:"banned_users"`
+	Posts       int64 `json:"posts"`
+	Published   int64 `json:"published"`
+	Drafts      int64 `json:"drafts"`
+	Comments    int64 `json:"comments"`
+	Likes       int64 `json:"likes"`
+	Tags        int64 `json:"tags"`
+}
+
+// DailyCount 是某一天（UTC）的数量
+type DailyCount struct {
+	Date  string `json:"date"`
+	Count int64  `json:"count"`
+}
+
+// GetCacheStats godoc
+// @Summary 缓存状态
+// @Description 返回 Redis 是否熔断、最近一次 Redis 错误、熔断次数以及两级缓存的命中和回源次数
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Success 200 {object} cache.Stats
+// @Router /admin/cache [get]
+// @Security ApiKeyAuth
+func GetCacheStats(c *gin.Context) {
+	c.JSON(http.StatusOK, cache.GetStats())
+}
+
+// GetSiteStats godoc
+// @Summary 站点统计
+// @Description 返回用户、文章、评论等总量，以及最近 days 天每天的注册人数（按 UTC 日期）
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Param days query int false "注册趋势的天数，1-365，默认 30"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Router /admin/stats [get]
+// @Security ApiKeyAuth
+func GetSiteStats(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 1 || days > 365 {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidDays))
+		return
+	}
+
+	db := database.DB.WithContext(c)
+	var stats SiteStats
+	counts := []*gorm.DB{
+		db.Model(&models.User{}),
+		db.Model(&models.User{}).Where("banned_at IS NOT NULL"),
+		db.Model(&models.Post{}).Where("is_draft = ?", false),
+		db.Model(&models.Post{}).Where("is_draft = ?", true),
+		db.Model(&models.Comment{}),
+		db.Model(&models.Like{}),
+		db.Model(&models.Tag{}),
+	}
+	dests := []*int64{&stats.Users, &stats.BannedUsers, &stats.Published, &stats.Drafts, &stats.Comments, &stats.Likes, &stats.Tags}
+	for i, query := range counts {
+		if err := query.Count(dests[i]).Error; err != nil {
+			c.Error(apperror.Internal(err))
+			return
+		}
+	}
+	stats.Posts = stats.Published + stats.Drafts
+
+	// 按天分组在 Go 里完成，避免依赖各数据库不同的日期函数
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	since := today.AddDate(0, 0, -(days - 1))
+	var createdAt []time.Time
+	if err := db.Model(&models.User{}).Where("created_at >= ?", since).Pluck("created_at", &createdAt).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	signups := make([]DailyCount, days)
+	for i := range signups {
+		signups[i].Date = since.AddDate(0, 0, i).Format(time.DateOnly)
+	}
+	for _, t := range createdAt {
+		if i := int(t.UTC().Sub(since) / (24 * time.Hour)); i >= 0 && i < days {
+			signups[i].Count++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"totals": stats, "signups": signups})
+}
@@ -0,0 +1,290 @@
+package controllers_test
+
+import (
+	"goblog/models"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	user := newUser(t)
+
+	for _, route := range []struct{ method, path string }{
+		{http.MethodGet, "/api/admin/users"},
+		{http.MethodGet, "/api/admin/stats"},
+		{http.MethodGet, "/api/admin/cache"},
+		{http.MethodGet, "/api/admin/audit-logs"},
+		{http.MethodDelete, "/api/admin/posts/1"},
+	} {
+		res := call(t, route.method, route.path, user.Token, nil)
+		expectError(t, res, http.StatusForbidden, "admin.forbidden")
+
+		res = call(t, route.method, route.path, "", nil)
+		expectError(t, res, http.StatusUnauthorized, "auth.token_missing")
+	}
+
+	// 编辑也不能访问管理后台
+	editor := withRole(t, newUser(t), models.RoleEditor)
+	expectError(t, call(t, http.MethodGet, "/api/admin/users", editor.Token, nil), http.StatusForbidden, "admin.forbidden")
+}
+
+func TestAdminListUsers(t *testing.T) {
+	admin := newAdmin(t)
+	user := newUser(t)
+
+	res := call(t, http.MethodGet, "/api/admin/users?q="+user.Username, admin.Token, nil)
+	expect(t, res, http.StatusOK, "users", "total", "page", "limit")
+	users := list(t, res, "users")
+	if len(users) != 1 || users[0].(map[string]any)["email"] != user.Email {
+		t.Fatalf("应只返回 %s: %s", user.Username, res.Raw)
+	}
+
+	res = call(t, http.MethodGet, "/api/admin/users?role=admin&banned=false", admin.Token, nil)
+	expect(t, res, http.StatusOK, "users")
+
+	res = call(t, http.MethodGet, "/api/admin/users?limit=1000", admin.Token, nil)
+	expectError(t, res, http.StatusBadRequest, "bad_request")
+
+	res = call(t, http.MethodGet, "/api/admin/users?banned=maybe", admin.Token, nil)
+	expectError(t, res, http.StatusBadRequest, "bad_request")
+}
+
+func TestAdminSetUserRole(t *testing.T) {
+	admin := newAdmin(t)
+	user := newUser(t)
+
+	res := call(t, http.MethodPut, path("/api/admin/users/%d/role", user.ID), admin.Token, gin.H{"role": models.RoleEditor})
+	expect(t, res, http.StatusOK, "message", "user.role")
+	if role, _ := lookup(res.Body, "user.role"); role != models.RoleEditor {
+		t.Fatalf("角色应为 editor: %s", res.Raw)
+	}
+
+	res = call(t, http.MethodPut, path("/api/admin/users/%d/role", admin.ID), admin.Token, gin.H{"role": models.RoleUser})
+	expectError(t, res, http.StatusForbidden, "admin.own_role")
+
+	res = call(t, http.MethodPut, path("/api/admin/users/%d/role", user.ID), admin.Token, gin.H{"role": "owner"})
+	expectInvalid(t, res, "role")
+
+	res = call(t, http.MethodPut, "/api/admin/users/999999/role", admin.Token, gin.H{"role": models.RoleUser})
+	expectError(t, res, http.StatusNotFound, "user.not_found")
+
+	res = call(t, http.MethodPut, "/api/admin/users/abc/role", admin.Token, gin.H{"role": models.RoleUser})
+	expectError(t, res, http.StatusBadRequest, "user.invalid_id")
+}
+
+func TestAdminBanUser(t *testing.T) {
+	admin, other := newAdmin(t), newAdmin(t)
+	user := newUser(t)
+
+	res := call(t, http.MethodPost, path("/api/admin/users/%d/ban", user.ID), admin.Token, gin.H{"reason": "spam"})
+	expect(t, res, http.StatusOK, "message", "user.banned_at", "user.ban_reason")
+
+	// 被封禁的用户不能登录
+	res = call(t, http.MethodPost, "/api/login", "", gin.H{"email": user.Email, "password": "secret1"})
+	expectError(t, res, http.StatusForbidden, "auth.user_banned")
+
+	res = call(t, http.MethodDelete, path("/api/admin/users/%d/ban", user.ID), admin.Token, nil)
+	expect(t, res, http.StatusOK, "message", "user.id")
+	if bannedAt, _ := lookup(res.Body, "user.banned_at"); bannedAt != nil {
+		t.Fatalf("解封后 banned_at 应为空: %s", res.Raw)
+	}
+	expect(t, call(t, http.MethodPost, "/api/login", "", gin.H{"email": user.Email, "password": "secret1"}), http.StatusOK, "token")
+
+	res = call(t, http.MethodPost, path("/api/admin/users/%d/ban", other.ID), admin.Token, nil)
+	expectError(t, res, http.StatusForbidden, "admin.ban_admin")
+
+	res = call(t, http.MethodPost, "/api/admin/users/999999/ban", admin.Token, nil)
+	expectError(t, res, http.StatusNotFound, "user.not_found")
+
+	res = call(t, http.MethodDelete, "/api/admin/users/abc/ban", admin.Token, nil)
+	expectError(t, res, http.StatusBadRequest, "user.invalid_id")
+}
+
+func TestAdminUpdatePost(t *testing.T) {
+	admin := newAdmin(t)
+	author, heir := newUser(t), newUser(t)
+	post := newPost(t, author, false)
+
+	res := call(t, http.MethodPut, path("/api/admin/posts/%d", post.ID), admin.Token, gin.H{"user_id": heir.ID, "is_recommend": true})
+	expect(t, res, http.StatusOK, "message", "post.author")
+	if owner := number(t, res, "post.user_id"); owner != heir.ID {
+		t.Fatalf("作者应改为 %d: %s", heir.ID, res.Raw)
+	}
+
+	res = call(t, http.MethodPut, path("/api/admin/posts/%d", post.ID), admin.Token, gin.H{"user_id": 999999})
+	expectError(t, res, http.StatusNotFound, "user.not_found")
+
+	res = call(t, http.MethodPut, path("/api/admin/posts/%d", post.ID), admin.Token, gin.H{"is_top": "yes"})
+	expectInvalid(t, res, "is_top")
+
+	res = call(t, http.MethodPut, "/api/admin/posts/999999", admin.Token, gin.H{"is_top": true})
+	expectError(t, res, http.StatusNotFound, "post.not_found")
+}
+
+func TestAdminDeleteAndPurgePost(t *testing.T) {
+	admin := newAdmin(t)
+	author := newUser(t)
+	post := newPost(t, author, false)
+
+	res := call(t, http.MethodDelete, path("/api/admin/trash/%d", post.ID), admin.Token, nil)
+	expectError(t, res, http.StatusNotFound, "post.not_in_trash")
+
+	res = call(t, http.MethodDelete, path("/api/admin/posts/%d", post.ID), admin.Token, nil)
+	expect(t, res, http.StatusOK, "message")
+
+	res = call(t, http.MethodDelete, path("/api/admin/posts/%d", post.ID), admin.Token, nil)
+	expectError(t, res, http.StatusNotFound, "post.not_found")
+
+	res = call(t, http.MethodDelete, path("/api/admin/trash/%d", post.ID), admin.Token, nil)
+	expect(t, res, http.StatusOK, "message")
+
+	res = call(t, http.MethodGet, "/api/trash", author.Token, nil)
+	expect(t, res, http.StatusOK, "posts")
+	if len(list(t, res, "posts")) != 0 {
+		t.Fatalf("永久删除后回收站应为空: %s", res.Raw)
+	}
+
+	res = call(t, http.MethodDelete, "/api/admin/trash/abc", admin.Token, nil)
+	expectError(t, res, http.StatusBadRequest, "post.invalid_id")
+}
+
+func TestAdminComments(t *testing.T) {
+	admin := newAdmin(t)
+	author, heir := newUser(t), newUser(t)
+	comment := newComment(t, author, newPost(t, author, false))
+
+	res := call(t, http.MethodPut, path("/api/admin/comments/%d", comment.ID), admin.Token, gin.H{"user_id": heir.ID})
+	expect(t, res, http.StatusOK, "message", "comment.user")
+	if owner := number(t, res, "comment.user_id"); owner != heir.ID {
+		t.Fatalf("评论作者应改为 %d: %s", heir.ID, res.Raw)
+	}
+
+	res = call(t, http.MethodPut, path("/api/admin/comments/%d", comment.ID), admin.Token, gin.H{})
+	expectInvalid(t, res, "user_id")
+
+	res = call(t, http.MethodDelete, path("/api/admin/comments/%d", comment.ID), admin.Token, nil)
+	expect(t, res, http.StatusOK, "message")
+
+	res = call(t, http.MethodDelete, path("/api/admin/comments/%d", comment.ID), admin.Token, nil)
+	expectError(t, res, http.StatusNotFound, "comment.not_found")
+
+	res = call(t, http.MethodPut, "/api/admin/comments/abc", admin.Token, gin.H{"user_id": heir.ID})
+	expectError(t, res, http.StatusBadRequest, "comment.invalid_id")
+}
+
+func TestAdminTags(t *testing.T) {
+	admin := newAdmin(t)
+	author := newUser(t)
+	first, second := newPost(t, author, false), newPost(t, author, false)
+	tag, taken := first.Tags[0], second.Tags[0]
+
+	res := call(t, http.MethodGet, "/api/admin/tags?q="+tag.Name, admin.Token, nil)
+	expect(t, res, http.StatusOK, "tags")
+	if len(list(t, res, "tags")) != 1 {
+		t.Fatalf("应只返回标签 %s: %s", tag.Name, res.Raw)
+	}
+
+	res = call(t, http.MethodPut, path("/api/admin/tags/%d", tag.ID), admin.Token, gin.H{"name": taken.Name})
+	expectError(t, res, http.StatusConflict, "tag.exists")
+
+	res = call(t, http.MethodPut, path("/api/admin/tags/%d", tag.ID), admin.Token, gin.H{"name": tag.Name + "-renamed"})
+	expect(t, res, http.StatusOK, "message", "tag.name")
+
+	res = call(t, http.MethodPut, path("/api/admin/tags/%d", tag.ID), admin.Token, gin.H{})
+	expectInvalid(t, res, "name")
+
+	res = call(t, http.MethodDelete, path("/api/admin/tags/%d", tag.ID), admin.Token, nil)
+	expect(t, res, http.StatusOK, "message")
+
+	res = call(t, http.MethodDelete, path("/api/admin/tags/%d", tag.ID), admin.Token, nil)
+	expectError(t, res, http.StatusNotFound, "tag.not_found")
+
+	res = call(t, http.MethodDelete, "/api/admin/tags/abc", admin.Token, nil)
+	expectError(t, res, http.StatusBadRequest, "tag.invalid_id")
+}
+
+func TestGetSiteStats(t *testing.T) {
+	admin := newAdmin(t)
+	newPost(t, admin, false)
+
+	res := call(t, http.MethodGet, "/api/admin/stats?days=7", admin.Token, nil)
+	expect(t, res, http.StatusOK, "totals.users", "totals.posts", "totals.published", "totals.drafts", "signups")
+	if len(list(t, res, "signups")) != 7 {
+		t.Fatalf("signups 应有 7 天: %s", res.Raw)
+	}
+
+	res = call(t, http.MethodGet, "/api/admin/stats?days=0", admin.Token, nil)
+	expectError(t, res, http.StatusBadRequest, "admin.invalid_days")
+}
+
+func TestGetCacheStats(t *testing.T) {
+	admin := newAdmin(t)
+
+	res := call(t, http.MethodGet, "/api/admin/cache", admin.Token, nil)
+	expect(t, res, http.StatusOK, "degraded", "trips", "local_entries", "loads")
+}
+
+func TestGetAuditLogs(t *testing.T) {
+	admin := newAdmin(t)
+	post := newPost(t, admin, false)
+	expect(t, call(t, http.MethodDelete, path("/api/posts/%d", post.ID), admin.Token, nil), http.StatusOK)
+
+	res := call(t, http.MethodGet, path("/api/admin/audit-logs?actor_id=%d&action=post.delete", admin.ID), admin.Token, nil)
+	expect(t, res, http.StatusOK, "logs", "total", "page", "limit")
+	if len(list(t, res, "logs")) != 1 {
+		t.Fatalf("应有 1 条删除文章的审计日志: %s", res.Raw)
+	}
+
+	res = call(t, http.MethodGet, "/api/admin/audit-logs?from=yesterday", admin.Token, nil)
+	expectError(t, res, http.StatusBadRequest, "audit.invalid_time")
+
+	res = call(t, http.MethodGet, "/api/admin/audit-logs?actor_id=abc", admin.Token, nil)
+	expectError(t, res, http.StatusBadRequest, "user.invalid_id")
+
+	res = call(t, http.MethodGet, "/api/admin/audit-logs?limit=0", admin.Token, nil)
+	expectError(t, res, http.StatusBadRequest, "bad_request")
+}
+
+const wxr = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+<item>
+<title>Imported</title>
+<dc:creator>admin</dc:creator>
+<content:encoded><![CDATA[<p>Hello</p>]]></content:encoded>
+<wp:post_id>1</wp:post_id>
+<wp:post_date_gmt>2024-01-02 03:04:05</wp:post_date_gmt>
+<wp:post_type>post</wp:post_type>
+<wp:status>publish</wp:status>
+</item>
+</channel>
+</rss>`
+
+func TestImportPosts(t *testing.T) {
+	admin := newAdmin(t)
+
+	// 只预览，不写入数据库
+	res := upload(t, "/api/admin/import", admin.Token, "site.xml", []byte(wxr), map[string]string{"dry_run": "true"})
+	expect(t, res, http.StatusOK, "format", "dry_run", "created", "items")
+	if res.Body["format"] != "wxr" || res.Body["created"] != float64(1) {
+		t.Fatalf("应预览导入 1 篇 WXR 文章: %s", res.Raw)
+	}
+
+	res = upload(t, "/api/admin/import", admin.Token, "", nil, nil)
+	expectError(t, res, http.StatusBadRequest, "import.file_required")
+
+	res = upload(t, "/api/admin/import", admin.Token, "site.xml", []byte(wxr), map[string]string{"format": "blogger"})
+	expectError(t, res, http.StatusBadRequest, "import.invalid_format")
+
+	res = upload(t, "/api/admin/import", admin.Token, "site.txt", []byte("hello"), nil)
+	expectError(t, res, http.StatusBadRequest, "import.invalid_file")
+
+	res = upload(t, "/api/admin/import", admin.Token, "site.xml", []byte(wxr), map[string]string{"user_id": "999999"})
+	expectError(t, res, http.StatusBadRequest, "import.invalid_user")
+
+	user := newUser(t)
+	res = upload(t, "/api/admin/import", user.Token, "site.xml", []byte(wxr), nil)
+	expectError(t, res, http.StatusForbidden, "admin.forbidden")
+}
@@ -0,0 +1,215 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"goblog/database"
+	"goblog/models"
+	"goblog/pkg/analytics"
+	"goblog/pkg/apperror"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxAnalyticsRange = 366 * 24 * time.Hour
+
+// GetAnalyticsSeries godoc
+// @Summary 浏览、点赞、评论趋势
+// @Description 默认统计当前作者的文章，管理员传 scope=site 可查看全站数据
+// @Tags 统计
+// @Accept json
+// @Produce json
+// @Param from query string false "开始日期 YYYY-MM-DD，默认 30 天前"
+// @Param to query string false "结束日期 YYYY-MM-DD，默认今天"
+// @Param interval query string false "统计粒度 day/week/month"
+// @Param scope query string false "site 表示全站（仅管理员）"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Router /analytics/series [get]
+// @Security ApiKeyAuth
+func GetAnalyticsSeries(c *gin.Context) {
+	scope, ok := analyticsScope(c)
+	if !ok {
+		return
+	}
+
+	points, err := analytics.Series(scope, c.DefaultQuery("interval", analytics.IntervalDay))
+	if err == analytics.ErrInvalidInterval {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidInterval))
+		return
+	} else if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"series": points})
+}
+
+// GetTopPosts godoc
+// @Summary 浏览量最高的文章
+// @Tags 统计
+// @Accept json
+// @Produce json
+// @Param from query string false "开始日期 YYYY-MM-DD，默认 30 天前"
+// @Param to query string false "结束日期 YYYY-MM-DD，默认今天"
+// @Param limit query int false "数量"
+// @Param scope query string false "site 表示全站（仅管理员）"
+// @Success 200 {object} map[string]interface{}
+// @Router /analytics/top-posts [get]
+// @Security ApiKeyAuth
+func GetTopPosts(c *gin.Context) {
+	scope, ok := analyticsScope(c)
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	top, err := analytics.TopPosts(scope, limit)
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"posts": top})
+}
+
+// GetReferrers godoc
+// @Summary 访问来源统计
+// @Tags 统计
+// @Accept json
+// @Produce json
+// @Param from query string false "开始日期 YYYY-MM-DD，默认 30 天前"
+// @Param to query string false "结束日期 YYYY-MM-DD，默认今天"
+// @Param scope query string false "site 表示全站（仅管理员）"
+// @Success 200 {object} map[string]interface{}
+// @Router /analytics/referrers [get]
+// @Security ApiKeyAuth
+func GetReferrers(c *gin.Context) {
+	scope, ok := analyticsScope(c)
+	if !ok {
+		return
+	}
+
+	referrers, err := analytics.TopReferrers(scope, 20)
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"referrers": referrers})
+}
+
+// GetPostAnalytics godoc
+// @Summary 单篇文章的统计数据
+// @Description 返回文章的每日浏览、点赞、评论数以及主要来源，仅作者和管理员可见
+// @Tags 统计
+// @Accept json
+// @Produce json
+// @Param id path int true "文章 ID"
+// @Param from query string false "开始日期 YYYY-MM-DD，默认 30 天前"
+// @Param to query string false "结束日期 YYYY-MM-DD，默认今天"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} apperror.Problem
+// @Router /analytics/posts/{id} [get]
+// @Security ApiKeyAuth
+func GetPostAnalytics(c *gin.Context) {
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidPostID))
+		return
+	}
+
+	var post models.Post
+	if err := database.DB.First(&post, postID).Error; err != nil {
+		c.Error(apperror.NotFound(apperror.CodePostNotFound))
+		return
+	}
+
+	userID := c.MustGet("user_id").(uint)
+	if post.UserID != userID && !isAdmin(userID) {
+		c.Error(apperror.Forbidden(apperror.CodeAnalyticsDenied))
+		return
+	}
+
+	from, to, ok := analyticsRange(c)
+	if !ok {
+		return
+	}
+	scope := analytics.Scope{PostID: &post.ID, From: from, To: to}
+
+	points, err := analytics.Series(scope, analytics.IntervalDay)
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	referrers, err := analytics.TopReferrers(scope, 20)
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"post_id": post.ID, "series": points, "referrers": referrers})
+}
+
+func analyticsScope(c *gin.Context) (analytics.Scope, bool) {
+	from, to, ok := analyticsRange(c)
+	if !ok {
+		return analytics.Scope{}, false
+	}
+
+	userID := c.MustGet("user_id").(uint)
+	scope := analytics.Scope{AuthorID: &userID, From: from, To: to}
+
+	if c.Query("scope") == "site" {
+		if !isAdmin(userID) {
+			c.Error(apperror.Forbidden(apperror.CodeSiteAnalyticsOnly))
+			return scope, false
+		}
+		scope.AuthorID = nil
+	}
+	return scope, true
+}
+
+func analyticsRange(c *gin.Context) (time.Time, time.Time, bool) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	to, from := today, today.AddDate(0, 0, -29)
+
+	var err error
+	if v := c.Query("to"); v != "" {
+		if to, err = time.Parse("2006-01-02", v); err != nil {
+			c.Error(apperror.BadRequest(apperror.CodeInvalidDate).With("param", "to"))
+			return from, to, false
+		}
+	}
+	if v := c.Query("from"); v != "" {
+		if from, err = time.Parse("2006-01-02", v); err != nil {
+			c.Error(apperror.BadRequest(apperror.CodeInvalidDate).With("param", "from"))
+			return from, to, false
+		}
+	} else if c.Query("to") != "" {
+		from = to.AddDate(0, 0, -29)
+	}
+
+	if from.After(to) || to.Sub(from) > maxAnalyticsRange {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidRange))
+		return from, to, false
+	}
+	return from, to, true
+}
+
+// visitorID 登录用户按用户 ID 去重，匿名访客按 IP + User-Agent 去重
+func visitorID(c *gin.Context) string {
+	if userID, ok := c.Get("user_id"); ok {
+		return fmt.Sprintf("u%d", userID)
+	}
+	sum := sha256.Sum256([]byte(c.ClientIP() + "|" + c.Request.UserAgent()))
+	return "a" + hex.EncodeToString(sum[:8])
+}
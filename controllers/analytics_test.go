@@ -0,0 +1,82 @@
+package controllers_test
+
+import (
+	"goblog/models"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestAnalytics(t *testing.T) {
+	author, other := newUser(t), newUser(t)
+	admin := newAdmin(t)
+	post := newPost(t, author, false)
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	seed(t, &models.PostView{PostID: post.ID, Date: today, Views: 7})
+	seed(t, &models.PostReferrer{PostID: post.ID, Date: today, Referrer: "example.com", Views: 3})
+
+	res := call(t, http.MethodGet, "/api/analytics/series?interval=week", author.Token, nil)
+	expect(t, res, http.StatusOK, "series")
+
+	res = call(t, http.MethodGet, "/api/analytics/series?interval=hour", author.Token, nil)
+	expectError(t, res, http.StatusBadRequest, "analytics.invalid_interval")
+
+	res = call(t, http.MethodGet, "/api/analytics/series?scope=site", author.Token, nil)
+	expectError(t, res, http.StatusForbidden, "analytics.site_forbidden")
+
+	res = call(t, http.MethodGet, "/api/analytics/series?scope=site", admin.Token, nil)
+	expect(t, res, http.StatusOK, "series")
+
+	res = call(t, http.MethodGet, "/api/analytics/top-posts?limit=5", author.Token, nil)
+	expect(t, res, http.StatusOK, "posts")
+	top := list(t, res, "posts")
+	if len(top) != 1 || top[0].(map[string]any)["views"] != float64(7) {
+		t.Fatalf("热门文章应为 %d，浏览量 7: %s", post.ID, res.Raw)
+	}
+
+	res = call(t, http.MethodGet, "/api/analytics/top-posts", other.Token, nil)
+	expect(t, res, http.StatusOK, "posts")
+	if len(list(t, res, "posts")) != 0 {
+		t.Fatalf("只统计自己的文章: %s", res.Raw)
+	}
+
+	res = call(t, http.MethodGet, "/api/analytics/referrers", author.Token, nil)
+	expect(t, res, http.StatusOK, "referrers")
+	if len(list(t, res, "referrers")) != 1 {
+		t.Fatalf("应有 1 个来源: %s", res.Raw)
+	}
+
+	res = call(t, http.MethodGet, "/api/analytics/referrers?from=2024-02-01&to=2024-01-01", author.Token, nil)
+	expectError(t, res, http.StatusBadRequest, "analytics.invalid_range")
+
+	res = call(t, http.MethodGet, "/api/analytics/referrers?from=yesterday", author.Token, nil)
+	expectError(t, res, http.StatusBadRequest, "analytics.invalid_date")
+
+	res = call(t, http.MethodGet, "/api/analytics/referrers", "", nil)
+	expectError(t, res, http.StatusUnauthorized, "auth.token_missing")
+}
+
+func TestPostAnalytics(t *testing.T) {
+	author, other := newUser(t), newUser(t)
+	admin := newAdmin(t)
+	post := newPost(t, author, false)
+
+	res := call(t, http.MethodGet, path("/api/analytics/posts/%d", post.ID), author.Token, nil)
+	expect(t, res, http.StatusOK, "post_id", "series", "referrers")
+
+	res = call(t, http.MethodGet, path("/api/analytics/posts/%d", post.ID), admin.Token, nil)
+	expect(t, res, http.StatusOK, "post_id")
+
+	res = call(t, http.MethodGet, path("/api/analytics/posts/%d", post.ID), other.Token, nil)
+	expectError(t, res, http.StatusForbidden, "analytics.forbidden")
+
+	res = call(t, http.MethodGet, path("/api/analytics/posts/%d?to=2024-13-01", post.ID), author.Token, nil)
+	expectError(t, res, http.StatusBadRequest, "analytics.invalid_date")
+
+	res = call(t, http.MethodGet, "/api/analytics/posts/999999", author.Token, nil)
+	expectError(t, res, http.StatusNotFound, "post.not_found")
+
+	res = call(t, http.MethodGet, "/api/analytics/posts/abc", author.Token, nil)
+	expectError(t, res, http.StatusBadRequest, "post.invalid_id")
+}
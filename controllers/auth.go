@@ -1,15 +1,14 @@
 package controllers
 
 import (
-	"goblog/config"
+	"errors"
 	"goblog/database"
 	"goblog/models"
-	"goblog/utils"
+	"goblog/pkg/apperror"
+	"goblog/pkg/audit"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt"
 )
 
 type RegisterInput struct {
@@ -26,29 +25,19 @@ type RegisterInput struct {
 // @Produce json
 // @Param user body models.User true "注册信息"
 // @Success 201 {object} map[string]interface{}
-// @Failure 400 {object} map[string]string
+// @Failure 400 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
 // @Router /register [post]
-func Register(c *gin.Context) {
+func (h *Handler) Register(c *gin.Context) {
 	var input RegisterInput
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-	}
-
-	hashedPassword, err := utils.HashPassword(input.Password)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error:": "密码加密失败"})
+		c.Error(apperror.FromBind(err))
 		return
 	}
 
-	user := models.User{
-		Username: input.Username,
-		Email:    input.Email,
-		Password: hashedPassword,
-	}
-
-	if err := database.DB.Create(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建用户失败,可能是用户名或邮箱已存在"})
+	if _, err := h.svc.Users.Register(c, input.Username, input.Email, input.Password); err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -68,37 +57,41 @@ type LoginInput struct {
 // @Produce json
 // @Param credentials body map[string]string true "用户名和密码"
 // @Success 200 {object} map[string]interface{}
-// @Failure 401 {object} map[string]string
+// @Failure 401 {object} apperror.Problem
 // @Router /login [post]
-func Login(c *gin.Context) {
+func (h *Handler) Login(c *gin.Context) {
 	var input LoginInput
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperror.FromBind(err))
 		return
 	}
 
-	var user models.User
-	if err := database.DB.Where("email = ?", input.Email).First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户不存在"})
-		return
-	}
-
-	if !utils.CheckPasswordHash(input.Password, user.Password) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "密码错误"})
+	user, token, err := h.svc.Users.Login(c, input.Email, input.Password)
+	if err != nil {
+		// 邮箱不存在时 TargetID 为 0，尝试登录的邮箱记录在 after 中
+		after := gin.H{"email": input.Email}
+		var appErr *apperror.Error
+		if errors.As(err, &appErr) {
+			after["code"] = appErr.Code
+		}
+		recordAudit(c, audit.Entry{Action: audit.ActionLoginFailed, TargetType: "user", TargetID: user.ID, After: after})
+		c.Error(err)
 		return
 	}
+	recordAudit(c, audit.Entry{ActorID: user.ID, Action: audit.ActionLogin, TargetType: "user", TargetID: user.ID})
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": user.ID,
-		"exp":     time.Now().Add(time.Hour * 72).Unix(),
-	})
+	c.JSON(http.StatusOK, gin.H{"token": token})
+}
 
-	tokenString, err := token.SignedString([]byte(config.AppConfig.JWTSecret))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成 token 失败"})
-		return
+func userRole(userID uint) string {
+	var user models.User
+	if err := database.DB.Select("role").First(&user, userID).Error; err != nil {
+		return ""
 	}
+	return user.Role
+}
 
-	c.JSON(http.StatusOK, gin.H{"token": tokenString})
+func isAdmin(userID uint) bool {
+	return userRole(userID) == models.RoleAdmin
 }
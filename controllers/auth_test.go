@@ -0,0 +1,65 @@
+package controllers_test
+
+import (
+	"goblog/pkg/cache"
+	"goblog/service"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestRegister(t *testing.T) {
+	name := unique("register")
+	res := call(t, http.MethodPost, "/api/register", "", gin.H{"username": name, "email": name + "@example.com", "password": "secret1"})
+	expect(t, res, http.StatusCreated, "message")
+
+	res = call(t, http.MethodPost, "/api/register", "", gin.H{"username": name, "email": name + "@example.com", "password": "secret1"})
+	expectError(t, res, http.StatusConflict, "user.exists")
+
+	res = call(t, http.MethodPost, "/api/register", "", gin.H{"username": "short", "email": "not-an-email", "password": "123"})
+	expectInvalid(t, res, "email")
+	expectInvalid(t, res, "password")
+}
+
+func TestLogin(t *testing.T) {
+	user := newUser(t)
+
+	res := call(t, http.MethodPost, "/api/login", "", gin.H{"email": user.Email, "password": "secret1"})
+	expect(t, res, http.StatusOK, "token")
+
+	res = call(t, http.MethodPost, "/api/login", "", gin.H{"email": user.Email, "password": "wrong-password"})
+	expectError(t, res, http.StatusUnauthorized, "auth.wrong_password")
+
+	res = call(t, http.MethodPost, "/api/login", "", gin.H{"email": "nobody@example.com", "password": "secret1"})
+	expectError(t, res, http.StatusUnauthorized, "auth.user_not_found")
+
+	res = call(t, http.MethodPost, "/api/login", "", gin.H{"email": user.Email})
+	expectInvalid(t, res, "password")
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	res := call(t, http.MethodGet, "/api/bookmarks", "", nil)
+	expectError(t, res, http.StatusUnauthorized, "auth.token_missing")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/bookmarks", nil)
+	req.Header.Set("Authorization", "Token abc")
+	expectError(t, serve(t, req, ""), http.StatusUnauthorized, "auth.token_malformed")
+
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"user_id": 1, "exp": time.Now().Add(time.Hour).Unix()})
+	signed, _ := forged.SignedString([]byte("another-secret-entirely"))
+	res = call(t, http.MethodGet, "/api/bookmarks", signed, nil)
+	expectError(t, res, http.StatusUnauthorized, "auth.token_invalid")
+
+	// 封禁后已签发的 token 也会被拒绝
+	user := newUser(t)
+	now := time.Now()
+	user.BannedAt = &now
+	mirror(t, &user.User)
+	cache.Del(service.UserStatusKey(user.ID))
+	res = call(t, http.MethodGet, "/api/bookmarks", user.Token, nil)
+	expectError(t, res, http.StatusForbidden, "auth.user_banned")
+}
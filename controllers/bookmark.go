@@ -0,0 +1,338 @@
+package controllers
+
+import (
+	"goblog/database"
+	"goblog/models"
+	"goblog/pkg/apperror"
+	"goblog/pkg/audit"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BookmarkInput struct {
+	PostID       uint  `json:"post_id" binding:"required"`
+	CollectionID *uint `json:"collection_id"`
+}
+
+type CollectionInput struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description"`
+	IsPublic    bool   `json:"is_public"`
+}
+
+// AddBookmark godoc
+// @Summary 收藏文章
+// @Description 收藏文章，可选放入某个收藏夹；已收藏的文章会被移动到新的收藏夹
+// @Tags 收藏
+// @Accept json
+// @Produce json
+// @Param bookmark body BookmarkInput true "收藏数据"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Router /bookmarks [post]
+// @Security ApiKeyAuth
+func AddBookmark(c *gin.Context) {
+	var input BookmarkInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperror.FromBind(err))
+		return
+	}
+
+	userID := c.MustGet("user_id").(uint)
+
+	if err := database.DB.Where("is_draft = ?", false).First(&models.Post{}, input.PostID).Error; err != nil {
+		c.Error(apperror.NotFound(apperror.CodePostNotFound))
+		return
+	}
+
+	if input.CollectionID != nil {
+		if err := database.DB.Where("user_id = ?", userID).First(&models.BookmarkCollection{}, *input.CollectionID).Error; err != nil {
+			c.Error(apperror.NotFound(apperror.CodeCollectionNotFound))
+			return
+		}
+	}
+
+	bookmark := models.Bookmark{
+		UserID:       userID,
+		PostID:       input.PostID,
+		CollectionID: input.CollectionID,
+	}
+
+	if err := database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "post_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"collection_id"}),
+	}).Create(&bookmark).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "收藏成功", "bookmark": bookmark})
+}
+
+// RemoveBookmark godoc
+// @Summary 取消收藏
+// @Tags 收藏
+// @Accept json
+// @Produce json
+// @Param post_id path int true "文章 ID"
+// @Success 200 {object} map[string]string
+// @Router /bookmarks/{post_id} [delete]
+// @Security ApiKeyAuth
+func RemoveBookmark(c *gin.Context) {
+	postID, err := strconv.Atoi(c.Param("post_id"))
+	if err != nil {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidPostID))
+		return
+	}
+
+	userID := c.MustGet("user_id").(uint)
+
+	if err := database.DB.Where("user_id = ? AND post_id = ?", userID, postID).Delete(&models.Bookmark{}).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "已取消收藏"})
+}
+
+// GetBookmarks godoc
+// @Summary 获取我的收藏
+// @Tags 收藏
+// @Accept json
+// @Produce json
+// @Param collection_id query int false "收藏夹 ID，不传返回全部收藏"
+// @Param page query int false "页码"
+// @Param limit query int false "每页数量"
+// @Success 200 {object} map[string]interface{}
+// @Router /bookmarks [get]
+// @Security ApiKeyAuth
+func GetBookmarks(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	query := database.DB.Where("bookmarks.user_id = ?", userID)
+	if collectionID := c.Query("collection_id"); collectionID != "" {
+		query = query.Where("bookmarks.collection_id = ?", collectionID)
+	}
+
+	bookmarks, err := listBookmarks(c, query)
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"bookmarks": bookmarks})
+}
+
+// CreateCollection godoc
+// @Summary 创建收藏夹
+// @Tags 收藏
+// @Accept json
+// @Produce json
+// @Param collection body CollectionInput true "收藏夹信息"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Router /collections [post]
+// @Security ApiKeyAuth
+func CreateCollection(c *gin.Context) {
+	var input CollectionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperror.FromBind(err))
+		return
+	}
+
+	collection := models.BookmarkCollection{
+		UserID:      c.MustGet("user_id").(uint),
+		Name:        input.Name,
+		Description: input.Description,
+		IsPublic:    input.IsPublic,
+	}
+
+	if err := database.DB.Create(&collection).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "收藏夹创建成功", "collection": collection})
+}
+
+// GetMyCollections godoc
+// @Summary 获取我的收藏夹
+// @Tags 收藏
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /collections [get]
+// @Security ApiKeyAuth
+func GetMyCollections(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	var collections []models.BookmarkCollection
+	if err := database.DB.Where("user_id = ?", userID).Order("created_at asc").Find(&collections).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"collections": collections})
+}
+
+// GetUserCollections godoc
+// @Summary 获取用户公开的收藏夹
+// @Tags 收藏
+// @Accept json
+// @Produce json
+// @Param id path int true "用户 ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /users/{id}/collections [get]
+func GetUserCollections(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidUserID))
+		return
+	}
+
+	var collections []models.BookmarkCollection
+	if err := database.DB.Where("user_id = ? AND is_public = ?", userID, true).Order("created_at asc").Find(&collections).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"collections": collections})
+}
+
+// GetCollection godoc
+// @Summary 获取收藏夹及其中的文章
+// @Description 公开收藏夹所有人可见，私有收藏夹仅创建者可见
+// @Tags 收藏
+// @Accept json
+// @Produce json
+// @Param id path int true "收藏夹 ID"
+// @Param page query int false "页码"
+// @Param limit query int false "每页数量"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} apperror.Problem
+// @Router /collections/{id} [get]
+func GetCollection(c *gin.Context) {
+	collectionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidCollectionID))
+		return
+	}
+
+	var collection models.BookmarkCollection
+	if err := database.DB.First(&collection, collectionID).Error; err != nil {
+		c.Error(apperror.NotFound(apperror.CodeCollectionNotFound))
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	if !collection.IsPublic && userID != collection.UserID {
+		c.Error(apperror.NotFound(apperror.CodeCollectionNotFound))
+		return
+	}
+
+	bookmarks, err := listBookmarks(c, database.DB.Where("bookmarks.collection_id = ?", collection.ID))
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"collection": collection, "bookmarks": bookmarks})
+}
+
+// UpdateCollection godoc
+// @Summary 修改收藏夹
+// @Tags 收藏
+// @Accept json
+// @Produce json
+// @Param id path int true "收藏夹 ID"
+// @Param collection body CollectionInput true "收藏夹信息"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} apperror.Problem
+// @Router /collections/{id} [put]
+// @Security ApiKeyAuth
+func UpdateCollection(c *gin.Context) {
+	collection, ok := findOwnCollection(c)
+	if !ok {
+		return
+	}
+
+	var input CollectionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperror.FromBind(err))
+		return
+	}
+
+	if err := database.DB.Model(&collection).Updates(map[string]any{
+		"name":        input.Name,
+		"description": input.Description,
+		"is_public":   input.IsPublic,
+	}).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "收藏夹更新成功", "collection": collection})
+}
+
+// DeleteCollection godoc
+// @Summary 删除收藏夹
+// @Description 收藏夹中的收藏不会被删除，只是移出该收藏夹
+// @Tags 收藏
+// @Accept json
+// @Produce json
+// @Param id path int true "收藏夹 ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} apperror.Problem
+// @Router /collections/{id} [delete]
+// @Security ApiKeyAuth
+func DeleteCollection(c *gin.Context) {
+	collection, ok := findOwnCollection(c)
+	if !ok {
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Bookmark{}).Where("collection_id = ?", collection.ID).Update("collection_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&collection).Error
+	})
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	recordAudit(c, audit.Entry{Action: audit.ActionCollectionDelete, TargetType: "collection", TargetID: collection.ID, Before: collection})
+	c.JSON(http.StatusOK, gin.H{"message": "收藏夹删除成功"})
+}
+
+func findOwnCollection(c *gin.Context) (models.BookmarkCollection, bool) {
+	var collection models.BookmarkCollection
+
+	collectionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidCollectionID))
+		return collection, false
+	}
+
+	userID := c.MustGet("user_id").(uint)
+	if err := database.DB.Where("user_id = ?", userID).First(&collection, collectionID).Error; err != nil {
+		c.Error(apperror.NotFound(apperror.CodeCollectionNotFound))
+		return collection, false
+	}
+	return collection, true
+}
+
+func listBookmarks(c *gin.Context, query *gorm.DB) ([]models.Bookmark, error) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	var bookmarks []models.Bookmark
+	err := query.Joins("JOIN posts ON posts.id = bookmarks.post_id AND posts.deleted_at IS NULL AND posts.is_draft = ?", false).
+		Preload("Post.User").Preload("Post.Tags").
+		Order("bookmarks.created_at desc").Limit(limit).Offset((page - 1) * limit).
+		Find(&bookmarks).Error
+	return bookmarks, err
+}
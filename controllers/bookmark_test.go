@@ -0,0 +1,131 @@
+package controllers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBookmarks(t *testing.T) {
+	author, reader := newUser(t), newUser(t)
+	post, draft := newPost(t, author, false), newPost(t, author, true)
+
+	res := call(t, http.MethodPost, "/api/collections", reader.Token, gin.H{"name": "稍后阅读"})
+	expect(t, res, http.StatusCreated, "collection.id")
+	collection := number(t, res, "collection.id")
+
+	res = call(t, http.MethodPost, "/api/bookmarks", reader.Token, gin.H{"post_id": post.ID, "collection_id": collection})
+	expect(t, res, http.StatusCreated, "message", "bookmark.post_id", "bookmark.collection_id")
+
+	res = call(t, http.MethodPost, "/api/bookmarks", reader.Token, gin.H{"post_id": draft.ID})
+	expectError(t, res, http.StatusNotFound, "post.not_found")
+
+	res = call(t, http.MethodPost, "/api/bookmarks", author.Token, gin.H{"post_id": post.ID, "collection_id": collection})
+	expectError(t, res, http.StatusNotFound, "collection.not_found")
+
+	res = call(t, http.MethodPost, "/api/bookmarks", reader.Token, gin.H{})
+	expectInvalid(t, res, "post_id")
+
+	res = call(t, http.MethodGet, "/api/bookmarks", reader.Token, nil)
+	expect(t, res, http.StatusOK, "bookmarks")
+	bookmarks := list(t, res, "bookmarks")
+	if len(bookmarks) != 1 {
+		t.Fatalf("应有 1 个收藏: %s", res.Raw)
+	}
+	if _, ok := lookup(bookmarks[0].(map[string]any), "post.author"); !ok {
+		t.Fatalf("收藏缺少文章和作者: %s", res.Raw)
+	}
+
+	res = call(t, http.MethodGet, path("/api/bookmarks?collection_id=%d", collection), reader.Token, nil)
+	expect(t, res, http.StatusOK, "bookmarks")
+	if len(list(t, res, "bookmarks")) != 1 {
+		t.Fatalf("收藏夹中应有 1 个收藏: %s", res.Raw)
+	}
+
+	res = call(t, http.MethodDelete, path("/api/bookmarks/%d", post.ID), reader.Token, nil)
+	expect(t, res, http.StatusOK, "message")
+
+	res = call(t, http.MethodGet, "/api/bookmarks", reader.Token, nil)
+	expect(t, res, http.StatusOK, "bookmarks")
+	if len(list(t, res, "bookmarks")) != 0 {
+		t.Fatalf("取消收藏后应为空: %s", res.Raw)
+	}
+
+	res = call(t, http.MethodDelete, "/api/bookmarks/abc", reader.Token, nil)
+	expectError(t, res, http.StatusBadRequest, "post.invalid_id")
+
+	res = call(t, http.MethodPost, "/api/bookmarks", "", gin.H{"post_id": post.ID})
+	expectError(t, res, http.StatusUnauthorized, "auth.token_missing")
+}
+
+func TestCollections(t *testing.T) {
+	owner, other := newUser(t), newUser(t)
+	post := newPost(t, other, false)
+
+	res := call(t, http.MethodPost, "/api/collections", owner.Token, gin.H{"name": "私藏", "description": "不公开"})
+	expect(t, res, http.StatusCreated, "message", "collection.id", "collection.name", "collection.is_public")
+	private := number(t, res, "collection.id")
+
+	res = call(t, http.MethodPost, "/api/collections", owner.Token, gin.H{"name": "推荐", "is_public": true})
+	expect(t, res, http.StatusCreated, "collection.id")
+	public := number(t, res, "collection.id")
+	expect(t, call(t, http.MethodPost, "/api/bookmarks", owner.Token, gin.H{"post_id": post.ID, "collection_id": public}), http.StatusCreated)
+
+	res = call(t, http.MethodPost, "/api/collections", owner.Token, gin.H{"description": "没有名字"})
+	expectInvalid(t, res, "name")
+
+	res = call(t, http.MethodGet, "/api/collections", owner.Token, nil)
+	expect(t, res, http.StatusOK, "collections")
+	if len(list(t, res, "collections")) != 2 {
+		t.Fatalf("应有 2 个收藏夹: %s", res.Raw)
+	}
+
+	res = call(t, http.MethodGet, path("/api/users/%d/collections", owner.ID), "", nil)
+	expect(t, res, http.StatusOK, "collections")
+	if len(list(t, res, "collections")) != 1 {
+		t.Fatalf("公开的收藏夹应只有 1 个: %s", res.Raw)
+	}
+
+	res = call(t, http.MethodGet, "/api/users/abc/collections", "", nil)
+	expectError(t, res, http.StatusBadRequest, "user.invalid_id")
+
+	res = call(t, http.MethodGet, path("/api/collections/%d", public), "", nil)
+	expect(t, res, http.StatusOK, "collection.id", "bookmarks")
+	if len(list(t, res, "bookmarks")) != 1 {
+		t.Fatalf("公开收藏夹应有 1 个收藏: %s", res.Raw)
+	}
+
+	// 私有收藏夹只有自己能看到
+	res = call(t, http.MethodGet, path("/api/collections/%d", private), other.Token, nil)
+	expectError(t, res, http.StatusNotFound, "collection.not_found")
+	res = call(t, http.MethodGet, path("/api/collections/%d", private), owner.Token, nil)
+	expect(t, res, http.StatusOK, "collection.id", "bookmarks")
+
+	res = call(t, http.MethodGet, "/api/collections/abc", "", nil)
+	expectError(t, res, http.StatusBadRequest, "collection.invalid_id")
+
+	res = call(t, http.MethodPut, path("/api/collections/%d", private), owner.Token, gin.H{"name": "改名", "is_public": true})
+	expect(t, res, http.StatusOK, "message", "collection.id")
+
+	res = call(t, http.MethodPut, path("/api/collections/%d", private), other.Token, gin.H{"name": "抢走"})
+	expectError(t, res, http.StatusNotFound, "collection.not_found")
+
+	res = call(t, http.MethodPut, path("/api/collections/%d", private), owner.Token, gin.H{"name": ""})
+	expectInvalid(t, res, "name")
+
+	res = call(t, http.MethodDelete, path("/api/collections/%d", public), other.Token, nil)
+	expectError(t, res, http.StatusNotFound, "collection.not_found")
+
+	// 删除收藏夹后收藏保留
+	res = call(t, http.MethodDelete, path("/api/collections/%d", public), owner.Token, nil)
+	expect(t, res, http.StatusOK, "message")
+	res = call(t, http.MethodGet, "/api/bookmarks", owner.Token, nil)
+	expect(t, res, http.StatusOK, "bookmarks")
+	if len(list(t, res, "bookmarks")) != 1 {
+		t.Fatalf("删除收藏夹后收藏应保留: %s", res.Raw)
+	}
+
+	res = call(t, http.MethodGet, "/api/collections", "", nil)
+	expectError(t, res, http.StatusUnauthorized, "auth.token_missing")
+}
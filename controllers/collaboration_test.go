@@ -0,0 +1,237 @@
+package controllers_test
+
+import (
+	"goblog/database"
+	"goblog/models"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCollaborators(t *testing.T) {
+	owner, coAuthor, outsider := newUser(t), newUser(t), newUser(t)
+	post := newPost(t, owner, false)
+
+	res := call(t, http.MethodPost, path("/api/posts/%d/collaborators", post.ID), owner.Token, gin.H{"user_id": coAuthor.ID, "role": models.CollaboratorCoAuthor})
+	expect(t, res, http.StatusCreated, "message", "invitation.id", "invitation.status", "invitation.user")
+	invitation := number(t, res, "invitation.id")
+
+	res = call(t, http.MethodPost, path("/api/posts/%d/collaborators", post.ID), owner.Token, gin.H{"user_id": coAuthor.ID, "role": models.CollaboratorReviewer})
+	expectError(t, res, http.StatusConflict, "collaborator.already_invited")
+
+	res = call(t, http.MethodPost, path("/api/posts/%d/collaborators", post.ID), owner.Token, gin.H{"user_id": owner.ID, "role": models.CollaboratorCoAuthor})
+	expectError(t, res, http.StatusBadRequest, "collaborator.invite_owner")
+
+	res = call(t, http.MethodPost, path("/api/posts/%d/collaborators", post.ID), owner.Token, gin.H{"user_id": 999999, "role": models.CollaboratorCoAuthor})
+	expectError(t, res, http.StatusNotFound, "user.not_found")
+
+	res = call(t, http.MethodPost, path("/api/posts/%d/collaborators", post.ID), owner.Token, gin.H{"user_id": outsider.ID, "role": "editor"})
+	expectInvalid(t, res, "role")
+
+	res = call(t, http.MethodPost, path("/api/posts/%d/collaborators", post.ID), outsider.Token, gin.H{"user_id": outsider.ID, "role": models.CollaboratorReviewer})
+	expectError(t, res, http.StatusForbidden, "collaborator.manage_forbidden")
+
+	// 接受邀请之前不能查看协作者，也不能修改文章
+	res = call(t, http.MethodGet, path("/api/posts/%d/collaborators", post.ID), coAuthor.Token, nil)
+	expectError(t, res, http.StatusForbidden, "collaborator.view_forbidden")
+
+	res = call(t, http.MethodGet, "/api/invitations", coAuthor.Token, nil)
+	expect(t, res, http.StatusOK, "invitations")
+	if len(list(t, res, "invitations")) != 1 {
+		t.Fatalf("应有 1 个待处理的邀请: %s", res.Raw)
+	}
+
+	res = call(t, http.MethodPost, path("/api/invitations/%d/accept", invitation), outsider.Token, nil)
+	expectError(t, res, http.StatusNotFound, "invitation.not_found")
+
+	res = call(t, http.MethodPost, path("/api/invitations/%d/accept", invitation), coAuthor.Token, nil)
+	expect(t, res, http.StatusOK, "message", "invitation.id")
+
+	res = call(t, http.MethodGet, path("/api/posts/%d/collaborators", post.ID), coAuthor.Token, nil)
+	expect(t, res, http.StatusOK, "owner.id", "collaborators")
+	if len(list(t, res, "collaborators")) != 1 {
+		t.Fatalf("应有 1 个协作者: %s", res.Raw)
+	}
+
+	res = call(t, http.MethodPut, path("/api/posts/%d", post.ID), coAuthor.Token, gin.H{"content": "合著者修改"})
+	expect(t, res, http.StatusOK, "post.id")
+
+	res = call(t, http.MethodDelete, path("/api/posts/%d/collaborators/%d", post.ID, coAuthor.ID), outsider.Token, nil)
+	expectError(t, res, http.StatusForbidden, "collaborator.remove_forbidden")
+
+	res = call(t, http.MethodDelete, path("/api/posts/%d/collaborators/%d", post.ID, coAuthor.ID), owner.Token, nil)
+	expect(t, res, http.StatusOK, "message")
+
+	res = call(t, http.MethodDelete, path("/api/posts/%d/collaborators/%d", post.ID, coAuthor.ID), owner.Token, nil)
+	expectError(t, res, http.StatusNotFound, "collaborator.not_found")
+
+	res = call(t, http.MethodDelete, path("/api/posts/%d/collaborators/abc", post.ID), owner.Token, nil)
+	expectError(t, res, http.StatusBadRequest, "user.invalid_id")
+
+	res = call(t, http.MethodGet, "/api/posts/999999/collaborators", owner.Token, nil)
+	expectError(t, res, http.StatusNotFound, "post.not_found")
+}
+
+func TestDeclineInvitation(t *testing.T) {
+	owner, reviewer := newUser(t), newUser(t)
+	post := newPost(t, owner, true)
+
+	res := call(t, http.MethodPost, path("/api/posts/%d/collaborators", post.ID), owner.Token, gin.H{"user_id": reviewer.ID, "role": models.CollaboratorReviewer})
+	expect(t, res, http.StatusCreated, "invitation.id")
+	invitation := number(t, res, "invitation.id")
+
+	res = call(t, http.MethodPost, path("/api/invitations/%d/decline", invitation), reviewer.Token, nil)
+	expect(t, res, http.StatusOK, "message", "invitation.id")
+
+	res = call(t, http.MethodPost, path("/api/invitations/%d/decline", invitation), reviewer.Token, nil)
+	expectError(t, res, http.StatusNotFound, "invitation.not_found")
+
+	res = call(t, http.MethodPost, "/api/invitations/abc/decline", reviewer.Token, nil)
+	expectError(t, res, http.StatusBadRequest, "invitation.invalid_id")
+
+	// 被拒绝的邀请可以重新发出
+	res = call(t, http.MethodPost, path("/api/posts/%d/collaborators", post.ID), owner.Token, gin.H{"user_id": reviewer.ID, "role": models.CollaboratorReviewer})
+	expect(t, res, http.StatusCreated, "invitation.id")
+
+	res = call(t, http.MethodGet, "/api/invitations", "", nil)
+	expectError(t, res, http.StatusUnauthorized, "auth.token_missing")
+}
+
+func TestTransitions(t *testing.T) {
+	owner, outsider := newUser(t), newUser(t)
+	editor := withRole(t, newUser(t), models.RoleEditor)
+	post := newPost(t, owner, true)
+
+	res := call(t, http.MethodPost, path("/api/posts/%d/transitions", post.ID), owner.Token, gin.H{"action": "submit"})
+	expect(t, res, http.StatusOK, "message", "post.status")
+	if status, _ := lookup(res.Body, "post.status"); status != models.PostInReview {
+		t.Fatalf("提交后状态应为 in_review: %s", res.Raw)
+	}
+
+	// 作者不能审核自己的文章
+	res = call(t, http.MethodPost, path("/api/posts/%d/transitions", post.ID), owner.Token, gin.H{"action": "approve"})
+	expectError(t, res, http.StatusForbidden, "workflow.forbidden")
+
+	res = call(t, http.MethodPost, path("/api/posts/%d/transitions", post.ID), editor.Token, gin.H{"action": "approve", "comment": "可以发布"})
+	expect(t, res, http.StatusOK, "post.status")
+
+	res = call(t, http.MethodPost, path("/api/posts/%d/transitions", post.ID), owner.Token, gin.H{"action": "submit"})
+	expectError(t, res, http.StatusConflict, "workflow.invalid_transition")
+
+	res = call(t, http.MethodPost, path("/api/posts/%d/transitions", post.ID), owner.Token, gin.H{"action": "delete"})
+	expectInvalid(t, res, "action")
+
+	res = call(t, http.MethodPost, "/api/posts/999999/transitions", owner.Token, gin.H{"action": "submit"})
+	expectError(t, res, http.StatusNotFound, "post.not_found")
+
+	res = call(t, http.MethodGet, path("/api/posts/%d/transitions", post.ID), owner.Token, nil)
+	expect(t, res, http.StatusOK, "status", "transitions")
+	transitions := list(t, res, "transitions")
+	if len(transitions) != 2 {
+		t.Fatalf("应有 2 条状态变更记录: %s", res.Raw)
+	}
+	if _, ok := transitions[1].(map[string]any)["actor"]; !ok {
+		t.Fatalf("状态变更记录缺少 actor: %s", res.Raw)
+	}
+
+	res = call(t, http.MethodGet, path("/api/posts/%d/transitions", post.ID), outsider.Token, nil)
+	expectError(t, res, http.StatusForbidden, "review.forbidden")
+
+	res = call(t, http.MethodGet, path("/api/posts/%d/transitions", post.ID), "", nil)
+	expectError(t, res, http.StatusUnauthorized, "auth.token_missing")
+}
+
+func TestConcurrentTransitions(t *testing.T) {
+	owner := newUser(t)
+	editor := withRole(t, newUser(t), models.RoleEditor)
+	post := newPost(t, owner, true)
+
+	// 同时审核通过和撤回，只能有一个生效，状态变更记录首尾相接
+	for range 5 {
+		res := call(t, http.MethodPost, path("/api/posts/%d/transitions", post.ID), owner.Token, gin.H{"action": "submit"})
+		expect(t, res, http.StatusOK, "post.status")
+
+		var wg sync.WaitGroup
+		codes := make([]int, 2)
+		for i, actor := range []struct {
+			token, action string
+		}{{editor.Token, "approve"}, {owner.Token, "withdraw"}} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				codes[i] = call(t, http.MethodPost, path("/api/posts/%d/transitions", post.ID), actor.token, gin.H{"action": actor.action}).Code
+			}()
+		}
+		wg.Wait()
+		slices.Sort(codes)
+		if !slices.Equal(codes, []int{http.StatusOK, http.StatusConflict}) {
+			t.Fatalf("并发操作的状态码为 %v，期望一个 200 一个 409", codes)
+		}
+
+		// 审核通过后退回草稿，进入下一轮
+		if status, _ := lookup(call(t, http.MethodGet, path("/api/posts/%d/transitions", post.ID), owner.Token, nil).Body, "status"); status == models.PostApproved {
+			res := call(t, http.MethodPost, path("/api/posts/%d/transitions", post.ID), editor.Token, gin.H{"action": "request_changes"})
+			expect(t, res, http.StatusOK, "post.status")
+		}
+	}
+
+	var transitions []models.PostTransition
+	if err := database.DB.Where("post_id = ?", post.ID).Order("id").Find(&transitions).Error; err != nil {
+		t.Fatal(err)
+	}
+	status := models.PostDraft
+	for _, tr := range transitions {
+		if tr.From != status {
+			t.Fatalf("状态变更记录 %s: %s -> %s 与上一条的 %s 不连续", tr.Action, tr.From, tr.To, status)
+		}
+		status = tr.To
+	}
+}
+
+func TestReviewComments(t *testing.T) {
+	owner, outsider := newUser(t), newUser(t)
+	editor := withRole(t, newUser(t), models.RoleEditor)
+	post := newPost(t, owner, true)
+
+	res := call(t, http.MethodPost, path("/api/posts/%d/review-comments", post.ID), editor.Token, gin.H{"body": "这里需要修改", "quote": "正文", "anchor_start": 0, "anchor_end": 2})
+	expect(t, res, http.StatusCreated, "message", "comment.id", "comment.user", "comment.anchor_start")
+	comment := number(t, res, "comment.id")
+
+	res = call(t, http.MethodPost, path("/api/posts/%d/review-comments", post.ID), editor.Token, gin.H{"body": "只有开始位置", "anchor_start": 1})
+	expectError(t, res, http.StatusBadRequest, "review.invalid_anchor")
+
+	res = call(t, http.MethodPost, path("/api/posts/%d/review-comments", post.ID), editor.Token, gin.H{"quote": "正文"})
+	expectInvalid(t, res, "body")
+
+	res = call(t, http.MethodPost, path("/api/posts/%d/review-comments", post.ID), outsider.Token, gin.H{"body": "路人批注"})
+	expectError(t, res, http.StatusForbidden, "review.forbidden")
+
+	res = call(t, http.MethodGet, path("/api/posts/%d/review-comments", post.ID), owner.Token, nil)
+	expect(t, res, http.StatusOK, "comments")
+	if len(list(t, res, "comments")) != 1 {
+		t.Fatalf("应有 1 条未解决的批注: %s", res.Raw)
+	}
+
+	res = call(t, http.MethodPost, path("/api/posts/%d/review-comments/%d/resolve", post.ID, comment), owner.Token, nil)
+	expect(t, res, http.StatusOK, "message")
+
+	res = call(t, http.MethodGet, path("/api/posts/%d/review-comments", post.ID), owner.Token, nil)
+	expect(t, res, http.StatusOK, "comments")
+	if len(list(t, res, "comments")) != 0 {
+		t.Fatalf("已解决的批注默认不返回: %s", res.Raw)
+	}
+	res = call(t, http.MethodGet, path("/api/posts/%d/review-comments?include_resolved=true", post.ID), owner.Token, nil)
+	expect(t, res, http.StatusOK, "comments")
+	if len(list(t, res, "comments")) != 1 {
+		t.Fatalf("include_resolved=true 时应返回已解决的批注: %s", res.Raw)
+	}
+
+	res = call(t, http.MethodPost, path("/api/posts/%d/review-comments/999999/resolve", post.ID), owner.Token, nil)
+	expectError(t, res, http.StatusNotFound, "review.comment_not_found")
+
+	res = call(t, http.MethodPost, path("/api/posts/%d/review-comments/abc/resolve", post.ID), owner.Token, nil)
+	expectError(t, res, http.StatusBadRequest, "review.invalid_comment_id")
+}
@@ -0,0 +1,293 @@
+package controllers
+
+import (
+	"goblog/database"
+	"goblog/models"
+	"goblog/pkg/apperror"
+	"goblog/pkg/audit"
+	"goblog/service"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InviteCollaboratorInput struct {
+	UserID uint   `json:"user_id" binding:"required"`
+	Role   string `json:"role" binding:"required,oneof=co_author reviewer"`
+}
+
+// postRole 返回用户在文章中的角色，owner 为文章作者，其余来自已接受的邀请
+func postRole(post models.Post, userID uint) string {
+	if post.UserID == userID {
+		return models.CollaboratorOwner
+	}
+
+	var collaborator models.PostCollaborator
+	if err := database.DB.Where("post_id = ? AND user_id = ? AND status = ?", post.ID, userID, models.InvitationAccepted).
+		First(&collaborator).Error; err != nil {
+		return ""
+	}
+	return collaborator.Role
+}
+
+func canEditPost(post models.Post, userID uint) bool {
+	role := postRole(post, userID)
+	return role == models.CollaboratorOwner || role == models.CollaboratorCoAuthor
+}
+
+// InviteCollaborator godoc
+// @Summary 邀请协作者
+// @Description 文章作者邀请其他用户成为合著者（co_author）或审稿人（reviewer），对方接受后生效
+// @Tags 协作
+// @Accept json
+// @Produce json
+// @Param id path int true "文章 ID"
+// @Param invitation body InviteCollaboratorInput true "邀请信息"
+// @Success 201 {object} map[string]interface{}
+// @Failure 403 {object} apperror.Problem
+// @Router /posts/{id}/collaborators [post]
+// @Security ApiKeyAuth
+func InviteCollaborator(c *gin.Context) {
+	post, ok := findOwnPost(c)
+	if !ok {
+		return
+	}
+
+	var input InviteCollaboratorInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperror.FromBind(err))
+		return
+	}
+
+	if input.UserID == post.UserID {
+		c.Error(apperror.BadRequest(apperror.CodeInviteSelf))
+		return
+	}
+	if err := database.DB.First(&models.User{}, input.UserID).Error; err != nil {
+		c.Error(apperror.NotFound(apperror.CodeUserNotExist))
+		return
+	}
+
+	var collaborator models.PostCollaborator
+	result := database.DB.Where("post_id = ? AND user_id = ?", post.ID, input.UserID).First(&collaborator)
+	if result.RowsAffected > 0 && collaborator.Status != models.InvitationDeclined {
+		c.Error(apperror.Conflict(apperror.CodeAlreadyInvited))
+		return
+	}
+
+	// 被拒绝过的邀请可以重新发出
+	collaborator.PostID = post.ID
+	collaborator.UserID = input.UserID
+	collaborator.Role = input.Role
+	collaborator.Status = models.InvitationPending
+	collaborator.InvitedBy = post.UserID
+	collaborator.AcceptedAt = nil
+
+	if err := database.DB.Save(&collaborator).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	database.DB.Preload("User").First(&collaborator, collaborator.ID)
+	recordAudit(c, audit.Entry{Action: audit.ActionCollaboratorInvite, TargetType: "post", TargetID: post.ID, After: collaborator})
+
+	c.JSON(http.StatusCreated, gin.H{"message": "邀请已发送", "invitation": collaborator})
+}
+
+// GetCollaborators godoc
+// @Summary 获取文章的协作者
+// @Tags 协作
+// @Accept json
+// @Produce json
+// @Param id path int true "文章 ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} apperror.Problem
+// @Router /posts/{id}/collaborators [get]
+// @Security ApiKeyAuth
+func GetCollaborators(c *gin.Context) {
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidPostID))
+		return
+	}
+
+	var post models.Post
+	if err := database.DB.Preload("User").First(&post, postID).Error; err != nil {
+		c.Error(apperror.NotFound(apperror.CodePostNotFound))
+		return
+	}
+
+	userID := c.MustGet("user_id").(uint)
+	if postRole(post, userID) == "" {
+		c.Error(apperror.Forbidden(apperror.CodeCollaboratorViewDenied))
+		return
+	}
+
+	var collaborators []models.PostCollaborator
+	if err := database.DB.Preload("User").Where("post_id = ?", post.ID).Order("created_at asc").Find(&collaborators).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"owner": post.User, "collaborators": collaborators})
+}
+
+// RemoveCollaborator godoc
+// @Summary 移除协作者
+// @Description 文章作者可以移除任意协作者，协作者也可以退出协作
+// @Tags 协作
+// @Accept json
+// @Produce json
+// @Param id path int true "文章 ID"
+// @Param user_id path int true "协作者用户 ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} apperror.Problem
+// @Router /posts/{id}/collaborators/{user_id} [delete]
+// @Security ApiKeyAuth
+func RemoveCollaborator(c *gin.Context) {
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidPostID))
+		return
+	}
+	collaboratorID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidUserID))
+		return
+	}
+
+	var post models.Post
+	if err := database.DB.First(&post, postID).Error; err != nil {
+		c.Error(apperror.NotFound(apperror.CodePostNotFound))
+		return
+	}
+
+	userID := c.MustGet("user_id").(uint)
+	if post.UserID != userID && uint(collaboratorID) != userID {
+		c.Error(apperror.Forbidden(apperror.CodeCollaboratorRemoveDenied))
+		return
+	}
+
+	var collaborator models.PostCollaborator
+	if err := database.DB.Preload("User").Where("post_id = ? AND user_id = ?", post.ID, collaboratorID).First(&collaborator).Error; err != nil {
+		c.Error(apperror.NotFound(apperror.CodeCollaboratorNotFound))
+		return
+	}
+	if err := database.DB.Delete(&collaborator).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	recordAudit(c, audit.Entry{Action: audit.ActionCollaboratorRemove, TargetType: "post", TargetID: post.ID, Before: collaborator})
+	// 文章详情和列表中的 authors 包含合著者
+	service.InvalidatePost(post.ID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "协作者已移除"})
+}
+
+// GetInvitations godoc
+// @Summary 获取我收到的协作邀请
+// @Tags 协作
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /invitations [get]
+// @Security ApiKeyAuth
+func GetInvitations(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	var invitations []models.PostCollaborator
+	if err := database.DB.Preload("Post").Preload("Post.User").
+		Where("user_id = ? AND status = ?", userID, models.InvitationPending).
+		Order("created_at desc").Find(&invitations).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invitations": invitations})
+}
+
+// AcceptInvitation godoc
+// @Summary 接受协作邀请
+// @Tags 协作
+// @Accept json
+// @Produce json
+// @Param id path int true "邀请 ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} apperror.Problem
+// @Router /invitations/{id}/accept [post]
+// @Security ApiKeyAuth
+func AcceptInvitation(c *gin.Context) {
+	respondInvitation(c, models.InvitationAccepted)
+}
+
+// DeclineInvitation godoc
+// @Summary 拒绝协作邀请
+// @Tags 协作
+// @Accept json
+// @Produce json
+// @Param id path int true "邀请 ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} apperror.Problem
+// @Router /invitations/{id}/decline [post]
+// @Security ApiKeyAuth
+func DeclineInvitation(c *gin.Context) {
+	respondInvitation(c, models.InvitationDeclined)
+}
+
+func respondInvitation(c *gin.Context, status string) {
+	invitationID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidInvitationID))
+		return
+	}
+
+	userID := c.MustGet("user_id").(uint)
+
+	var invitation models.PostCollaborator
+	if err := database.DB.Preload("User").Where("user_id = ? AND status = ?", userID, models.InvitationPending).
+		First(&invitation, invitationID).Error; err != nil {
+		c.Error(apperror.NotFound(apperror.CodeInvitationNotFound))
+		return
+	}
+
+	updates := map[string]any{"status": status}
+	if status == models.InvitationAccepted {
+		updates["accepted_at"] = time.Now()
+	}
+	if err := database.DB.Model(&invitation).Updates(updates).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	if status == models.InvitationAccepted {
+		service.InvalidatePost(invitation.PostID)
+	}
+
+	message := "已拒绝邀请"
+	if status == models.InvitationAccepted {
+		message = "已接受邀请"
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message, "invitation": invitation})
+}
+
+// findOwnPost 查找当前用户作为 owner 的文章
+func findOwnPost(c *gin.Context) (models.Post, bool) {
+	var post models.Post
+
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidPostID))
+		return post, false
+	}
+
+	if err := database.DB.First(&post, postID).Error; err != nil {
+		c.Error(apperror.NotFound(apperror.CodePostNotFound))
+		return post, false
+	}
+
+	if post.UserID != c.MustGet("user_id").(uint) {
+		c.Error(apperror.Forbidden(apperror.CodeCollaboratorManageDenied))
+		return post, false
+	}
+	return post, true
+}
@@ -1,8 +1,11 @@
 package controllers
 
 import (
-	"goblog/database"
+	"goblog/config"
 	"goblog/models"
+	"goblog/pkg/apperror"
+	"goblog/pkg/cache"
+	"goblog/service"
 	"net/http"
 	"strconv"
 
@@ -22,33 +25,22 @@ type CreateCommentInput struct {
 // @Produce json
 // @Param comment body CreateCommentInput true "评论数据"
 // @Success 201 {object} map[string]interface{}
-// @Failure 400 {object} map[string]string
+// @Failure 400 {object} apperror.Problem
 // @Router /comments [post]
 // @Security ApiKeyAuth
-func CreateComment(c *gin.Context) {
+func (h *Handler) CreateComment(c *gin.Context) {
 	var input CreateCommentInput
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数无效", "detail": err.Error()})
+		c.Error(apperror.FromBind(err))
 		return
 	}
 
 	userID := c.MustGet("user_id").(uint)
 
-	comment := models.Conment{
-		Content:  input.Content,
-		UserID:   userID,
-		PostID:   input.PostID,
-		ParentID: input.ParentID,
-	}
-
-	if err := database.DB.Create(&comment).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建评论失败"})
-		return
-	}
-
-	if err := database.DB.Preload("User").Preload("Replies.User").First(&comment, comment.ID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "加载用户消息失败"})
+	comment, err := h.svc.Comments.Create(c, userID, input.PostID, input.Content, input.ParentID)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -63,23 +55,24 @@ func CreateComment(c *gin.Context) {
 // @Param post_id query int true "文章 ID"
 // @Success 200 {object} map[string]interface{}
 // @Router /comments [get]
-func GetCommentsByPostID(c *gin.Context) {
+func (h *Handler) GetCommentsByPostID(c *gin.Context) {
 	postIDStr := c.Query("post_id")
 	if postIDStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "必须提供 post_id 参数"})
+		c.Error(apperror.BadRequest(apperror.CodeCommentPostNeeded))
 		return
 	}
 
-	postID, err := strconv.Atoi(postIDStr)
+	postID, err := strconv.ParseUint(postIDStr, 10, 0)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "post_id 无效"})
+		c.Error(apperror.BadRequest(apperror.CodeCommentPostNeeded))
 		return
 	}
 
-	var comments []models.Conment
-
-	if err := database.DB.Preload("User").Preload("Replies").Preload("Replies.User").Where("post_id = ? AND parent_id IS NULL", postID).Order("created_at ASC").Find(&comments).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询评论失败", "detail": err.Error()})
+	comments, err := cache.Get(c, service.CommentsKey(uint(postID)), config.AppConfig.Cache.ReadTTL, func() ([]models.Comment, error) {
+		return h.svc.Comments.ListByPost(c, uint(postID))
+	})
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
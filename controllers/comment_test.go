@@ -0,0 +1,48 @@
+package controllers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCreateComment(t *testing.T) {
+	author, reader := newUser(t), newUser(t)
+	post := newPost(t, author, false)
+
+	res := call(t, http.MethodPost, "/api/comments", reader.Token, gin.H{"post_id": post.ID, "content": "写得好"})
+	expect(t, res, http.StatusCreated, "message", "comment.id", "comment.post_id", "comment.content")
+	parent := number(t, res, "comment.id")
+
+	res = call(t, http.MethodPost, "/api/comments", author.Token, gin.H{"post_id": post.ID, "content": "谢谢", "parent_id": parent})
+	expect(t, res, http.StatusCreated, "comment.parent_id")
+
+	res = call(t, http.MethodPost, "/api/comments", "", gin.H{"post_id": post.ID, "content": "匿名"})
+	expectError(t, res, http.StatusUnauthorized, "auth.token_missing")
+
+	res = call(t, http.MethodPost, "/api/comments", reader.Token, gin.H{"content": "没有文章"})
+	expectInvalid(t, res, "post_id")
+}
+
+func TestGetComments(t *testing.T) {
+	author := newUser(t)
+	post := newPost(t, author, false)
+	newComment(t, author, post)
+
+	res := call(t, http.MethodGet, path("/api/comments?post_id=%d", post.ID), "", nil)
+	expect(t, res, http.StatusOK, "comments")
+	comments := list(t, res, "comments")
+	if len(comments) != 1 {
+		t.Fatalf("应有 1 条评论: %s", res.Raw)
+	}
+	if _, ok := comments[0].(map[string]any)["replies"]; !ok {
+		t.Fatalf("评论缺少 replies: %s", res.Raw)
+	}
+
+	res = call(t, http.MethodGet, "/api/comments", "", nil)
+	expectError(t, res, http.StatusBadRequest, "comment.post_id_required")
+
+	res = call(t, http.MethodGet, "/api/comments?post_id=abc", "", nil)
+	expectError(t, res, http.StatusBadRequest, "comment.post_id_required")
+}
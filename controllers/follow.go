@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"goblog/database"
+	"goblog/models"
+	"goblog/pkg/apperror"
+	"goblog/pkg/feed"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FollowInput struct {
+	TargetID   uint   `json:"target_id" binding:"required"`
+	TargetType string `json:"target_type" binding:"required,oneof=user tag"`
+}
+
+// Follow godoc
+// @Summary 关注作者或标签
+// @Tags 关注
+// @Accept json
+// @Produce json
+// @Param follow body FollowInput true "关注对象"
+// @Success 201 {object} map[string]string
+// @Failure 400 {object} apperror.Problem
+// @Router /follows [post]
+// @Security ApiKeyAuth
+func Follow(c *gin.Context) {
+	var input FollowInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperror.FromBind(err))
+		return
+	}
+
+	userID := c.MustGet("user_id").(uint)
+
+	switch input.TargetType {
+	case "user":
+		if input.TargetID == userID {
+			c.Error(apperror.BadRequest(apperror.CodeFollowSelf))
+			return
+		}
+		if err := database.DB.First(&models.User{}, input.TargetID).Error; err != nil {
+			c.Error(apperror.NotFound(apperror.CodeUserNotExist))
+			return
+		}
+	case "tag":
+		if err := database.DB.First(&models.Tag{}, input.TargetID).Error; err != nil {
+			c.Error(apperror.NotFound(apperror.CodeTagNotFound))
+			return
+		}
+	}
+
+	var follow models.Follow
+	result := database.DB.Where("follower_id = ? AND target_id = ? AND target_type = ?", userID, input.TargetID, input.TargetType).First(&follow)
+	if result.RowsAffected > 0 {
+		c.JSON(http.StatusOK, gin.H{"message": "你已经关注过了"})
+		return
+	}
+
+	follow = models.Follow{
+		FollowerID: userID,
+		TargetID:   input.TargetID,
+		TargetType: input.TargetType,
+	}
+	if err := database.DB.Create(&follow).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	feed.Invalidate(userID)
+	c.JSON(http.StatusCreated, gin.H{"message": "关注成功"})
+}
+
+// Unfollow godoc
+// @Summary 取消关注
+// @Tags 关注
+// @Accept json
+// @Produce json
+// @Param target_id query int true "目标 ID"
+// @Param target_type query string true "目标类型（user/tag）"
+// @Success 200 {object} map[string]string
+// @Router /follows [delete]
+// @Security ApiKeyAuth
+func Unfollow(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	targetID := c.Query("target_id")
+	targetType := c.Query("target_type")
+
+	if targetID == "" || targetType == "" {
+		c.Error(apperror.BadRequest(apperror.CodeFollowTargetRequired))
+		return
+	}
+
+	if err := database.DB.Where("follower_id = ? AND target_id = ? AND target_type = ?", userID, targetID, targetType).Delete(&models.Follow{}).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	feed.Invalidate(userID)
+	c.JSON(http.StatusOK, gin.H{"message": "已取消关注"})
+}
+
+// GetFollowing godoc
+// @Summary 获取当前用户的关注列表
+// @Tags 关注
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /follows [get]
+// @Security ApiKeyAuth
+func GetFollowing(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	var follows []models.Follow
+	if err := database.DB.Where("follower_id = ?", userID).Order("created_at desc").Find(&follows).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"follows": follows})
+}
+
+// GetFeed godoc
+// @Summary 获取个性化首页动态
+// @Description 返回关注的作者和标签下的文章，按时间倒序，使用 next_cursor 翻页
+// @Tags 关注
+// @Accept json
+// @Produce json
+// @Param cursor query string false "上一页返回的 next_cursor"
+// @Param limit query int false "每页数量"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Router /feed [get]
+// @Security ApiKeyAuth
+func GetFeed(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	cursor, err := feed.ParseCursor(c.Query("cursor"))
+	if err != nil {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidCursor))
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit < 1 || limit > 50 {
+		limit = 10
+	}
+
+	posts, next, err := feed.Load(userID, cursor, limit)
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"posts": posts, "next_cursor": next})
+}
@@ -0,0 +1,72 @@
+package controllers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestFollow(t *testing.T) {
+	reader, author := newUser(t), newUser(t)
+	post := newPost(t, author, false)
+	tag := post.Tags[0]
+	mirror(t, tag)
+
+	res := call(t, http.MethodPost, "/api/follows", reader.Token, gin.H{"target_id": author.ID, "target_type": "user"})
+	expect(t, res, http.StatusCreated, "message")
+
+	res = call(t, http.MethodPost, "/api/follows", reader.Token, gin.H{"target_id": author.ID, "target_type": "user"})
+	expect(t, res, http.StatusOK, "message")
+
+	res = call(t, http.MethodPost, "/api/follows", reader.Token, gin.H{"target_id": tag.ID, "target_type": "tag"})
+	expect(t, res, http.StatusCreated, "message")
+
+	res = call(t, http.MethodPost, "/api/follows", reader.Token, gin.H{"target_id": reader.ID, "target_type": "user"})
+	expectError(t, res, http.StatusBadRequest, "follow.self")
+
+	res = call(t, http.MethodPost, "/api/follows", reader.Token, gin.H{"target_id": 999999, "target_type": "user"})
+	expectError(t, res, http.StatusNotFound, "user.not_found")
+
+	res = call(t, http.MethodPost, "/api/follows", reader.Token, gin.H{"target_id": 999999, "target_type": "tag"})
+	expectError(t, res, http.StatusNotFound, "tag.not_found")
+
+	res = call(t, http.MethodPost, "/api/follows", reader.Token, gin.H{"target_id": author.ID, "target_type": "post"})
+	expectInvalid(t, res, "target_type")
+
+	res = call(t, http.MethodGet, "/api/follows", reader.Token, nil)
+	expect(t, res, http.StatusOK, "follows")
+	if len(list(t, res, "follows")) != 2 {
+		t.Fatalf("应关注了 2 个对象: %s", res.Raw)
+	}
+
+	res = call(t, http.MethodDelete, path("/api/follows?target_id=%d&target_type=user", author.ID), reader.Token, nil)
+	expect(t, res, http.StatusOK, "message")
+
+	res = call(t, http.MethodDelete, "/api/follows?target_type=user", reader.Token, nil)
+	expectError(t, res, http.StatusBadRequest, "follow.target_required")
+
+	res = call(t, http.MethodGet, "/api/follows", reader.Token, nil)
+	expect(t, res, http.StatusOK, "follows")
+	if len(list(t, res, "follows")) != 1 {
+		t.Fatalf("取消关注后应剩 1 个: %s", res.Raw)
+	}
+
+	res = call(t, http.MethodPost, "/api/follows", "", gin.H{"target_id": author.ID, "target_type": "user"})
+	expectError(t, res, http.StatusUnauthorized, "auth.token_missing")
+}
+
+func TestGetFeed(t *testing.T) {
+	reader, author := newUser(t), newUser(t)
+	expect(t, call(t, http.MethodPost, "/api/follows", reader.Token, gin.H{"target_id": author.ID, "target_type": "user"}), http.StatusCreated)
+	newPost(t, author, false)
+
+	res := call(t, http.MethodGet, "/api/feed?limit=5", reader.Token, nil)
+	expect(t, res, http.StatusOK, "posts", "next_cursor")
+
+	res = call(t, http.MethodGet, "/api/feed?cursor=not-a-cursor", reader.Token, nil)
+	expectError(t, res, http.StatusBadRequest, "feed.invalid_cursor")
+
+	res = call(t, http.MethodGet, "/api/feed", "", nil)
+	expectError(t, res, http.StatusUnauthorized, "auth.token_missing")
+}
@@ -0,0 +1,13 @@
+package controllers
+
+import "goblog/service"
+
+// Handler 处理用户、文章、评论、点赞和标签相关的接口，数据访问都通过注入的服务完成，
+// 可以配合 repository/memory 在没有 PostgreSQL 的情况下运行
+type Handler struct {
+	svc *service.Services
+}
+
+func NewHandler(svc *service.Services) *Handler {
+	return &Handler{svc: svc}
+}
@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	"context"
+	"goblog/database"
+	"goblog/pkg/cache"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const readinessTimeout = 2 * time.Second
+
+// Healthz 存活检查，进程能处理请求即返回 200，不检查依赖。
+// 挂在根路径而不是 /api 下，不出现在 Swagger 文档中
+func Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz 就绪检查，数据库不可用时返回 503。
+// Redis 不可用时缓存会降级到本地，服务仍然可用，只把 status 标为 degraded。
+// 缓存的详细状态里有 Redis 的错误信息，只在需要管理员权限的 /api/admin/cache 中返回
+func Readyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	checks := gin.H{"database": "ok", "redis": "ok"}
+	status, code := "ok", http.StatusOK
+	// 探针地址不需要登录，错误详情只写日志，不返回给调用方
+	if err := database.Ping(ctx); err != nil {
+		slog.ErrorContext(ctx, "就绪检查: 数据库不可用", "err", err)
+		checks["database"] = "unavailable"
+		status, code = "unavailable", http.StatusServiceUnavailable
+	}
+	if err := cache.Ping(ctx); err != nil {
+		slog.WarnContext(ctx, "就绪检查: Redis 不可用", "err", err)
+		checks["redis"] = "unavailable"
+		if code == http.StatusOK {
+			status = "degraded"
+		}
+	}
+	c.JSON(code, gin.H{"status": status, "checks": checks})
+}
@@ -0,0 +1,38 @@
+package controllers_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestHealthz(t *testing.T) {
+	res := call(t, http.MethodGet, "/healthz", "", nil)
+	expect(t, res, http.StatusOK, "status")
+}
+
+func TestReadyz(t *testing.T) {
+	res := call(t, http.MethodGet, "/readyz", "", nil)
+	expect(t, res, http.StatusOK, "status", "checks.database", "checks.redis")
+	if res.Body["status"] != "ok" {
+		t.Fatalf("依赖都可用时 status 应为 ok: %s", res.Raw)
+	}
+	// 缓存状态可能带有 Redis 的错误信息，不在无需登录的探针中返回
+	if _, ok := res.Body["cache"]; ok {
+		t.Fatalf("readyz 不应返回缓存状态: %s", res.Raw)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	call(t, http.MethodGet, "/healthz", "", nil)
+
+	res := call(t, http.MethodGet, "/metrics", "", nil)
+	if res.Code != http.StatusOK || !strings.Contains(string(res.Raw), `goblog_http_request_duration_seconds_count{method="GET",route="/healthz",status="200"}`) {
+		t.Fatalf("指标中缺少 /healthz 的请求耗时: %d %.200s", res.Code, res.Raw)
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	res := call(t, http.MethodGet, "/api/no-such-route", "", nil)
+	expectError(t, res, http.StatusNotFound, "route_not_found")
+}
@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"errors"
+	"goblog/database"
+	"goblog/models"
+	"goblog/pkg/apperror"
+	"goblog/pkg/audit"
+	"goblog/pkg/importer"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxImportSize 是上传文件的大小上限，更大的站点用 goblog import 命令导入
+const maxImportSize = 32 << 20
+
+// ImportPosts godoc
+// @Summary 导入文章
+// @Description 上传 WordPress 导出的 WXR 文件（.xml），或 Markdown/Hugo/Jekyll 站点目录的 .zip 压缩包，导入文章、标签和评论。
+// @Description 重复导入同一来源时只更新有变化的文章，导入后在本站修改过的文章不会被覆盖。dry_run 为 true 时只返回将要执行的操作
+// @Tags 管理
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "WXR 文件或站点压缩包，最大 32MB"
+// @Param format formData string false "导入格式" Enums(auto, markdown, hugo, jekyll, wxr) default(auto)
+// @Param user_id formData int false "来源中没有作者或作者不是本站用户时文章归属的用户，默认为当前管理员"
+// @Param dry_run formData bool false "只返回将要执行的操作，不写入数据库"
+// @Success 200 {object} importer.Report
+// @Failure 400 {object} apperror.Problem
+// @Failure 413 {object} apperror.Problem
+// @Router /admin/import [post]
+// @Security ApiKeyAuth
+func ImportPosts(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize+1<<20)
+
+	header, err := c.FormFile("file")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) || err == nil && header.Size > maxImportSize {
+		c.Error(apperror.New(http.StatusRequestEntityTooLarge, apperror.CodeImportFileTooLarge))
+		return
+	}
+	if err != nil {
+		c.Error(apperror.BadRequest(apperror.CodeImportFileRequired))
+		return
+	}
+
+	format := c.DefaultPostForm("format", importer.FormatAuto)
+	if !slices.Contains(importer.Formats, format) {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidImportFormat))
+		return
+	}
+	dryRun, _ := strconv.ParseBool(c.PostForm("dry_run"))
+
+	userID := c.MustGet("user_id").(uint)
+	if value := c.PostForm("user_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			c.Error(apperror.BadRequest(apperror.CodeInvalidImportUser))
+			return
+		}
+		var user models.User
+		err = database.DB.Select("id", "deleted_at").First(&user, id).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) || err == nil && !user.DeletedAt.IsZero() {
+			c.Error(apperror.BadRequest(apperror.CodeInvalidImportUser))
+			return
+		}
+		if err != nil {
+			c.Error(apperror.Internal(err))
+			return
+		}
+		userID = user.ID
+	}
+
+	f, err := header.Open()
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	items, format, err := importer.LoadFile(header.Filename, data, format)
+	if errors.Is(err, importer.ErrTooLarge) {
+		c.Error(apperror.New(http.StatusRequestEntityTooLarge, apperror.CodeImportContentLarge).Wrap(err))
+		return
+	}
+	if err != nil {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidImportFile).With("reason", err.Error()).Wrap(err))
+		return
+	}
+
+	report, err := importer.Import(c.Request.Context(), items, importer.Options{UserID: userID, DryRun: dryRun})
+	report.Format = format
+	// 出错前已导入的文章不会回滚，同样需要刷新缓存和记录审计
+	importer.Refresh(report)
+	if report.Changed() {
+		recordAudit(c, audit.Entry{Action: audit.ActionImport, TargetType: "import", After: gin.H{
+			"file": header.Filename, "format": report.Format, "user_id": userID,
+			"created": report.Created, "updated": report.Updated, "comments": report.Comments,
+		}})
+	}
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -1,9 +1,12 @@
 package controllers
 
 import (
-	"goblog/database"
-	"goblog/models"
+	"goblog/config"
+	"goblog/pkg/apperror"
+	"goblog/pkg/cache"
+	"goblog/service"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -20,34 +23,25 @@ type LikeInput struct {
 // @Produce json
 // @Param like body LikeInput true "点赞对象"
 // @Success 201 {object} map[string]string
-// @Failure 400 {object} map[string]string
+// @Failure 400 {object} apperror.Problem
 // @Router /likes [post]
 // @Security ApiKeyAuth
-func Like(c *gin.Context) {
+func (h *Handler) Like(c *gin.Context) {
 	var input LikeInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误", "detail": err.Error()})
+		c.Error(apperror.FromBind(err))
 		return
 	}
 
 	userID := c.MustGet("user_id").(uint)
 
-	var like models.Like
-	result := database.DB.Where("user_id = ? AND target_id ? AND target_type = ?", userID, input.TargetID, input.TargetType).First(&like)
-
-	if result.RowsAffected > 0 {
-		c.JSON(http.StatusOK, gin.H{"message": "你已经点过赞了"})
+	created, err := h.svc.Likes.Like(c, userID, input.TargetID, input.TargetType)
+	if err != nil {
+		c.Error(err)
 		return
 	}
-
-	newLike := models.Like{
-		UserID:     userID,
-		TargetID:   input.TargetID,
-		TargetType: input.TargetType,
-	}
-
-	if err := database.DB.Create(&newLike).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "点赞失败"})
+	if !created {
+		c.JSON(http.StatusOK, gin.H{"message": "你已经点过赞了"})
 		return
 	}
 	c.JSON(http.StatusCreated, gin.H{"message": "点赞成功"})
@@ -62,18 +56,17 @@ func Like(c *gin.Context) {
 // @Param target_type query string true "目标类型（post/comment）"
 // @Success 200 {object} map[string]int
 // @Router /likes/count [get]
-func GetLikeCount(c *gin.Context) {
-	targetID := c.Query("target_id")
-	targetType := c.Query("target_type")
-
-	if targetID == "" || targetType == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少 target_id 或 target_type"})
+func (h *Handler) GetLikeCount(c *gin.Context) {
+	targetID, targetType, ok := likeTarget(c)
+	if !ok {
 		return
 	}
 
-	var count int64
-	if err := database.DB.Model(&models.Like{}).Where("target_id = ? AND target_type = ?", targetID, targetType).Count(&count).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+	count, err := cache.Get(c, service.LikeCountKey(targetType, targetID), config.AppConfig.Cache.ReadTTL, func() (int64, error) {
+		return h.svc.Likes.Count(c, targetID, targetType)
+	})
+	if err != nil {
+		c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"like_count": count})
@@ -89,17 +82,28 @@ func GetLikeCount(c *gin.Context) {
 // @Success 200 {object} map[string]bool
 // @Router /likes/check [get]
 // @Security ApiKeyAuth
-func CheckIfLiked(c *gin.Context) {
+func (h *Handler) CheckIfLiked(c *gin.Context) {
 	userID := c.MustGet("user_id").(uint)
-	targetID := c.Query("target_id")
-	targetType := c.Query("target_type")
+	targetID, targetType, ok := likeTarget(c)
+	if !ok {
+		return
+	}
 
-	if targetID == "" || targetType == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少参数"})
+	liked, err := h.svc.Likes.Liked(c, userID, targetID, targetType)
+	if err != nil {
+		c.Error(err)
 		return
 	}
+	c.JSON(http.StatusOK, gin.H{"liked": liked})
+}
 
-	var like models.Like
-	result := database.DB.Where("user_id = ? AND target_id = ? AND target_type = ?", userID, targetID, targetType).First(&like)
-	c.JSON(http.StatusOK, gin.H{"liked": result.RowsAffected > 0})
+// likeTarget 读取查询参数中的点赞对象
+func likeTarget(c *gin.Context) (uint, string, bool) {
+	targetID, err := strconv.ParseUint(c.Query("target_id"), 10, 0)
+	targetType := c.Query("target_type")
+	if err != nil || targetType == "" {
+		c.Error(apperror.BadRequest(apperror.CodeLikeTargetRequired))
+		return 0, "", false
+	}
+	return uint(targetID), targetType, true
 }
@@ -0,0 +1,65 @@
+package controllers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLike(t *testing.T) {
+	author, reader := newUser(t), newUser(t)
+	post := newPost(t, author, false)
+
+	res := call(t, http.MethodPost, "/api/likes", reader.Token, gin.H{"target_id": post.ID, "target_type": "post"})
+	expect(t, res, http.StatusCreated, "message")
+
+	// 重复点赞不报错
+	res = call(t, http.MethodPost, "/api/likes", reader.Token, gin.H{"target_id": post.ID, "target_type": "post"})
+	expect(t, res, http.StatusOK, "message")
+
+	res = call(t, http.MethodPost, "/api/likes", "", gin.H{"target_id": post.ID, "target_type": "post"})
+	expectError(t, res, http.StatusUnauthorized, "auth.token_missing")
+
+	res = call(t, http.MethodPost, "/api/likes", reader.Token, gin.H{"target_id": post.ID, "target_type": "user"})
+	expectInvalid(t, res, "target_type")
+}
+
+func TestGetLikeCount(t *testing.T) {
+	author, reader := newUser(t), newUser(t)
+	post := newPost(t, author, false)
+	expect(t, call(t, http.MethodPost, "/api/likes", reader.Token, gin.H{"target_id": post.ID, "target_type": "post"}), http.StatusCreated)
+
+	res := call(t, http.MethodGet, path("/api/likes/count?target_id=%d&target_type=post", post.ID), "", nil)
+	expect(t, res, http.StatusOK, "like_count")
+	if res.Body["like_count"] != float64(1) {
+		t.Fatalf("点赞数应为 1: %s", res.Raw)
+	}
+
+	res = call(t, http.MethodGet, "/api/likes/count?target_type=post", "", nil)
+	expectError(t, res, http.StatusBadRequest, "like.target_required")
+}
+
+func TestCheckIfLiked(t *testing.T) {
+	author, reader := newUser(t), newUser(t)
+	post := newPost(t, author, false)
+	expect(t, call(t, http.MethodPost, "/api/likes", reader.Token, gin.H{"target_id": post.ID, "target_type": "post"}), http.StatusCreated)
+
+	res := call(t, http.MethodGet, path("/api/likes/check?target_id=%d&target_type=post", post.ID), reader.Token, nil)
+	expect(t, res, http.StatusOK, "liked")
+	if res.Body["liked"] != true {
+		t.Fatalf("点赞后 liked 应为 true: %s", res.Raw)
+	}
+
+	res = call(t, http.MethodGet, path("/api/likes/check?target_id=%d&target_type=post", post.ID), author.Token, nil)
+	expect(t, res, http.StatusOK, "liked")
+	if res.Body["liked"] != false {
+		t.Fatalf("未点赞时 liked 应为 false: %s", res.Raw)
+	}
+
+	res = call(t, http.MethodGet, path("/api/likes/check?target_id=%d&target_type=post", post.ID), "", nil)
+	expectError(t, res, http.StatusUnauthorized, "auth.token_missing")
+
+	res = call(t, http.MethodGet, "/api/likes/check?target_id=abc&target_type=post", reader.Token, nil)
+	expectError(t, res, http.StatusBadRequest, "like.target_required")
+}
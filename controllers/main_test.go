@@ -0,0 +1,381 @@
+package controllers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"goblog/config"
+	"goblog/database"
+	"goblog/models"
+	"goblog/pkg/cache"
+	"goblog/pkg/lifecycle"
+	"goblog/repository"
+	"goblog/repository/memory"
+	"goblog/routes"
+	"goblog/service"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
+)
+
+// 核心数据（用户、文章、评论、点赞、标签）通过 service 存在 repository/memory 中，
+// 收藏、系列、webhook 等功能直接读写 database.DB，测试用临时 SQLite 文件。
+// newUser、newPost 等辅助函数在两边保存同一 ID 的记录，两类接口可以共用测试数据
+var (
+	router *gin.Engine
+	repos  repository.Repositories
+
+	// served 记录返回成功的路由，全部测试结束后检查是否每个路由都被覆盖
+	served   = map[string]bool{}
+	servedMu sync.Mutex
+
+	seq int
+)
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "goblog-controllers")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	code := run(m, dir)
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func run(m *testing.M, dir string) int {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	conf := config.Default()
+	conf.Database.Driver = "sqlite"
+	conf.Database.Path = filepath.Join(dir, "test.db")
+	conf.JWT.Secret = "controllers-test-jwt-secret"
+	conf.Webhook.AllowPrivate = true
+	config.AppConfig = conf
+
+	if err := database.ConnectDatabase(); err != nil {
+		fmt.Fprintln(os.Stderr, "连接测试数据库失败:", err)
+		return 1
+	}
+	defer database.Close()
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "启动 miniredis 失败:", err)
+		return 1
+	}
+	defer mr.Close()
+	host, port, _ := net.SplitHostPort(mr.Addr())
+	conf.Redis.Host = host
+	conf.Redis.Port, _ = strconv.Atoi(port)
+	if err := cache.InitRedis(conf.Redis, conf.Cache); err != nil {
+		fmt.Fprintln(os.Stderr, "连接 Redis 失败:", err)
+		return 1
+	}
+
+	gin.SetMode(gin.TestMode)
+	router = gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Next()
+		if c.FullPath() != "" && c.Writer.Status() < http.StatusBadRequest {
+			servedMu.Lock()
+			served[c.Request.Method+" "+c.FullPath()] = true
+			servedMu.Unlock()
+		}
+	})
+	repos = memory.New()
+	routes.SetupRoutes(router, service.New(repos, service.Options{
+		JWTSecret: conf.JWT.Secret,
+		JWTExpire: conf.JWT.Expire,
+	}))
+
+	code := m.Run()
+
+	// 等待浏览记录等请求结束后启动的 goroutine，再关闭数据库和 Redis
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	lifecycle.Drain("request tasks").OnStop(ctx)
+
+	if code == 0 && flag.Lookup("test.run").Value.String() == "" {
+		var missing []string
+		for _, route := range router.Routes() {
+			if !served[route.Method+" "+route.Path] {
+				missing = append(missing, route.Method+" "+route.Path)
+			}
+		}
+		if len(missing) > 0 {
+			sort.Strings(missing)
+			fmt.Fprintf(os.Stderr, "以下路由没有成功请求的测试:\n  %s\n", strings.Join(missing, "\n  "))
+			return 1
+		}
+	}
+	return code
+}
+
+type response struct {
+	Code int
+	Body map[string]any
+	Raw  []byte
+}
+
+// call 发送 JSON 请求，token 为空时不带 Authorization 头
+func call(t *testing.T, method, path, token string, body any) response {
+	t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return serve(t, req, token)
+}
+
+// upload 以 multipart/form-data 上传文件
+func upload(t *testing.T, path, token, filename string, content []byte, fields map[string]string) response {
+	t.Helper()
+
+	var buf bytes.Buffer
+	form := multipart.NewWriter(&buf)
+	for k, v := range fields {
+		form.WriteField(k, v)
+	}
+	if filename != "" {
+		part, err := form.CreateFormFile("file", filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(content)
+	}
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, path, &buf)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return serve(t, req, token)
+}
+
+func serve(t *testing.T, req *http.Request, token string) response {
+	t.Helper()
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	res := response{Code: w.Code, Raw: w.Body.Bytes()}
+	if strings.HasPrefix(w.Header().Get("Content-Type"), "application/") && json.Valid(res.Raw) {
+		json.Unmarshal(res.Raw, &res.Body)
+	}
+	return res
+}
+
+// expect 检查状态码和响应中必须出现的字段，字段可以用 . 访问嵌套对象，如 post.id
+func expect(t *testing.T, res response, status int, keys ...string) {
+	t.Helper()
+
+	if res.Code != status {
+		t.Fatalf("状态码为 %d，期望 %d，响应: %s", res.Code, status, res.Raw)
+	}
+	for _, key := range keys {
+		if _, ok := lookup(res.Body, key); !ok {
+			t.Fatalf("响应缺少字段 %s: %s", key, res.Raw)
+		}
+	}
+}
+
+// expectError 检查错误响应是 problem+json 格式，并带有指定的状态码和错误码
+func expectError(t *testing.T, res response, status int, code string) {
+	t.Helper()
+
+	expect(t, res, status, "type", "title", "status", "detail", "code")
+	if got := res.Body["code"]; got != code {
+		t.Fatalf("错误码为 %v，期望 %s，响应: %s", got, code, res.Raw)
+	}
+	if got := res.Body["status"]; got != float64(status) {
+		t.Fatalf("响应中的 status 为 %v，期望 %d", got, status)
+	}
+}
+
+// expectInvalid 检查请求体校验失败的响应，并且 errors 中包含 field
+func expectInvalid(t *testing.T, res response, field string) {
+	t.Helper()
+
+	expectError(t, res, http.StatusBadRequest, "validation_failed")
+	errs, _ := res.Body["errors"].([]any)
+	for _, e := range errs {
+		if item, ok := e.(map[string]any); ok && item["field"] == field {
+			return
+		}
+	}
+	t.Fatalf("errors 中没有字段 %s: %s", field, res.Raw)
+}
+
+func lookup(body map[string]any, key string) (any, bool) {
+	var value any = body
+	for _, part := range strings.Split(key, ".") {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if value, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// number 返回响应中的数字字段
+func number(t *testing.T, res response, key string) uint {
+	t.Helper()
+
+	value, _ := lookup(res.Body, key)
+	n, ok := value.(float64)
+	if !ok {
+		t.Fatalf("响应字段 %s 不是数字: %s", key, res.Raw)
+	}
+	return uint(n)
+}
+
+// list 返回响应中的数组字段
+func list(t *testing.T, res response, key string) []any {
+	t.Helper()
+
+	value, _ := lookup(res.Body, key)
+	items, ok := value.([]any)
+	if !ok {
+		t.Fatalf("响应字段 %s 不是数组: %s", key, res.Raw)
+	}
+	return items
+}
+
+// unique 返回带序号的名称，同一进程中重复运行测试（-count）也不会冲突
+func unique(prefix string) string {
+	seq++
+	return fmt.Sprintf("%s%d", prefix, seq)
+}
+
+func path(format string, args ...any) string {
+	return fmt.Sprintf(format, args...)
+}
+
+type account struct {
+	models.User
+	Token string
+}
+
+// newUser 通过注册和登录接口创建用户，并把用户写入数据库
+func newUser(t *testing.T) account {
+	t.Helper()
+
+	name := unique("user")
+	email := name + "@example.com"
+	expect(t, call(t, http.MethodPost, "/api/register", "", gin.H{"username": name, "email": email, "password": "secret1"}), http.StatusCreated, "message")
+	res := call(t, http.MethodPost, "/api/login", "", gin.H{"email": email, "password": "secret1"})
+	expect(t, res, http.StatusOK, "token")
+
+	user, err := repos.Users.FindByEmail(context.Background(), email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mirror(t, &user)
+	return account{User: user, Token: res.Body["token"].(string)}
+}
+
+// newAdmin 创建站点管理员
+func newAdmin(t *testing.T) account {
+	t.Helper()
+	return withRole(t, newUser(t), models.RoleAdmin)
+}
+
+func withRole(t *testing.T, a account, role string) account {
+	t.Helper()
+
+	if err := repos.Users.Update(context.Background(), &a.User, map[string]any{"role": role}); err != nil {
+		t.Fatal(err)
+	}
+	mirror(t, &a.User)
+	return a
+}
+
+// newPost 通过接口发布文章，并把文章写入数据库
+func newPost(t *testing.T, author account, draft bool) models.Post {
+	t.Helper()
+
+	res := call(t, http.MethodPost, "/api/posts", author.Token, gin.H{
+		"title":    unique("文章 "),
+		"content":  "正文内容",
+		"is_draft": draft,
+		"tags":     []string{unique("tag")},
+	})
+	expect(t, res, http.StatusCreated, "message", "post.id")
+	return syncPost(t, number(t, res, "post.id"))
+}
+
+// syncPost 把内存中的文章写入数据库
+func syncPost(t *testing.T, id uint) models.Post {
+	t.Helper()
+
+	post, err := repos.Posts.FindByID(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mirror(t, &post)
+	return post
+}
+
+// newComment 通过接口发表评论，并把评论写入数据库
+func newComment(t *testing.T, author account, post models.Post) models.Comment {
+	t.Helper()
+
+	res := call(t, http.MethodPost, "/api/comments", author.Token, gin.H{"post_id": post.ID, "content": "评论内容"})
+	expect(t, res, http.StatusCreated, "message", "comment.id")
+
+	comment, err := repos.Comments.FindByID(context.Background(), number(t, res, "comment.id"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mirror(t, &comment)
+	return comment
+}
+
+// mirror 按主键写入或覆盖数据库中的记录，不处理关联
+func mirror(t *testing.T, value any) {
+	t.Helper()
+
+	if err := database.DB.Omit(clause.Associations).Clauses(clause.OnConflict{UpdateAll: true}).Create(value).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// seed 直接在数据库中创建记录
+func seed(t *testing.T, value any) {
+	t.Helper()
+
+	if err := database.DB.Create(value).Error; err != nil {
+		t.Fatal(err)
+	}
+}
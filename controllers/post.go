@@ -1,32 +1,41 @@
 package controllers
 
 import (
-	"fmt"
+	"context"
+	"goblog/config"
 	"goblog/database"
 	"goblog/models"
+	"goblog/pkg/analytics"
+	"goblog/pkg/apperror"
+	"goblog/pkg/audit"
 	"goblog/pkg/cache"
+	"goblog/pkg/feed"
+	"goblog/pkg/lifecycle"
+	"goblog/pkg/ranking"
+	"goblog/pkg/related"
+	"goblog/pkg/webhook"
+	"goblog/pkg/workflow"
+	"goblog/repository"
+	"goblog/service"
+	"log/slog"
 	"net/http"
 	"strconv"
-	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// CreatePostInput 和 UpdatePostInput 不包含置顶和推荐，这两项只能由管理员通过 /admin/posts/{id} 设置
 type CreatePostInput struct {
-	Title       string   `json:"title" binding:"required"`
-	Content     string   `json:"content" binding:"required"`
-	IsDraft     bool     `json:"is_draft"`
-	IsTop       bool     `json:"is_top"`
-	IsRecommend bool     `json:"is_recommend"`
-	Tags        []string `json:"tags"`
+	Title   string   `json:"title" binding:"required"`
+	Content string   `json:"content" binding:"required"`
+	IsDraft bool     `json:"is_draft"`
+	Tags    []string `json:"tags"`
 }
 
 type UpdatePostInput struct {
-	Title       *string `json:"title"`
-	Content     *string `json:"content"`
-	IsDraft     *bool   `json:"is_draft"`
-	IsTop       *bool   `json:"is_top"`
-	IsRecommend *bool   `json:"is_recommend"`
+	Title   *string `json:"title"`
+	Content *string `json:"content"`
+	IsDraft *bool   `json:"is_draft"`
 }
 
 // CreatePost godoc
@@ -37,72 +46,86 @@ type UpdatePostInput struct {
 // @Produce json
 // @Param post body CreatePostInput true "文章数据"
 // @Success 201 {object} map[string]interface{}
-// @Failure 400 {object} map[string]string
+// @Failure 400 {object} apperror.Problem
 // @Router /posts [post]
 // @Security ApiKeyAuth
-func CreatePost(c *gin.Context) {
+func (h *Handler) CreatePost(c *gin.Context) {
 	var input CreatePostInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperror.FromBind(err))
 		return
 	}
 
 	userID := c.MustGet("user_id").(uint)
 
-	var tags []*models.Tag
-
-	for _, tagName := range input.Tags {
-		var tag models.Tag
-		if err := database.DB.FirstOrCreate(&tag, models.Tag{Name: tagName}).Error; err == nil {
-			tags = append(tags, &tag)
-		}
-	}
-
-	post := models.Post{
-		Title:       input.Title,
-		Content:     input.Content,
-		UserID:      userID,
-		IsDraft:     input.IsDraft,
-		IsTop:       input.IsTop,
-		IsRecommend: input.IsRecommend,
-		Tags:        tags,
-	}
-
-	if err := database.DB.Create(&post).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建文章失败"})
+	post, err := h.svc.Posts.Create(c, userID, service.NewPost{
+		Title:   input.Title,
+		Content: input.Content,
+		IsDraft: input.IsDraft,
+		Tags:    input.Tags,
+	})
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "文章创建成功", "post": post})
-
-	for i := 1; i <= 5; i++ {
-		cacheKey := fmt.Sprintf("posts:page:%d:limit:10", i)
-		cache.Rdb.Del(cache.Ctx, cacheKey)
+	message := "文章创建成功"
+	if !input.IsDraft && post.IsDraft {
+		message = "文章已保存为草稿，需审核通过后由编辑发布"
 	}
+	c.JSON(http.StatusCreated, gin.H{"message": message, "post": post})
 }
 
 // GetPosts godoc
 // @Summary 获取文章列表
-// @Description 支持分页获取文章列表
+// @Description 支持分页和多种排序，置顶文章总是排在最前面
 // @Tags 文章
 // @Accept json
 // @Produce json
 // @Param page query int false "页码"
 // @Param limit query int false "每页数量"
+// @Param sort query string false "排序方式 new（默认）/hot/top/recommended"
+// @Param window query string false "sort=top 时的时间范围 day/week（默认）/month/all"
 // @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
 // @Router /posts [get]
-func GetPosts(c *gin.Context) {
+func (h *Handler) GetPosts(c *gin.Context) {
 	page := c.DefaultQuery("page", "1")
 	limit := c.DefaultQuery("limit", "10")
-	cacheKey := fmt.Sprintf("posts:page:%s:limit:%s", page, limit)
-
-	var posts []models.Post
+	sort := c.DefaultQuery("sort", "new")
+	window := c.DefaultQuery("window", ranking.WindowWeek)
+
+	switch sort {
+	case "new", "hot", "recommended":
+		window = ""
+	case "top":
+		if window != ranking.WindowDay && window != ranking.WindowWeek && window != ranking.WindowMonth && window != ranking.WindowAll {
+			c.Error(apperror.BadRequest(apperror.CodeInvalidWindow))
+			return
+		}
+	default:
+		c.Error(apperror.BadRequest(apperror.CodeInvalidSort))
+		return
+	}
 
-	if hit, err := cache.GetJSON(cacheKey, &posts); hit && err == nil {
+	loaded := false
+	posts, err := cache.Get(c, service.PostListKey(sort, window, page, limit), config.AppConfig.Cache.PostListTTL, func() ([]models.Post, error) {
+		loaded = true
+		return h.listPosts(c, sort, window, page, limit)
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !loaded {
 		c.JSON(http.StatusOK, gin.H{"from": "cache", "posts": posts})
 		return
 	}
+	c.JSON(http.StatusOK, gin.H{"posts": posts})
+}
 
+// listPosts 从数据库读取一页文章并填充作者、点赞数等详情
+func (h *Handler) listPosts(ctx context.Context, sort, window, page, limit string) ([]models.Post, error) {
 	offset, _ := strconv.Atoi(page)
 	pageSize, _ := strconv.Atoi(limit)
 	offset = (offset - 1) * pageSize
@@ -110,76 +133,179 @@ func GetPosts(c *gin.Context) {
 	// limit, _ := strconv.Atoi(limitStr)
 	// offset := (page - 1) * limit
 
-	if err := database.DB.Preload("User").Preload("Tags").Order("created_at desc").Limit(pageSize).Offset(offset).Find(&posts).Error; err != nil {
-		fmt.Println("查询出错:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询文章失败"})
-		return
+	var posts []models.Post
+	var err error
+	switch sort {
+	case "hot", "top":
+		posts, err = h.rankedPosts(ctx, sort, window, offset, pageSize)
+		if err != nil {
+			slog.WarnContext(ctx, "读取排行出错，按发布时间排序", "err", err)
+			posts, err = h.latestPosts(ctx, "is_top desc, created_at desc", offset, pageSize)
+		}
+	case "recommended":
+		posts, err = h.latestPosts(ctx, "is_top desc, is_recommend desc, created_at desc", offset, pageSize)
+	default:
+		posts, err = h.latestPosts(ctx, "is_top desc, created_at desc", offset, pageSize)
 	}
-	cache.SetJSON(cacheKey, posts, 30*time.Second)
-	c.JSON(http.StatusOK, gin.H{"posts": posts})
+	if err != nil {
+		return nil, err
+	}
+	if err := h.svc.Posts.FillDetails(ctx, posts); err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+func (h *Handler) latestPosts(ctx context.Context, order string, offset, limit int) ([]models.Post, error) {
+	return h.svc.Posts.List(ctx, repository.PostQuery{Order: order, Published: true, Offset: offset, Limit: limit})
+}
+
+// rankedPosts 按热度或时间窗口排行分页，置顶文章排在排行之前
+func (h *Handler) rankedPosts(ctx context.Context, sort, window string, offset, limit int) ([]models.Post, error) {
+	pinned, err := h.svc.Posts.PinnedIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []uint
+	if offset < len(pinned) {
+		ids = append(ids, pinned[offset:min(len(pinned), offset+limit)]...)
+	}
+
+	if need := limit - len(ids); need > 0 {
+		start := max(0, offset-len(pinned))
+		// 排行里也可能包含置顶文章，多取一些后再过滤
+		count := int64(start + need + len(pinned))
+
+		var ranked []uint
+		var err error
+		if sort == "hot" {
+			ranked, err = ranking.Hot(0, count)
+		} else {
+			ranked, err = ranking.Top(window, 0, count)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		isPinned := make(map[uint]bool, len(pinned))
+		for _, id := range pinned {
+			isPinned[id] = true
+		}
+		rest := make([]uint, 0, len(ranked))
+		for _, id := range ranked {
+			if !isPinned[id] {
+				rest = append(rest, id)
+			}
+		}
+		if start < len(rest) {
+			ids = append(ids, rest[start:min(len(rest), start+need)]...)
+		}
+	}
+
+	return h.svc.Posts.FindPublished(ctx, ids)
 }
 
 // GetPostByID godoc
 // @Summary 获取文章详情
+// @Description 文章属于某个系列时，series 字段包含系列目录以及上一篇/下一篇。未发布的文章只有作者、协作者以及编辑和管理员可以查看
 // @Tags 文章
 // @Accept json
 // @Produce json
 // @Param id path int true "文章 ID"
 // @Success 200 {object} map[string]interface{}
-// @Failure 404 {object} map[string]string
+// @Failure 404 {object} apperror.Problem
 // @Router /posts/{id} [get]
-func GetPostByID(c *gin.Context) {
+func (h *Handler) GetPostByID(c *gin.Context) {
 	postID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的文章 ID"})
+		c.Error(apperror.BadRequest(apperror.CodeInvalidPostID))
 		return
 	}
 
-	var post models.Post
-
-	if err := database.DB.Preload("User").Preload("Tags").First(&post, postID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "文章未找到"})
+	// 是否收藏、系列导航和浏览记录因人而异，只缓存文章本身
+	post, err := cache.Get(c, service.PostKey(uint(postID)), config.AppConfig.Cache.ReadTTL, func() (models.Post, error) {
+		return h.svc.Posts.Get(c, uint(postID))
+	})
+	if err != nil {
+		c.Error(err)
 		return
 	}
+	if !canViewPost(c, post) {
+		c.Error(apperror.NotFound(apperror.CodePostNotFound))
+		return
+	}
+
+	bookmarked := false
+	if userID, ok := c.Get("user_id"); ok {
+		var count int64
+		database.DB.Model(&models.Bookmark{}).Where("user_id = ? AND post_id = ?", userID, post.ID).Count(&count)
+		bookmarked = count > 0
+	}
+
+	c.JSON(http.StatusOK, gin.H{"post": post, "bookmarked": bookmarked, "series": seriesNav(post.ID)})
 
-	c.JSON(http.StatusOK, gin.H{"post": post})
+	if !post.IsDraft {
+		visitor, referer := visitorID(c), c.Request.Referer()
+		lifecycle.Go(func() {
+			if analytics.RecordView(post.ID, visitor, referer) {
+				ranking.Bump(post.ID, ranking.WeightView)
+			}
+		})
+	}
+}
+
+// canViewPost 未发布的文章（草稿、审核中、已审核）只对作者、协作者以及编辑和管理员可见
+func canViewPost(c *gin.Context, post models.Post) bool {
+	if !post.IsDraft {
+		return true
+	}
+	userID, ok := c.Get("user_id")
+	return ok && canReview(actorFor(post, userID.(uint)))
 }
 
 // UpdatePost godoc
 // @Summary 修改文章
+// @Description 文章作者和已接受邀请的合著者可以修改，审核中的文章需要先撤回才能修改标题和内容
 // @Tags 文章
 // @Accept json
 // @Produce json
 // @Param id path int true "文章 ID"
 // @Param post body UpdatePostInput true "更新数据"
 // @Success 200 {object} map[string]interface{}
-// @Failure 403 {object} map[string]string
+// @Failure 403 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
 // @Router /posts/{id} [put]
 // @Security ApiKeyAuth
-func UpdataPost(c *gin.Context) {
+func (h *Handler) UpdataPost(c *gin.Context) {
 	postID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的文章 ID"})
+		c.Error(apperror.BadRequest(apperror.CodeInvalidPostID))
 		return
 	}
 
-	var post models.Post
-
-	if err := database.DB.First(&post, postID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "文章未找到"})
+	post, err := h.svc.Posts.Find(c, uint(postID))
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
 	userID := c.MustGet("user_id").(uint)
 
-	if post.UserID != userID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "无权修改该文章"})
+	if !canEditPost(post, userID) {
+		c.Error(apperror.Forbidden(apperror.CodePostEditDenied))
 		return
 	}
 
 	var input UpdatePostInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "请求体无效"})
+		c.Error(apperror.FromBind(err))
+		return
+	}
+
+	// 审核中的文章内容要和审稿人看到的一致，作者需要先撤回
+	if post.Status == models.PostInReview && (input.Title != nil || input.Content != nil) {
+		c.Error(apperror.Conflict(apperror.CodePostInReview))
 		return
 	}
 
@@ -190,22 +316,52 @@ func UpdataPost(c *gin.Context) {
 	if input.Content != nil {
 		updatdData["content"] = *input.Content
 	}
-	if input.IsDraft != nil {
-		updatdData["is_draft"] = *input.IsDraft
-	}
-	if input.IsTop != nil {
-		updatdData["is_top"] = *input.IsTop
-	}
-	if input.IsRecommend != nil {
-		updatdData["is_recommend"] = *input.IsRecommend
+
+	// is_draft 的修改按审稿流程的 publish/unpublish 处理，已审核的文章修改内容后需要重新审核
+	action := ""
+	switch {
+	case input.IsDraft != nil && *input.IsDraft && post.Status == models.PostPublished:
+		action = workflow.ActionUnpublish
+	case input.IsDraft != nil && !*input.IsDraft && post.Status != models.PostPublished:
+		action = workflow.ActionPublish
+	case post.Status == models.PostApproved && (input.Title != nil || input.Content != nil):
+		action = workflow.ActionRevise
 	}
 
-	if err := database.DB.Model(&post).Updates(updatdData).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "更新失败"})
-		return
+	wasPublished := !post.IsDraft
+
+	if action == "" {
+		if err := h.svc.Posts.Update(c, &post, updatdData); err != nil {
+			c.Error(err)
+			return
+		}
+	} else {
+		to, err := workflow.Transition(post.Status, action, actorFor(post, userID), config.AppConfig.EditorialWorkflow)
+		if err != nil {
+			c.Error(transitionError(err))
+			return
+		}
+		if err := applyTransition(&post, userID, action, to, "", updatdData); err != nil {
+			c.Error(err)
+			return
+		}
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "文章更新成功", "post": post})
+
+	afterStatusChange(post, wasPublished)
+}
+
+// afterStatusChange 文章发布或撤回后更新动态、排行、webhook 和相关文章
+func afterStatusChange(post models.Post, wasPublished bool) {
+	if !wasPublished && !post.IsDraft {
+		lifecycle.Go(func() { feed.FanOut(post) })
+		lifecycle.Go(func() { ranking.Add(post) })
+		lifecycle.Go(func() { webhook.Publish(webhook.EventPostPublished, post) })
+	} else if wasPublished && post.IsDraft {
+		lifecycle.Go(func() { ranking.Remove(post.ID) })
+	}
+	related.MarkDirty(post.ID)
 }
 
 // DeletePost godoc
@@ -215,32 +371,23 @@ func UpdataPost(c *gin.Context) {
 // @Produce json
 // @Param id path int true "文章 ID"
 // @Success 200 {object} map[string]string
-// @Failure 403 {object} map[string]string
+// @Failure 403 {object} apperror.Problem
 // @Router /posts/{id} [delete]
 // @Security ApiKeyAuth
-func DeletePost(c *gin.Context) {
+func (h *Handler) DeletePost(c *gin.Context) {
 	postID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的文章 ID"})
-		return
-	}
-
-	var post models.Post
-	if err := database.DB.First(&post, postID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "文章未找到"})
+		c.Error(apperror.BadRequest(apperror.CodeInvalidPostID))
 		return
 	}
 
 	userID := c.MustGet("user_id").(uint)
 
-	if post.UserID != userID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "无权删除该文章"})
-		return
-	}
-
-	if err := database.DB.Delete(&post).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除失败"})
+	post, err := h.svc.Posts.Delete(c, uint(postID), userID)
+	if err != nil {
+		c.Error(err)
 		return
 	}
+	recordAudit(c, audit.Entry{Action: audit.ActionPostDelete, TargetType: "post", TargetID: post.ID, Before: post})
 	c.JSON(http.StatusOK, gin.H{"message": "文章删除成功"})
 }
@@ -0,0 +1,224 @@
+package controllers_test
+
+import (
+	"context"
+	"goblog/models"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCreatePost(t *testing.T) {
+	author := newUser(t)
+
+	res := call(t, http.MethodPost, "/api/posts", author.Token, gin.H{"title": "标题", "content": "正文", "tags": []string{"go"}, "is_top": true})
+	expect(t, res, http.StatusCreated, "message", "post.id", "post.context", "post.tags", "post.status")
+	if status, _ := lookup(res.Body, "post.status"); status != models.PostPublished {
+		t.Fatalf("新文章状态为 %v，期望 %s", status, models.PostPublished)
+	}
+	if top, _ := lookup(res.Body, "post.is_top"); top != false {
+		t.Fatalf("作者不能置顶文章: %s", res.Raw)
+	}
+
+	res = call(t, http.MethodPost, "/api/posts", "", gin.H{"title": "标题", "content": "正文"})
+	expectError(t, res, http.StatusUnauthorized, "auth.token_missing")
+
+	res = call(t, http.MethodPost, "/api/posts", author.Token, gin.H{"content": "正文"})
+	expectInvalid(t, res, "title")
+}
+
+func TestGetPosts(t *testing.T) {
+	author := newUser(t)
+	newPost(t, author, false)
+
+	for _, query := range []string{"", "?sort=hot", "?sort=recommended", "?sort=top&window=all", "?page=2&limit=5"} {
+		res := call(t, http.MethodGet, "/api/posts"+query, "", nil)
+		expect(t, res, http.StatusOK, "posts")
+	}
+
+	res := call(t, http.MethodGet, "/api/posts?sort=oldest", "", nil)
+	expectError(t, res, http.StatusBadRequest, "post.invalid_sort")
+
+	res = call(t, http.MethodGet, "/api/posts?sort=top&window=year", "", nil)
+	expectError(t, res, http.StatusBadRequest, "post.invalid_window")
+}
+
+func TestGetPostByID(t *testing.T) {
+	author := newUser(t)
+	post := newPost(t, author, false)
+
+	res := call(t, http.MethodGet, path("/api/posts/%d", post.ID), "", nil)
+	expect(t, res, http.StatusOK, "post.id", "post.author", "bookmarked", "series")
+	if res.Body["bookmarked"] != false {
+		t.Fatalf("未登录时 bookmarked 应为 false: %s", res.Raw)
+	}
+
+	// 带 token 时返回当前用户的收藏状态
+	seed(t, &models.Bookmark{UserID: author.ID, PostID: post.ID})
+	res = call(t, http.MethodGet, path("/api/posts/%d", post.ID), author.Token, nil)
+	expect(t, res, http.StatusOK, "post.id", "bookmarked")
+	if res.Body["bookmarked"] != true {
+		t.Fatalf("收藏后 bookmarked 应为 true: %s", res.Raw)
+	}
+
+	res = call(t, http.MethodGet, "/api/posts/abc", "", nil)
+	expectError(t, res, http.StatusBadRequest, "post.invalid_id")
+
+	res = call(t, http.MethodGet, "/api/posts/999999", "", nil)
+	expectError(t, res, http.StatusNotFound, "post.not_found")
+}
+
+func TestUnpublishedPostVisibility(t *testing.T) {
+	author, reviewer, editor, outsider := newUser(t), newUser(t), withRole(t, newUser(t), models.RoleEditor), newUser(t)
+	published := newPost(t, author, false)
+	draft := newPost(t, author, true)
+	seed(t, &models.PostCollaborator{PostID: draft.ID, UserID: reviewer.ID, InvitedBy: author.ID, Role: models.CollaboratorReviewer, Status: models.InvitationAccepted})
+
+	for _, c := range []struct {
+		name   string
+		token  string
+		status int
+	}{
+		{"未登录", "", http.StatusNotFound},
+		{"其他用户", outsider.Token, http.StatusNotFound},
+		{"作者", author.Token, http.StatusOK},
+		{"审稿人", reviewer.Token, http.StatusOK},
+		{"编辑", editor.Token, http.StatusOK},
+	} {
+		res := call(t, http.MethodGet, path("/api/posts/%d", draft.ID), c.token, nil)
+		if res.Code != c.status {
+			t.Fatalf("%s查看草稿返回 %d，期望 %d: %s", c.name, res.Code, c.status, res.Raw)
+		}
+	}
+
+	// 列表只包含已发布的文章
+	for _, sort := range []string{"new", "recommended", "hot"} {
+		res := call(t, http.MethodGet, "/api/posts?limit=100&sort="+sort, author.Token, nil)
+		expect(t, res, http.StatusOK, "posts")
+		found := map[uint]bool{}
+		for _, item := range list(t, res, "posts") {
+			found[uint(item.(map[string]any)["id"].(float64))] = true
+		}
+		if found[draft.ID] {
+			t.Fatalf("sort=%s 的列表包含草稿: %s", sort, res.Raw)
+		}
+		if sort != "hot" && !found[published.ID] {
+			t.Fatalf("sort=%s 的列表缺少已发布的文章: %s", sort, res.Raw)
+		}
+	}
+}
+
+func TestGetRelatedPosts(t *testing.T) {
+	author := newUser(t)
+	post := newPost(t, author, false)
+	draft := newPost(t, author, true)
+
+	res := call(t, http.MethodGet, path("/api/posts/%d/related?limit=3", post.ID), "", nil)
+	expect(t, res, http.StatusOK, "posts")
+
+	res = call(t, http.MethodGet, path("/api/posts/%d/related", draft.ID), "", nil)
+	expectError(t, res, http.StatusNotFound, "post.not_found")
+
+	res = call(t, http.MethodGet, "/api/posts/abc/related", "", nil)
+	expectError(t, res, http.StatusBadRequest, "post.invalid_id")
+}
+
+func TestUpdatePost(t *testing.T) {
+	author, other := newUser(t), newUser(t)
+	post := newPost(t, author, false)
+
+	res := call(t, http.MethodPut, path("/api/posts/%d", post.ID), author.Token, gin.H{"title": "新标题", "is_top": true, "is_recommend": true})
+	expect(t, res, http.StatusOK, "message", "post.id")
+	if title, _ := lookup(res.Body, "post.context"); title != "新标题" {
+		t.Fatalf("标题未更新: %s", res.Raw)
+	}
+	// 置顶和推荐只能由管理员设置
+	if top, _ := lookup(res.Body, "post.is_top"); top != false {
+		t.Fatalf("作者不能置顶文章: %s", res.Raw)
+	}
+	if recommend, _ := lookup(res.Body, "post.is_recommend"); recommend != false {
+		t.Fatalf("作者不能推荐文章: %s", res.Raw)
+	}
+
+	res = call(t, http.MethodPut, path("/api/posts/%d", post.ID), other.Token, gin.H{"title": "改掉"})
+	expectError(t, res, http.StatusForbidden, "post.edit_forbidden")
+
+	res = call(t, http.MethodPut, path("/api/posts/%d", post.ID), "", gin.H{"title": "改掉"})
+	expectError(t, res, http.StatusUnauthorized, "auth.token_missing")
+
+	res = call(t, http.MethodPut, path("/api/posts/%d", post.ID), author.Token, gin.H{"title": 1})
+	expectInvalid(t, res, "title")
+
+	res = call(t, http.MethodPut, "/api/posts/999999", author.Token, gin.H{"title": "改掉"})
+	expectError(t, res, http.StatusNotFound, "post.not_found")
+
+	// 审核中的文章不能修改标题和内容
+	inReview := newPost(t, author, true)
+	if err := repos.Posts.Update(context.Background(), &inReview, map[string]any{"status": models.PostInReview}); err != nil {
+		t.Fatal(err)
+	}
+	res = call(t, http.MethodPut, path("/api/posts/%d", inReview.ID), author.Token, gin.H{"content": "改掉"})
+	expectError(t, res, http.StatusConflict, "workflow.post_in_review")
+}
+
+func TestDeletePost(t *testing.T) {
+	author, other := newUser(t), newUser(t)
+	post := newPost(t, author, false)
+
+	res := call(t, http.MethodDelete, path("/api/posts/%d", post.ID), other.Token, nil)
+	expectError(t, res, http.StatusForbidden, "post.delete_forbidden")
+
+	res = call(t, http.MethodDelete, path("/api/posts/%d", post.ID), author.Token, nil)
+	expect(t, res, http.StatusOK, "message")
+
+	res = call(t, http.MethodGet, path("/api/posts/%d", post.ID), "", nil)
+	expectError(t, res, http.StatusNotFound, "post.not_found")
+
+	res = call(t, http.MethodDelete, "/api/posts/abc", author.Token, nil)
+	expectError(t, res, http.StatusBadRequest, "post.invalid_id")
+}
+
+func TestGetPostByTag(t *testing.T) {
+	author := newUser(t)
+	tag := unique("bytag")
+	res := call(t, http.MethodPost, "/api/posts", author.Token, gin.H{"title": "标题", "content": "正文", "tags": []string{tag}})
+	expect(t, res, http.StatusCreated, "post.id")
+
+	res = call(t, http.MethodGet, "/api/tags/"+tag+"/posts", "", nil)
+	expect(t, res, http.StatusOK, "tag", "posts", "count")
+	if res.Body["count"] != float64(1) {
+		t.Fatalf("标签下应有 1 篇文章: %s", res.Raw)
+	}
+
+	res = call(t, http.MethodGet, "/api/tags/no-such-tag/posts", "", nil)
+	expectError(t, res, http.StatusNotFound, "tag.not_found")
+}
+
+func TestTrash(t *testing.T) {
+	author, other := newUser(t), newUser(t)
+	post := newPost(t, author, false)
+	expect(t, call(t, http.MethodDelete, path("/api/posts/%d", post.ID), author.Token, nil), http.StatusOK)
+
+	res := call(t, http.MethodGet, "/api/trash", author.Token, nil)
+	expect(t, res, http.StatusOK, "posts")
+	trashed := list(t, res, "posts")
+	if len(trashed) != 1 {
+		t.Fatalf("回收站应有 1 篇文章: %s", res.Raw)
+	}
+	if _, ok := trashed[0].(map[string]any)["deleted_at"]; !ok {
+		t.Fatalf("回收站文章缺少 deleted_at: %s", res.Raw)
+	}
+
+	res = call(t, http.MethodGet, "/api/trash", "", nil)
+	expectError(t, res, http.StatusUnauthorized, "auth.token_missing")
+
+	res = call(t, http.MethodPost, path("/api/trash/%d/restore", post.ID), other.Token, nil)
+	expectError(t, res, http.StatusForbidden, "post.restore_forbidden")
+
+	res = call(t, http.MethodPost, path("/api/trash/%d/restore", post.ID), author.Token, nil)
+	expect(t, res, http.StatusOK, "message", "post.id")
+
+	res = call(t, http.MethodPost, path("/api/trash/%d/restore", post.ID), author.Token, nil)
+	expectError(t, res, http.StatusNotFound, "post.not_in_trash")
+}
@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"goblog/pkg/apperror"
+	"goblog/pkg/related"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetRelatedPosts godoc
+// @Summary 获取相关文章
+// @Description 综合共同标签、标题正文的 TF-IDF 相似度和共同点赞用户推荐相关文章，结果由后台定期预计算，尚未计算的文章返回空列表
+// @Tags 文章
+// @Accept json
+// @Produce json
+// @Param id path int true "文章 ID"
+// @Param limit query int false "数量，默认 5，最多 10"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} apperror.Problem
+// @Router /posts/{id}/related [get]
+func (h *Handler) GetRelatedPosts(c *gin.Context) {
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidPostID))
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "5"))
+	if limit < 1 || limit > related.MaxRelated {
+		limit = 5
+	}
+
+	post, err := h.svc.Posts.Find(c, uint(postID))
+	if err == nil && post.IsDraft {
+		err = apperror.NotFound(apperror.CodePostNotFound)
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// 缓存可能落后于删除或撤回操作，按缓存顺序取仍然可见的文章
+	posts, err := h.svc.Posts.FindPublished(c, related.Get(post.ID))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	posts = posts[:min(len(posts), limit)]
+
+	if err := h.svc.Posts.FillDetails(c, posts); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"posts": posts})
+}
@@ -0,0 +1,292 @@
+package controllers
+
+import (
+	"errors"
+	"goblog/config"
+	"goblog/database"
+	"goblog/models"
+	"goblog/pkg/apperror"
+	"goblog/pkg/workflow"
+	"goblog/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type TransitionInput struct {
+	Action  string `json:"action" binding:"required,oneof=submit withdraw approve request_changes publish unpublish"`
+	Comment string `json:"comment"`
+}
+
+type ReviewCommentInput struct {
+	Body        string `json:"body" binding:"required"`
+	Quote       string `json:"quote"`
+	AnchorStart *int   `json:"anchor_start" binding:"omitempty,min=0"`
+	AnchorEnd   *int   `json:"anchor_end" binding:"omitempty,min=0"`
+}
+
+func actorFor(post models.Post, userID uint) workflow.Actor {
+	return workflow.Actor{PostRole: postRole(post, userID), SiteRole: userRole(userID)}
+}
+
+// canReview 文章作者、协作者以及编辑和管理员可以查看审稿记录和批注
+func canReview(actor workflow.Actor) bool {
+	return actor.PostRole != "" || actor.SiteRole == models.RoleEditor || actor.SiteRole == models.RoleAdmin
+}
+
+// applyTransition 在同一事务中修改文章状态（以及 updates 中的其他字段）并写入状态变更记录。
+// 只有状态仍是读取时的值才会修改，并发的另一个操作已经改过状态时返回 409，变更记录里不会出现没有发生过的流转
+func applyTransition(post *models.Post, actorID uint, action, to, comment string, updates map[string]any) error {
+	from := post.Status
+	if updates == nil {
+		updates = map[string]any{}
+	}
+	updates["status"] = to
+	updates["is_draft"] = to != models.PostPublished
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(post).Where("status = ?", from).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return apperror.Conflict(apperror.CodeStatusChanged)
+		}
+		return tx.Create(&models.PostTransition{
+			PostID:  post.ID,
+			ActorID: actorID,
+			Action:  action,
+			From:    from,
+			To:      to,
+			Comment: comment,
+		}).Error
+	})
+	if err != nil {
+		return apperror.From(err)
+	}
+	service.InvalidatePost(post.ID)
+	return nil
+}
+
+func transitionError(err error) *apperror.Error {
+	switch {
+	case errors.Is(err, workflow.ErrForbidden):
+		return apperror.Forbidden(apperror.CodeTransitionDenied)
+	case errors.Is(err, workflow.ErrInvalidTransition):
+		return apperror.Conflict(apperror.CodeInvalidTransition)
+	}
+	return apperror.Internal(err)
+}
+
+// TransitionPost godoc
+// @Summary 变更文章审稿状态
+// @Description 状态流转：draft -submit-> in_review -approve-> approved -publish-> published。审稿人可以 request_changes 退回草稿，作者可以 withdraw 撤回，已发布文章可以 unpublish。开启审稿流程后只有编辑可以发布已审核的文章
+// @Tags 审稿
+// @Accept json
+// @Produce json
+// @Param id path int true "文章 ID"
+// @Param transition body TransitionInput true "操作"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Router /posts/{id}/transitions [post]
+// @Security ApiKeyAuth
+func TransitionPost(c *gin.Context) {
+	post, ok := findPost(c)
+	if !ok {
+		return
+	}
+
+	var input TransitionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperror.FromBind(err))
+		return
+	}
+
+	userID := c.MustGet("user_id").(uint)
+	to, err := workflow.Transition(post.Status, input.Action, actorFor(post, userID), config.AppConfig.EditorialWorkflow)
+	if err != nil {
+		c.Error(transitionError(err))
+		return
+	}
+
+	wasPublished := !post.IsDraft
+	if err := applyTransition(&post, userID, input.Action, to, input.Comment, nil); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "状态已变更", "post": post})
+
+	afterStatusChange(post, wasPublished)
+}
+
+// GetPostTransitions godoc
+// @Summary 获取文章状态变更记录
+// @Tags 审稿
+// @Accept json
+// @Produce json
+// @Param id path int true "文章 ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} apperror.Problem
+// @Router /posts/{id}/transitions [get]
+// @Security ApiKeyAuth
+func GetPostTransitions(c *gin.Context) {
+	post, ok := findReviewablePost(c)
+	if !ok {
+		return
+	}
+
+	var transitions []models.PostTransition
+	if err := database.DB.Preload("Actor").Where("post_id = ?", post.ID).Order("created_at asc, id asc").Find(&transitions).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": post.Status, "transitions": transitions})
+}
+
+// CreateReviewComment godoc
+// @Summary 添加审稿批注
+// @Description 针对草稿中的一段内容添加批注，quote 为引用的原文，anchor_start/anchor_end 为字符偏移
+// @Tags 审稿
+// @Accept json
+// @Produce json
+// @Param id path int true "文章 ID"
+// @Param comment body ReviewCommentInput true "批注"
+// @Success 201 {object} map[string]interface{}
+// @Failure 403 {object} apperror.Problem
+// @Router /posts/{id}/review-comments [post]
+// @Security ApiKeyAuth
+func CreateReviewComment(c *gin.Context) {
+	post, ok := findReviewablePost(c)
+	if !ok {
+		return
+	}
+
+	var input ReviewCommentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperror.FromBind(err))
+		return
+	}
+
+	if (input.AnchorStart == nil) != (input.AnchorEnd == nil) ||
+		(input.AnchorStart != nil && *input.AnchorStart > *input.AnchorEnd) {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidAnchor))
+		return
+	}
+
+	comment := models.ReviewComment{
+		PostID:      post.ID,
+		UserID:      c.MustGet("user_id").(uint),
+		Body:        input.Body,
+		Quote:       input.Quote,
+		AnchorStart: input.AnchorStart,
+		AnchorEnd:   input.AnchorEnd,
+	}
+	if err := database.DB.Create(&comment).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	database.DB.Preload("User").First(&comment, comment.ID)
+
+	c.JSON(http.StatusCreated, gin.H{"message": "批注已添加", "comment": comment})
+}
+
+// GetReviewComments godoc
+// @Summary 获取审稿批注
+// @Tags 审稿
+// @Accept json
+// @Produce json
+// @Param id path int true "文章 ID"
+// @Param include_resolved query bool false "是否包含已解决的批注"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} apperror.Problem
+// @Router /posts/{id}/review-comments [get]
+// @Security ApiKeyAuth
+func GetReviewComments(c *gin.Context) {
+	post, ok := findReviewablePost(c)
+	if !ok {
+		return
+	}
+
+	query := database.DB.Preload("User").Where("post_id = ?", post.ID)
+	if c.Query("include_resolved") != "true" {
+		query = query.Where("resolved = ?", false)
+	}
+
+	var comments []models.ReviewComment
+	if err := query.Order("created_at asc").Find(&comments).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"comments": comments})
+}
+
+// ResolveReviewComment godoc
+// @Summary 将审稿批注标记为已解决
+// @Tags 审稿
+// @Accept json
+// @Produce json
+// @Param id path int true "文章 ID"
+// @Param comment_id path int true "批注 ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} apperror.Problem
+// @Router /posts/{id}/review-comments/{comment_id}/resolve [post]
+// @Security ApiKeyAuth
+func ResolveReviewComment(c *gin.Context) {
+	post, ok := findReviewablePost(c)
+	if !ok {
+		return
+	}
+
+	commentID, err := strconv.Atoi(c.Param("comment_id"))
+	if err != nil {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidReviewID))
+		return
+	}
+
+	result := database.DB.Model(&models.ReviewComment{}).Where("id = ? AND post_id = ?", commentID, post.ID).Update("resolved", true)
+	if result.Error != nil {
+		c.Error(apperror.Internal(result.Error))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.Error(apperror.NotFound(apperror.CodeReviewCommentAbsent))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "批注已解决"})
+}
+
+func findPost(c *gin.Context) (models.Post, bool) {
+	var post models.Post
+
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidPostID))
+		return post, false
+	}
+
+	if err := database.DB.First(&post, postID).Error; err != nil {
+		c.Error(apperror.NotFound(apperror.CodePostNotFound))
+		return post, false
+	}
+	return post, true
+}
+
+func findReviewablePost(c *gin.Context) (models.Post, bool) {
+	post, ok := findPost(c)
+	if !ok {
+		return post, false
+	}
+
+	if !canReview(actorFor(post, c.MustGet("user_id").(uint))) {
+		c.Error(apperror.Forbidden(apperror.CodeReviewDenied))
+		return post, false
+	}
+	return post, true
+}
@@ -0,0 +1,457 @@
+package controllers
+
+import (
+	"errors"
+	"goblog/database"
+	"goblog/models"
+	"goblog/pkg/apperror"
+	"goblog/pkg/audit"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type SeriesInput struct {
+	Title       string `json:"title" binding:"required,max=200"`
+	Description string `json:"description"`
+}
+
+type SeriesPostInput struct {
+	PostID   uint `json:"post_id" binding:"required"`
+	Position int  `json:"position" binding:"omitempty,min=1"` // 不传则追加到末尾
+}
+
+type SeriesOrderInput struct {
+	PostIDs []uint `json:"post_ids" binding:"required"`
+}
+
+// SeriesEntry 系列目录中的一项
+type SeriesEntry struct {
+	PostID   uint   `json:"post_id"`
+	Title    string `json:"title"`
+	Status   string `json:"status"`
+	Position int    `json:"position"`
+}
+
+// SeriesNav 文章详情中的系列导航
+type SeriesNav struct {
+	ID       uint          `json:"id"`
+	Title    string        `json:"title"`
+	Position int           `json:"position"`
+	Total    int           `json:"total"`
+	Prev     *SeriesEntry  `json:"prev"`
+	Next     *SeriesEntry  `json:"next"`
+	TOC      []SeriesEntry `json:"toc"`
+}
+
+// CreateSeries godoc
+// @Summary 创建系列
+// @Tags 系列
+// @Accept json
+// @Produce json
+// @Param series body SeriesInput true "系列信息"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Router /series [post]
+// @Security ApiKeyAuth
+func CreateSeries(c *gin.Context) {
+	var input SeriesInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperror.FromBind(err))
+		return
+	}
+
+	series := models.Series{
+		UserID:      c.MustGet("user_id").(uint),
+		Title:       input.Title,
+		Description: input.Description,
+	}
+
+	if err := database.DB.Create(&series).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "系列创建成功", "series": series})
+}
+
+// GetSeries godoc
+// @Summary 获取系列及目录
+// @Description 目录按顺序列出已发布的文章，系列创建者还能看到其中的草稿
+// @Tags 系列
+// @Accept json
+// @Produce json
+// @Param id path int true "系列 ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} apperror.Problem
+// @Router /series/{id} [get]
+func GetSeries(c *gin.Context) {
+	seriesID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidSeriesID))
+		return
+	}
+
+	var series models.Series
+	if err := database.DB.Preload("User").First(&series, seriesID).Error; err != nil {
+		c.Error(apperror.NotFound(apperror.CodeSeriesNotFound))
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	toc, err := seriesEntries(series.ID, userID == series.UserID)
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"series": series, "toc": toc})
+}
+
+// GetUserSeries godoc
+// @Summary 获取用户创建的系列
+// @Tags 系列
+// @Accept json
+// @Produce json
+// @Param id path int true "用户 ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /users/{id}/series [get]
+func GetUserSeries(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidUserID))
+		return
+	}
+
+	var series []models.Series
+	if err := database.DB.Where("user_id = ?", userID).Order("created_at desc").Find(&series).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"series": series})
+}
+
+// UpdateSeries godoc
+// @Summary 修改系列信息
+// @Tags 系列
+// @Accept json
+// @Produce json
+// @Param id path int true "系列 ID"
+// @Param series body SeriesInput true "系列信息"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} apperror.Problem
+// @Router /series/{id} [put]
+// @Security ApiKeyAuth
+func UpdateSeries(c *gin.Context) {
+	series, ok := findOwnSeries(c)
+	if !ok {
+		return
+	}
+
+	var input SeriesInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperror.FromBind(err))
+		return
+	}
+
+	if err := database.DB.Model(&series).Updates(map[string]any{
+		"title":       input.Title,
+		"description": input.Description,
+	}).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "系列更新成功", "series": series})
+}
+
+// DeleteSeries godoc
+// @Summary 删除系列
+// @Description 系列中的文章不会被删除，只是移出该系列
+// @Tags 系列
+// @Accept json
+// @Produce json
+// @Param id path int true "系列 ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} apperror.Problem
+// @Router /series/{id} [delete]
+// @Security ApiKeyAuth
+func DeleteSeries(c *gin.Context) {
+	series, ok := findOwnSeries(c)
+	if !ok {
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("series_id = ?", series.ID).Delete(&models.SeriesPost{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&series).Error
+	})
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	recordAudit(c, audit.Entry{Action: audit.ActionSeriesDelete, TargetType: "series", TargetID: series.ID, Before: series})
+	c.JSON(http.StatusOK, gin.H{"message": "系列删除成功"})
+}
+
+// AddSeriesPost godoc
+// @Summary 向系列中添加文章
+// @Description 只能添加自己有编辑权限的文章，一篇文章最多属于一个系列。position 从 1 开始，不传则追加到末尾
+// @Tags 系列
+// @Accept json
+// @Produce json
+// @Param id path int true "系列 ID"
+// @Param post body SeriesPostInput true "文章"
+// @Success 201 {object} map[string]interface{}
+// @Failure 409 {object} apperror.Problem
+// @Router /series/{id}/posts [post]
+// @Security ApiKeyAuth
+func AddSeriesPost(c *gin.Context) {
+	series, ok := findOwnSeries(c)
+	if !ok {
+		return
+	}
+
+	var input SeriesPostInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperror.FromBind(err))
+		return
+	}
+
+	var post models.Post
+	if err := database.DB.First(&post, input.PostID).Error; err != nil {
+		c.Error(apperror.NotFound(apperror.CodePostNotFound))
+		return
+	}
+	if !canEditPost(post, series.UserID) {
+		c.Error(apperror.Forbidden(apperror.CodeSeriesPostDenied))
+		return
+	}
+
+	var count int64
+	database.DB.Model(&models.SeriesPost{}).Where("post_id = ?", post.ID).Count(&count)
+	if count > 0 {
+		c.Error(apperror.Conflict(apperror.CodeSeriesPostTaken))
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		ids, err := seriesPostIDs(tx, series.ID)
+		if err != nil {
+			return err
+		}
+
+		pos := len(ids)
+		if input.Position > 0 && input.Position <= len(ids) {
+			pos = input.Position - 1
+		}
+		ids = append(ids[:pos], append([]uint{post.ID}, ids[pos:]...)...)
+
+		if err := tx.Create(&models.SeriesPost{SeriesID: series.ID, PostID: post.ID, Position: pos + 1}).Error; err != nil {
+			return err
+		}
+		return renumberSeries(tx, series.ID, ids)
+	})
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	toc, _ := seriesEntries(series.ID, true)
+	c.JSON(http.StatusCreated, gin.H{"message": "已添加到系列", "toc": toc})
+}
+
+// RemoveSeriesPost godoc
+// @Summary 将文章移出系列
+// @Tags 系列
+// @Accept json
+// @Produce json
+// @Param id path int true "系列 ID"
+// @Param post_id path int true "文章 ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} apperror.Problem
+// @Router /series/{id}/posts/{post_id} [delete]
+// @Security ApiKeyAuth
+func RemoveSeriesPost(c *gin.Context) {
+	series, ok := findOwnSeries(c)
+	if !ok {
+		return
+	}
+
+	postID, err := strconv.Atoi(c.Param("post_id"))
+	if err != nil {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidPostID))
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("series_id = ? AND post_id = ?", series.ID, postID).Delete(&models.SeriesPost{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		ids, err := seriesPostIDs(tx, series.ID)
+		if err != nil {
+			return err
+		}
+		return renumberSeries(tx, series.ID, ids)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.Error(apperror.NotFound(apperror.CodeSeriesPostAbsent))
+		return
+	}
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	toc, _ := seriesEntries(series.ID, true)
+	c.JSON(http.StatusOK, gin.H{"message": "已移出系列", "toc": toc})
+}
+
+// ReorderSeries godoc
+// @Summary 调整系列中文章的顺序
+// @Description post_ids 必须恰好包含系列中的所有文章，按新的顺序排列
+// @Tags 系列
+// @Accept json
+// @Produce json
+// @Param id path int true "系列 ID"
+// @Param order body SeriesOrderInput true "新的顺序"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Router /series/{id}/posts [put]
+// @Security ApiKeyAuth
+func ReorderSeries(c *gin.Context) {
+	series, ok := findOwnSeries(c)
+	if !ok {
+		return
+	}
+
+	var input SeriesOrderInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperror.FromBind(err))
+		return
+	}
+
+	ids, err := seriesPostIDs(database.DB, series.ID)
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	current := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		current[id] = true
+	}
+	for _, id := range input.PostIDs {
+		if !current[id] {
+			c.Error(apperror.BadRequest(apperror.CodeSeriesOrderDenied))
+			return
+		}
+		delete(current, id)
+	}
+	if len(current) > 0 || len(input.PostIDs) != len(ids) {
+		c.Error(apperror.BadRequest(apperror.CodeSeriesOrderDenied))
+		return
+	}
+
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return renumberSeries(tx, series.ID, input.PostIDs)
+	}); err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	toc, _ := seriesEntries(series.ID, true)
+	c.JSON(http.StatusOK, gin.H{"message": "顺序已更新", "toc": toc})
+}
+
+func findOwnSeries(c *gin.Context) (models.Series, bool) {
+	var series models.Series
+
+	seriesID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidSeriesID))
+		return series, false
+	}
+
+	userID := c.MustGet("user_id").(uint)
+	if err := database.DB.Where("user_id = ?", userID).First(&series, seriesID).Error; err != nil {
+		c.Error(apperror.NotFound(apperror.CodeSeriesNotFound))
+		return series, false
+	}
+	return series, true
+}
+
+func seriesPostIDs(tx *gorm.DB, seriesID uint) ([]uint, error) {
+	var ids []uint
+	err := tx.Model(&models.SeriesPost{}).Where("series_id = ?", seriesID).Order("position asc").Pluck("post_id", &ids).Error
+	return ids, err
+}
+
+// renumberSeries 按 ids 的顺序把 position 重新编号为 1..n
+func renumberSeries(tx *gorm.DB, seriesID uint, ids []uint) error {
+	for i, id := range ids {
+		if err := tx.Model(&models.SeriesPost{}).Where("series_id = ? AND post_id = ?", seriesID, id).Update("position", i+1).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seriesEntries 按顺序返回系列目录，已删除的文章不会出现；不包含草稿时位置会重新连续编号
+func seriesEntries(seriesID uint, includeDrafts bool) ([]SeriesEntry, error) {
+	query := database.DB.Table("series_posts").
+		Select("series_posts.post_id, posts.title, posts.status, series_posts.position").
+		Joins("JOIN posts ON posts.id = series_posts.post_id AND posts.deleted_at IS NULL").
+		Where("series_posts.series_id = ?", seriesID)
+	if !includeDrafts {
+		query = query.Where("posts.is_draft = ?", false)
+	}
+
+	entries := []SeriesEntry{}
+	if err := query.Order("series_posts.position asc").Scan(&entries).Error; err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		entries[i].Position = i + 1
+	}
+	return entries, nil
+}
+
+// seriesNav 返回文章所在系列的目录和上一篇/下一篇，文章不属于任何系列时返回 nil
+func seriesNav(postID uint) *SeriesNav {
+	var item models.SeriesPost
+	if err := database.DB.Where("post_id = ?", postID).First(&item).Error; err != nil {
+		return nil
+	}
+
+	var series models.Series
+	if err := database.DB.First(&series, item.SeriesID).Error; err != nil {
+		return nil
+	}
+
+	toc, err := seriesEntries(series.ID, false)
+	if err != nil {
+		return nil
+	}
+
+	nav := &SeriesNav{ID: series.ID, Title: series.Title, Total: len(toc), TOC: toc}
+	for i, entry := range toc {
+		if entry.PostID != postID {
+			continue
+		}
+		nav.Position = entry.Position
+		if i > 0 {
+			nav.Prev = &toc[i-1]
+		}
+		if i < len(toc)-1 {
+			nav.Next = &toc[i+1]
+		}
+	}
+	return nav
+}
@@ -0,0 +1,111 @@
+package controllers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSeries(t *testing.T) {
+	owner, other := newUser(t), newUser(t)
+	first, second, draft := newPost(t, owner, false), newPost(t, owner, false), newPost(t, owner, true)
+	foreign := newPost(t, other, false)
+
+	res := call(t, http.MethodPost, "/api/series", owner.Token, gin.H{"title": "Go 入门", "description": "从零开始"})
+	expect(t, res, http.StatusCreated, "message", "series.id", "series.title")
+	series := number(t, res, "series.id")
+
+	res = call(t, http.MethodPost, "/api/series", owner.Token, gin.H{"description": "没有标题"})
+	expectInvalid(t, res, "title")
+
+	res = call(t, http.MethodPost, "/api/series", "", gin.H{"title": "匿名"})
+	expectError(t, res, http.StatusUnauthorized, "auth.token_missing")
+
+	for _, post := range []uint{first.ID, second.ID, draft.ID} {
+		res = call(t, http.MethodPost, path("/api/series/%d/posts", series), owner.Token, gin.H{"post_id": post})
+		expect(t, res, http.StatusCreated, "message", "toc")
+	}
+	if len(list(t, res, "toc")) != 3 {
+		t.Fatalf("作者看到的目录应有 3 篇: %s", res.Raw)
+	}
+
+	res = call(t, http.MethodPost, path("/api/series/%d/posts", series), owner.Token, gin.H{"post_id": first.ID})
+	expectError(t, res, http.StatusConflict, "series.post_taken")
+
+	res = call(t, http.MethodPost, path("/api/series/%d/posts", series), owner.Token, gin.H{"post_id": foreign.ID})
+	expectError(t, res, http.StatusForbidden, "series.post_forbidden")
+
+	res = call(t, http.MethodPost, path("/api/series/%d/posts", series), owner.Token, gin.H{"post_id": foreign.ID, "position": -1})
+	expectInvalid(t, res, "position")
+
+	res = call(t, http.MethodPost, path("/api/series/%d/posts", series), other.Token, gin.H{"post_id": foreign.ID})
+	expectError(t, res, http.StatusNotFound, "series.not_found")
+
+	// 其他人看不到草稿
+	res = call(t, http.MethodGet, path("/api/series/%d", series), "", nil)
+	expect(t, res, http.StatusOK, "series.id", "series.author", "toc")
+	if len(list(t, res, "toc")) != 2 {
+		t.Fatalf("读者看到的目录应有 2 篇: %s", res.Raw)
+	}
+	res = call(t, http.MethodGet, path("/api/series/%d", series), owner.Token, nil)
+	expect(t, res, http.StatusOK, "toc")
+	if len(list(t, res, "toc")) != 3 {
+		t.Fatalf("作者看到的目录应有 3 篇: %s", res.Raw)
+	}
+
+	// 文章详情带系列导航
+	res = call(t, http.MethodGet, path("/api/posts/%d", first.ID), "", nil)
+	expect(t, res, http.StatusOK, "series.id", "series.next", "series.toc")
+
+	res = call(t, http.MethodPut, path("/api/series/%d/posts", series), owner.Token, gin.H{"post_ids": []uint{draft.ID, second.ID, first.ID}})
+	expect(t, res, http.StatusOK, "message", "toc")
+	if toc := list(t, res, "toc"); toc[0].(map[string]any)["post_id"] != float64(draft.ID) {
+		t.Fatalf("调整顺序后第一篇应为 %d: %s", draft.ID, res.Raw)
+	}
+
+	res = call(t, http.MethodPut, path("/api/series/%d/posts", series), owner.Token, gin.H{"post_ids": []uint{first.ID, second.ID}})
+	expectError(t, res, http.StatusBadRequest, "series.invalid_order")
+
+	res = call(t, http.MethodPut, path("/api/series/%d/posts", series), owner.Token, gin.H{})
+	expectInvalid(t, res, "post_ids")
+
+	res = call(t, http.MethodDelete, path("/api/series/%d/posts/%d", series, draft.ID), owner.Token, nil)
+	expect(t, res, http.StatusOK, "message", "toc")
+
+	res = call(t, http.MethodDelete, path("/api/series/%d/posts/%d", series, draft.ID), owner.Token, nil)
+	expectError(t, res, http.StatusNotFound, "series.post_not_found")
+
+	res = call(t, http.MethodDelete, path("/api/series/%d/posts/abc", series), owner.Token, nil)
+	expectError(t, res, http.StatusBadRequest, "post.invalid_id")
+
+	res = call(t, http.MethodPut, path("/api/series/%d", series), owner.Token, gin.H{"title": "Go 进阶"})
+	expect(t, res, http.StatusOK, "message", "series.id")
+
+	res = call(t, http.MethodPut, path("/api/series/%d", series), other.Token, gin.H{"title": "抢走"})
+	expectError(t, res, http.StatusNotFound, "series.not_found")
+
+	res = call(t, http.MethodPut, path("/api/series/%d", series), owner.Token, gin.H{"title": ""})
+	expectInvalid(t, res, "title")
+
+	res = call(t, http.MethodGet, path("/api/users/%d/series", owner.ID), "", nil)
+	expect(t, res, http.StatusOK, "series")
+	if len(list(t, res, "series")) != 1 {
+		t.Fatalf("用户应有 1 个系列: %s", res.Raw)
+	}
+
+	res = call(t, http.MethodGet, "/api/users/abc/series", "", nil)
+	expectError(t, res, http.StatusBadRequest, "user.invalid_id")
+
+	res = call(t, http.MethodDelete, path("/api/series/%d", series), other.Token, nil)
+	expectError(t, res, http.StatusNotFound, "series.not_found")
+
+	res = call(t, http.MethodDelete, path("/api/series/%d", series), owner.Token, nil)
+	expect(t, res, http.StatusOK, "message")
+
+	res = call(t, http.MethodGet, path("/api/series/%d", series), "", nil)
+	expectError(t, res, http.StatusNotFound, "series.not_found")
+
+	res = call(t, http.MethodGet, "/api/series/abc", "", nil)
+	expectError(t, res, http.StatusBadRequest, "series.invalid_id")
+}
@@ -1,12 +1,13 @@
 package controllers
 
 import (
-	"goblog/database"
+	"goblog/config"
 	"goblog/models"
+	"goblog/pkg/cache"
+	"goblog/service"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 // GetPostsByTag godoc
@@ -17,20 +18,26 @@ import (
 // @Param name path string true "标签名"
 // @Success 200 {object} map[string]interface{}
 // @Router /tags/{name}/posts [get]
-func GetPostByTag(c *gin.Context) {
-	tagName := c.Param("name")
-	var tag models.Tag
-
-	if err := database.DB.Preload("Posts", func(db *gorm.DB) *gorm.DB {
-		return db.Preload("User").Preload("Tags").Order("created_at desc")
-	}).First(&tag, "name = ?", tagName).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "标签未找到"})
+func (h *Handler) GetPostByTag(c *gin.Context) {
+	name := c.Param("name")
+	result, err := cache.Get(c, service.TagPostsKey(name), config.AppConfig.Cache.ReadTTL, func() (tagPosts, error) {
+		tag, posts, err := h.svc.Tags.Posts(c, name)
+		return tagPosts{Tag: tag.Name, Posts: posts}, err
+	})
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"tag":   tag.Name,
-		"posts": tag.Posts,
-		"count": len(tag.Posts),
+		"tag":   result.Tag,
+		"posts": result.Posts,
+		"count": len(result.Posts),
 	})
 }
+
+// tagPosts 是标签文章列表的缓存内容
+type tagPosts struct {
+	Tag   string        `json:"tag"`
+	Posts []models.Post `json:"posts"`
+}
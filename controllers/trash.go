@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"goblog/config"
+	"goblog/models"
+	"goblog/pkg/apperror"
+	"goblog/pkg/audit"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TrashedPost 是回收站中的文章，PurgeAt 是自动永久删除的时间，为空表示不会自动删除
+type TrashedPost struct {
+	models.Post
+	DeletedAt time.Time  `json:"deleted_at"`
+	PurgeAt   *time.Time `json:"purge_at"`
+}
+
+// GetTrash godoc
+// @Summary 获取我的回收站
+// @Description 返回当前用户删除的文章，最近删除的在前。超过 trash.retention 的文章会被永久删除
+// @Tags 回收站
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /trash [get]
+// @Security ApiKeyAuth
+func (h *Handler) GetTrash(c *gin.Context) {
+	posts, err := h.svc.Posts.Trash(c, c.MustGet("user_id").(uint))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	retention := config.AppConfig.Trash.Retention
+	result := make([]TrashedPost, len(posts))
+	for i, post := range posts {
+		result[i] = TrashedPost{Post: post, DeletedAt: post.DeletedAt.Time}
+		if retention > 0 {
+			purgeAt := post.DeletedAt.Time.Add(retention)
+			result[i].PurgeAt = &purgeAt
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"posts": result})
+}
+
+// RestorePost godoc
+// @Summary 从回收站恢复文章
+// @Description 只有作者可以恢复，恢复后文章保持删除前的状态
+// @Tags 回收站
+// @Accept json
+// @Produce json
+// @Param id path int true "文章 ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /trash/{id}/restore [post]
+// @Security ApiKeyAuth
+func (h *Handler) RestorePost(c *gin.Context) {
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidPostID))
+		return
+	}
+
+	post, err := h.svc.Posts.Restore(c, uint(postID), c.MustGet("user_id").(uint))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	recordAudit(c, audit.Entry{Action: audit.ActionPostRestore, TargetType: "post", TargetID: post.ID, After: post})
+	c.JSON(http.StatusOK, gin.H{"message": "文章已恢复", "post": post})
+}
+
+// AdminPurgePost godoc
+// @Summary 永久删除回收站中的文章
+// @Description 同时删除文章的评论、点赞、标签关联、收藏等数据，无法恢复。未删除的文章需要先删除
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Param id path int true "文章 ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} apperror.Problem
+// @Router /admin/trash/{id} [delete]
+// @Security ApiKeyAuth
+func (h *Handler) AdminPurgePost(c *gin.Context) {
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidPostID))
+		return
+	}
+
+	post, err := h.svc.Posts.Purge(c, uint(postID))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	recordAudit(c, audit.Entry{Action: audit.ActionPostPurge, TargetType: "post", TargetID: post.ID, Before: post})
+	c.JSON(http.StatusOK, gin.H{"message": "文章已永久删除"})
+}
@@ -0,0 +1,214 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"goblog/database"
+	"goblog/models"
+	"goblog/pkg/apperror"
+	"goblog/pkg/audit"
+	"goblog/pkg/webhook"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CreateWebhookInput struct {
+	URL    string   `json:"url" binding:"required,url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events" binding:"required,min=1"`
+}
+
+// CreateWebhook godoc
+// @Summary 创建 webhook 订阅
+// @Description 订阅 post.published、comment.created、like.created 等事件，只会收到与自己的文章和评论有关的事件；订阅地址必须是 http(s) 公网地址，未提供 secret 时自动生成
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Param webhook body CreateWebhookInput true "订阅信息"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} apperror.Problem
+// @Router /webhooks [post]
+// @Security ApiKeyAuth
+func CreateWebhook(c *gin.Context) {
+	var input CreateWebhookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(apperror.FromBind(err))
+		return
+	}
+
+	for _, event := range input.Events {
+		if event != "*" && !webhook.IsValidEvent(event) {
+			c.Error(apperror.BadRequest(apperror.CodeUnsupportedEvent).With("event", event))
+			return
+		}
+	}
+
+	if err := webhook.ValidateURL(c.Request.Context(), input.URL); err != nil {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidWebhookURL).With("reason", err.Error()))
+		return
+	}
+
+	secret := input.Secret
+	if secret == "" {
+		buf := make([]byte, 20)
+		if _, err := rand.Read(buf); err != nil {
+			c.Error(apperror.Internal(err))
+			return
+		}
+		secret = hex.EncodeToString(buf)
+	}
+
+	hook := models.Webhook{
+		UserID: c.MustGet("user_id").(uint),
+		URL:    input.URL,
+		Secret: secret,
+		Events: strings.Join(input.Events, ","),
+		Active: true,
+	}
+
+	if err := database.DB.Create(&hook).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	// secret 只在创建时返回一次
+	c.JSON(http.StatusCreated, gin.H{"message": "webhook 创建成功", "webhook": hook, "secret": secret})
+}
+
+// GetWebhooks godoc
+// @Summary 获取当前用户的 webhook 订阅
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /webhooks [get]
+// @Security ApiKeyAuth
+func GetWebhooks(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	var hooks []models.Webhook
+	if err := database.DB.Where("user_id = ?", userID).Order("created_at desc").Find(&hooks).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"webhooks": hooks})
+}
+
+// DeleteWebhook godoc
+// @Summary 删除 webhook 订阅
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Param id path int true "webhook ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} apperror.Problem
+// @Router /webhooks/{id} [delete]
+// @Security ApiKeyAuth
+func DeleteWebhook(c *gin.Context) {
+	hook, ok := findOwnWebhook(c)
+	if !ok {
+		return
+	}
+
+	if err := database.DB.Delete(&hook).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	recordAudit(c, audit.Entry{Action: audit.ActionWebhookDelete, TargetType: "webhook", TargetID: hook.ID, Before: hook})
+	c.JSON(http.StatusOK, gin.H{"message": "webhook 删除成功"})
+}
+
+// GetWebhookDeliveries godoc
+// @Summary 获取 webhook 投递记录
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Param id path int true "webhook ID"
+// @Param page query int false "页码"
+// @Param limit query int false "每页数量"
+// @Success 200 {object} map[string]interface{}
+// @Router /webhooks/{id}/deliveries [get]
+// @Security ApiKeyAuth
+func GetWebhookDeliveries(c *gin.Context) {
+	hook, ok := findOwnWebhook(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	var deliveries []models.WebhookDelivery
+	if err := database.DB.Where("webhook_id = ?", hook.ID).Order("created_at desc").
+		Limit(limit).Offset((page - 1) * limit).Find(&deliveries).Error; err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries})
+}
+
+// RedeliverWebhook godoc
+// @Summary 手动重新投递
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Param id path int true "webhook ID"
+// @Param delivery_id path int true "投递记录 ID"
+// @Success 202 {object} map[string]interface{}
+// @Failure 404 {object} apperror.Problem
+// @Router /webhooks/{id}/deliveries/{delivery_id}/redeliver [post]
+// @Security ApiKeyAuth
+func RedeliverWebhook(c *gin.Context) {
+	hook, ok := findOwnWebhook(c)
+	if !ok {
+		return
+	}
+
+	deliveryID, err := strconv.Atoi(c.Param("delivery_id"))
+	if err != nil {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidDeliveryID))
+		return
+	}
+
+	var delivery models.WebhookDelivery
+	if err := database.DB.Where("webhook_id = ?", hook.ID).First(&delivery, deliveryID).Error; err != nil {
+		c.Error(apperror.NotFound(apperror.CodeDeliveryNotFound))
+		return
+	}
+
+	retry, err := webhook.Redeliver(delivery)
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "已重新加入投递队列", "delivery": retry})
+}
+
+func findOwnWebhook(c *gin.Context) (models.Webhook, bool) {
+	var hook models.Webhook
+
+	hookID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.BadRequest(apperror.CodeInvalidWebhookID))
+		return hook, false
+	}
+
+	userID := c.MustGet("user_id").(uint)
+	if err := database.DB.Where("user_id = ?", userID).First(&hook, hookID).Error; err != nil {
+		c.Error(apperror.NotFound(apperror.CodeWebhookNotFound))
+		return hook, false
+	}
+	return hook, true
+}
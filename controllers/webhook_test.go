@@ -0,0 +1,95 @@
+package controllers_test
+
+import (
+	"goblog/config"
+	"goblog/models"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCreateWebhook(t *testing.T) {
+	user := newUser(t)
+
+	res := call(t, http.MethodPost, "/api/webhooks", user.Token, gin.H{"url": "http://127.0.0.1:9/hook", "events": []string{"post.published", "comment.created"}})
+	expect(t, res, http.StatusCreated, "message", "webhook.id", "webhook.events", "secret")
+	if _, ok := lookup(res.Body, "webhook.secret"); ok {
+		t.Fatalf("webhook 中不应包含 secret: %s", res.Raw)
+	}
+
+	res = call(t, http.MethodPost, "/api/webhooks", user.Token, gin.H{"url": "http://127.0.0.1:9/hook", "secret": "s3cret", "events": []string{"*"}})
+	expect(t, res, http.StatusCreated, "secret")
+	if res.Body["secret"] != "s3cret" {
+		t.Fatalf("应使用指定的 secret: %s", res.Raw)
+	}
+
+	res = call(t, http.MethodPost, "/api/webhooks", user.Token, gin.H{"url": "http://127.0.0.1:9/hook", "events": []string{"post.deleted"}})
+	expectError(t, res, http.StatusBadRequest, "webhook.unsupported_event")
+
+	res = call(t, http.MethodPost, "/api/webhooks", user.Token, gin.H{"url": "not a url", "events": []string{}})
+	expectInvalid(t, res, "url")
+	expectInvalid(t, res, "events")
+
+	// 默认不允许订阅内网地址
+	config.AppConfig.Webhook.AllowPrivate = false
+	res = call(t, http.MethodPost, "/api/webhooks", user.Token, gin.H{"url": "http://127.0.0.1:9/hook", "events": []string{"*"}})
+	config.AppConfig.Webhook.AllowPrivate = true
+	expectError(t, res, http.StatusBadRequest, "webhook.invalid_url")
+
+	res = call(t, http.MethodPost, "/api/webhooks", "", gin.H{"url": "http://127.0.0.1:9/hook", "events": []string{"*"}})
+	expectError(t, res, http.StatusUnauthorized, "auth.token_missing")
+}
+
+func TestWebhooks(t *testing.T) {
+	owner, other := newUser(t), newUser(t)
+
+	res := call(t, http.MethodPost, "/api/webhooks", owner.Token, gin.H{"url": "http://127.0.0.1:9/hook", "events": []string{"*"}})
+	expect(t, res, http.StatusCreated, "webhook.id")
+	hook := number(t, res, "webhook.id")
+	delivery := models.WebhookDelivery{WebhookID: hook, Event: "post.published", Payload: "{}", Status: models.DeliveryFailed, NextAttemptAt: time.Now()}
+	seed(t, &delivery)
+
+	res = call(t, http.MethodGet, "/api/webhooks", owner.Token, nil)
+	expect(t, res, http.StatusOK, "webhooks")
+	if len(list(t, res, "webhooks")) != 1 {
+		t.Fatalf("应有 1 个 webhook: %s", res.Raw)
+	}
+
+	res = call(t, http.MethodGet, "/api/webhooks", other.Token, nil)
+	expect(t, res, http.StatusOK, "webhooks")
+	if len(list(t, res, "webhooks")) != 0 {
+		t.Fatalf("不应看到其他人的 webhook: %s", res.Raw)
+	}
+
+	res = call(t, http.MethodGet, path("/api/webhooks/%d/deliveries", hook), owner.Token, nil)
+	expect(t, res, http.StatusOK, "deliveries")
+	if len(list(t, res, "deliveries")) != 1 {
+		t.Fatalf("应有 1 条投递记录: %s", res.Raw)
+	}
+
+	res = call(t, http.MethodGet, path("/api/webhooks/%d/deliveries", hook), other.Token, nil)
+	expectError(t, res, http.StatusNotFound, "webhook.not_found")
+
+	res = call(t, http.MethodPost, path("/api/webhooks/%d/deliveries/%d/redeliver", hook, delivery.ID), owner.Token, nil)
+	expect(t, res, http.StatusAccepted, "message", "delivery.id", "delivery.status")
+	if status, _ := lookup(res.Body, "delivery.status"); status != models.DeliveryPending {
+		t.Fatalf("重新投递的状态应为 pending: %s", res.Raw)
+	}
+
+	res = call(t, http.MethodPost, path("/api/webhooks/%d/deliveries/999999/redeliver", hook), owner.Token, nil)
+	expectError(t, res, http.StatusNotFound, "webhook.delivery_not_found")
+
+	res = call(t, http.MethodPost, path("/api/webhooks/%d/deliveries/abc/redeliver", hook), owner.Token, nil)
+	expectError(t, res, http.StatusBadRequest, "webhook.invalid_delivery_id")
+
+	res = call(t, http.MethodDelete, path("/api/webhooks/%d", hook), other.Token, nil)
+	expectError(t, res, http.StatusNotFound, "webhook.not_found")
+
+	res = call(t, http.MethodDelete, path("/api/webhooks/%d", hook), owner.Token, nil)
+	expect(t, res, http.StatusOK, "message")
+
+	res = call(t, http.MethodDelete, "/api/webhooks/abc", owner.Token, nil)
+	expectError(t, res, http.StatusBadRequest, "webhook.invalid_id")
+}
@@ -1,29 +1,69 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"goblog/config"
-	"goblog/models"
-	"log"
+	"goblog/pkg/logger"
+	"goblog/pkg/telemetry"
+	"log/slog"
 
-	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 var DB *gorm.DB
 
-func ConnectDatabase() {
-	conf := config.AppConfig
+// ConnectDatabase 连接数据库，开启 database.auto_migrate 时执行未应用的迁移
+func ConnectDatabase() error {
+	if err := Open(); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if config.AppConfig.Database.AutoMigrate {
+		if _, err := Migrate(ctx); err != nil {
+			return err
+		}
+	} else if pending, err := PendingMigrations(ctx); err != nil {
+		return err
+	} else if pending > 0 {
+		slog.Warn("数据库有迁移尚未执行，请运行 goblog migrate up", "pending", pending)
+	}
 
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s", conf.DBHost, conf.DBUser, conf.DBPassword, conf.DBName, conf.DBPort, conf.DBSSLMode)
+	slog.Info("Database connected successfully!", "driver", Driver())
+	return nil
+}
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+// Open 只建立数据库连接，不执行迁移
+func Open() error {
+	dialector, err := dialector(config.AppConfig.Database)
 	if err != nil {
-		log.Fatalf("Failed to connect to database %v", err)
+		return err
 	}
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Gorm(config.AppConfig.Log.SlowQuery)})
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	if err := db.Use(telemetry.GormPlugin{}); err != nil {
+		return err
+	}
+	DB = db
+	return nil
+}
 
-	db.AutoMigrate(&models.User{}, &models.Post{}, &models.Conment{}, &models.Like{}, &models.Tag{})
+// Ping 检查数据库连接是否可用
+func Ping(ctx context.Context) error {
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
 
-	DB = db
-	log.Println("Database connected successfully!")
+func Close() error {
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
 }
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"fmt"
+	"goblog/config"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+const (
+	Postgres = "postgres"
+	MySQL    = "mysql"
+	SQLite   = "sqlite"
+)
+
+// Capabilities 是各驱动之间有差异的数据库特性，驱动相关的 SQL 先判断这里再选择写法
+type Capabilities struct {
+	// AdvisoryLock 支持跨连接的命名锁，PostgreSQL 为 pg_advisory_lock，MySQL 为 GET_LOCK
+	AdvisoryLock bool
+	// TransactionalDDL 建表、建索引可以在事务中回滚，MySQL 执行 DDL 时会隐式提交
+	TransactionalDDL bool
+	// PartialIndex 支持带 WHERE 条件的索引，用来让唯一约束忽略软删除的记录
+	PartialIndex bool
+	// ExcludedRow upsert 时用 excluded.col 引用待插入的值，否则用 MySQL 的 VALUES(col)
+	ExcludedRow bool
+	// LocalDatetime 时间列保存的是连接时区下的本地时间而不是时刻，MySQL 的 datetime 如此，换算 UTC 要靠 CONVERT_TZ
+	LocalDatetime bool
+}
+
+var capabilities = map[string]Capabilities{
+	Postgres: {AdvisoryLock: true, TransactionalDDL: true, PartialIndex: true, ExcludedRow: true},
+	MySQL:    {AdvisoryLock: true, LocalDatetime: true},
+	SQLite:   {TransactionalDDL: true, PartialIndex: true, ExcludedRow: true},
+}
+
+// Driver 返回当前连接使用的驱动名
+func Driver() string {
+	return DB.Dialector.Name()
+}
+
+// Supports 返回当前驱动的特性
+func Supports() Capabilities {
+	return CapabilitiesOf(DB)
+}
+
+// CapabilitiesOf 返回 db 所用驱动的特性，供注入了自己连接的仓储使用
+func CapabilitiesOf(db *gorm.DB) Capabilities {
+	return capabilities[db.Dialector.Name()]
+}
+
+// Excluded 返回 upsert 冲突时引用待插入列值的表达式
+func Excluded(column string) string {
+	if Supports().ExcludedRow {
+		return "excluded." + column
+	}
+	return "VALUES(" + column + ")"
+}
+
+// UTCDate 返回按 UTC 取时间列日期的表达式，供按天分组统计使用。
+// DATE() 在 PostgreSQL 中按会话时区取日期，需要先换算到 UTC；SQLite 按值末尾的时区偏移换算成 UTC；
+// MySQL 按连接时区保存，database.timezone 不是 UTC 时用 CONVERT_TZ 换算，需要数据库已导入时区表
+func UTCDate(column string) string {
+	switch {
+	case Supports().LocalDatetime:
+		if tz := config.AppConfig.Database.TimeZone; tz != "" && tz != "UTC" {
+			return fmt.Sprintf("DATE(CONVERT_TZ(%s, '%s', '+00:00'))", column, tz)
+		}
+		return fmt.Sprintf("DATE(%s)", column)
+	case Driver() == Postgres:
+		return fmt.Sprintf("DATE(%s AT TIME ZONE 'UTC')", column)
+	default:
+		return fmt.Sprintf("DATE(%s)", column)
+	}
+}
+
+// dialector 按 database.driver 选择 GORM 驱动
+func dialector(conf config.DatabaseConfig) (gorm.Dialector, error) {
+	switch conf.Driver {
+	case Postgres:
+		return postgres.Open(conf.DSN()), nil
+	case MySQL:
+		return mysql.Open(mysqlDSN(conf)), nil
+	case SQLite:
+		// SQLite 默认不检查外键；busy_timeout 让并发写入时等待而不是直接报 database is locked
+		return sqlite.Open(conf.Path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"), nil
+	default:
+		return nil, fmt.Errorf("不支持的数据库驱动 %q", conf.Driver)
+	}
+}
+
+// mysqlDSN 生成 MySQL 连接串，迁移文件包含多条语句，需要开启 multiStatements
+func mysqlDSN(conf config.DatabaseConfig) string {
+	c := mysqldriver.NewConfig()
+	c.Net = "tcp"
+	c.Addr = fmt.Sprintf("%s:%d", conf.Host, conf.Port)
+	c.User = conf.User
+	c.Passwd = conf.Password
+	c.DBName = conf.Name
+	c.ParseTime = true
+	c.MultiStatements = true
+	c.Params = map[string]string{"charset": "utf8mb4"}
+	c.Loc = time.UTC
+	if conf.TimeZone != "" {
+		if loc, err := time.LoadLocation(conf.TimeZone); err == nil {
+			c.Loc = loc
+		}
+	}
+	return c.FormatDSN()
+}
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"context"
+	"goblog/database/migrations"
+	"goblog/pkg/migrate"
+	"log/slog"
+)
+
+// Migrator 返回使用内嵌迁移文件的迁移器，需要先调用 Open
+func Migrator() (*migrate.Migrator, error) {
+	dialect, err := migrate.DialectFor(Driver())
+	if err != nil {
+		return nil, err
+	}
+	dialect.TransactionalDDL = Supports().TransactionalDDL
+	fsys, err := migrations.FS(Driver())
+	if err != nil {
+		return nil, err
+	}
+	list, err := migrate.Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return nil, err
+	}
+	return migrate.New(sqlDB, dialect, list), nil
+}
+
+// Migrate 执行全部未应用的迁移，多个实例同时启动时由迁移锁保证只执行一次
+func Migrate(ctx context.Context) ([]migrate.Migration, error) {
+	m, err := Migrator()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := m.Up(ctx, 0)
+	for _, migration := range applied {
+		slog.Info("已执行迁移", "migration", migration)
+	}
+	return applied, err
+}
+
+func PendingMigrations(ctx context.Context) (int, error) {
+	m, err := Migrator()
+	if err != nil {
+		return 0, err
+	}
+	return m.Pending(ctx)
+}
@@ -0,0 +1,142 @@
+package database_test
+
+import (
+	"context"
+	"goblog/database"
+	"goblog/database/databasetest"
+	"goblog/models"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// tables 是由迁移创建、由 GORM 读写的全部模型
+var tables = []any{
+	&models.User{}, &models.Post{}, &models.Tag{}, &models.Comment{}, &models.Like{},
+	&models.Webhook{}, &models.WebhookDelivery{}, &models.Follow{},
+	&models.BookmarkCollection{}, &models.Bookmark{}, &models.PostView{}, &models.PostReferrer{},
+	&models.PostCollaborator{}, &models.PostTransition{}, &models.ReviewComment{},
+	&models.Series{}, &models.SeriesPost{}, &models.AuditLog{},
+	&models.DataExport{}, &models.AccountDeletion{}, &models.ImportedItem{},
+}
+
+func TestMigrations(t *testing.T) {
+	databasetest.ForEach(t, func(t *testing.T, driver string) {
+		db := databasetest.Connect(t, driver)
+		ctx := context.Background()
+
+		m, err := database.Migrator()
+		if err != nil {
+			t.Fatal(err)
+		}
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		applied, err := database.Migrate(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(applied) != len(statuses) {
+			t.Fatalf("执行了 %d 个迁移，期望 %d 个", len(applied), len(statuses))
+		}
+		if pending, err := database.PendingMigrations(ctx); err != nil || pending != 0 {
+			t.Fatalf("迁移后还有 %d 个未执行 (%v)", pending, err)
+		}
+		checkSchema(t, db)
+
+		// 全部回滚后表都被删除，再次执行得到同样的表结构
+		reverted, err := m.Down(ctx, len(applied))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(reverted) != len(applied) {
+			t.Fatalf("回滚了 %d 个迁移，期望 %d 个", len(reverted), len(applied))
+		}
+		for _, model := range tables {
+			if db.Migrator().HasTable(model) {
+				t.Errorf("回滚后 %T 的表仍然存在", model)
+			}
+		}
+
+		if _, err := database.Migrate(ctx); err != nil {
+			t.Fatal(err)
+		}
+		checkSchema(t, db)
+	})
+}
+
+// checkSchema 检查每个模型的表和列都由迁移创建
+func checkSchema(t *testing.T, db *gorm.DB) {
+	t.Helper()
+
+	for _, model := range tables {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			t.Fatal(err)
+		}
+		if !db.Migrator().HasTable(model) {
+			t.Errorf("缺少表 %s", stmt.Schema.Table)
+			continue
+		}
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName == "" || !field.Readable {
+				continue
+			}
+			if !db.Migrator().HasColumn(model, field.DBName) {
+				t.Errorf("表 %s 缺少列 %s", stmt.Schema.Table, field.DBName)
+			}
+		}
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	databasetest.ForEach(t, func(t *testing.T, driver string) {
+		db := databasetest.Open(t, driver)
+		if database.Driver() != driver {
+			t.Fatalf("Driver() 为 %s，期望 %s", database.Driver(), driver)
+		}
+		caps := database.Supports()
+		if caps != database.CapabilitiesOf(db) {
+			t.Fatalf("Supports() 与 CapabilitiesOf 不一致: %+v", caps)
+		}
+
+		// Excluded 生成的 upsert 表达式在每种数据库上都能执行
+		date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		for _, views := range []int64{3, 5} {
+			err := db.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "post_id"}, {Name: "date"}},
+				DoUpdates: clause.Assignments(map[string]any{"views": gorm.Expr(database.Excluded("views"))}),
+			}).Create(&models.PostView{PostID: 1, Date: date, Views: views}).Error
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		var views int64
+		if err := db.Model(&models.PostView{}).Where("post_id = ?", 1).Pluck("views", &views).Error; err != nil {
+			t.Fatal(err)
+		}
+		if views != 5 {
+			t.Fatalf("upsert 后浏览量为 %d，期望 5", views)
+		}
+
+		// PartialIndex 要与迁移建出的索引一致：支持时软删除的标签不占用名称，否则唯一索引仍包含它
+		tag := models.Tag{Name: "capabilities"}
+		if err := db.Create(&tag).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Delete(&tag).Error; err != nil {
+			t.Fatal(err)
+		}
+		err := db.Create(&models.Tag{Name: tag.Name}).Error
+		if caps.PartialIndex && err != nil {
+			t.Fatalf("支持部分索引时软删除后应能再建同名标签: %v", err)
+		}
+		if !caps.PartialIndex && err == nil {
+			t.Fatal("不支持部分索引时唯一索引应包含软删除的记录，需要更新 Capabilities")
+		}
+	})
+}
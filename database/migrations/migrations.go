@@ -0,0 +1,19 @@
+// Package migrations 嵌入数据库迁移文件，每种数据库一个目录，版本号在各目录间保持一致。
+// 新的迁移用 goblog migrate create <name> 生成
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed postgres/*.sql mysql/*.sql sqlite/*.sql
+var files embed.FS
+
+// Drivers 是提供了迁移文件的数据库驱动
+var Drivers = []string{"postgres", "mysql", "sqlite"}
+
+// FS 返回 driver 对应目录下的迁移文件
+func FS(driver string) (fs.FS, error) {
+	return fs.Sub(files, driver)
+}
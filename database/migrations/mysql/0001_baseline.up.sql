@@ -0,0 +1,236 @@
+-- MySQL 基线：直接建出与 PostgreSQL 执行完 0001-0003 后相同的表结构。
+-- MySQL 不支持 CREATE INDEX IF NOT EXISTS，索引都写在建表语句中；带索引或默认值的字符串列用 varchar。
+-- MySQL 没有部分索引，likes 和 tags 的唯一索引也包含软删除的记录，目前这两张表都不会被软删除。
+
+CREATE TABLE IF NOT EXISTS users (
+    id         bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    username   varchar(255) NOT NULL,
+    email      varchar(255) NOT NULL,
+    password   text NOT NULL,
+    role       varchar(32) NOT NULL DEFAULT 'user',
+    created_at datetime(3),
+    updated_at datetime(3),
+    deleted_at datetime(3),
+    CONSTRAINT uni_users_username UNIQUE (username),
+    CONSTRAINT uni_users_email UNIQUE (email)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS posts (
+    id           bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    title        text NOT NULL,
+    content      text NOT NULL,
+    user_id      bigint,
+    is_draft     boolean DEFAULT false,
+    status       varchar(32) DEFAULT 'published',
+    is_top       boolean DEFAULT false,
+    is_recommend boolean DEFAULT false,
+    created_at   datetime(3),
+    updated_at   datetime(3),
+    deleted_at   datetime(3),
+    CONSTRAINT fk_posts_user FOREIGN KEY (user_id) REFERENCES users (id),
+    KEY idx_posts_status (status),
+    KEY idx_posts_deleted_at (deleted_at),
+    KEY idx_posts_user_id (user_id),
+    KEY idx_posts_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS tags (
+    id         bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    name       varchar(255),
+    created_at datetime(3),
+    updated_at datetime(3),
+    delete_at  datetime(3),
+    KEY idx_tags_delete_at (delete_at),
+    UNIQUE KEY idx_tags_name (name)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS post_tags (
+    post_id bigint,
+    tag_id  bigint,
+    PRIMARY KEY (post_id, tag_id),
+    CONSTRAINT fk_post_tags_post FOREIGN KEY (post_id) REFERENCES posts (id),
+    CONSTRAINT fk_post_tags_tag FOREIGN KEY (tag_id) REFERENCES tags (id),
+    KEY idx_post_tags_tag_id (tag_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS comments (
+    id         bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    content    text NOT NULL,
+    user_id    bigint,
+    post_id    bigint,
+    parent_id  bigint,
+    created_at datetime(3),
+    updated_at datetime(3),
+    deleted_at datetime(3),
+    CONSTRAINT fk_comments_user FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE SET NULL,
+    CONSTRAINT fk_comments_post FOREIGN KEY (post_id) REFERENCES posts (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    CONSTRAINT fk_comments_replies FOREIGN KEY (parent_id) REFERENCES comments (id),
+    KEY idx_comments_deleted_at (deleted_at),
+    KEY idx_comments_post_id (post_id),
+    KEY idx_comments_parent_id (parent_id),
+    KEY idx_comments_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS likes (
+    id          bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    user_id     bigint,
+    target_id   bigint,
+    target_type varchar(32),
+    created_at  datetime(3),
+    deleted_at  datetime(3),
+    KEY idx_likes_deleted_at (deleted_at),
+    UNIQUE KEY idx_likes_user_target (user_id, target_type, target_id),
+    KEY idx_likes_target (target_type, target_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    id         bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    user_id    bigint,
+    url        text NOT NULL,
+    secret     text NOT NULL,
+    events     text NOT NULL,
+    active     boolean DEFAULT true,
+    created_at datetime(3),
+    updated_at datetime(3),
+    deleted_at datetime(3),
+    KEY idx_webhooks_user_id (user_id),
+    KEY idx_webhooks_deleted_at (deleted_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id              bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    webhook_id      bigint,
+    event           text NOT NULL,
+    payload         text NOT NULL,
+    status          varchar(32) DEFAULT 'pending',
+    attempts        bigint DEFAULT 0,
+    next_attempt_at datetime(3),
+    response_status bigint,
+    response_body   text,
+    error           text,
+    delivered_at    datetime(3),
+    created_at      datetime(3),
+    updated_at      datetime(3),
+    KEY idx_webhook_deliveries_webhook_id (webhook_id),
+    KEY idx_webhook_deliveries_status (status),
+    KEY idx_webhook_deliveries_next_attempt_at (next_attempt_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS follows (
+    id          bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    follower_id bigint NOT NULL,
+    target_id   bigint NOT NULL,
+    target_type varchar(32) NOT NULL,
+    created_at  datetime(3),
+    UNIQUE KEY idx_follow_target (follower_id, target_id, target_type),
+    KEY idx_follow_lookup (target_id, target_type)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS bookmark_collections (
+    id          bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    user_id     bigint,
+    name        text NOT NULL,
+    description text,
+    is_public   boolean DEFAULT false,
+    created_at  datetime(3),
+    updated_at  datetime(3),
+    deleted_at  datetime(3),
+    KEY idx_bookmark_collections_user_id (user_id),
+    KEY idx_bookmark_collections_deleted_at (deleted_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS bookmarks (
+    id            bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    user_id       bigint NOT NULL,
+    post_id       bigint NOT NULL,
+    collection_id bigint,
+    created_at    datetime(3),
+    CONSTRAINT fk_bookmarks_post FOREIGN KEY (post_id) REFERENCES posts (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    UNIQUE KEY idx_bookmark_user_post (user_id, post_id),
+    KEY idx_bookmarks_post_id (post_id),
+    KEY idx_bookmarks_collection_id (collection_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS post_views (
+    post_id bigint,
+    date    date,
+    views   bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY (post_id, date)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS post_referrers (
+    post_id  bigint,
+    date     date,
+    referrer varchar(255),
+    views    bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY (post_id, date, referrer)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS post_collaborators (
+    id          bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    post_id     bigint NOT NULL,
+    user_id     bigint NOT NULL,
+    role        varchar(32) NOT NULL,
+    status      varchar(32) DEFAULT 'pending',
+    invited_by  bigint,
+    accepted_at datetime(3),
+    created_at  datetime(3),
+    updated_at  datetime(3),
+    CONSTRAINT fk_post_collaborators_post FOREIGN KEY (post_id) REFERENCES posts (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    CONSTRAINT fk_post_collaborators_user FOREIGN KEY (user_id) REFERENCES users (id),
+    UNIQUE KEY idx_collaborator_post_user (post_id, user_id),
+    KEY idx_post_collaborators_user_id (user_id),
+    KEY idx_post_collaborators_status (status)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS post_transitions (
+    id          bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    post_id     bigint NOT NULL,
+    actor_id    bigint,
+    action      text NOT NULL,
+    from_status text NOT NULL,
+    to_status   text NOT NULL,
+    comment     text,
+    created_at  datetime(3),
+    CONSTRAINT fk_post_transitions_actor FOREIGN KEY (actor_id) REFERENCES users (id),
+    KEY idx_post_transitions_post_id (post_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS review_comments (
+    id           bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    post_id      bigint NOT NULL,
+    user_id      bigint,
+    body         text NOT NULL,
+    quote        text,
+    anchor_start bigint,
+    anchor_end   bigint,
+    resolved     boolean DEFAULT false,
+    created_at   datetime(3),
+    updated_at   datetime(3),
+    CONSTRAINT fk_review_comments_user FOREIGN KEY (user_id) REFERENCES users (id),
+    KEY idx_review_comments_post_id (post_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS series (
+    id          bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    user_id     bigint,
+    title       text NOT NULL,
+    description text,
+    created_at  datetime(3),
+    updated_at  datetime(3),
+    deleted_at  datetime(3),
+    CONSTRAINT fk_series_user FOREIGN KEY (user_id) REFERENCES users (id),
+    KEY idx_series_user_id (user_id),
+    KEY idx_series_deleted_at (deleted_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS series_posts (
+    id         bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    series_id  bigint NOT NULL,
+    post_id    bigint NOT NULL,
+    position   bigint NOT NULL,
+    created_at datetime(3),
+    CONSTRAINT fk_series_posts_post FOREIGN KEY (post_id) REFERENCES posts (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    KEY idx_series_posts_series_id (series_id),
+    UNIQUE KEY idx_series_posts_post_id (post_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,3 @@
+ALTER TABLE users
+    DROP COLUMN ban_reason,
+    DROP COLUMN banned_at;
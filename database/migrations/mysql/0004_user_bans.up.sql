@@ -0,0 +1,3 @@
+ALTER TABLE users
+    ADD COLUMN banned_at datetime(3) NULL,
+    ADD COLUMN ban_reason varchar(255) NOT NULL DEFAULT '';
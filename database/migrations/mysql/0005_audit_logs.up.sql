@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id          bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    actor_id    bigint,
+    action      varchar(64) NOT NULL,
+    target_type varchar(32) NOT NULL,
+    target_id   bigint NOT NULL DEFAULT 0,
+    `before`    mediumtext,
+    `after`     mediumtext,
+    ip          varchar(64) NOT NULL DEFAULT '',
+    user_agent  text NOT NULL,
+    request_id  varchar(128) NOT NULL DEFAULT '',
+    created_at  datetime(3) NOT NULL,
+    INDEX idx_audit_logs_actor_id (actor_id, created_at),
+    INDEX idx_audit_logs_target (target_type, target_id, created_at),
+    INDEX idx_audit_logs_action (action),
+    INDEX idx_audit_logs_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS data_exports (
+    id           bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    user_id      bigint NOT NULL,
+    status       varchar(32) NOT NULL DEFAULT 'pending',
+    data         longblob,
+    size         bigint NOT NULL DEFAULT 0,
+    error        text NOT NULL,
+    created_at   datetime(3) NOT NULL,
+    completed_at datetime(3),
+    expires_at   datetime(3),
+    INDEX idx_data_exports_user_id (user_id),
+    INDEX idx_data_exports_status (status),
+    INDEX idx_data_exports_expires_at (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS account_deletions (
+    id             bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    user_id        bigint NOT NULL,
+    post_action    varchar(32) NOT NULL,
+    transfer_to_id bigint,
+    requested_at   datetime(3) NOT NULL,
+    execute_at     datetime(3) NOT NULL,
+    started_at     datetime(3),
+    CONSTRAINT uni_account_deletions_user_id UNIQUE (user_id),
+    INDEX idx_account_deletions_execute_at (execute_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
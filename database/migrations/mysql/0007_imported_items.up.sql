@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS imported_items (
+    id          bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    source_key  varchar(500) NOT NULL,
+    target_type varchar(32) NOT NULL,
+    target_id   bigint NOT NULL,
+    checksum    varchar(64) NOT NULL,
+    created_at  datetime(3) NOT NULL,
+    updated_at  datetime(3) NOT NULL,
+    CONSTRAINT uni_imported_items_source_key UNIQUE (source_key),
+    INDEX idx_imported_items_target (target_type, target_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,4 @@
+ALTER TABLE account_deletions
+    DROP COLUMN error,
+    DROP COLUMN failed_at;
+ALTER TABLE data_exports DROP COLUMN started_at;
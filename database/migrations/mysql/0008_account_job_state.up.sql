@@ -0,0 +1,4 @@
+ALTER TABLE data_exports ADD COLUMN started_at datetime(3) NULL;
+ALTER TABLE account_deletions
+    ADD COLUMN failed_at datetime(3) NULL,
+    ADD COLUMN error varchar(255) NOT NULL DEFAULT '';
@@ -0,0 +1,231 @@
+-- 基线：与之前 AutoMigrate 生成的表结构一致。
+-- 全部使用 IF NOT EXISTS，已经由 AutoMigrate 建好表的数据库执行后只会补上缺失的列和索引。
+
+CREATE TABLE IF NOT EXISTS users (
+    id         bigserial PRIMARY KEY,
+    username   text NOT NULL,
+    email      text NOT NULL,
+    password   text NOT NULL,
+    role       text NOT NULL DEFAULT 'user',
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    CONSTRAINT uni_users_username UNIQUE (username),
+    CONSTRAINT uni_users_email UNIQUE (email)
+);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role text NOT NULL DEFAULT 'user';
+
+CREATE TABLE IF NOT EXISTS posts (
+    id           bigserial PRIMARY KEY,
+    title        text NOT NULL,
+    content      text NOT NULL,
+    user_id      bigint,
+    is_draft     boolean DEFAULT false,
+    status       text DEFAULT 'published',
+    is_top       boolean DEFAULT false,
+    is_recommend boolean DEFAULT false,
+    created_at   timestamptz,
+    updated_at   timestamptz,
+    deleted_at   timestamptz,
+    CONSTRAINT fk_posts_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS status text DEFAULT 'published';
+CREATE INDEX IF NOT EXISTS idx_posts_status ON posts (status);
+CREATE INDEX IF NOT EXISTS idx_posts_deleted_at ON posts (deleted_at);
+
+-- 旧数据只有 is_draft 字段，status 列的默认值为 published，这里把草稿同步过来
+UPDATE posts SET status = 'draft' WHERE is_draft = true AND status = 'published';
+
+CREATE TABLE IF NOT EXISTS tags (
+    id         bigserial PRIMARY KEY,
+    name       text,
+    created_at timestamptz,
+    updated_at timestamptz,
+    delete_at  timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_tags_delete_at ON tags (delete_at);
+
+CREATE TABLE IF NOT EXISTS post_tags (
+    post_id bigint,
+    tag_id  bigint,
+    PRIMARY KEY (post_id, tag_id),
+    CONSTRAINT fk_post_tags_post FOREIGN KEY (post_id) REFERENCES posts (id),
+    CONSTRAINT fk_post_tags_tag FOREIGN KEY (tag_id) REFERENCES tags (id)
+);
+
+CREATE TABLE IF NOT EXISTS conments (
+    id         bigserial PRIMARY KEY,
+    content    text NOT NULL,
+    user_id    bigint,
+    post_id    bigint,
+    parent_id  bigint,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    CONSTRAINT fk_conments_user FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE SET NULL,
+    CONSTRAINT fk_conments_post FOREIGN KEY (post_id) REFERENCES posts (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    CONSTRAINT fk_conments_replies FOREIGN KEY (parent_id) REFERENCES conments (id)
+);
+CREATE INDEX IF NOT EXISTS idx_conments_deleted_at ON conments (deleted_at);
+
+CREATE TABLE IF NOT EXISTS likes (
+    id          bigserial PRIMARY KEY,
+    user_id     bigint,
+    target_id   bigint,
+    target_type text,
+    created_at  timestamptz,
+    deleted_at  timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_likes_deleted_at ON likes (deleted_at);
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    id         bigserial PRIMARY KEY,
+    user_id    bigint,
+    url        text NOT NULL,
+    secret     text NOT NULL,
+    events     text NOT NULL,
+    active     boolean DEFAULT true,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_webhooks_user_id ON webhooks (user_id);
+CREATE INDEX IF NOT EXISTS idx_webhooks_deleted_at ON webhooks (deleted_at);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id              bigserial PRIMARY KEY,
+    webhook_id      bigint,
+    event           text NOT NULL,
+    payload         text NOT NULL,
+    status          text DEFAULT 'pending',
+    attempts        bigint DEFAULT 0,
+    next_attempt_at timestamptz,
+    response_status bigint,
+    response_body   text,
+    error           text,
+    delivered_at    timestamptz,
+    created_at      timestamptz,
+    updated_at      timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_status ON webhook_deliveries (status);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_next_attempt_at ON webhook_deliveries (next_attempt_at);
+
+CREATE TABLE IF NOT EXISTS follows (
+    id          bigserial PRIMARY KEY,
+    follower_id bigint NOT NULL,
+    target_id   bigint NOT NULL,
+    target_type text NOT NULL,
+    created_at  timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_follow_target ON follows (follower_id, target_id, target_type);
+CREATE INDEX IF NOT EXISTS idx_follow_lookup ON follows (target_id, target_type);
+
+CREATE TABLE IF NOT EXISTS bookmark_collections (
+    id          bigserial PRIMARY KEY,
+    user_id     bigint,
+    name        text NOT NULL,
+    description text,
+    is_public   boolean DEFAULT false,
+    created_at  timestamptz,
+    updated_at  timestamptz,
+    deleted_at  timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_bookmark_collections_user_id ON bookmark_collections (user_id);
+CREATE INDEX IF NOT EXISTS idx_bookmark_collections_deleted_at ON bookmark_collections (deleted_at);
+
+CREATE TABLE IF NOT EXISTS bookmarks (
+    id            bigserial PRIMARY KEY,
+    user_id       bigint NOT NULL,
+    post_id       bigint NOT NULL,
+    collection_id bigint,
+    created_at    timestamptz,
+    CONSTRAINT fk_bookmarks_post FOREIGN KEY (post_id) REFERENCES posts (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bookmark_user_post ON bookmarks (user_id, post_id);
+CREATE INDEX IF NOT EXISTS idx_bookmarks_post_id ON bookmarks (post_id);
+CREATE INDEX IF NOT EXISTS idx_bookmarks_collection_id ON bookmarks (collection_id);
+
+CREATE TABLE IF NOT EXISTS post_views (
+    post_id bigint,
+    date    date,
+    views   bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY (post_id, date)
+);
+
+CREATE TABLE IF NOT EXISTS post_referrers (
+    post_id  bigint,
+    date     date,
+    referrer text,
+    views    bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY (post_id, date, referrer)
+);
+
+CREATE TABLE IF NOT EXISTS post_collaborators (
+    id          bigserial PRIMARY KEY,
+    post_id     bigint NOT NULL,
+    user_id     bigint NOT NULL,
+    role        text NOT NULL,
+    status      text DEFAULT 'pending',
+    invited_by  bigint,
+    accepted_at timestamptz,
+    created_at  timestamptz,
+    updated_at  timestamptz,
+    CONSTRAINT fk_post_collaborators_post FOREIGN KEY (post_id) REFERENCES posts (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    CONSTRAINT fk_post_collaborators_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_collaborator_post_user ON post_collaborators (post_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_post_collaborators_user_id ON post_collaborators (user_id);
+CREATE INDEX IF NOT EXISTS idx_post_collaborators_status ON post_collaborators (status);
+
+CREATE TABLE IF NOT EXISTS post_transitions (
+    id          bigserial PRIMARY KEY,
+    post_id     bigint NOT NULL,
+    actor_id    bigint,
+    action      text NOT NULL,
+    from_status text NOT NULL,
+    to_status   text NOT NULL,
+    comment     text,
+    created_at  timestamptz,
+    CONSTRAINT fk_post_transitions_actor FOREIGN KEY (actor_id) REFERENCES users (id)
+);
+CREATE INDEX IF NOT EXISTS idx_post_transitions_post_id ON post_transitions (post_id);
+
+CREATE TABLE IF NOT EXISTS review_comments (
+    id           bigserial PRIMARY KEY,
+    post_id      bigint NOT NULL,
+    user_id      bigint,
+    body         text NOT NULL,
+    quote        text,
+    anchor_start bigint,
+    anchor_end   bigint,
+    resolved     boolean DEFAULT false,
+    created_at   timestamptz,
+    updated_at   timestamptz,
+    CONSTRAINT fk_review_comments_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE INDEX IF NOT EXISTS idx_review_comments_post_id ON review_comments (post_id);
+
+CREATE TABLE IF NOT EXISTS series (
+    id          bigserial PRIMARY KEY,
+    user_id     bigint,
+    title       text NOT NULL,
+    description text,
+    created_at  timestamptz,
+    updated_at  timestamptz,
+    deleted_at  timestamptz,
+    CONSTRAINT fk_series_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE INDEX IF NOT EXISTS idx_series_user_id ON series (user_id);
+CREATE INDEX IF NOT EXISTS idx_series_deleted_at ON series (deleted_at);
+
+CREATE TABLE IF NOT EXISTS series_posts (
+    id         bigserial PRIMARY KEY,
+    series_id  bigint NOT NULL,
+    post_id    bigint NOT NULL,
+    position   bigint NOT NULL,
+    created_at timestamptz,
+    CONSTRAINT fk_series_posts_post FOREIGN KEY (post_id) REFERENCES posts (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_series_posts_series_id ON series_posts (series_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_series_posts_post_id ON series_posts (post_id);
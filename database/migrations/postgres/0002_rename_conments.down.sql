@@ -0,0 +1,12 @@
+ALTER TABLE comments RENAME TO conments;
+ALTER SEQUENCE IF EXISTS comments_id_seq RENAME TO conments_id_seq;
+ALTER INDEX IF EXISTS idx_comments_deleted_at RENAME TO idx_conments_deleted_at;
+
+DO $$
+DECLARE
+    c record;
+BEGIN
+    FOR c IN SELECT conname FROM pg_constraint WHERE conrelid = 'conments'::regclass AND conname LIKE '%comments%' LOOP
+        EXECUTE format('ALTER TABLE conments RENAME CONSTRAINT %I TO %I', c.conname, replace(c.conname, 'comments', 'conments'));
+    END LOOP;
+END $$;
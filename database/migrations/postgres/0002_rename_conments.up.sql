@@ -0,0 +1,13 @@
+-- 修正评论表名的拼写，约束、索引和序列一并改名
+ALTER TABLE conments RENAME TO comments;
+ALTER SEQUENCE IF EXISTS conments_id_seq RENAME TO comments_id_seq;
+ALTER INDEX IF EXISTS idx_conments_deleted_at RENAME TO idx_comments_deleted_at;
+
+DO $$
+DECLARE
+    c record;
+BEGIN
+    FOR c IN SELECT conname FROM pg_constraint WHERE conrelid = 'comments'::regclass AND conname LIKE '%conments%' LOOP
+        EXECUTE format('ALTER TABLE comments RENAME CONSTRAINT %I TO %I', c.conname, replace(c.conname, 'conments', 'comments'));
+    END LOOP;
+END $$;
@@ -0,0 +1,10 @@
+-- 被清理的重复点赞和合并的标签无法恢复，这里只删除索引
+DROP INDEX IF EXISTS idx_comments_user_id;
+DROP INDEX IF EXISTS idx_comments_parent_id;
+DROP INDEX IF EXISTS idx_comments_post_id;
+DROP INDEX IF EXISTS idx_posts_created_at;
+DROP INDEX IF EXISTS idx_posts_user_id;
+DROP INDEX IF EXISTS idx_post_tags_tag_id;
+DROP INDEX IF EXISTS idx_tags_name;
+DROP INDEX IF EXISTS idx_likes_target;
+DROP INDEX IF EXISTS idx_likes_user_target;
@@ -0,0 +1,32 @@
+-- Like 的重复检查之前一直没有生效，先清理重复点赞，每人每个对象只保留最早的一条
+DELETE FROM likes a USING likes b
+WHERE a.user_id = b.user_id
+  AND a.target_type = b.target_type
+  AND a.target_id = b.target_id
+  AND a.id > b.id
+  AND a.deleted_at IS NULL
+  AND b.deleted_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_likes_user_target ON likes (user_id, target_type, target_id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_likes_target ON likes (target_type, target_id);
+
+-- 同名标签合并到 id 最小的那个，文章上的标签关联随之迁移
+CREATE TEMPORARY TABLE duplicate_tags ON COMMIT DROP AS
+SELECT id, keep_id
+FROM (SELECT id, MIN(id) OVER (PARTITION BY name) AS keep_id FROM tags WHERE delete_at IS NULL) t
+WHERE id <> keep_id;
+
+INSERT INTO post_tags (post_id, tag_id)
+SELECT pt.post_id, d.keep_id FROM post_tags pt JOIN duplicate_tags d ON d.id = pt.tag_id
+ON CONFLICT DO NOTHING;
+DELETE FROM post_tags WHERE tag_id IN (SELECT id FROM duplicate_tags);
+DELETE FROM tags WHERE id IN (SELECT id FROM duplicate_tags);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_name ON tags (name) WHERE delete_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_post_tags_tag_id ON post_tags (tag_id);
+
+CREATE INDEX IF NOT EXISTS idx_posts_user_id ON posts (user_id);
+CREATE INDEX IF NOT EXISTS idx_posts_created_at ON posts (created_at);
+
+CREATE INDEX IF NOT EXISTS idx_comments_post_id ON comments (post_id);
+CREATE INDEX IF NOT EXISTS idx_comments_parent_id ON comments (parent_id);
+CREATE INDEX IF NOT EXISTS idx_comments_user_id ON comments (user_id);
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS data_exports (
+    id           bigserial PRIMARY KEY,
+    user_id      bigint NOT NULL,
+    status       text NOT NULL DEFAULT 'pending',
+    data         bytea,
+    size         bigint NOT NULL DEFAULT 0,
+    error        text NOT NULL DEFAULT '',
+    created_at   timestamptz NOT NULL,
+    completed_at timestamptz,
+    expires_at   timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_data_exports_user_id ON data_exports (user_id);
+CREATE INDEX IF NOT EXISTS idx_data_exports_status ON data_exports (status);
+CREATE INDEX IF NOT EXISTS idx_data_exports_expires_at ON data_exports (expires_at);
+
+CREATE TABLE IF NOT EXISTS account_deletions (
+    id             bigserial PRIMARY KEY,
+    user_id        bigint NOT NULL,
+    post_action    text NOT NULL,
+    transfer_to_id bigint,
+    requested_at   timestamptz NOT NULL,
+    execute_at     timestamptz NOT NULL,
+    started_at     timestamptz,
+    CONSTRAINT uni_account_deletions_user_id UNIQUE (user_id)
+);
+CREATE INDEX IF NOT EXISTS idx_account_deletions_execute_at ON account_deletions (execute_at);
@@ -0,0 +1,3 @@
+ALTER TABLE account_deletions DROP COLUMN IF EXISTS error;
+ALTER TABLE account_deletions DROP COLUMN IF EXISTS failed_at;
+ALTER TABLE data_exports DROP COLUMN IF EXISTS started_at;
@@ -0,0 +1,3 @@
+ALTER TABLE data_exports ADD COLUMN IF NOT EXISTS started_at timestamptz;
+ALTER TABLE account_deletions ADD COLUMN IF NOT EXISTS failed_at timestamptz;
+ALTER TABLE account_deletions ADD COLUMN IF NOT EXISTS error text NOT NULL DEFAULT '';
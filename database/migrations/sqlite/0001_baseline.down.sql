@@ -0,0 +1,18 @@
+DROP TABLE IF EXISTS series_posts;
+DROP TABLE IF EXISTS series;
+DROP TABLE IF EXISTS review_comments;
+DROP TABLE IF EXISTS post_transitions;
+DROP TABLE IF EXISTS post_collaborators;
+DROP TABLE IF EXISTS post_referrers;
+DROP TABLE IF EXISTS post_views;
+DROP TABLE IF EXISTS bookmarks;
+DROP TABLE IF EXISTS bookmark_collections;
+DROP TABLE IF EXISTS follows;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhooks;
+DROP TABLE IF EXISTS likes;
+DROP TABLE IF EXISTS comments;
+DROP TABLE IF EXISTS post_tags;
+DROP TABLE IF EXISTS tags;
+DROP TABLE IF EXISTS posts;
+DROP TABLE IF EXISTS users;
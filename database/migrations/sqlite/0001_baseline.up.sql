@@ -0,0 +1,237 @@
+-- SQLite 基线：直接建出与 PostgreSQL 执行完 0001-0003 后相同的表结构，供本地开发和测试使用。
+-- 类型按 GORM sqlite 驱动的映射：自增主键为 integer，布尔为 numeric，时间为 datetime。
+
+CREATE TABLE IF NOT EXISTS users (
+    id         integer PRIMARY KEY AUTOINCREMENT,
+    username   text NOT NULL,
+    email      text NOT NULL,
+    password   text NOT NULL,
+    role       text NOT NULL DEFAULT 'user',
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    CONSTRAINT uni_users_username UNIQUE (username),
+    CONSTRAINT uni_users_email UNIQUE (email)
+);
+
+CREATE TABLE IF NOT EXISTS posts (
+    id           integer PRIMARY KEY AUTOINCREMENT,
+    title        text NOT NULL,
+    content      text NOT NULL,
+    user_id      bigint,
+    is_draft     numeric DEFAULT false,
+    status       text DEFAULT 'published',
+    is_top       numeric DEFAULT false,
+    is_recommend numeric DEFAULT false,
+    created_at   datetime,
+    updated_at   datetime,
+    deleted_at   datetime,
+    CONSTRAINT fk_posts_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE INDEX IF NOT EXISTS idx_posts_status ON posts (status);
+CREATE INDEX IF NOT EXISTS idx_posts_deleted_at ON posts (deleted_at);
+
+CREATE TABLE IF NOT EXISTS tags (
+    id         integer PRIMARY KEY AUTOINCREMENT,
+    name       text,
+    created_at datetime,
+    updated_at datetime,
+    delete_at  datetime
+);
+CREATE INDEX IF NOT EXISTS idx_tags_delete_at ON tags (delete_at);
+
+CREATE TABLE IF NOT EXISTS post_tags (
+    post_id bigint,
+    tag_id  bigint,
+    PRIMARY KEY (post_id, tag_id),
+    CONSTRAINT fk_post_tags_post FOREIGN KEY (post_id) REFERENCES posts (id),
+    CONSTRAINT fk_post_tags_tag FOREIGN KEY (tag_id) REFERENCES tags (id)
+);
+
+CREATE TABLE IF NOT EXISTS comments (
+    id         integer PRIMARY KEY AUTOINCREMENT,
+    content    text NOT NULL,
+    user_id    bigint,
+    post_id    bigint,
+    parent_id  bigint,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    CONSTRAINT fk_comments_user FOREIGN KEY (user_id) REFERENCES users (id) ON UPDATE CASCADE ON DELETE SET NULL,
+    CONSTRAINT fk_comments_post FOREIGN KEY (post_id) REFERENCES posts (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    CONSTRAINT fk_comments_replies FOREIGN KEY (parent_id) REFERENCES comments (id)
+);
+CREATE INDEX IF NOT EXISTS idx_comments_deleted_at ON comments (deleted_at);
+
+CREATE TABLE IF NOT EXISTS likes (
+    id          integer PRIMARY KEY AUTOINCREMENT,
+    user_id     bigint,
+    target_id   bigint,
+    target_type text,
+    created_at  datetime,
+    deleted_at  datetime
+);
+CREATE INDEX IF NOT EXISTS idx_likes_deleted_at ON likes (deleted_at);
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    id         integer PRIMARY KEY AUTOINCREMENT,
+    user_id    bigint,
+    url        text NOT NULL,
+    secret     text NOT NULL,
+    events     text NOT NULL,
+    active     numeric DEFAULT true,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime
+);
+CREATE INDEX IF NOT EXISTS idx_webhooks_user_id ON webhooks (user_id);
+CREATE INDEX IF NOT EXISTS idx_webhooks_deleted_at ON webhooks (deleted_at);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id              integer PRIMARY KEY AUTOINCREMENT,
+    webhook_id      bigint,
+    event           text NOT NULL,
+    payload         text NOT NULL,
+    status          text DEFAULT 'pending',
+    attempts        bigint DEFAULT 0,
+    next_attempt_at datetime,
+    response_status bigint,
+    response_body   text,
+    error           text,
+    delivered_at    datetime,
+    created_at      datetime,
+    updated_at      datetime
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_status ON webhook_deliveries (status);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_next_attempt_at ON webhook_deliveries (next_attempt_at);
+
+CREATE TABLE IF NOT EXISTS follows (
+    id          integer PRIMARY KEY AUTOINCREMENT,
+    follower_id bigint NOT NULL,
+    target_id   bigint NOT NULL,
+    target_type text NOT NULL,
+    created_at  datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_follow_target ON follows (follower_id, target_id, target_type);
+CREATE INDEX IF NOT EXISTS idx_follow_lookup ON follows (target_id, target_type);
+
+CREATE TABLE IF NOT EXISTS bookmark_collections (
+    id          integer PRIMARY KEY AUTOINCREMENT,
+    user_id     bigint,
+    name        text NOT NULL,
+    description text,
+    is_public   numeric DEFAULT false,
+    created_at  datetime,
+    updated_at  datetime,
+    deleted_at  datetime
+);
+CREATE INDEX IF NOT EXISTS idx_bookmark_collections_user_id ON bookmark_collections (user_id);
+CREATE INDEX IF NOT EXISTS idx_bookmark_collections_deleted_at ON bookmark_collections (deleted_at);
+
+CREATE TABLE IF NOT EXISTS bookmarks (
+    id            integer PRIMARY KEY AUTOINCREMENT,
+    user_id       bigint NOT NULL,
+    post_id       bigint NOT NULL,
+    collection_id bigint,
+    created_at    datetime,
+    CONSTRAINT fk_bookmarks_post FOREIGN KEY (post_id) REFERENCES posts (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bookmark_user_post ON bookmarks (user_id, post_id);
+CREATE INDEX IF NOT EXISTS idx_bookmarks_post_id ON bookmarks (post_id);
+CREATE INDEX IF NOT EXISTS idx_bookmarks_collection_id ON bookmarks (collection_id);
+
+CREATE TABLE IF NOT EXISTS post_views (
+    post_id bigint,
+    date    date,
+    views   bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY (post_id, date)
+);
+
+CREATE TABLE IF NOT EXISTS post_referrers (
+    post_id  bigint,
+    date     date,
+    referrer text,
+    views    bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY (post_id, date, referrer)
+);
+
+CREATE TABLE IF NOT EXISTS post_collaborators (
+    id          integer PRIMARY KEY AUTOINCREMENT,
+    post_id     bigint NOT NULL,
+    user_id     bigint NOT NULL,
+    role        text NOT NULL,
+    status      text DEFAULT 'pending',
+    invited_by  bigint,
+    accepted_at datetime,
+    created_at  datetime,
+    updated_at  datetime,
+    CONSTRAINT fk_post_collaborators_post FOREIGN KEY (post_id) REFERENCES posts (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    CONSTRAINT fk_post_collaborators_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_collaborator_post_user ON post_collaborators (post_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_post_collaborators_user_id ON post_collaborators (user_id);
+CREATE INDEX IF NOT EXISTS idx_post_collaborators_status ON post_collaborators (status);
+
+CREATE TABLE IF NOT EXISTS post_transitions (
+    id          integer PRIMARY KEY AUTOINCREMENT,
+    post_id     bigint NOT NULL,
+    actor_id    bigint,
+    action      text NOT NULL,
+    from_status text NOT NULL,
+    to_status   text NOT NULL,
+    comment     text,
+    created_at  datetime,
+    CONSTRAINT fk_post_transitions_actor FOREIGN KEY (actor_id) REFERENCES users (id)
+);
+CREATE INDEX IF NOT EXISTS idx_post_transitions_post_id ON post_transitions (post_id);
+
+CREATE TABLE IF NOT EXISTS review_comments (
+    id           integer PRIMARY KEY AUTOINCREMENT,
+    post_id      bigint NOT NULL,
+    user_id      bigint,
+    body         text NOT NULL,
+    quote        text,
+    anchor_start bigint,
+    anchor_end   bigint,
+    resolved     numeric DEFAULT false,
+    created_at   datetime,
+    updated_at   datetime,
+    CONSTRAINT fk_review_comments_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE INDEX IF NOT EXISTS idx_review_comments_post_id ON review_comments (post_id);
+
+CREATE TABLE IF NOT EXISTS series (
+    id          integer PRIMARY KEY AUTOINCREMENT,
+    user_id     bigint,
+    title       text NOT NULL,
+    description text,
+    created_at  datetime,
+    updated_at  datetime,
+    deleted_at  datetime,
+    CONSTRAINT fk_series_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE INDEX IF NOT EXISTS idx_series_user_id ON series (user_id);
+CREATE INDEX IF NOT EXISTS idx_series_deleted_at ON series (deleted_at);
+
+CREATE TABLE IF NOT EXISTS series_posts (
+    id         integer PRIMARY KEY AUTOINCREMENT,
+    series_id  bigint NOT NULL,
+    post_id    bigint NOT NULL,
+    position   bigint NOT NULL,
+    created_at datetime,
+    CONSTRAINT fk_series_posts_post FOREIGN KEY (post_id) REFERENCES posts (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_series_posts_series_id ON series_posts (series_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_series_posts_post_id ON series_posts (post_id);
+
+-- 对应 PostgreSQL 的 0003_indexes_and_unique_constraints
+CREATE UNIQUE INDEX IF NOT EXISTS idx_likes_user_target ON likes (user_id, target_type, target_id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_likes_target ON likes (target_type, target_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_name ON tags (name) WHERE delete_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_post_tags_tag_id ON post_tags (tag_id);
+CREATE INDEX IF NOT EXISTS idx_posts_user_id ON posts (user_id);
+CREATE INDEX IF NOT EXISTS idx_posts_created_at ON posts (created_at);
+CREATE INDEX IF NOT EXISTS idx_comments_post_id ON comments (post_id);
+CREATE INDEX IF NOT EXISTS idx_comments_parent_id ON comments (parent_id);
+CREATE INDEX IF NOT EXISTS idx_comments_user_id ON comments (user_id);
@@ -0,0 +1,2 @@
+ALTER TABLE users ADD COLUMN banned_at datetime;
+ALTER TABLE users ADD COLUMN ban_reason text NOT NULL DEFAULT '';
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id          integer PRIMARY KEY AUTOINCREMENT,
+    actor_id    bigint,
+    action      text NOT NULL,
+    target_type text NOT NULL,
+    target_id   bigint NOT NULL DEFAULT 0,
+    before      text,
+    after       text,
+    ip          text NOT NULL DEFAULT '',
+    user_agent  text NOT NULL DEFAULT '',
+    request_id  text NOT NULL DEFAULT '',
+    created_at  datetime NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor_id ON audit_logs (actor_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_target ON audit_logs (target_type, target_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action ON audit_logs (action);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs (created_at);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS imported_items (
+    id          integer PRIMARY KEY AUTOINCREMENT,
+    source_key  varchar(500) NOT NULL,
+    target_type text NOT NULL,
+    target_id   bigint NOT NULL,
+    checksum    text NOT NULL,
+    created_at  datetime NOT NULL,
+    updated_at  datetime NOT NULL,
+    CONSTRAINT uni_imported_items_source_key UNIQUE (source_key)
+);
+CREATE INDEX IF NOT EXISTS idx_imported_items_target ON imported_items (target_type, target_id);
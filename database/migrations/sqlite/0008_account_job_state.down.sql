@@ -0,0 +1,3 @@
+ALTER TABLE account_deletions DROP COLUMN error;
+ALTER TABLE account_deletions DROP COLUMN failed_at;
+ALTER TABLE data_exports DROP COLUMN started_at;
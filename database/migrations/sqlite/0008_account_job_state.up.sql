@@ -0,0 +1,3 @@
+ALTER TABLE data_exports ADD COLUMN started_at datetime;
+ALTER TABLE account_deletions ADD COLUMN failed_at datetime;
+ALTER TABLE account_deletions ADD COLUMN error text NOT NULL DEFAULT '';
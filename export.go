@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"goblog/config"
+	"goblog/database"
+	"goblog/pkg/staticsite"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+const exportUsage = `用法: goblog [参数] export [-full]
+
+把已发布的文章生成静态站点到 static.out_dir，站点地址、标题、模板等见配置中的 static 部分。
+再次执行时只重新渲染有变化的文章，并删除已不存在的页面
+
+参数:
+  -full  忽略上次生成的记录，重新渲染全部文章`
+
+// runExport 处理 export 子命令
+func runExport(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprintln(flags.Output(), exportUsage) }
+	full := flags.Bool("full", false, "重新渲染全部文章")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errors.New(exportUsage)
+	}
+
+	if err := database.Open(); err != nil {
+		return err
+	}
+	defer database.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	conf := config.AppConfig.Static
+	stats, err := staticsite.Build(ctx, conf, *full)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("已生成到 %s：文章 %d 篇（重新渲染 %d 篇），列表页 %d 个，写入 %d 个文件，删除 %d 个文件\n",
+		conf.OutDir, stats.Posts, stats.Rendered, stats.Pages, stats.Written, stats.Removed)
+	return nil
+}
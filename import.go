@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"goblog/config"
+	"goblog/database"
+	"goblog/models"
+	"goblog/pkg/cache"
+	"goblog/pkg/importer"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"gorm.io/gorm"
+)
+
+const importUsage = `用法: goblog [参数] import [-format 格式] [-user 用户] [-dry-run] <路径>
+
+<路径> 为 Markdown/Hugo/Jekyll 站点目录、WordPress 导出的 WXR 文件（.xml），或它们的 .zip 压缩包
+
+参数:
+  -format   auto|markdown|hugo|jekyll|wxr，默认 auto，根据目录结构判断
+  -user     来源中没有作者或作者不是本站用户时文章归属的用户（ID、用户名或邮箱），默认最早注册的管理员
+  -dry-run  只列出将要执行的操作，不写入数据库`
+
+// runImport 处理 import 子命令，重复导入同一来源时只更新有变化的文章
+func runImport(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprintln(flags.Output(), importUsage) }
+	format := flags.String("format", importer.FormatAuto, "导入格式")
+	user := flags.String("user", "", "文章默认归属的用户")
+	dryRun := flags.Bool("dry-run", false, "只列出将要执行的操作")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New(importUsage)
+	}
+	if !slices.Contains(importer.Formats, *format) {
+		return fmt.Errorf("不支持的导入格式 %q，可用的格式: %s", *format, strings.Join(importer.Formats, ", "))
+	}
+
+	items, used, err := loadImportSource(flags.Arg(0), *format)
+	if err != nil {
+		return err
+	}
+
+	if err := database.Open(); err != nil {
+		return err
+	}
+	defer database.Close()
+	userID, err := importUser(*user)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	report, err := importer.Import(ctx, items, importer.Options{UserID: userID, DryRun: *dryRun})
+	report.Format = used
+	printImportReport(report)
+
+	// 服务可能正在运行，清掉它缓存的文章和排行。出错前已导入的文章不会回滚，同样需要刷新
+	if report.Changed() {
+		if err := cache.InitRedis(config.AppConfig.Redis, config.AppConfig.Cache); err != nil {
+			return fmt.Errorf("导入完成，但刷新缓存失败: %w", err)
+		}
+		defer cache.Close()
+		importer.Refresh(report)
+	}
+	return err
+}
+
+// loadImportSource 读取目录、WXR 文件或压缩包
+func loadImportSource(name, format string) ([]importer.Item, string, error) {
+	info, err := os.Stat(name)
+	if err != nil {
+		return nil, format, err
+	}
+	if info.IsDir() {
+		return importer.Load(os.DirFS(name), format)
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, format, err
+	}
+	return importer.LoadFile(name, data, format)
+}
+
+// importUser 查找 -user 指定的用户，未指定时使用最早注册的管理员，已注销的用户不能作为作者
+func importUser(value string) (uint, error) {
+	var user models.User
+	query := database.DB.Select("id", "deleted_at")
+	var err error
+	if value == "" {
+		err = query.Where("role = ?", models.RoleAdmin).Order("id").First(&user).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, errors.New("没有管理员账号，请用 -user 指定文章归属的用户")
+		}
+	} else if id, convErr := strconv.ParseUint(value, 10, 64); convErr == nil {
+		err = query.First(&user, id).Error
+	} else {
+		err = query.Where("username = ? OR email = ?", value, value).First(&user).Error
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, fmt.Errorf("用户 %q 不存在", value)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if !user.DeletedAt.IsZero() {
+		return 0, fmt.Errorf("用户 %d 已注销", user.ID)
+	}
+	return user.ID, nil
+}
+
+func printImportReport(report importer.Report) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "操作\t文章\t评论\t来源\t标题\t说明")
+	for _, item := range report.Items {
+		postID := "-"
+		if item.PostID != 0 {
+			postID = strconv.FormatUint(uint64(item.PostID), 10)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\n", item.Action, postID, item.Comments, item.Key, item.Title, item.Reason)
+	}
+	w.Flush()
+
+	prefix := ""
+	if report.DryRun {
+		prefix = "（dry run，未写入数据库）"
+	}
+	fmt.Printf("%s格式 %s：新建 %d 篇，更新 %d 篇，未变化 %d 篇，跳过 %d 篇，导入评论 %d 条\n",
+		prefix, report.Format, report.Created, report.Updated, report.Unchanged, report.Skipped, report.Comments)
+}
@@ -6,10 +6,29 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"goblog/config"
 	"goblog/database"
+	"goblog/pkg/account"
+	"goblog/pkg/analytics"
 	"goblog/pkg/cache"
+	"goblog/pkg/lifecycle"
+	"goblog/pkg/logger"
+	"goblog/pkg/ranking"
+	"goblog/pkg/related"
+	"goblog/pkg/telemetry"
+	"goblog/pkg/webhook"
+	"goblog/repository/gormrepo"
 	"goblog/routes"
+	"goblog/service"
+	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
 	_ "goblog/docs"
 
@@ -19,17 +38,152 @@ import (
 )
 
 func main() {
-	config.LoadConfig()
+	args := config.LoadConfig()
+	conf := config.AppConfig
 
-	database.ConnectDatabase()
+	if len(args) > 0 {
+		var err error
+		switch args[0] {
+		case "migrate":
+			err = runMigrate(args[1:])
+		case "import":
+			err = runImport(args[1:])
+		case "export":
+			err = runExport(args[1:])
+		default:
+			log.Fatalf("未知的子命令 %q，可用的子命令: migrate, import, export", args[0])
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
-	cache.InitRedis("localhost", "6379", "", 0)
+	logger.Init(conf.Log)
+	gin.SetMode(conf.Server.Mode)
 
-	r := gin.Default()
+	srv := &http.Server{Addr: conf.Server.Addr()}
+	serveErr := make(chan error, 1)
 
-	routes.SetupRoutes(r)
+	app := lifecycle.New()
+	var shutdownTracing func(context.Context) error
+	// 业务服务在数据库连接后组装一次，后台任务和 HTTP 服务共用
+	var svc *service.Services
+	app.Append(
+		lifecycle.Hook{
+			Name: "tracing",
+			OnStart: func(ctx context.Context) (err error) {
+				shutdownTracing, err = telemetry.InitTracing(ctx, conf.Tracing)
+				return err
+			},
+			// 最后关闭，把关闭过程中产生的 span 也导出
+			OnStop: func(ctx context.Context) error { return shutdownTracing(ctx) },
+		},
+		lifecycle.Hook{
+			Name:    "database",
+			OnStart: func(context.Context) error { return database.ConnectDatabase() },
+			OnStop:  func(context.Context) error { return database.Close() },
+		},
+		lifecycle.Hook{
+			Name: "services",
+			OnStart: func(context.Context) error {
+				svc = newServices(conf)
+				return nil
+			},
+		},
+		lifecycle.Hook{
+			Name: "redis",
+			OnStart: func(context.Context) error {
+				if err := cache.InitRedis(conf.Redis, conf.Cache); err != nil {
+					return err
+				}
+				cache.Rdb.AddHook(telemetry.RedisHook{})
+				return nil
+			},
+			OnStop: func(context.Context) error { return cache.Close() },
+		},
+		lifecycle.Background("redis reconnector", cache.RunReconnector),
+		lifecycle.Background("cache invalidator", cache.RunInvalidator),
+		lifecycle.Hook{
+			Name: "ranking",
+			OnStart: func(context.Context) error {
+				ranking.Init()
+				// 降级期间 Redis 可能被重启清空，恢复后按需重建排行
+				cache.OnRecover(ranking.Init)
+				return nil
+			},
+		},
+		lifecycle.Background("webhook worker", webhook.RunWorker),
+		lifecycle.Background("analytics flusher", analytics.RunFlusher),
+		lifecycle.Background("related worker", related.RunWorker),
+		lifecycle.Background("trash purger", func(ctx context.Context) {
+			service.RunTrashPurger(ctx, svc.Posts, conf.Trash.Retention)
+		}),
+		lifecycle.Background("account worker", func(ctx context.Context) {
+			account.RunWorker(ctx, svc.Posts)
+		}),
+		// 在 HTTP 服务停止后、Redis 和数据库关闭前等待请求派生的后台任务完成
+		lifecycle.Drain("request tasks"),
+		lifecycle.Hook{
+			Name: "http server",
+			OnStart: func(context.Context) error {
+				srv.Handler = newRouter(svc)
+				ln, err := net.Listen("tcp", srv.Addr)
+				if err != nil {
+					return err
+				}
+				go func() {
+					if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+						serveErr <- err
+					}
+				}()
+				return nil
+			},
+			// Shutdown 先停止接收新连接，再等待进行中的请求处理完
+			OnStop: srv.Shutdown,
+		},
+	)
 
-	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	if err := app.Start(context.Background()); err != nil {
+		slog.Error("启动失败", "err", err)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	select {
+	case <-ctx.Done():
+		slog.Info("收到退出信号，开始关闭")
+	case err := <-serveErr:
+		slog.Error("HTTP 服务异常退出", "err", err)
+	}
+	// 再次收到信号时直接退出
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), conf.Server.ShutdownTimeout)
+	err := app.Stop(shutdownCtx)
+	cancel()
+	if err != nil {
+		slog.Error("关闭时出错", "err", err)
+		os.Exit(1)
+	}
+	slog.Info("已退出")
+}
 
-	r.Run(":8080")
+// newServices 用数据库仓储组装业务服务，需要在数据库连接之后调用
+func newServices(conf *config.Config) *service.Services {
+	return service.New(gormrepo.New(database.DB), service.Options{
+		JWTSecret:         conf.JWT.Secret,
+		JWTExpire:         conf.JWT.Expire,
+		EditorialWorkflow: conf.EditorialWorkflow,
+		Events:            service.BlogEvents{},
+		Extras:            gormrepo.NewPostExtras(database.DB),
+	})
+}
+
+func newRouter(svc *service.Services) *gin.Engine {
+	// 访问日志和 panic 恢复由 SetupRoutes 中的中间件处理
+	r := gin.New()
+	routes.SetupRoutes(r, svc)
+	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	return r
 }
@@ -0,0 +1,37 @@
+package middlewares
+
+import (
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccessLog 请求结束后记录一条访问日志，替代 gin 默认的文本日志。
+// quiet 中的路径（如探活、指标抓取）只在 debug 级别记录
+func AccessLog(quiet ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		level := slog.LevelInfo
+		if slices.Contains(quiet, c.Request.URL.Path) {
+			level = slog.LevelDebug
+		}
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", c.Writer.Status()),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.String("ip", c.ClientIP()),
+			slog.String("user_agent", c.Request.UserAgent()),
+		}
+		if userID, ok := c.Get("user_id"); ok {
+			attrs = append(attrs, slog.Any("user_id", userID))
+		}
+		slog.LogAttrs(c, level, "request", attrs...)
+	}
+}
@@ -0,0 +1,47 @@
+package middlewares
+
+import (
+	"goblog/config"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORSMiddleware 按配置处理跨域请求，cors.allow_origins 为空时直接放行
+func CORSMiddleware(conf config.CORSConfig) gin.HandlerFunc {
+	allowAll := slices.Contains(conf.AllowOrigins, "*")
+	methods := strings.Join(conf.AllowMethods, ", ")
+	headers := strings.Join(conf.AllowHeaders, ", ")
+	maxAge := strconv.Itoa(int(conf.MaxAge.Seconds()))
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" || (!allowAll && !slices.Contains(conf.AllowOrigins, origin)) {
+			c.Next()
+			return
+		}
+
+		h := c.Writer.Header()
+		if allowAll {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+			h.Add("Vary", "Origin")
+		}
+		if conf.AllowCredentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != "" {
+			h.Set("Access-Control-Allow-Methods", methods)
+			h.Set("Access-Control-Allow-Headers", headers)
+			h.Set("Access-Control-Max-Age", maxAge)
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Next()
+	}
+}
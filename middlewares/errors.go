@@ -0,0 +1,38 @@
+package middlewares
+
+import (
+	"goblog/pkg/apperror"
+	"goblog/pkg/logger"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorHandler 把处理函数通过 c.Error 记录的错误渲染为 problem+json，消息语言由 Accept-Language 决定
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		appErr := apperror.From(c.Errors.Last().Err)
+		if appErr.Status >= http.StatusInternalServerError {
+			slog.ErrorContext(c, "请求处理失败", "method", c.Request.Method, "path", c.Request.URL.Path, "err", appErr)
+		}
+
+		lang := apperror.MatchLanguage(c.GetHeader("Accept-Language"))
+		problem := appErr.Problem(lang, c.Request.URL.Path)
+		problem.RequestID = logger.RequestID(c)
+		c.Header("Content-Language", lang)
+		c.Header("Content-Type", apperror.ProblemContentType)
+		c.JSON(appErr.Status, problem)
+	}
+}
+
+// NotFoundHandler 让不存在的路由也返回 problem+json
+func NotFoundHandler(c *gin.Context) {
+	c.Error(apperror.NotFound(apperror.CodeRouteNotFound))
+}
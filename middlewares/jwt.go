@@ -1,55 +1,120 @@
 package middlewares
 
 import (
+	"context"
+	"errors"
 	"goblog/config"
-	"net/http"
+	"goblog/database"
+	"goblog/models"
+	"goblog/pkg/apperror"
+	"goblog/pkg/cache"
+	"goblog/service"
+	"log/slog"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
 )
 
 func JWTAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "未提供认证的token",
-			})
+			c.Error(apperror.Unauthorized(apperror.CodeTokenMissing))
 			c.Abort()
 			return
 		}
 
-		parts := strings.SplitN(authHeader, " ", 2)
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token 格式错误"})
+		userID, code := parseToken(authHeader)
+		if code != "" {
+			c.Error(apperror.Unauthorized(code))
+			c.Abort()
+			return
+		}
+		if err := blocked(c, userID); err != nil {
+			c.Error(err)
 			c.Abort()
 			return
 		}
 
-		tokenString := parts[1]
+		c.Set("user_id", userID)
+		c.Next()
+	}
+}
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
+// OptionalJWTAuthMiddleware 在携带有效 token 时设置 user_id，未登录也放行，被封禁或已注销的用户按未登录处理
+func OptionalJWTAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if authHeader := c.GetHeader("Authorization"); authHeader != "" {
+			if userID, code := parseToken(authHeader); code == "" && blocked(c, userID) == nil {
+				c.Set("user_id", userID)
 			}
-			return []byte(config.AppConfig.JWTSecret), nil
-		})
-
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "无效或过期的 Token"})
-			c.Abort()
-			return
 		}
+		c.Next()
+	}
+}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "无法解析 Token"})
-			c.Abort()
-			return
+// parseToken 解析 Authorization 头，失败时返回错误码
+func parseToken(authHeader string) (uint, string) {
+	parts := strings.SplitN(authHeader, " ", 2)
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return 0, apperror.CodeTokenMalformed
+	}
+
+	tokenString := parts[1]
+
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
 		}
+		return []byte(config.AppConfig.JWT.Secret), nil
+	})
 
-		c.Set("user_id", uint(claims["user_id"].(float64)))
-		c.Next()
+	if err != nil || !token.Valid {
+		return 0, apperror.CodeTokenInvalid
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return 0, apperror.CodeTokenInvalid
+	}
+
+	userID, ok := claims["user_id"].(float64)
+	if !ok {
+		return 0, apperror.CodeTokenInvalid
+	}
+
+	return uint(userID), ""
+}
+
+// blocked 检查用户是否被封禁或已注销，token 在此之前签发也会被拒绝。结果缓存 cache.read_ttl，
+// 封禁、解封和注销时删除缓存；查询出错时放行，不因数据库故障拒绝所有请求
+func blocked(ctx context.Context, userID uint) *apperror.Error {
+	code, err := cache.Get(ctx, service.UserStatusKey(userID), config.AppConfig.Cache.ReadTTL, func() (string, error) {
+		var user models.User
+		err := database.DB.WithContext(ctx).Select("banned_at", "deleted_at").First(&user, userID).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return "", nil
+		case err != nil:
+			return "", err
+		case !user.DeletedAt.IsZero():
+			return apperror.CodeAccountDeleted, nil
+		case user.BannedAt != nil:
+			return apperror.CodeUserBanned, nil
+		}
+		return "", nil
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "检查用户状态失败", "user_id", userID, "err", err)
+		return nil
+	}
+	switch code {
+	case apperror.CodeAccountDeleted:
+		return apperror.Unauthorized(code)
+	case apperror.CodeUserBanned:
+		return apperror.Forbidden(code)
 	}
+	return nil
 }
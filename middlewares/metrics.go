@@ -0,0 +1,25 @@
+package middlewares
+
+import (
+	"goblog/pkg/telemetry"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics 按方法、路由模板和状态码记录请求耗时。用模板而不是实际路径，避免 /api/posts/1、/api/posts/2 各占一组指标
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		telemetry.HTTPRequestDuration.
+			WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
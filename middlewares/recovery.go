@@ -0,0 +1,24 @@
+package middlewares
+
+import (
+	"fmt"
+	"goblog/pkg/apperror"
+	"log/slog"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Recovery 捕获处理函数中的 panic，记录堆栈后交给 ErrorHandler 返回 500，需要注册在 ErrorHandler 之后
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if r := recover(); r != nil {
+				slog.ErrorContext(c, "处理请求时 panic", "panic", r, "stack", string(debug.Stack()))
+				c.Error(apperror.Internal(fmt.Errorf("panic: %v", r)))
+				c.Abort()
+			}
+		}()
+		c.Next()
+	}
+}
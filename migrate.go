@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"goblog/database"
+	"goblog/database/migrations"
+	"goblog/pkg/migrate"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+	"time"
+)
+
+const migrateUsage = `用法: goblog [参数] migrate <命令>
+
+命令:
+  up [n]                     执行未应用的迁移，n 为最多执行的数量，默认全部
+  down [n]                   回滚最近执行的 n 个迁移，默认 1
+  status                     列出所有迁移及其执行时间
+  create [-dir 目录] <name>  为每种数据库生成下一个版本的 up/down 迁移文件，默认目录 database/migrations`
+
+// runMigrate 处理 migrate 子命令，数据库连接参数与服务启动时相同
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	cmd, args := args[0], args[1:]
+
+	if cmd == "create" {
+		flags := flag.NewFlagSet("migrate create", flag.ContinueOnError)
+		dir := flags.String("dir", "database/migrations", "迁移文件目录")
+		if err := flags.Parse(args); err != nil {
+			return err
+		}
+		if flags.NArg() != 1 {
+			return errors.New(migrateUsage)
+		}
+		created, err := migrate.Create(*dir, migrations.Drivers, flags.Arg(0))
+		for _, file := range created {
+			fmt.Println("已创建", file)
+		}
+		return err
+	}
+
+	n, err := migrateCount(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	if err := database.Open(); err != nil {
+		return err
+	}
+	defer database.Close()
+	m, err := database.Migrator()
+	if err != nil {
+		return err
+	}
+
+	// 等待迁移锁时可以用 Ctrl-C 取消
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	switch cmd {
+	case "up":
+		applied, err := m.Up(ctx, n)
+		for _, migration := range applied {
+			fmt.Println("已执行", migration)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("没有需要执行的迁移")
+		}
+		return err
+	case "down":
+		reverted, err := m.Down(ctx, n)
+		for _, migration := range reverted {
+			fmt.Println("已回滚", migration)
+		}
+		return err
+	default:
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "版本\t名称\t执行时间")
+		for _, s := range statuses {
+			applied := "未执行"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Local().Format(time.DateTime)
+			}
+			if s.Missing {
+				applied += "（程序中不存在）"
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, applied)
+		}
+		return w.Flush()
+	}
+}
+
+// migrateCount 校验 up/down/status 的参数，返回要执行或回滚的数量
+func migrateCount(cmd string, args []string) (int, error) {
+	switch cmd {
+	case "up", "down":
+	case "status":
+		if len(args) > 0 {
+			return 0, errors.New(migrateUsage)
+		}
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("未知的 migrate 命令 %q\n\n%s", cmd, migrateUsage)
+	}
+
+	if len(args) == 0 {
+		if cmd == "down" {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n <= 0 || len(args) > 1 {
+		return 0, errors.New(migrateUsage)
+	}
+	return n, nil
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+const (
+	ExportPending = "pending"
+	ExportRunning = "running"
+	ExportDone    = "done"
+	ExportFailed  = "failed"
+)
+
+// DataExport 是用户导出个人数据的任务，由后台 worker 生成 ZIP 保存在 Data 中，过期后删除。
+// StartedAt 为开始生成的时间，生成过程中副本退出的任务在租约到期后重新生成
+type DataExport struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UserID      uint       `gorm:"index;not null" json:"user_id"`
+	Status      string     `gorm:"index;not null;default:pending" json:"status"`
+	Data        []byte     `json:"-"`
+	Size        int64      `gorm:"not null;default:0" json:"size"`
+	Error       string     `gorm:"type:text;not null;default:''" json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	StartedAt   *time.Time `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	ExpiresAt   *time.Time `gorm:"index" json:"expires_at"`
+}
+
+const (
+	DeletePosts   = "delete"
+	TransferPosts = "transfer"
+)
+
+// AccountDeletion 是待执行的注销申请，到 ExecuteAt 后由后台 worker 执行，StartedAt 为开始执行的时间，
+// 开始之前用户可以撤销。PostAction 为 transfer 时文章转给 TransferToID，为 delete 时永久删除。
+// 无法执行时（如文章接收人已注销）记录 FailedAt 和 Error，不再重试，用户可以撤销后重新申请
+type AccountDeletion struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	UserID       uint       `gorm:"uniqueIndex;not null" json:"user_id"`
+	PostAction   string     `gorm:"not null" json:"post_action"`
+	TransferToID *uint      `json:"transfer_to_id"`
+	RequestedAt  time.Time  `json:"requested_at"`
+	ExecuteAt    time.Time  `gorm:"index" json:"execute_at"`
+	StartedAt    *time.Time `json:"started_at"`
+	FailedAt     *time.Time `json:"failed_at"`
+	Error        string     `gorm:"not null;default:''" json:"error,omitempty"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// AuditLog 记录一次特权或破坏性操作，只追加不修改。Before/After 是操作前后对象的 JSON 快照，
+// ActorID 为空表示匿名操作（如登录失败）或后台任务（如回收站自动清理）
+type AuditLog struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	ActorID    *uint     `gorm:"index" json:"actor_id"`
+	Action     string    `gorm:"index;not null" json:"action"`
+	TargetType string    `gorm:"not null" json:"target_type"`
+	TargetID   uint      `json:"target_id"`
+	Before     JSONText  `gorm:"type:text" json:"before"`
+	After      JSONText  `gorm:"type:text" json:"after"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `gorm:"type:text" json:"user_agent"`
+	RequestID  string    `json:"request_id"`
+	CreatedAt  time.Time `gorm:"index" json:"created_at"`
+}
+
+// JSONText 是以文本保存的 JSON，序列化时原样输出而不是作为字符串转义，空值输出 null
+type JSONText string
+
+func (t JSONText) MarshalJSON() ([]byte, error) {
+	if t == "" {
+		return []byte("null"), nil
+	}
+	return []byte(t), nil
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type BookmarkCollection struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	UserID      uint           `gorm:"index" json:"user_id"`
+	Name        string         `gorm:"not null" json:"name"`
+	Description string         `gorm:"type:text" json:"description"`
+	IsPublic    bool           `gorm:"default:false" json:"is_public"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+type Bookmark struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	UserID       uint      `gorm:"uniqueIndex:idx_bookmark_user_post;not null" json:"user_id"`
+	PostID       uint      `gorm:"uniqueIndex:idx_bookmark_user_post;index;not null" json:"post_id"`
+	Post         *Post     `gorm:"foreignKey:PostID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"post,omitempty"`
+	CollectionID *uint     `gorm:"index" json:"collection_id"`
+	CreatedAt    time.Time `json:"created_at"`
+}
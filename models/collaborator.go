@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+const (
+	CollaboratorOwner    = "owner"
+	CollaboratorCoAuthor = "co_author"
+	CollaboratorReviewer = "reviewer"
+)
+
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationDeclined = "declined"
+)
+
+// PostCollaborator 文章协作者，文章的 UserID 始终是 owner，这里只记录被邀请的合著者和审稿人
+type PostCollaborator struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	PostID     uint       `gorm:"uniqueIndex:idx_collaborator_post_user;not null" json:"post_id"`
+	Post       *Post      `gorm:"foreignKey:PostID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"post,omitempty"`
+	UserID     uint       `gorm:"uniqueIndex:idx_collaborator_post_user;index;not null" json:"user_id"`
+	User       User       `gorm:"foreignKey:UserID" json:"user"`
+	Role       string     `gorm:"not null" json:"role"`
+	Status     string     `gorm:"index;default:pending" json:"status"`
+	InvitedBy  uint       `json:"invited_by"`
+	AcceptedAt *time.Time `json:"accepted_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
@@ -2,7 +2,7 @@ package models
 
 import "time"
 
-type Conment struct {
+type Comment struct {
 	ID      uint   `gorm:"primaryKey" json:"id"`
 	Content string `gorm:"type:text;not null" json:"content"`
 	UserID  uint   `json:"user_id"`
@@ -12,9 +12,9 @@ type Conment struct {
 	Post   Post `gorm:"foreignKey:PostID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
 
 	ParentID *uint     `json:"parent_id"`
-	Replies  []Conment `gorm:"foreignKey:ParentID" json:"replies"`
+	Replies  []Comment `gorm:"foreignKey:ParentID" json:"replies"`
 
-	CreatedAT time.Time `json:"created_at"`
+	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `josn:"updated_at"`
 	DeletedAt time.Time `gorm:"index" json:"-"`
 }
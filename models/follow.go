@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+type Follow struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	FollowerID uint      `gorm:"uniqueIndex:idx_follow_target;not null" json:"follower_id"`
+	TargetID   uint      `gorm:"uniqueIndex:idx_follow_target;index:idx_follow_lookup;not null" json:"target_id"`
+	TargetType string    `gorm:"uniqueIndex:idx_follow_target;index:idx_follow_lookup;not null" json:"target_type"` // user 或 tag
+	CreatedAt  time.Time `json:"created_at"`
+}
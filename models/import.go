@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// ImportedItem 记录导入来源中的一篇文章或一条评论对应的本地记录，重复导入时据此更新或跳过。
+// SourceKey 如 wxr:<guid>、hugo:posts/hello.md，Checksum 是导入时内容的摘要
+type ImportedItem struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	SourceKey  string    `gorm:"size:500;uniqueIndex;not null" json:"source_key"`
+	TargetType string    `gorm:"not null" json:"target_type"` // post 或 comment
+	TargetID   uint      `gorm:"not null" json:"target_id"`
+	Checksum   string    `gorm:"not null" json:"checksum"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
@@ -6,6 +6,13 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	PostDraft     = "draft"
+	PostInReview  = "in_review"
+	PostApproved  = "approved"
+	PostPublished = "published"
+)
+
 type Post struct {
 	ID          uint           `gorm:"primaryKey" json:"id"`
 	Title       string         `gorm:"type:text;not null" json:"context"`
@@ -13,10 +20,22 @@ type Post struct {
 	UserID      uint           `json:"user_id"`
 	User        User           `json:"author"`
 	IsDraft     bool           `gorm:"default:false" json:"is_draft"`
+	Status      string         `gorm:"index;default:published" json:"status"` // 审稿流程状态，IsDraft 始终等于 Status != published
 	IsTop       bool           `gorm:"default:false" json:"is_top"`
 	IsRecommend bool           `gorm:"default:false" json:"is_recommend"`
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
 	Tags        []*Tag         `gorm:"many2many:post_tags;" json:"tags"` // 该标签通过 gorm:"many2many:post_tags;" 指定了用 post_tags 中间表来建立 Post 与 Tag 的多对多关联，并在 JSON 序列化时将该字段命名为 tags。
+
+	LikeCount     int64  `gorm:"-" json:"like_count"`
+	BookmarkCount int64  `gorm:"-" json:"bookmark_count"`
+	ViewCount     int64  `gorm:"-" json:"view_count"`
+	Authors       []User `gorm:"-" json:"authors"` // owner 和已接受邀请的合著者
+}
+
+// SetStatus 修改审稿状态并同步 IsDraft
+func (p *Post) SetStatus(status string) {
+	p.Status = status
+	p.IsDraft = status != PostPublished
 }
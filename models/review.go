@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// PostTransition 文章状态变更记录，只追加不修改
+type PostTransition struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	PostID    uint      `gorm:"index;not null" json:"post_id"`
+	ActorID   uint      `json:"actor_id"`
+	Actor     User      `gorm:"foreignKey:ActorID" json:"actor"`
+	Action    string    `gorm:"not null" json:"action"`
+	From      string    `gorm:"column:from_status;not null" json:"from"`
+	To        string    `gorm:"column:to_status;not null" json:"to"`
+	Comment   string    `gorm:"type:text" json:"comment"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReviewComment 审稿时针对草稿某段内容的批注，AnchorStart/AnchorEnd 为正文中的字符偏移
+type ReviewComment struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	PostID      uint      `gorm:"index;not null" json:"post_id"`
+	UserID      uint      `json:"user_id"`
+	User        User      `gorm:"foreignKey:UserID" json:"user"`
+	Body        string    `gorm:"type:text;not null" json:"body"`
+	Quote       string    `gorm:"type:text" json:"quote"`
+	AnchorStart *int      `json:"anchor_start"`
+	AnchorEnd   *int      `json:"anchor_end"`
+	Resolved    bool      `gorm:"default:false" json:"resolved"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
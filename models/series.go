@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Series struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	UserID      uint           `gorm:"index" json:"user_id"`
+	User        User           `json:"author"`
+	Title       string         `gorm:"not null" json:"title"`
+	Description string         `gorm:"type:text" json:"description"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// SeriesPost 系列中的一篇文章，一篇文章最多属于一个系列，Position 从 1 开始连续编号
+type SeriesPost struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	SeriesID  uint      `gorm:"index;not null" json:"series_id"`
+	PostID    uint      `gorm:"uniqueIndex;not null" json:"post_id"`
+	Post      *Post     `gorm:"foreignKey:PostID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"post,omitempty"`
+	Position  int       `gorm:"not null" json:"position"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -13,4 +13,6 @@ type Tag struct {
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeleteAt  gorm.DeletedAt `gorm:"index" json:"-"`
+
+	PostCount int64 `gorm:"-" json:"post_count,omitempty"`
 }
@@ -2,12 +2,22 @@ package models
 
 import "time"
 
+const (
+	RoleUser   = "user"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
 type User struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	Username  string    `gorm:"unique; not null" json:"username"`
-	Email     string    `gorm:"unique; not null" json:"email"`
-	Password  string    `gorm:"not null" json:"-"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	DeletedAt time.Time `grom:"index" josn:"-"`
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	Username string `gorm:"unique; not null" json:"username"`
+	Email    string `gorm:"unique; not null" json:"email"`
+	Password string `gorm:"not null" json:"-"`
+	Role     string `gorm:"default:user;not null" json:"role"`
+	// BannedAt 不为空表示已被管理员封禁，不能登录，已签发的 token 也会被拒绝
+	BannedAt  *time.Time `json:"-"`
+	BanReason string     `gorm:"not null;default:''" json:"-"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt time.Time  `grom:"index" josn:"-"`
 }
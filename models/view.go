@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// PostView 文章每日浏览量
+type PostView struct {
+	PostID uint      `gorm:"primaryKey" json:"post_id"`
+	Date   time.Time `gorm:"primaryKey;type:date" json:"date"`
+	Views  int64     `gorm:"not null;default:0" json:"views"`
+}
+
+// PostReferrer 文章每日来源统计，Referrer 为来源域名，直接访问记为 direct
+type PostReferrer struct {
+	PostID   uint      `gorm:"primaryKey" json:"post_id"`
+	Date     time.Time `gorm:"primaryKey;type:date" json:"date"`
+	Referrer string    `gorm:"primaryKey" json:"referrer"`
+	Views    int64     `gorm:"not null;default:0" json:"views"`
+}
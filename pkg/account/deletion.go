@@ -0,0 +1,167 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"goblog/database"
+	"goblog/models"
+	"goblog/pkg/audit"
+	"goblog/pkg/cache"
+	"goblog/service"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AnonymousName 是注销后用户的用户名，评论、审稿批注等仍然保留，作者显示为该名称
+func AnonymousName(userID uint) string {
+	return fmt.Sprintf("deleted-%d", userID)
+}
+
+// Delete 执行注销申请：按 PostAction 转移或永久删除文章，删除点赞、收藏、关注、webhook、
+// 协作关系和导出文件，最后匿名化用户资料。评论保留，作者变为匿名用户。
+// 每一步都可以重复执行，中途失败时下次重试会从头再来
+func Delete(ctx context.Context, posts *service.PostService, deletion models.AccountDeletion) error {
+	db := database.DB.WithContext(ctx)
+	userID := deletion.UserID
+
+	var postIDs []uint
+	if err := db.Unscoped().Model(&models.Post{}).Where("user_id = ?", userID).Pluck("id", &postIDs).Error; err != nil {
+		return err
+	}
+	transferTo, err := transferTarget(ctx, deletion)
+	if err != nil {
+		return err
+	}
+	if transferTo != 0 {
+		if err := transferPosts(ctx, userID, transferTo, postIDs); err != nil {
+			return err
+		}
+	} else if err := purgePosts(ctx, posts, userID); err != nil {
+		return err
+	}
+
+	var commentedPosts []uint
+	if err := db.Model(&models.Comment{}).Where("user_id = ?", userID).Distinct().Pluck("post_id", &commentedPosts).Error; err != nil {
+		return err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		steps := []*gorm.DB{
+			tx.Unscoped().Where("user_id = ?", userID).Delete(&models.Like{}),
+			tx.Where("user_id = ?", userID).Delete(&models.Bookmark{}),
+			tx.Unscoped().Where("user_id = ?", userID).Delete(&models.BookmarkCollection{}),
+			tx.Where("follower_id = ? OR (target_type = ? AND target_id = ?)", userID, "user", userID).Delete(&models.Follow{}),
+			tx.Where("webhook_id IN (?)", tx.Unscoped().Model(&models.Webhook{}).Select("id").Where("user_id = ?", userID)).Delete(&models.WebhookDelivery{}),
+			tx.Unscoped().Where("user_id = ?", userID).Delete(&models.Webhook{}),
+			tx.Where("user_id = ?", userID).Delete(&models.PostCollaborator{}),
+			tx.Where("user_id = ?", userID).Delete(&models.DataExport{}),
+			tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]any{
+				"username":   AnonymousName(userID),
+				"email":      AnonymousName(userID) + "@deleted.invalid",
+				"password":   "",
+				"role":       models.RoleUser,
+				"banned_at":  nil,
+				"ban_reason": "",
+				"deleted_at": time.Now(),
+			}),
+			tx.Delete(&deletion),
+		}
+		for _, step := range steps {
+			if step.Error != nil {
+				return step.Error
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	cache.Del(service.UserStatusKey(userID))
+	for _, postID := range commentedPosts {
+		cache.Del(service.CommentsKey(postID))
+	}
+	audit.Record(ctx, audit.Entry{
+		Action:     audit.ActionAccountDelete,
+		TargetType: "user",
+		TargetID:   userID,
+		After:      map[string]any{"post_action": deletion.PostAction, "transfer_to_id": transferTo, "posts": len(postIDs)},
+	})
+	return nil
+}
+
+// ErrTransferTarget 表示申请时指定的文章接收人已不存在或已注销，注销不会执行，更不会改为删除文章
+var ErrTransferTarget = errors.New("文章接收人不存在或已注销，请撤销后重新申请")
+
+// transferTarget 返回文章要转给的用户，不转移时返回 0
+func transferTarget(ctx context.Context, deletion models.AccountDeletion) (uint, error) {
+	if deletion.PostAction != models.TransferPosts {
+		return 0, nil
+	}
+	if deletion.TransferToID == nil {
+		return 0, ErrTransferTarget
+	}
+	var target models.User
+	err := database.DB.WithContext(ctx).Select("id", "deleted_at").First(&target, *deletion.TransferToID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) || err == nil && !target.DeletedAt.IsZero() {
+		return 0, ErrTransferTarget
+	}
+	if err != nil {
+		return 0, err
+	}
+	return target.ID, nil
+}
+
+// transferPosts 把文章和系列转给 to，接收人原来是这些文章的协作者时去掉协作者身份
+func transferPosts(ctx context.Context, from, to uint, postIDs []uint) error {
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(postIDs) > 0 {
+			if err := tx.Where("user_id = ? AND post_id IN ?", to, postIDs).Delete(&models.PostCollaborator{}).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Unscoped().Model(&models.Post{}).Where("user_id = ?", from).Update("user_id", to).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Model(&models.Series{}).Where("user_id = ?", from).Update("user_id", to).Error
+	})
+	if err != nil {
+		return err
+	}
+	for _, id := range postIDs {
+		service.InvalidatePost(id)
+	}
+	return nil
+}
+
+// purgePosts 先删除未删除的文章（触发与作者删除时相同的事件），再永久删除回收站中的全部文章和系列
+func purgePosts(ctx context.Context, posts *service.PostService, userID uint) error {
+	db := database.DB.WithContext(ctx)
+
+	var live []uint
+	if err := db.Model(&models.Post{}).Where("user_id = ?", userID).Pluck("id", &live).Error; err != nil {
+		return err
+	}
+	for _, id := range live {
+		if _, err := posts.DeleteAny(ctx, id); err != nil {
+			return err
+		}
+	}
+
+	var trashed []uint
+	if err := db.Unscoped().Model(&models.Post{}).Where("user_id = ?", userID).Pluck("id", &trashed).Error; err != nil {
+		return err
+	}
+	for _, id := range trashed {
+		if _, err := posts.Purge(ctx, id); err != nil {
+			return err
+		}
+	}
+	series := db.Unscoped().Model(&models.Series{}).Select("id").Where("user_id = ?", userID)
+	if err := db.Where("series_id IN (?)", series).Delete(&models.SeriesPost{}).Error; err != nil {
+		return err
+	}
+	return db.Unscoped().Where("user_id = ?", userID).Delete(&models.Series{}).Error
+}
@@ -0,0 +1,148 @@
+// Package account 生成用户的数据导出，并在宽限期过后执行注销申请。
+// 任务都保存在数据库中，由 RunWorker 处理，多个副本同时运行时每个任务只会被一个副本领取
+package account
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"goblog/database"
+	"goblog/models"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+type profile struct {
+	ID        uint      `json:"id"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type comment struct {
+	ID        uint      `json:"id"`
+	PostID    uint      `json:"post_id"`
+	ParentID  *uint     `json:"parent_id"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type like struct {
+	TargetType string    `json:"target_type"`
+	TargetID   uint      `json:"target_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// FrontMatter 是导出文章开头的 YAML 元数据
+type FrontMatter struct {
+	Title   string     `yaml:"title"`
+	Date    time.Time  `yaml:"date"`
+	Updated time.Time  `yaml:"updated"`
+	Tags    []string   `yaml:"tags,omitempty"`
+	Status  string     `yaml:"status"`
+	Draft   bool       `yaml:"draft"`
+	Deleted *time.Time `yaml:"deleted,omitempty"` // 文章在回收站中时为删除时间
+}
+
+// BuildExport 把用户的数据打包成 ZIP：
+//
+//	profile.json   用户资料
+//	posts/<id>.md  文章，YAML front matter 加 Markdown 正文，包括草稿和回收站中的文章
+//	comments.json  发表的评论
+//	likes.json     点赞过的文章和评论
+func BuildExport(ctx context.Context, userID uint) ([]byte, error) {
+	db := database.DB.WithContext(ctx)
+
+	var user models.User
+	if err := db.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	var posts []models.Post
+	if err := db.Unscoped().Preload("Tags").Where("user_id = ?", userID).Order("id").Find(&posts).Error; err != nil {
+		return nil, err
+	}
+	var comments []comment
+	if err := db.Model(&models.Comment{}).Where("user_id = ?", userID).Order("id").Find(&comments).Error; err != nil {
+		return nil, err
+	}
+	var likes []like
+	if err := db.Model(&models.Like{}).Where("user_id = ?", userID).Order("id").Find(&likes).Error; err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	files := []struct {
+		name string
+		data any
+	}{
+		{"profile.json", profile{ID: user.ID, Username: user.Username, Email: user.Email, Role: user.Role, CreatedAt: user.CreatedAt}},
+		{"comments.json", comments},
+		{"likes.json", likes},
+	}
+	for _, f := range files {
+		data, err := json.MarshalIndent(f.data, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		if err := writeFile(w, f.name, data); err != nil {
+			return nil, err
+		}
+	}
+	for _, post := range posts {
+		data, err := Markdown(post)
+		if err != nil {
+			return nil, err
+		}
+		if err := writeFile(w, fmt.Sprintf("posts/%d.md", post.ID), data); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Markdown 把文章转换为带 YAML front matter 的 Markdown
+func Markdown(post models.Post) ([]byte, error) {
+	meta := FrontMatter{
+		Title:   post.Title,
+		Date:    post.CreatedAt,
+		Updated: post.UpdatedAt,
+		Status:  post.Status,
+		Draft:   post.IsDraft,
+	}
+	for _, tag := range post.Tags {
+		meta.Tags = append(meta.Tags, tag.Name)
+	}
+	if post.DeletedAt.Valid {
+		meta.Deleted = &post.DeletedAt.Time
+	}
+	header, err := yaml.Marshal(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	buf.Write(header)
+	buf.WriteString("---\n\n")
+	buf.WriteString(post.Content)
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+func writeFile(w *zip.Writer, name string, data []byte) error {
+	f, err := w.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	return err
+}
@@ -0,0 +1,138 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"goblog/config"
+	"goblog/database"
+	"goblog/models"
+	"goblog/service"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	pollInterval = 10 * time.Second
+	batchSize    = 10
+	// deletionLease 是注销执行的最长时间，超过后认为执行它的副本已退出，其他副本可以重新领取
+	deletionLease = time.Hour
+	// exportLease 是生成导出文件的最长时间，超过后仍为 running 的任务会被重新领取
+	exportLease = 30 * time.Minute
+)
+
+var wakeup = make(chan struct{}, 1)
+
+// Notify 唤醒 worker 立即处理新的导出或注销任务
+func Notify() {
+	select {
+	case wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// RunWorker 生成待处理的数据导出、执行到期的注销申请并删除过期的导出文件，阻塞直到 ctx 被取消。
+// 注销时通过 posts 删除文章，和作者自己删除一样触发缓存失效、排行和 webhook 等事件
+func RunWorker(ctx context.Context, posts *service.PostService) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		processExports(ctx)
+		processDeletions(ctx, posts)
+		removeExpiredExports(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-wakeup:
+		}
+	}
+}
+
+func processExports(ctx context.Context) {
+	// 待生成的任务，以及生成过程中副本退出、超过租约仍为 running 的任务
+	due := func() *gorm.DB {
+		stale := time.Now().Add(-exportLease)
+		return database.DB.WithContext(ctx).Where("status = ? OR (status = ? AND (started_at IS NULL OR started_at < ?))",
+			models.ExportPending, models.ExportRunning, stale)
+	}
+
+	var exports []models.DataExport
+	if err := due().Omit("data").Order("id").Limit(batchSize).Find(&exports).Error; err != nil {
+		slog.ErrorContext(ctx, "account: 读取导出任务失败", "err", err)
+		return
+	}
+
+	for _, export := range exports {
+		if ctx.Err() != nil {
+			return
+		}
+		claim := due().Model(&export).Updates(map[string]any{"status": models.ExportRunning, "started_at": time.Now()})
+		if claim.Error != nil || claim.RowsAffected == 0 {
+			continue
+		}
+
+		data, err := BuildExport(ctx, export.UserID)
+		now := time.Now()
+		updates := map[string]any{
+			"status":       models.ExportDone,
+			"data":         data,
+			"size":         len(data),
+			"completed_at": now,
+			"expires_at":   now.Add(config.AppConfig.Account.ExportRetention),
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "account: 生成导出文件失败", "export_id", export.ID, "user_id", export.UserID, "err", err)
+			updates["status"] = models.ExportFailed
+			updates["error"] = err.Error()
+		}
+		if err := database.DB.WithContext(ctx).Model(&export).Updates(updates).Error; err != nil {
+			slog.ErrorContext(ctx, "account: 更新导出任务失败", "export_id", export.ID, "err", err)
+		}
+	}
+}
+
+func processDeletions(ctx context.Context, posts *service.PostService) {
+	now := time.Now()
+	stale := now.Add(-deletionLease)
+	var deletions []models.AccountDeletion
+	if err := database.DB.WithContext(ctx).Where("execute_at <= ? AND failed_at IS NULL AND (started_at IS NULL OR started_at < ?)", now, stale).
+		Order("execute_at").Limit(batchSize).Find(&deletions).Error; err != nil {
+		slog.ErrorContext(ctx, "account: 读取注销申请失败", "err", err)
+		return
+	}
+
+	for _, deletion := range deletions {
+		if ctx.Err() != nil {
+			return
+		}
+		// 领取后用户不能再撤销
+		claim := database.DB.WithContext(ctx).Model(&deletion).Where("started_at IS NULL OR started_at < ?", stale).Update("started_at", time.Now())
+		if claim.Error != nil || claim.RowsAffected == 0 {
+			continue
+		}
+		if err := Delete(ctx, posts, deletion); errors.Is(err, ErrTransferTarget) {
+			// 还没有修改任何数据，释放领取让用户可以撤销
+			slog.WarnContext(ctx, "account: 注销无法执行", "user_id", deletion.UserID, "err", err)
+			if err := database.DB.WithContext(ctx).Model(&deletion).Updates(map[string]any{
+				"started_at": nil,
+				"failed_at":  time.Now(),
+				"error":      err.Error(),
+			}).Error; err != nil {
+				slog.ErrorContext(ctx, "account: 更新注销申请失败", "user_id", deletion.UserID, "err", err)
+			}
+			continue
+		} else if err != nil {
+			slog.ErrorContext(ctx, "account: 执行注销失败", "user_id", deletion.UserID, "err", err)
+			continue
+		}
+		slog.InfoContext(ctx, "account: 已注销用户", "user_id", deletion.UserID)
+	}
+}
+
+func removeExpiredExports(ctx context.Context) {
+	if err := database.DB.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&models.DataExport{}).Error; err != nil {
+		slog.ErrorContext(ctx, "account: 删除过期的导出文件失败", "err", err)
+	}
+}
@@ -0,0 +1,216 @@
+package analytics
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"goblog/database"
+	"goblog/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+)
+
+var ErrInvalidInterval = errors.New("interval 只能是 day、week 或 month")
+
+// Scope 限定统计范围，AuthorID 为空表示全站，PostID 不为空时只统计单篇文章
+type Scope struct {
+	AuthorID *uint
+	PostID   *uint
+	From     time.Time
+	To       time.Time
+}
+
+type Point struct {
+	Period   string `json:"period"`
+	Views    int64  `json:"views"`
+	Likes    int64  `json:"likes"`
+	Comments int64  `json:"comments"`
+}
+
+type TopPost struct {
+	PostID uint   `json:"post_id"`
+	Title  string `json:"title"`
+	Views  int64  `json:"views"`
+}
+
+type ReferrerCount struct {
+	Referrer string `json:"referrer"`
+	Views    int64  `json:"views"`
+}
+
+type dayCount struct {
+	Day   day
+	Count int64
+}
+
+// day 兼容不同数据库 date 列的返回类型，SQLite 返回字符串，其他数据库返回 time.Time
+type day struct{ time.Time }
+
+func (d *day) Scan(value any) error {
+	switch v := value.(type) {
+	case time.Time:
+		d.Time = v
+	case string:
+		return d.parse(v)
+	case []byte:
+		return d.parse(string(v))
+	default:
+		return fmt.Errorf("无法将 %T 解析为日期", value)
+	}
+	return nil
+}
+
+func (d day) Value() (driver.Value, error) {
+	return d.Time, nil
+}
+
+func (d *day) parse(s string) error {
+	if len(s) > len(dateLayout) {
+		s = s[:len(dateLayout)]
+	}
+	t, err := time.Parse(dateLayout, s)
+	d.Time = t
+	return err
+}
+
+// postIDs 返回范围内文章 ID 的子查询
+func (s Scope) postIDs() *gorm.DB {
+	q := database.DB.Model(&models.Post{}).Select("id")
+	if s.AuthorID != nil {
+		q = q.Where("user_id = ?", *s.AuthorID)
+	}
+	if s.PostID != nil {
+		q = q.Where("id = ?", *s.PostID)
+	}
+	return q
+}
+
+func (s Scope) restricted() bool {
+	return s.AuthorID != nil || s.PostID != nil
+}
+
+func bucket(t time.Time, interval string) string {
+	switch interval {
+	case IntervalWeek:
+		offset := (int(t.Weekday()) + 6) % 7
+		return t.AddDate(0, 0, -offset).Format(dateLayout)
+	case IntervalMonth:
+		return t.Format("2006-01")
+	default:
+		return t.Format(dateLayout)
+	}
+}
+
+// Series 按时间粒度汇总浏览、点赞、评论数，没有数据的区间补零
+func Series(s Scope, interval string) ([]Point, error) {
+	if interval != IntervalDay && interval != IntervalWeek && interval != IntervalMonth {
+		return nil, ErrInvalidInterval
+	}
+
+	var views []dayCount
+	viewQuery := database.DB.Model(&models.PostView{}).Select("date AS day, SUM(views) AS count").
+		Where("date BETWEEN ? AND ?", s.From, s.To)
+	if s.restricted() {
+		viewQuery = viewQuery.Where("post_id IN (?)", s.postIDs())
+	}
+	if err := viewQuery.Group("date").Scan(&views).Error; err != nil {
+		return nil, err
+	}
+
+	// 点赞和评论在数据库中按 UTC 日期分组，周和月再由下面按天汇总
+	end := s.To.AddDate(0, 0, 1)
+	var likes, comments []dayCount
+
+	date := database.UTCDate("created_at")
+	likeQuery := database.DB.Model(&models.Like{}).Select(date+" AS day, COUNT(*) AS count").
+		Where("target_type = ? AND created_at >= ? AND created_at < ?", "post", s.From, end)
+	if s.restricted() {
+		likeQuery = likeQuery.Where("target_id IN (?)", s.postIDs())
+	}
+	if err := likeQuery.Group(date).Scan(&likes).Error; err != nil {
+		return nil, err
+	}
+
+	commentQuery := database.DB.Model(&models.Comment{}).Select(date+" AS day, COUNT(*) AS count").
+		Where("created_at >= ? AND created_at < ?", s.From, end)
+	if s.restricted() {
+		commentQuery = commentQuery.Where("post_id IN (?)", s.postIDs())
+	}
+	if err := commentQuery.Group(date).Scan(&comments).Error; err != nil {
+		return nil, err
+	}
+
+	var points []Point
+	index := map[string]int{}
+	for d := s.From; !d.After(s.To); d = d.AddDate(0, 0, 1) {
+		period := bucket(d, interval)
+		if _, ok := index[period]; !ok {
+			index[period] = len(points)
+			points = append(points, Point{Period: period})
+		}
+	}
+
+	add := func(rows []dayCount, field func(*Point) *int64) {
+		for _, row := range rows {
+			if i, ok := index[bucket(row.Day.Time, interval)]; ok {
+				*field(&points[i]) += row.Count
+			}
+		}
+	}
+	add(views, func(p *Point) *int64 { return &p.Views })
+	add(likes, func(p *Point) *int64 { return &p.Likes })
+	add(comments, func(p *Point) *int64 { return &p.Comments })
+
+	return points, nil
+}
+
+// TopPosts 返回范围内浏览量最高的文章
+func TopPosts(s Scope, limit int) ([]TopPost, error) {
+	top := []TopPost{}
+	q := database.DB.Model(&models.PostView{}).
+		Select("post_views.post_id, posts.title, SUM(post_views.views) AS views").
+		Joins("JOIN posts ON posts.id = post_views.post_id AND posts.deleted_at IS NULL").
+		Where("post_views.date BETWEEN ? AND ?", s.From, s.To)
+	if s.AuthorID != nil {
+		q = q.Where("posts.user_id = ?", *s.AuthorID)
+	}
+	err := q.Group("post_views.post_id, posts.title").Order("views desc").Limit(limit).Scan(&top).Error
+	return top, err
+}
+
+// TopReferrers 返回范围内的主要来源域名
+func TopReferrers(s Scope, limit int) ([]ReferrerCount, error) {
+	referrers := []ReferrerCount{}
+	q := database.DB.Model(&models.PostReferrer{}).Select("referrer, SUM(views) AS views").
+		Where("date BETWEEN ? AND ?", s.From, s.To)
+	if s.restricted() {
+		q = q.Where("post_id IN (?)", s.postIDs())
+	}
+	err := q.Group("referrer").Order("views desc").Limit(limit).Scan(&referrers).Error
+	return referrers, err
+}
+
+// TotalViews 返回每篇文章累计的浏览量
+func TotalViews(postIDs []uint) (map[uint]int64, error) {
+	var rows []struct {
+		PostID uint
+		Views  int64
+	}
+	if err := database.DB.Model(&models.PostView{}).Select("post_id, SUM(views) AS views").
+		Where("post_id IN ?", postIDs).Group("post_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	totals := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		totals[row.PostID] = row.Views
+	}
+	return totals, nil
+}
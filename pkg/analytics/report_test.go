@@ -0,0 +1,114 @@
+package analytics
+
+import (
+	"fmt"
+	"goblog/database/databasetest"
+	"goblog/models"
+	"testing"
+	"time"
+)
+
+func TestReports(t *testing.T) {
+	databasetest.ForEach(t, func(t *testing.T, driver string) {
+		db := databasetest.Open(t, driver)
+
+		author := models.User{Username: "author", Email: "author@example.com", Password: "hash"}
+		other := models.User{Username: "other", Email: "other@example.com", Password: "hash"}
+		for _, u := range []*models.User{&author, &other} {
+			if err := db.Create(u).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+		post := models.Post{Title: "文章", Content: "正文", UserID: author.ID, Status: models.PostPublished}
+		others := models.Post{Title: "别人的文章", Content: "正文", UserID: other.ID, Status: models.PostPublished}
+		for _, p := range []*models.Post{&post, &others} {
+			if err := db.Create(p).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		monday := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+		tuesday := monday.AddDate(0, 0, 1)
+
+		// 重复落库保留较大的值，不会累加
+		for _, save := range []struct {
+			day    time.Time
+			counts map[string]string
+		}{
+			{monday, map[string]string{fmt.Sprint(post.ID): "5"}},
+			{monday, map[string]string{fmt.Sprint(post.ID): "3"}},
+			{tuesday, map[string]string{fmt.Sprint(post.ID): "2", fmt.Sprint(others.ID): "4"}},
+		} {
+			if err := saveViews(save.day, save.counts); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, counts := range []map[string]string{
+			{fmt.Sprintf("%d|example.com", post.ID): "4"},
+			{fmt.Sprintf("%d|example.com", post.ID): "6", fmt.Sprintf("%d|news.com", others.ID): "1"},
+		} {
+			if err := saveReferrers(monday, counts); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// 周一深夜的点赞和周二凌晨的评论按 UTC 日期统计，不受数据库会话时区影响
+		if err := db.Create(&models.Like{UserID: other.ID, TargetID: post.ID, TargetType: "post", CreatedAt: monday.Add(23*time.Hour + 30*time.Minute)}).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Create(&models.Comment{Content: "评论", UserID: other.ID, PostID: post.ID, CreatedAt: tuesday.Add(30 * time.Minute)}).Error; err != nil {
+			t.Fatal(err)
+		}
+		// 以 UTC+8 写入的周二早上 7 点是 UTC 的周一 23 点
+		shanghai := time.FixedZone("UTC+8", 8*60*60)
+		if err := db.Create(&models.Like{UserID: author.ID, TargetID: post.ID, TargetType: "post", CreatedAt: time.Date(2024, 3, 5, 7, 0, 0, 0, shanghai)}).Error; err != nil {
+			t.Fatal(err)
+		}
+
+		scope := Scope{AuthorID: &author.ID, From: monday, To: tuesday}
+		for _, c := range []struct {
+			interval string
+			want     []Point
+		}{
+			{IntervalDay, []Point{{"2024-03-04", 5, 2, 0}, {"2024-03-05", 2, 0, 1}}},
+			{IntervalWeek, []Point{{"2024-03-04", 7, 2, 1}}},
+			{IntervalMonth, []Point{{"2024-03", 7, 2, 1}}},
+		} {
+			points, err := Series(scope, c.interval)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fmt.Sprint(points) != fmt.Sprint(c.want) {
+				t.Errorf("Series(%s) = %v，期望 %v", c.interval, points, c.want)
+			}
+		}
+
+		site := Scope{From: monday, To: tuesday}
+		top, err := TopPosts(site, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []TopPost{{post.ID, post.Title, 7}, {others.ID, others.Title, 4}}; fmt.Sprint(top) != fmt.Sprint(want) {
+			t.Errorf("TopPosts = %v，期望 %v", top, want)
+		}
+		if top, _ := TopPosts(scope, 10); len(top) != 1 || top[0].PostID != post.ID {
+			t.Errorf("限定作者时 TopPosts = %v", top)
+		}
+
+		referrers, err := TopReferrers(site, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []ReferrerCount{{"example.com", 6}, {"news.com", 1}}; fmt.Sprint(referrers) != fmt.Sprint(want) {
+			t.Errorf("TopReferrers = %v，期望 %v", referrers, want)
+		}
+
+		totals, err := TotalViews([]uint{post.ID, others.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := map[uint]int64{post.ID: 7, others.ID: 4}; fmt.Sprint(totals) != fmt.Sprint(want) {
+			t.Errorf("TotalViews = %v，期望 %v", totals, want)
+		}
+	})
+}
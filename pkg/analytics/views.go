@@ -0,0 +1,172 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"goblog/database"
+	"goblog/models"
+	"goblog/pkg/cache"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// DedupWindow 同一访客在该时间窗口内重复访问同一篇文章只计一次
+	DedupWindow   = 30 * time.Minute
+	FlushInterval = time.Minute
+
+	// 每天的累计浏览量和来源保存在按天（UTC）划分的 hash 中，保留到第二天结束后才过期，
+	// 落库时写入当天的累计值，重复落库或多个副本同时落库结果都一样
+	viewsKeyPrefix     = "analytics:views:"
+	referrersKeyPrefix = "analytics:referrers:"
+	dayKeyTTL          = 48 * time.Hour
+	flushLockKey       = "analytics:flush:lock"
+	dateLayout         = "2006-01-02"
+)
+
+// RecordView 记录一次文章浏览，先累加到 Redis 中当天的计数，由 flusher 定期落库。
+// 返回 false 表示该访客在去重窗口内已经浏览过
+func RecordView(postID uint, visitor, referer string) bool {
+	seenKey := fmt.Sprintf("analytics:seen:%d:%s", postID, visitor)
+	fresh, err := cache.Rdb.SetNX(cache.Ctx, seenKey, 1, DedupWindow).Result()
+	if err != nil {
+		slog.Warn("analytics: 记录浏览失败", "post_id", postID, "err", err)
+		return false
+	}
+	if !fresh {
+		return false
+	}
+
+	day := time.Now().UTC().Format(dateLayout)
+	pipe := cache.Rdb.Pipeline()
+	pipe.HIncrBy(cache.Ctx, viewsKeyPrefix+day, strconv.FormatUint(uint64(postID), 10), 1)
+	pipe.Expire(cache.Ctx, viewsKeyPrefix+day, dayKeyTTL)
+	pipe.HIncrBy(cache.Ctx, referrersKeyPrefix+day, fmt.Sprintf("%d|%s", postID, referrerHost(referer)), 1)
+	pipe.Expire(cache.Ctx, referrersKeyPrefix+day, dayKeyTTL)
+	if _, err := pipe.Exec(cache.Ctx); err != nil {
+		slog.Warn("analytics: 记录浏览失败", "post_id", postID, "err", err)
+		return false
+	}
+	return true
+}
+
+func referrerHost(referer string) string {
+	if referer == "" {
+		return "direct"
+	}
+	u, err := url.Parse(referer)
+	if err != nil || u.Host == "" {
+		return "direct"
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// RunFlusher 定期把 Redis 中缓冲的浏览数据批量写入数据库，阻塞直到 ctx 取消，退出前做最后一次写入
+func RunFlusher(ctx context.Context) {
+	ticker := time.NewTicker(FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			Flush()
+			return
+		case <-ticker.C:
+			Flush()
+		}
+	}
+}
+
+// Flush 把今天和昨天（UTC）的累计数据写入数据库。多个副本同时运行时同一时刻只有一个在落库
+func Flush() {
+	token := strconv.FormatInt(time.Now().UnixNano(), 36)
+	ok, err := cache.Rdb.SetNX(cache.Ctx, flushLockKey, token, FlushInterval/2).Result()
+	if err != nil {
+		slog.Error("analytics: 获取落库锁失败", "err", err)
+		return
+	}
+	if !ok {
+		return
+	}
+	defer unlockScript.Run(cache.Ctx, cache.Rdb, []string{flushLockKey}, token)
+
+	now := time.Now().UTC()
+	for _, day := range []time.Time{now.AddDate(0, 0, -1), now} {
+		if err := flush(viewsKeyPrefix, day, saveViews); err != nil {
+			slog.Error("analytics: 写入浏览量失败", "date", day.Format(dateLayout), "err", err)
+		}
+		if err := flush(referrersKeyPrefix, day, saveReferrers); err != nil {
+			slog.Error("analytics: 写入来源统计失败", "date", day.Format(dateLayout), "err", err)
+		}
+	}
+}
+
+// unlockScript 只删除自己持有的锁，落库超过锁的有效期时不会删掉其他副本的锁
+var unlockScript = redis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) end return 0`)
+
+// flush 读取一天的累计数据交给 save 落库，数据留在 Redis 中，当天后续的浏览继续累加
+func flush(prefix string, day time.Time, save func(time.Time, map[string]string) error) error {
+	date, _ := time.Parse(dateLayout, day.Format(dateLayout))
+	counts, err := cache.Rdb.HGetAll(cache.Ctx, prefix+day.Format(dateLayout)).Result()
+	if err != nil || len(counts) == 0 {
+		return err
+	}
+	return save(date, counts)
+}
+
+// keepMax 在冲突时保留较大的值：Redis 中的数据丢失后重新计数时，不会把已落库的浏览量改小
+func keepMax(table string) clause.Set {
+	column := table + ".views"
+	return clause.Assignments(map[string]any{"views": gorm.Expr(fmt.Sprintf("CASE WHEN %[1]s > %[2]s THEN %[1]s ELSE %[2]s END",
+		database.Excluded("views"), column))})
+}
+
+func saveViews(date time.Time, counts map[string]string) error {
+	rows := make([]models.PostView, 0, len(counts))
+	for field, value := range counts {
+		postID, err1 := strconv.ParseUint(field, 10, 64)
+		views, err2 := strconv.ParseInt(value, 10, 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		rows = append(rows, models.PostView{PostID: uint(postID), Date: date, Views: views})
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	return database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "post_id"}, {Name: "date"}},
+		DoUpdates: keepMax("post_views"),
+	}).CreateInBatches(rows, 500).Error
+}
+
+func saveReferrers(date time.Time, counts map[string]string) error {
+	rows := make([]models.PostReferrer, 0, len(counts))
+	for field, value := range counts {
+		parts := strings.SplitN(field, "|", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		postID, err1 := strconv.ParseUint(parts[0], 10, 64)
+		views, err2 := strconv.ParseInt(value, 10, 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		rows = append(rows, models.PostReferrer{PostID: uint(postID), Date: date, Referrer: parts[1], Views: views})
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	return database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "post_id"}, {Name: "date"}, {Name: "referrer"}},
+		DoUpdates: keepMax("post_referrers"),
+	}).CreateInBatches(rows, 500).Error
+}
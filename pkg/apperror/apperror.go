@@ -0,0 +1,75 @@
+// Package apperror 定义带稳定错误码的应用错误，由 middlewares.ErrorHandler 渲染为 RFC 7807 problem+json。
+package apperror
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Error 是返回给客户端的错误。Code 是稳定的机器可读错误码，同时也是消息目录的 key；
+// Err 是内部原因，只写日志，不会返回给客户端。
+type Error struct {
+	Status int
+	Code   string
+	Params map[string]string
+	Fields []FieldError
+	Err    error
+}
+
+// FieldError 是请求参数中某个字段的校验错误
+type FieldError struct {
+	Field string
+	Rule  string
+	Param string
+	kind  string // 字段类型，区分字符串长度和数值大小的提示
+}
+
+func New(status int, code string) *Error {
+	return &Error{Status: status, Code: code}
+}
+
+func BadRequest(code string) *Error   { return New(http.StatusBadRequest, code) }
+func Unauthorized(code string) *Error { return New(http.StatusUnauthorized, code) }
+func Forbidden(code string) *Error    { return New(http.StatusForbidden, code) }
+func NotFound(code string) *Error     { return New(http.StatusNotFound, code) }
+func Conflict(code string) *Error     { return New(http.StatusConflict, code) }
+
+// Internal 包装未预期的错误，客户端只会看到通用的提示
+func Internal(err error) *Error {
+	return &Error{Status: http.StatusInternalServerError, Code: CodeInternal, Err: err}
+}
+
+// With 设置消息模板中的参数，例如 {event}
+func (e *Error) With(key, value string) *Error {
+	if e.Params == nil {
+		e.Params = map[string]string{}
+	}
+	e.Params[key] = value
+	return e
+}
+
+// Wrap 记录内部原因
+func (e *Error) Wrap(err error) *Error {
+	e.Err = err
+	return e
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Code + ": " + e.Err.Error()
+	}
+	return e.Code
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// From 把任意错误转换为 *Error，非 *Error 的错误视为内部错误
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return Internal(err)
+}
@@ -0,0 +1,106 @@
+package apperror
+
+// 错误码一旦发布就不应修改，客户端可以依赖它们做判断
+const (
+	CodeBadRequest    = "bad_request"
+	CodeInvalidBody   = "invalid_body"
+	CodeValidation    = "validation_failed"
+	CodeInternal      = "internal_error"
+	CodeRouteNotFound = "route_not_found"
+
+	CodeTokenMissing   = "auth.token_missing"
+	CodeTokenMalformed = "auth.token_malformed"
+	CodeTokenInvalid   = "auth.token_invalid"
+	CodeUserNotFound   = "auth.user_not_found"
+	CodeWrongPassword  = "auth.wrong_password"
+	CodeUserExists     = "user.exists"
+	CodeInvalidUserID  = "user.invalid_id"
+	CodeUserNotExist   = "user.not_found"
+	CodeUserBanned     = "auth.user_banned"
+	CodeAccountDeleted = "auth.account_deleted"
+
+	CodeInvalidPostID    = "post.invalid_id"
+	CodePostNotFound     = "post.not_found"
+	CodePostEditDenied   = "post.edit_forbidden"
+	CodePostDeleteDenied = "post.delete_forbidden"
+	CodeNotInTrash       = "post.not_in_trash"
+	CodeRestoreDenied    = "post.restore_forbidden"
+	CodeInvalidSort      = "post.invalid_sort"
+	CodeInvalidWindow    = "post.invalid_window"
+
+	CodeTagNotFound       = "tag.not_found"
+	CodeInvalidTagID      = "tag.invalid_id"
+	CodeTagExists         = "tag.exists"
+	CodeCommentPostNeeded = "comment.post_id_required"
+	CodeInvalidCommentID  = "comment.invalid_id"
+	CodeCommentNotFound   = "comment.not_found"
+
+	CodeLikeTargetRequired = "like.target_required"
+
+	CodeFollowSelf           = "follow.self"
+	CodeFollowTargetRequired = "follow.target_required"
+	CodeInvalidCursor        = "feed.invalid_cursor"
+
+	CodeInvalidCollectionID = "collection.invalid_id"
+	CodeCollectionNotFound  = "collection.not_found"
+
+	CodeInvalidDate       = "analytics.invalid_date"
+	CodeInvalidRange      = "analytics.invalid_range"
+	CodeInvalidInterval   = "analytics.invalid_interval"
+	CodeAnalyticsDenied   = "analytics.forbidden"
+	CodeSiteAnalyticsOnly = "analytics.site_forbidden"
+
+	CodeInvalidWebhookID  = "webhook.invalid_id"
+	CodeWebhookNotFound   = "webhook.not_found"
+	CodeUnsupportedEvent  = "webhook.unsupported_event"
+	CodeInvalidWebhookURL = "webhook.invalid_url"
+	CodeInvalidDeliveryID = "webhook.invalid_delivery_id"
+	CodeDeliveryNotFound  = "webhook.delivery_not_found"
+
+	CodeCollaboratorManageDenied = "collaborator.manage_forbidden"
+	CodeCollaboratorViewDenied   = "collaborator.view_forbidden"
+	CodeCollaboratorRemoveDenied = "collaborator.remove_forbidden"
+	CodeCollaboratorNotFound     = "collaborator.not_found"
+	CodeInviteSelf               = "collaborator.invite_owner"
+	CodeAlreadyInvited           = "collaborator.already_invited"
+	CodeInvalidInvitationID      = "invitation.invalid_id"
+	CodeInvitationNotFound       = "invitation.not_found"
+
+	CodeInvalidTransition   = "workflow.invalid_transition"
+	CodeTransitionDenied    = "workflow.forbidden"
+	CodePostInReview        = "workflow.post_in_review"
+	CodeStatusChanged       = "workflow.status_changed"
+	CodeReviewDenied        = "review.forbidden"
+	CodeInvalidAnchor       = "review.invalid_anchor"
+	CodeInvalidReviewID     = "review.invalid_comment_id"
+	CodeReviewCommentAbsent = "review.comment_not_found"
+
+	CodeInvalidSeriesID   = "series.invalid_id"
+	CodeSeriesNotFound    = "series.not_found"
+	CodeSeriesPostDenied  = "series.post_forbidden"
+	CodeSeriesPostTaken   = "series.post_taken"
+	CodeSeriesPostAbsent  = "series.post_not_found"
+	CodeSeriesOrderDenied = "series.invalid_order"
+
+	CodeAdminRequired = "admin.forbidden"
+	CodeBanAdmin      = "admin.ban_admin"
+	CodeChangeOwnRole = "admin.own_role"
+	CodeInvalidDays   = "admin.invalid_days"
+
+	CodeInvalidAuditTime = "audit.invalid_time"
+
+	CodeInvalidExportID   = "export.invalid_id"
+	CodeExportNotFound    = "export.not_found"
+	CodeExportNotReady    = "export.not_ready"
+	CodeDeletionPending   = "account.deletion_pending"
+	CodeDeletionNotFound  = "account.deletion_not_found"
+	CodeDeletionStarted   = "account.deletion_started"
+	CodeInvalidTransferTo = "account.invalid_transfer_target"
+
+	CodeImportFileRequired  = "import.file_required"
+	CodeImportFileTooLarge  = "import.file_too_large"
+	CodeImportContentLarge  = "import.content_too_large"
+	CodeInvalidImportFormat = "import.invalid_format"
+	CodeInvalidImportFile   = "import.invalid_file"
+	CodeInvalidImportUser   = "import.invalid_user"
+)
@@ -0,0 +1,349 @@
+package apperror
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	LangZH      = "zh-CN"
+	LangEN      = "en"
+	DefaultLang = LangZH
+)
+
+var messages = map[string]map[string]string{
+	LangZH: {
+		CodeBadRequest:    "请求参数错误",
+		CodeInvalidBody:   "请求体格式错误",
+		CodeValidation:    "请求参数校验失败",
+		CodeInternal:      "服务器内部错误，请稍后重试",
+		CodeRouteNotFound: "接口不存在",
+
+		CodeTokenMissing:   "未提供认证的token",
+		CodeTokenMalformed: "Token 格式错误",
+		CodeTokenInvalid:   "无效或过期的 Token",
+		CodeUserNotFound:   "用户不存在",
+		CodeWrongPassword:  "密码错误",
+		CodeUserExists:     "用户名或邮箱已存在",
+		CodeUserBanned:     "账号已被封禁",
+		CodeAccountDeleted: "账号已注销",
+		CodeInvalidUserID:  "无效的用户 ID",
+		CodeUserNotExist:   "用户不存在",
+
+		CodeInvalidPostID:    "无效的文章 ID",
+		CodePostNotFound:     "文章未找到",
+		CodePostEditDenied:   "无权修改该文章",
+		CodePostDeleteDenied: "无权删除该文章",
+		CodeNotInTrash:       "回收站中没有该文章",
+		CodeRestoreDenied:    "只有作者可以恢复该文章",
+		CodeInvalidSort:      "sort 只能是 new、hot、top 或 recommended",
+		CodeInvalidWindow:    "window 只能是 day、week、month 或 all",
+
+		CodeTagNotFound:       "标签未找到",
+		CodeInvalidTagID:      "无效的标签 ID",
+		CodeTagExists:         "同名标签已存在",
+		CodeCommentPostNeeded: "必须提供有效的 post_id 参数",
+		CodeInvalidCommentID:  "无效的评论 ID",
+		CodeCommentNotFound:   "评论未找到",
+
+		CodeLikeTargetRequired: "缺少 target_id 或 target_type",
+
+		CodeFollowSelf:           "不能关注自己",
+		CodeFollowTargetRequired: "缺少 target_id 或 target_type",
+		CodeInvalidCursor:        "cursor 无效",
+
+		CodeInvalidCollectionID: "无效的收藏夹 ID",
+		CodeCollectionNotFound:  "收藏夹未找到",
+
+		CodeInvalidDate:       "{param} 日期格式应为 YYYY-MM-DD",
+		CodeInvalidRange:      "日期范围无效，最长一年",
+		CodeInvalidInterval:   "interval 只能是 day、week 或 month",
+		CodeAnalyticsDenied:   "无权查看该文章的统计数据",
+		CodeSiteAnalyticsOnly: "只有管理员可以查看全站数据",
+
+		CodeInvalidWebhookID:  "无效的 webhook ID",
+		CodeWebhookNotFound:   "webhook 未找到",
+		CodeUnsupportedEvent:  "不支持的事件类型: {event}",
+		CodeInvalidWebhookURL: "无效的订阅地址: {reason}",
+		CodeInvalidDeliveryID: "无效的投递记录 ID",
+		CodeDeliveryNotFound:  "投递记录未找到",
+
+		CodeCollaboratorManageDenied: "只有文章作者可以管理协作者",
+		CodeCollaboratorViewDenied:   "无权查看该文章的协作者",
+		CodeCollaboratorRemoveDenied: "无权移除该协作者",
+		CodeCollaboratorNotFound:     "协作者不存在",
+		CodeInviteSelf:               "不能邀请文章作者本人",
+		CodeAlreadyInvited:           "该用户已被邀请",
+		CodeInvalidInvitationID:      "无效的邀请 ID",
+		CodeInvitationNotFound:       "邀请不存在或已处理",
+
+		CodeInvalidTransition:   "当前状态不允许该操作",
+		CodeTransitionDenied:    "无权执行该操作",
+		CodePostInReview:        "文章正在审核中，请先撤回再修改标题和内容",
+		CodeStatusChanged:       "文章状态已被其他操作修改，请刷新后重试",
+		CodeReviewDenied:        "无权查看该文章的审稿信息",
+		CodeInvalidAnchor:       "anchor_start 和 anchor_end 必须同时提供且 start 不大于 end",
+		CodeInvalidReviewID:     "无效的批注 ID",
+		CodeReviewCommentAbsent: "批注未找到",
+
+		CodeInvalidSeriesID:   "无效的系列 ID",
+		CodeSeriesNotFound:    "系列未找到",
+		CodeSeriesPostDenied:  "只能添加自己的文章",
+		CodeSeriesPostTaken:   "该文章已属于某个系列",
+		CodeSeriesPostAbsent:  "该文章不在系列中",
+		CodeSeriesOrderDenied: "post_ids 必须恰好包含系列中的所有文章",
+
+		CodeAdminRequired: "只有管理员可以执行该操作",
+		CodeBanAdmin:      "不能封禁管理员，请先修改其角色",
+		CodeChangeOwnRole: "不能修改自己的角色",
+		CodeInvalidDays:   "days 必须在 1-365 之间",
+
+		CodeInvalidAuditTime: "{param} 应为 RFC 3339 格式的时间，如 2025-01-01T00:00:00Z",
+
+		CodeInvalidExportID:   "无效的导出 ID",
+		CodeExportNotFound:    "导出记录不存在或已过期",
+		CodeExportNotReady:    "导出文件还没有生成",
+		CodeDeletionPending:   "已经申请过注销",
+		CodeDeletionNotFound:  "没有待执行的注销申请",
+		CodeDeletionStarted:   "注销正在执行，无法撤销",
+		CodeInvalidTransferTo: "文章只能转给其他未注销的用户",
+
+		CodeImportFileRequired:  "请上传 WXR 文件或站点压缩包",
+		CodeImportFileTooLarge:  "导入文件不能超过 32MB，更大的站点请使用 goblog import 命令",
+		CodeImportContentLarge:  "导入内容过大：单个文件（压缩包中的按解压后计算）不能超过 64MB，全部文件不能超过 512MB",
+		CodeInvalidImportFormat: "format 只能是 auto、markdown、hugo、jekyll 或 wxr",
+		CodeInvalidImportFile:   "无法解析导入文件: {reason}",
+		CodeInvalidImportUser:   "user_id 对应的用户不存在或已注销",
+	},
+	LangEN: {
+		CodeBadRequest:    "Bad request",
+		CodeInvalidBody:   "Malformed request body",
+		CodeValidation:    "Request validation failed",
+		CodeInternal:      "Internal server error, please try again later",
+		CodeRouteNotFound: "No such endpoint",
+
+		CodeTokenMissing:   "Authentication token is missing",
+		CodeTokenMalformed: "Authorization header must be \"Bearer <token>\"",
+		CodeTokenInvalid:   "Token is invalid or expired",
+		CodeUserNotFound:   "User does not exist",
+		CodeWrongPassword:  "Incorrect password",
+		CodeUserExists:     "Username or email is already taken",
+		CodeUserBanned:     "This account has been banned",
+		CodeAccountDeleted: "This account has been deleted",
+		CodeInvalidUserID:  "Invalid user ID",
+		CodeUserNotExist:   "User not found",
+
+		CodeInvalidPostID:    "Invalid post ID",
+		CodePostNotFound:     "Post not found",
+		CodePostEditDenied:   "You are not allowed to edit this post",
+		CodePostDeleteDenied: "You are not allowed to delete this post",
+		CodeNotInTrash:       "Post not found in trash",
+		CodeRestoreDenied:    "Only the author can restore this post",
+		CodeInvalidSort:      "sort must be one of new, hot, top or recommended",
+		CodeInvalidWindow:    "window must be one of day, week, month or all",
+
+		CodeTagNotFound:       "Tag not found",
+		CodeInvalidTagID:      "Invalid tag ID",
+		CodeTagExists:         "A tag with this name already exists",
+		CodeCommentPostNeeded: "A valid post_id query parameter is required",
+		CodeInvalidCommentID:  "Invalid comment ID",
+		CodeCommentNotFound:   "Comment not found",
+
+		CodeLikeTargetRequired: "target_id and target_type are required",
+
+		CodeFollowSelf:           "You cannot follow yourself",
+		CodeFollowTargetRequired: "target_id and target_type are required",
+		CodeInvalidCursor:        "Invalid cursor",
+
+		CodeInvalidCollectionID: "Invalid collection ID",
+		CodeCollectionNotFound:  "Collection not found",
+
+		CodeInvalidDate:       "{param} must be a date in YYYY-MM-DD format",
+		CodeInvalidRange:      "Invalid date range, at most one year is allowed",
+		CodeInvalidInterval:   "interval must be one of day, week or month",
+		CodeAnalyticsDenied:   "You are not allowed to view analytics for this post",
+		CodeSiteAnalyticsOnly: "Only administrators can view site-wide analytics",
+
+		CodeInvalidWebhookID:  "Invalid webhook ID",
+		CodeWebhookNotFound:   "Webhook not found",
+		CodeUnsupportedEvent:  "Unsupported event type: {event}",
+		CodeInvalidWebhookURL: "Invalid webhook URL: {reason}",
+		CodeInvalidDeliveryID: "Invalid delivery ID",
+		CodeDeliveryNotFound:  "Delivery not found",
+
+		CodeCollaboratorManageDenied: "Only the post owner can manage collaborators",
+		CodeCollaboratorViewDenied:   "You are not allowed to view collaborators of this post",
+		CodeCollaboratorRemoveDenied: "You are not allowed to remove this collaborator",
+		CodeCollaboratorNotFound:     "Collaborator not found",
+		CodeInviteSelf:               "The post owner cannot be invited",
+		CodeAlreadyInvited:           "This user has already been invited",
+		CodeInvalidInvitationID:      "Invalid invitation ID",
+		CodeInvitationNotFound:       "Invitation not found or already handled",
+
+		CodeInvalidTransition:   "This action is not allowed in the current state",
+		CodeTransitionDenied:    "You are not allowed to perform this action",
+		CodePostInReview:        "The post is in review; withdraw it before editing the title or content",
+		CodeStatusChanged:       "The post status was changed by another request; reload and try again",
+		CodeReviewDenied:        "You are not allowed to view the review of this post",
+		CodeInvalidAnchor:       "anchor_start and anchor_end must be given together and start must not exceed end",
+		CodeInvalidReviewID:     "Invalid review comment ID",
+		CodeReviewCommentAbsent: "Review comment not found",
+
+		CodeInvalidSeriesID:   "Invalid series ID",
+		CodeSeriesNotFound:    "Series not found",
+		CodeSeriesPostDenied:  "You can only add your own posts",
+		CodeSeriesPostTaken:   "This post already belongs to a series",
+		CodeSeriesPostAbsent:  "This post is not in the series",
+		CodeSeriesOrderDenied: "post_ids must contain exactly the posts of the series",
+
+		CodeAdminRequired: "Only administrators can perform this action",
+		CodeBanAdmin:      "Administrators cannot be banned, change their role first",
+		CodeChangeOwnRole: "You cannot change your own role",
+		CodeInvalidDays:   "days must be between 1 and 365",
+
+		CodeInvalidAuditTime: "{param} must be an RFC 3339 timestamp, e.g. 2025-01-01T00:00:00Z",
+
+		CodeInvalidExportID:   "Invalid export ID",
+		CodeExportNotFound:    "Export not found or expired",
+		CodeExportNotReady:    "The export is not ready yet",
+		CodeDeletionPending:   "Account deletion has already been requested",
+		CodeDeletionNotFound:  "No pending account deletion",
+		CodeDeletionStarted:   "Account deletion is in progress and can no longer be cancelled",
+		CodeInvalidTransferTo: "Posts can only be transferred to another active user",
+
+		CodeImportFileRequired:  "Please upload a WXR file or a site archive",
+		CodeImportFileTooLarge:  "Import files are limited to 32MB; use the goblog import command for larger sites",
+		CodeImportContentLarge:  "Import content is too large: each file (uncompressed, for archives) is limited to 64MB and all files to 512MB",
+		CodeInvalidImportFormat: "format must be one of auto, markdown, hugo, jekyll or wxr",
+		CodeInvalidImportFile:   "Unable to parse the import file: {reason}",
+		CodeInvalidImportUser:   "The user_id does not exist or the account has been deleted",
+	},
+}
+
+// fieldMessages 按校验规则给出字段错误提示，{field} 为字段名，{param} 为规则参数
+var fieldMessages = map[string]map[string]string{
+	LangZH: {
+		"required":   "{field} 为必填项",
+		"email":      "{field} 必须是有效的邮箱地址",
+		"url":        "{field} 必须是有效的 URL",
+		"min":        "{field} 不能小于 {param}",
+		"max":        "{field} 不能大于 {param}",
+		"min.string": "{field} 长度不能少于 {param} 个字符",
+		"max.string": "{field} 长度不能超过 {param} 个字符",
+		"oneof":      "{field} 只能是 {param} 之一",
+		"type":       "{field} 的类型不正确",
+		"":           "{field} 不合法",
+	},
+	LangEN: {
+		"required":   "{field} is required",
+		"email":      "{field} must be a valid email address",
+		"url":        "{field} must be a valid URL",
+		"min":        "{field} must be at least {param}",
+		"max":        "{field} must be at most {param}",
+		"min.string": "{field} must be at least {param} characters long",
+		"max.string": "{field} must be at most {param} characters long",
+		"oneof":      "{field} must be one of: {param}",
+		"type":       "{field} has the wrong type",
+		"":           "{field} is invalid",
+	},
+}
+
+var zhTitles = map[int]string{
+	http.StatusBadRequest:          "请求错误",
+	http.StatusUnauthorized:        "未认证",
+	http.StatusForbidden:           "无权访问",
+	http.StatusNotFound:            "资源不存在",
+	http.StatusMethodNotAllowed:    "方法不允许",
+	http.StatusConflict:            "资源冲突",
+	http.StatusUnprocessableEntity: "无法处理的请求",
+	http.StatusTooManyRequests:     "请求过于频繁",
+	http.StatusInternalServerError: "服务器内部错误",
+	http.StatusServiceUnavailable:  "服务不可用",
+}
+
+// Message 返回错误码在指定语言下的消息，找不到时依次回退到默认语言和错误码本身
+func Message(lang, code string, params map[string]string) string {
+	msg, ok := messages[lang][code]
+	if !ok {
+		if msg, ok = messages[DefaultLang][code]; !ok {
+			msg = code
+		}
+	}
+	return expand(msg, params)
+}
+
+func fieldMessage(lang string, f FieldError) string {
+	table := fieldMessages[lang]
+	if table == nil {
+		table = fieldMessages[DefaultLang]
+	}
+
+	msg, ok := table[f.Rule+"."+f.kind]
+	if !ok {
+		if msg, ok = table[f.Rule]; !ok {
+			msg = table[""]
+		}
+	}
+	return expand(msg, map[string]string{"field": f.Field, "param": f.Param})
+}
+
+// Title 返回 HTTP 状态码的简短说明
+func Title(lang string, status int) string {
+	if lang == LangZH {
+		if title, ok := zhTitles[status]; ok {
+			return title
+		}
+	}
+	return http.StatusText(status)
+}
+
+func expand(msg string, params map[string]string) string {
+	if len(params) == 0 {
+		return msg
+	}
+	pairs := make([]string, 0, len(params)*2)
+	for k, v := range params {
+		pairs = append(pairs, "{"+k+"}", v)
+	}
+	return strings.NewReplacer(pairs...).Replace(msg)
+}
+
+// MatchLanguage 根据 Accept-Language 选择支持的语言，按 q 值从高到低匹配
+func MatchLanguage(header string) string {
+	type candidate struct {
+		tag string
+		q   float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{strings.ToLower(tag), q})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+
+	for _, c := range candidates {
+		switch {
+		case c.tag == "zh" || strings.HasPrefix(c.tag, "zh-"):
+			return LangZH
+		case c.tag == "en" || strings.HasPrefix(c.tag, "en-"):
+			return LangEN
+		case c.tag == "*":
+			return DefaultLang
+		}
+	}
+	return DefaultLang
+}
@@ -0,0 +1,45 @@
+package apperror
+
+// ProblemContentType 是 RFC 7807 规定的响应类型
+const ProblemContentType = "application/problem+json"
+
+// Problem 是 RFC 7807 problem details，code、errors 和 request_id 为扩展字段
+type Problem struct {
+	Type     string         `json:"type"`
+	Title    string         `json:"title"`
+	Status   int            `json:"status"`
+	Detail   string         `json:"detail"`
+	Instance string         `json:"instance,omitempty"`
+	Code     string         `json:"code"`
+	Errors   []InvalidParam `json:"errors,omitempty"`
+	// RequestID 与响应头 X-Request-ID 相同，方便按它查日志
+	RequestID string `json:"request_id,omitempty"`
+}
+
+type InvalidParam struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// Problem 按语言渲染错误，instance 一般为请求路径
+func (e *Error) Problem(lang, instance string) Problem {
+	p := Problem{
+		Type:     "urn:goblog:error:" + e.Code,
+		Title:    Title(lang, e.Status),
+		Status:   e.Status,
+		Detail:   Message(lang, e.Code, e.Params),
+		Instance: instance,
+		Code:     e.Code,
+	}
+	for _, f := range e.Fields {
+		p.Errors = append(p.Errors, InvalidParam{
+			Field:   f.Field,
+			Rule:    f.Rule,
+			Param:   f.Param,
+			Message: fieldMessage(lang, f),
+		})
+	}
+	return p
+}
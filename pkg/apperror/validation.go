@@ -0,0 +1,65 @@
+package apperror
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	// 校验错误中的字段名使用 json tag，与请求体保持一致
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return field.Name
+			}
+			return name
+		})
+	}
+}
+
+// FromBind 把 ShouldBind 系列方法返回的错误转换为带字段信息的校验错误
+func FromBind(err error) *Error {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		appErr := BadRequest(CodeValidation).Wrap(err)
+		for _, fe := range validationErrs {
+			kind := ""
+			if fe.Kind() == reflect.String || fe.Kind() == reflect.Slice || fe.Kind() == reflect.Map {
+				kind = "string"
+			}
+			appErr.Fields = append(appErr.Fields, FieldError{
+				Field: fieldPath(fe.Namespace()),
+				Rule:  fe.Tag(),
+				Param: fe.Param(),
+				kind:  kind,
+			})
+		}
+		return appErr
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		appErr := BadRequest(CodeValidation).Wrap(err)
+		appErr.Fields = []FieldError{{Field: typeErr.Field, Rule: "type"}}
+		return appErr
+	}
+
+	return BadRequest(CodeInvalidBody).Wrap(err)
+}
+
+// fieldPath 去掉命名空间中的结构体名，CreatePostInput.title -> title
+func fieldPath(namespace string) string {
+	if _, rest, ok := strings.Cut(namespace, "."); ok {
+		return rest
+	}
+	return namespace
+}
@@ -0,0 +1,135 @@
+// Package audit 记录删除、权限变更、内容管理和登录等操作的审计日志。
+// 日志只追加：这里只提供写入和查询，不提供修改和删除的入口
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"goblog/database"
+	"goblog/models"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ActionLogin       = "auth.login"
+	ActionLoginFailed = "auth.login_failed"
+
+	ActionUserRole  = "user.role"
+	ActionUserBan   = "user.ban"
+	ActionUserUnban = "user.unban"
+
+	ActionAccountDeletionRequest = "account.deletion_request"
+	ActionAccountDeletionCancel  = "account.deletion_cancel"
+	ActionAccountDelete          = "account.delete"
+
+	ActionPostUpdate    = "post.update"
+	ActionPostDelete    = "post.delete"
+	ActionPostRestore   = "post.restore"
+	ActionPostPurge     = "post.purge"
+	ActionCommentUpdate = "comment.update"
+	ActionCommentDelete = "comment.delete"
+	ActionTagRename     = "tag.rename"
+	ActionTagDelete     = "tag.delete"
+
+	ActionCollaboratorInvite = "collaborator.invite"
+	ActionCollaboratorRemove = "collaborator.remove"
+
+	ActionSeriesDelete     = "series.delete"
+	ActionCollectionDelete = "collection.delete"
+	ActionWebhookDelete    = "webhook.delete"
+
+	ActionImport = "import.run"
+)
+
+// Entry 是一条待写入的审计日志。Before/After 会序列化为 JSON 保存，为 nil 时不保存
+type Entry struct {
+	ActorID    uint // 0 表示匿名操作或后台任务
+	Action     string
+	TargetType string
+	TargetID   uint
+	Before     any
+	After      any
+	IP         string
+	UserAgent  string
+	RequestID  string
+}
+
+// Record 在操作完成后同步写入审计日志。写入失败只记错误日志，不影响已经完成的操作
+func Record(ctx context.Context, e Entry) {
+	entry := models.AuditLog{
+		Action:     e.Action,
+		TargetType: e.TargetType,
+		TargetID:   e.TargetID,
+		Before:     snapshot(ctx, e.Before),
+		After:      snapshot(ctx, e.After),
+		IP:         e.IP,
+		UserAgent:  e.UserAgent,
+		RequestID:  e.RequestID,
+	}
+	if e.ActorID != 0 {
+		entry.ActorID = &e.ActorID
+	}
+	if err := database.DB.WithContext(ctx).Create(&entry).Error; err != nil {
+		slog.ErrorContext(ctx, "写入审计日志失败", "action", e.Action, "target_type", e.TargetType, "target_id", e.TargetID, "err", err)
+	}
+}
+
+func snapshot(ctx context.Context, v any) models.JSONText {
+	if v == nil {
+		return ""
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		slog.WarnContext(ctx, "审计快照序列化失败", "err", err)
+		return ""
+	}
+	return models.JSONText(data)
+}
+
+// Query 是审计日志的筛选条件，零值表示不按该条件筛选，From 包含、To 不包含
+type Query struct {
+	ActorID    uint
+	Action     string
+	TargetType string
+	TargetID   uint
+	From       time.Time
+	To         time.Time
+	Offset     int
+	Limit      int
+}
+
+// List 按条件分页返回审计日志，新的在前，同时返回符合条件的总数
+func List(ctx context.Context, q Query) ([]models.AuditLog, int64, error) {
+	db := database.DB.WithContext(ctx).Model(&models.AuditLog{})
+	if q.ActorID != 0 {
+		db = db.Where("actor_id = ?", q.ActorID)
+	}
+	if q.Action != "" {
+		db = db.Where("action = ?", q.Action)
+	}
+	if q.TargetType != "" {
+		db = db.Where("target_type = ?", q.TargetType)
+	}
+	if q.TargetID != 0 {
+		db = db.Where("target_id = ?", q.TargetID)
+	}
+	if !q.From.IsZero() {
+		db = db.Where("created_at >= ?", q.From)
+	}
+	if !q.To.IsZero() {
+		db = db.Where("created_at < ?", q.To)
+	}
+	// Count 和 Find 共用筛选条件，用新会话避免 Count 的 SELECT 影响 Find
+	db = db.Session(&gorm.Session{})
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var logs []models.AuditLog
+	err := db.Order("id desc").Offset(q.Offset).Limit(q.Limit).Find(&logs).Error
+	return logs, total, err
+}
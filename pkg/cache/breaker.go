@@ -0,0 +1,150 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrUnavailable 表示 Redis 已熔断，命令没有发送给 Redis
+var ErrUnavailable = errors.New("redis 不可用，已熔断")
+
+// breaker 是包在 Redis 客户端上的熔断器：连续失败 threshold 次后拒绝所有命令，
+// 直到后台重连（见 RunReconnector）成功。服务端返回的错误（如 redis.Nil、WRONGTYPE）不算失败
+type breaker struct {
+	mu        sync.Mutex
+	threshold int
+	failures  int
+	open      bool
+	openedAt  time.Time
+	lastErr   error
+	trips     uint64
+	rejected  uint64
+}
+
+func newBreaker(threshold int) *breaker {
+	return &breaker{threshold: threshold}
+}
+
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.open {
+		b.rejected++
+	}
+	return !b.open
+}
+
+// record 根据命令结果更新连续失败次数，达到阈值时熔断
+func (b *breaker) record(err error) {
+	if !isFailure(err) {
+		b.mu.Lock()
+		b.failures = 0
+		b.mu.Unlock()
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.lastErr = err
+	if !b.open && b.failures >= b.threshold {
+		b.trip()
+	}
+}
+
+// trip 打开熔断器，调用方需持有锁
+func (b *breaker) trip() {
+	b.open = true
+	b.openedAt = time.Now()
+	b.trips++
+	slog.Warn("cache: Redis 连续失败，进入降级模式", "failures", b.failures, "err", b.lastErr)
+}
+
+// forceOpen 直接熔断，用于启动时 Redis 就不可用的情况
+func (b *breaker) forceOpen(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.open {
+		b.lastErr = err
+		b.trip()
+	}
+}
+
+// reset 关闭熔断器，返回之前是否处于熔断状态
+func (b *breaker) reset() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	wasOpen := b.open
+	b.open = false
+	b.failures = 0
+	return wasOpen
+}
+
+func (b *breaker) isOpen() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.open
+}
+
+// isFailure 判断错误是否说明 Redis 本身不可用
+func isFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, ErrUnavailable) {
+		return false
+	}
+	var redisErr redis.Error
+	return !errors.As(err, &redisErr)
+}
+
+// probeKey 标记重连探测的请求，熔断期间只有它能发送到 Redis
+type probeKey struct{}
+
+func isProbe(ctx context.Context) bool {
+	probe, _ := ctx.Value(probeKey{}).(bool)
+	return probe
+}
+
+// hook 把熔断器接入 go-redis，所有通过 Rdb 发出的命令和 pipeline 都经过它
+type hook struct {
+	b *breaker
+}
+
+func (h hook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (h hook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if isProbe(ctx) {
+			return next(ctx, cmd)
+		}
+		if !h.b.allow() {
+			cmd.SetErr(ErrUnavailable)
+			return ErrUnavailable
+		}
+		err := next(ctx, cmd)
+		h.b.record(err)
+		return err
+	}
+}
+
+func (h hook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if !h.b.allow() {
+			for _, cmd := range cmds {
+				cmd.SetErr(ErrUnavailable)
+			}
+			return ErrUnavailable
+		}
+		err := next(ctx, cmds)
+		h.b.record(err)
+		return err
+	}
+}
@@ -0,0 +1,197 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Get 是读穿缓存：依次读本地缓存和 Redis，都没有时调用 load 并写回两级缓存。
+// 同一个键同时只有一个 load 在执行，其余请求等待它的结果。load 返回错误时不缓存
+func Get[T any](ctx context.Context, key string, ttl time.Duration, load func() (T, error)) (T, error) {
+	var value T
+	if data, ok := getBytes(ctx, key); ok {
+		if err := json.Unmarshal(data, &value); err == nil {
+			return value, nil
+		}
+	}
+
+	data, err := loads.do(key, func() ([]byte, error) {
+		v, err := load()
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		setBytes(ctx, key, data, ttl)
+		return data, nil
+	})
+	if err != nil {
+		return value, err
+	}
+	err = json.Unmarshal(data, &value)
+	return value, err
+}
+
+// Lookup 只读缓存，不回源
+func Lookup[T any](ctx context.Context, key string) (T, bool) {
+	var value T
+	data, ok := getBytes(ctx, key)
+	if !ok || json.Unmarshal(data, &value) != nil {
+		return value, false
+	}
+	return value, true
+}
+
+// Set 写入两级缓存
+func Set[T any](ctx context.Context, key string, value T, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	setBytes(ctx, key, data, ttl)
+	return nil
+}
+
+// Del 删除两级缓存中的键，并通知其他副本删除各自的本地缓存
+func Del(keys ...string) {
+	invalidate(invalidation{Keys: keys})
+}
+
+// DelPrefix 删除两级缓存中以任一 prefix 开头的键，并通知其他副本
+func DelPrefix(prefixes ...string) {
+	invalidate(invalidation{Prefixes: prefixes})
+}
+
+func getBytes(ctx context.Context, key string) ([]byte, bool) {
+	if data, ok := local.get(key); ok {
+		return data, true
+	}
+	data, err := Rdb.Get(ctx, key).Bytes()
+	if err != nil {
+		if err != redis.Nil && !unavailable(err) {
+			slog.WarnContext(ctx, "cache: 读取失败", "key", key, "err", err)
+		}
+		return nil, false
+	}
+	redisHits.Add(1)
+	if localTTL > 0 {
+		local.set(key, data, localTTL)
+	}
+	return data, true
+}
+
+// setBytes 写入 Redis 和本地缓存。本地缓存最多保存 localTTL，限制其他副本失效消息丢失时读到旧数据的时间；
+// Redis 不可用时本地缓存是唯一的一级，按完整的 ttl 保存。local_ttl 为 0 时 Redis 可用期间不使用本地缓存
+func setBytes(ctx context.Context, key string, data []byte, ttl time.Duration) {
+	err := Rdb.Set(ctx, key, data, ttl).Err()
+	switch {
+	case unavailable(err):
+		local.set(key, data, ttl)
+	case err != nil:
+		slog.WarnContext(ctx, "cache: 写入失败", "key", key, "err", err)
+	case localTTL > 0:
+		if ttl <= 0 || ttl > localTTL {
+			ttl = localTTL
+		}
+		local.set(key, data, ttl)
+	}
+}
+
+// invalidation 是一次失效操作，也是发布到其他副本的消息
+type invalidation struct {
+	Keys     []string `json:"keys,omitempty"`
+	Prefixes []string `json:"prefixes,omitempty"`
+	Source   string   `json:"source"`
+}
+
+func invalidate(inv invalidation) {
+	local.del(inv.Keys...)
+	local.delPrefix(inv.Prefixes...)
+
+	err := delRedis(Ctx, inv)
+	if err == nil {
+		inv.Source = instanceID
+		if data, e := json.Marshal(inv); e == nil {
+			err = Rdb.Publish(Ctx, invalidateChannel, data).Err()
+		}
+	}
+	if unavailable(err) {
+		// Redis 恢复后补删，避免降级前写入 Redis 的旧数据继续被读到
+		recoverMu.Lock()
+		pending.Keys = append(pending.Keys, inv.Keys...)
+		pending.Prefixes = append(pending.Prefixes, inv.Prefixes...)
+		recoverMu.Unlock()
+	} else if err != nil {
+		slog.Warn("cache: 删除缓存失败", "err", err)
+	}
+}
+
+// delRedis 删除 Redis 中的键，前缀用 SCAN 查找
+func delRedis(ctx context.Context, inv invalidation) error {
+	keys := inv.Keys
+	for _, prefix := range inv.Prefixes {
+		iter := Rdb.Scan(ctx, 0, globEscaper.Replace(prefix)+"*", 500).Iterator()
+		for iter.Next(ctx) {
+			keys = append(keys, iter.Val())
+		}
+		if err := iter.Err(); err != nil {
+			return err
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	return Rdb.Del(ctx, keys...).Err()
+}
+
+var globEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+
+// unavailable 判断错误是否是 Redis 不可用，而不是服务端返回的错误
+func unavailable(err error) bool {
+	return errors.Is(err, ErrUnavailable) || isFailure(err)
+}
+
+// loadGroup 合并同一个键同时发生的回源
+type loadGroup struct {
+	mu    sync.Mutex
+	calls map[string]*loadCall
+}
+
+type loadCall struct {
+	done chan struct{}
+	data []byte
+	err  error
+}
+
+var loads = &loadGroup{calls: map[string]*loadCall{}}
+
+func (g *loadGroup) do(key string, fn func() ([]byte, error)) ([]byte, error) {
+	g.mu.Lock()
+	if call, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		<-call.done
+		return call.data, call.err
+	}
+	call := &loadCall{done: make(chan struct{})}
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(call.done)
+	}()
+	loadCount.Add(1)
+	call.data, call.err = fn()
+	return call.data, call.err
+}
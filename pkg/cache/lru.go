@@ -0,0 +1,112 @@
+package cache
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+)
+
+// lru 是两级缓存中进程内的一级，保存序列化后的值，读取时再反序列化，调用方修改结果不会影响缓存
+type lru struct {
+	mu     sync.Mutex
+	size   int
+	items  map[string]*list.Element
+	order  *list.List // 最近使用的在前
+	hits   uint64
+	misses uint64
+}
+
+type lruEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time // 为零表示不过期
+}
+
+func newLRU(size int) *lru {
+	return &lru{size: size, items: map[string]*list.Element{}, order: list.New()}
+}
+
+func (c *lru) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if ok {
+		entry := el.Value.(*lruEntry)
+		if entry.expiresAt.IsZero() || time.Now().Before(entry.expiresAt) {
+			c.order.MoveToFront(el)
+			c.hits++
+			return entry.value, true
+		}
+		c.remove(el)
+	}
+	c.misses++
+	return nil, false
+}
+
+func (c *lru) set(key string, value []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.size <= 0 {
+		return
+	}
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+	if el, ok := c.items[key]; ok {
+		el.Value = &lruEntry{key: key, value: value, expiresAt: expiresAt}
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.order.PushFront(&lruEntry{key: key, value: value, expiresAt: expiresAt})
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *lru) del(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		if el, ok := c.items[key]; ok {
+			c.remove(el)
+		}
+	}
+}
+
+// delPrefix 删除所有以 prefix 开头的键
+func (c *lru) delPrefix(prefixes ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, el := range c.items {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(key, prefix) {
+				c.remove(el)
+				break
+			}
+		}
+	}
+}
+
+// purge 清空缓存，Redis 恢复后本地数据可能已经过时
+func (c *lru) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items = map[string]*list.Element{}
+	c.order.Init()
+}
+
+func (c *lru) stats() (entries int, hits, misses uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len(), c.hits, c.misses
+}
+
+// remove 删除一个元素，调用方需持有锁
+func (c *lru) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.items, el.Value.(*lruEntry).key)
+}
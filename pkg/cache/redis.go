@@ -2,25 +2,186 @@ package cache
 
 import (
 	"context"
-	"fmt"
-	"log"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"goblog/config"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
+// invalidateChannel 是各副本之间同步缓存失效的 Redis pub/sub 频道
+const invalidateChannel = "cache:invalidate"
+
 var (
 	Rdb *redis.Client
 	Ctx = context.Background()
+
+	breakr        = newBreaker(config.Default().Redis.FailureThreshold)
+	retryInterval = config.Default().Redis.RetryInterval
+	local         = newLRU(config.Default().Cache.LocalSize)
+	localTTL      = config.Default().Cache.LocalTTL
+
+	// instanceID 标识当前进程，收到自己发布的失效消息时跳过
+	instanceID = newInstanceID()
+
+	redisHits atomic.Uint64
+	loadCount atomic.Uint64
+
+	recoverMu sync.Mutex
+	pending   invalidation // 降级期间没能在 Redis 中执行的失效操作，恢复后补上
+	onRecover []func()
 )
 
-func InitRedis(host string, port string, password string, db int) {
+func newInstanceID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// InitRedis 创建 Redis 客户端和本地缓存。Redis 连不上时不返回错误，而是直接进入降级模式，
+// 由 RunReconnector 在后台重连
+func InitRedis(conf config.RedisConfig, cacheConf config.CacheConfig) error {
+	breakr = newBreaker(conf.FailureThreshold)
+	retryInterval = conf.RetryInterval
+	local = newLRU(cacheConf.LocalSize)
+	localTTL = cacheConf.LocalTTL
+
 	Rdb = redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%s", host, port),
-		Password: password,
-		DB:       db,
+		Addr:        conf.Addr(),
+		Password:    conf.Password,
+		DB:          conf.DB,
+		DialTimeout: 2 * time.Second,
 	})
-	if err := Rdb.Ping(Ctx).Err(); err != nil {
-		log.Fatalf("Redis 连接失败: %v", err)
+	Rdb.AddHook(hook{breakr})
+
+	if err := Rdb.Ping(context.WithValue(Ctx, probeKey{}, true)).Err(); err != nil {
+		breakr.forceOpen(err)
+		return nil
+	}
+	slog.Info("redis 连接成功")
+	return nil
+}
+
+// RunReconnector 在熔断期间定时探测 Redis，恢复后关闭熔断器、清空本地缓存并补上降级期间的失效操作
+func RunReconnector(ctx context.Context) {
+	ticker := time.NewTicker(retryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if !breakr.isOpen() {
+			continue
+		}
+		if err := Rdb.Ping(context.WithValue(ctx, probeKey{}, true)).Err(); err != nil {
+			continue
+		}
+		if breakr.reset() {
+			recovered()
+		}
+	}
+}
+
+// RunInvalidator 订阅其他副本发布的失效消息，删除本地缓存中对应的键。
+// 订阅断开后 go-redis 会自动重连，期间丢失的消息由本地缓存的 local_ttl 兜底
+func RunInvalidator(ctx context.Context) {
+	sub := Rdb.Subscribe(ctx, invalidateChannel)
+	defer sub.Close()
+
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			var inv invalidation
+			if err := json.Unmarshal([]byte(msg.Payload), &inv); err != nil || inv.Source == instanceID {
+				continue
+			}
+			local.del(inv.Keys...)
+			local.delPrefix(inv.Prefixes...)
+		}
+	}
+}
+
+// OnRecover 注册 Redis 从降级中恢复后要执行的函数，如重建排行
+func OnRecover(fn func()) {
+	recoverMu.Lock()
+	defer recoverMu.Unlock()
+	onRecover = append(onRecover, fn)
+}
+
+func recovered() {
+	slog.Info("cache: Redis 已恢复，退出降级模式")
+	// 降级期间本地缓存按完整 TTL 保存，且收不到其他副本的失效消息，全部丢弃
+	local.purge()
+
+	recoverMu.Lock()
+	inv := pending
+	pending = invalidation{}
+	callbacks := append([]func(){}, onRecover...)
+	recoverMu.Unlock()
+
+	if len(inv.Keys) > 0 || len(inv.Prefixes) > 0 {
+		invalidate(inv)
+	}
+	for _, fn := range callbacks {
+		go fn()
+	}
+}
+
+// Ping 检查 Redis 连接是否可用，熔断期间直接返回 ErrUnavailable
+func Ping(ctx context.Context) error {
+	return Rdb.Ping(ctx).Err()
+}
+
+func Close() error {
+	return Rdb.Close()
+}
+
+// Stats 是缓存层的运行状态，Degraded 为 true 表示 Redis 已熔断，只使用本地缓存
+type Stats struct {
+	Degraded      bool       `json:"degraded"`
+	DegradedSince *time.Time `json:"degraded_since,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+	Trips         uint64     `json:"trips"`         // 累计熔断次数
+	Rejected      uint64     `json:"rejected"`      // 熔断期间未发送给 Redis 的命令数
+	LocalEntries  int        `json:"local_entries"` // 本地缓存当前条数
+	LocalHits     uint64     `json:"local_hits"`
+	LocalMisses   uint64     `json:"local_misses"`
+	RedisHits     uint64     `json:"redis_hits"` // 本地未命中、Redis 命中的次数
+	Loads         uint64     `json:"loads"`      // 两级都未命中，回源的次数
+}
+
+func GetStats() Stats {
+	breakr.mu.Lock()
+	s := Stats{Degraded: breakr.open, Trips: breakr.trips, Rejected: breakr.rejected}
+	if breakr.open {
+		since := breakr.openedAt
+		s.DegradedSince = &since
+		if breakr.lastErr != nil {
+			s.LastError = breakr.lastErr.Error()
+		}
 	}
-	log.Println("redis 连接成功")
+	breakr.mu.Unlock()
+
+	s.LocalEntries, s.LocalHits, s.LocalMisses = local.stats()
+	s.RedisHits = redisHits.Load()
+	s.Loads = loadCount.Load()
+	return s
+}
+
+// Degraded 返回 Redis 是否处于熔断状态
+func Degraded() bool {
+	return breakr.isOpen()
 }
@@ -0,0 +1,363 @@
+package feed
+
+import (
+	"errors"
+	"fmt"
+	"goblog/database"
+	"goblog/models"
+	"goblog/pkg/cache"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+const (
+	// FanoutThreshold 关注者不超过该数量的作者/标签在发文时推送到关注者时间线（写扩散），
+	// 超过的在读取时间线时直接查库合并（读扩散）
+	FanoutThreshold = 1000
+
+	timelineSize = 800
+	// 时间线在最后一次读取 timelineTTL 后过期，不再登录的用户不会一直占用 Redis
+	timelineTTL = 7 * 24 * time.Hour
+	// 空时间线中的占位成员，避免每次读取都重建
+	placeholder = "0"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor 指向上一页最后一篇文章，格式为 "<创建时间毫秒>_<文章 ID>"
+type Cursor struct {
+	Time time.Time
+	ID   uint
+}
+
+func ParseCursor(s string) (Cursor, error) {
+	if s == "" {
+		return Cursor{Time: time.Now().Add(time.Hour), ID: 0}, nil
+	}
+	ms, id, ok := strings.Cut(s, "_")
+	if !ok {
+		return Cursor{}, ErrInvalidCursor
+	}
+	msVal, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	idVal, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return Cursor{Time: time.UnixMilli(msVal), ID: uint(idVal)}, nil
+}
+
+func (c Cursor) String() string {
+	return fmt.Sprintf("%d_%d", c.Time.UnixMilli(), c.ID)
+}
+
+// before 判断文章是否排在游标之后（即更旧）
+func (c Cursor) before(post models.Post) bool {
+	ms, cms := post.CreatedAt.UnixMilli(), c.Time.UnixMilli()
+	if ms != cms {
+		return ms < cms
+	}
+	return c.ID == 0 || post.ID < c.ID
+}
+
+func timelineKey(userID uint) string {
+	return fmt.Sprintf("timeline:user:%d", userID)
+}
+
+type sources struct {
+	smallAuthors, largeAuthors []uint
+	smallTags, largeTags       []uint
+}
+
+func (s sources) empty() bool {
+	return len(s.smallAuthors)+len(s.largeAuthors)+len(s.smallTags)+len(s.largeTags) == 0
+}
+
+func following(userID uint) (sources, error) {
+	var s sources
+	var follows []models.Follow
+	if err := database.DB.Where("follower_id = ?", userID).Find(&follows).Error; err != nil {
+		return s, err
+	}
+
+	var authors, tags []uint
+	for _, f := range follows {
+		switch f.TargetType {
+		case "user":
+			authors = append(authors, f.TargetID)
+		case "tag":
+			tags = append(tags, f.TargetID)
+		}
+	}
+
+	var err error
+	if s.smallAuthors, s.largeAuthors, err = splitBySize("user", authors); err != nil {
+		return s, err
+	}
+	if s.smallTags, s.largeTags, err = splitBySize("tag", tags); err != nil {
+		return s, err
+	}
+	return s, nil
+}
+
+func splitBySize(targetType string, ids []uint) (small, large []uint, err error) {
+	if len(ids) == 0 {
+		return nil, nil, nil
+	}
+
+	var big []uint
+	if err := database.DB.Model(&models.Follow{}).
+		Where("target_type = ? AND target_id IN ?", targetType, ids).
+		Group("target_id").Having("COUNT(*) > ?", FanoutThreshold).
+		Pluck("target_id", &big).Error; err != nil {
+		return nil, nil, err
+	}
+
+	isBig := make(map[uint]bool, len(big))
+	for _, id := range big {
+		isBig[id] = true
+	}
+	for _, id := range ids {
+		if isBig[id] {
+			large = append(large, id)
+		} else {
+			small = append(small, id)
+		}
+	}
+	return small, large, nil
+}
+
+// postsBy 构造“由这些作者发布或带有这些标签”的已发布文章查询
+func postsBy(authors, tags []uint) *gorm.DB {
+	cond := database.DB.Where("1 = 0")
+	if len(authors) > 0 {
+		cond = cond.Or("posts.user_id IN ?", authors)
+	}
+	if len(tags) > 0 {
+		cond = cond.Or("posts.id IN (?)", database.DB.Table("post_tags").Select("post_id").Where("tag_id IN ?", tags))
+	}
+	return database.DB.Model(&models.Post{}).Where("posts.is_draft = ?", false).Where(cond)
+}
+
+func followerCount(targetType string, targetID uint) (int64, error) {
+	var count int64
+	err := database.DB.Model(&models.Follow{}).Where("target_type = ? AND target_id = ?", targetType, targetID).Count(&count).Error
+	return count, err
+}
+
+// FanOut 将新发布的文章推送到“小”作者/标签关注者的时间线中，
+// 只写入已经存在的时间线，不存在的会在读取时重建
+func FanOut(post models.Post) {
+	if post.IsDraft {
+		return
+	}
+
+	followers := map[uint]bool{}
+	collect := func(targetType string, targetID uint) {
+		count, err := followerCount(targetType, targetID)
+		if err != nil || count == 0 || count > FanoutThreshold {
+			return
+		}
+		var ids []uint
+		database.DB.Model(&models.Follow{}).Where("target_type = ? AND target_id = ?", targetType, targetID).Pluck("follower_id", &ids)
+		for _, id := range ids {
+			followers[id] = true
+		}
+	}
+
+	collect("user", post.UserID)
+
+	var tagIDs []uint
+	database.DB.Table("post_tags").Where("post_id = ?", post.ID).Pluck("tag_id", &tagIDs)
+	for _, tagID := range tagIDs {
+		collect("tag", tagID)
+	}
+
+	score, member := post.CreatedAt.UnixMilli(), strconv.FormatUint(uint64(post.ID), 10)
+	for followerID := range followers {
+		if err := pushScript.Run(cache.Ctx, cache.Rdb, []string{timelineKey(followerID)}, score, member, timelineSize).Err(); err != nil {
+			slog.Warn("feed: 推送文章到时间线失败", "post_id", post.ID, "user_id", followerID, "err", err)
+		}
+	}
+}
+
+// pushScript 只向已存在的时间线推送并裁剪到 timelineSize，推送不延长过期时间。
+// 检查和写入在同一个脚本中，时间线恰好过期时不会留下一个没有过期时间、内容不完整的键
+var pushScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then return 0 end
+redis.call("ZADD", KEYS[1], ARGV[1], ARGV[2])
+redis.call("ZREMRANGEBYRANK", KEYS[1], 0, -(tonumber(ARGV[3]) + 2))
+return 1`)
+
+// Invalidate 在关注关系变化后丢弃用户的时间线，下次读取时重建
+func Invalidate(userID uint) {
+	cache.Del(timelineKey(userID))
+}
+
+func rebuild(userID uint, s sources) error {
+	var rows []struct {
+		ID        uint
+		CreatedAt time.Time
+	}
+	if err := postsBy(s.smallAuthors, s.smallTags).Select("posts.id, posts.created_at").
+		Order("posts.created_at desc").Limit(timelineSize).Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	members := []redis.Z{{Score: 0, Member: placeholder}}
+	for _, row := range rows {
+		members = append(members, redis.Z{Score: float64(row.CreatedAt.UnixMilli()), Member: strconv.FormatUint(uint64(row.ID), 10)})
+	}
+
+	key := timelineKey(userID)
+	pipe := cache.Rdb.TxPipeline()
+	pipe.Del(cache.Ctx, key)
+	pipe.ZAdd(cache.Ctx, key, members...)
+	pipe.Expire(cache.Ctx, key, timelineTTL)
+	_, err := pipe.Exec(cache.Ctx)
+	return err
+}
+
+// fromTimeline 从 Redis 时间线中读取游标之前最多 limit 篇文章的 ID，并延长时间线的过期时间。
+// 取满 limit 篇时 oldest 为其中最旧一篇的毫秒时间戳，更早的文章还没有读到；没有取满时为 0
+func fromTimeline(userID uint, s sources, cursor Cursor, limit int) (ids []uint, oldest int64, err error) {
+	key := timelineKey(userID)
+	exists, err := cache.Rdb.Expire(cache.Ctx, key, timelineTTL).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+	if !exists {
+		if err := rebuild(userID, s); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	members, err := cache.Rdb.ZRevRangeByScoreWithScores(cache.Ctx, key, &redis.ZRangeBy{
+		Max:   strconv.FormatInt(cursor.Time.UnixMilli(), 10),
+		Min:   "1",
+		Count: int64(limit),
+	}).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ids = make([]uint, 0, len(members))
+	for _, m := range members {
+		id, err := strconv.ParseUint(m.Member.(string), 10, 64)
+		if err == nil && id != 0 {
+			ids = append(ids, uint(id))
+		}
+	}
+	if len(members) == limit {
+		oldest = int64(members[len(members)-1].Score)
+	}
+	return ids, oldest, nil
+}
+
+// Load 返回用户关注的作者和标签的文章，按时间倒序，nextCursor 为空表示没有更多。
+// 时间线和直接查库的结果合并后分页，一页可能少于 limit 篇，只要 nextCursor 不为空就还有后续
+func Load(userID uint, cursor Cursor, limit int) ([]models.Post, string, error) {
+	s, err := following(userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if s.empty() {
+		return []models.Post{}, "", nil
+	}
+
+	// 同一毫秒内的文章以及已删除的文章会在下面被过滤，多取一些保证能凑满一页
+	fetch := limit*2 + 10
+
+	fanIn := postsBy(s.largeAuthors, s.largeTags)
+	ids, horizon, err := fromTimeline(userID, s, cursor, fetch)
+	if err != nil {
+		// Redis 不可用时退化为全部读扩散
+		slog.Warn("feed: 读取时间线失败，改为直接查库", "user_id", userID, "err", err)
+		ids, horizon = nil, 0
+		fanIn = postsBy(append(s.smallAuthors, s.largeAuthors...), append(s.smallTags, s.largeTags...))
+	}
+
+	var candidates []models.Post
+	if len(ids) > 0 {
+		var pushed []models.Post
+		if err := database.DB.Where("id IN ? AND is_draft = ?", ids, false).Find(&pushed).Error; err != nil {
+			return nil, "", err
+		}
+		candidates = append(candidates, pushed...)
+	}
+
+	var pulled []models.Post
+	if err := fanIn.Where("posts.created_at < ?", cursor.Time.Add(time.Millisecond)).
+		Order("posts.created_at desc, posts.id desc").Limit(fetch).Find(&pulled).Error; err != nil {
+		return nil, "", err
+	}
+	candidates = append(candidates, pulled...)
+
+	// 某个来源取满时，比它最旧一篇更早的文章还没有读到，本页只保留比这个位置新的文章，
+	// 否则另一个来源中更旧的文章会排到前面，翻页时跳过这些没读到的文章。
+	// 所有文章都在游标的同一毫秒内时无法再往前推进，不做截断
+	if len(pulled) == fetch {
+		horizon = max(horizon, pulled[len(pulled)-1].CreatedAt.UnixMilli())
+	}
+	if horizon >= cursor.Time.UnixMilli() {
+		horizon = 0
+	}
+
+	seen := map[uint]bool{}
+	page := make([]models.Post, 0, limit)
+	for _, post := range candidates {
+		if seen[post.ID] || !cursor.before(post) || post.CreatedAt.UnixMilli() <= horizon {
+			continue
+		}
+		seen[post.ID] = true
+		page = append(page, post)
+	}
+	sort.Slice(page, func(i, j int) bool {
+		if !page[i].CreatedAt.Equal(page[j].CreatedAt) {
+			return page[i].CreatedAt.After(page[j].CreatedAt)
+		}
+		return page[i].ID > page[j].ID
+	})
+
+	next := ""
+	switch {
+	case len(page) > limit:
+		page = page[:limit]
+		last := page[len(page)-1]
+		next = Cursor{Time: last.CreatedAt, ID: last.ID}.String()
+	case horizon > 0:
+		next = Cursor{Time: time.UnixMilli(horizon)}.String()
+	}
+	if len(page) == 0 {
+		return page, next, nil
+	}
+
+	postIDs := make([]uint, len(page))
+	for i, post := range page {
+		postIDs[i] = post.ID
+	}
+	var loaded []models.Post
+	if err := database.DB.Preload("User").Preload("Tags").Where("id IN ?", postIDs).Find(&loaded).Error; err != nil {
+		return nil, "", err
+	}
+	byID := make(map[uint]models.Post, len(loaded))
+	for _, post := range loaded {
+		byID[post.ID] = post
+	}
+
+	posts := make([]models.Post, 0, len(page))
+	for _, post := range page {
+		if full, ok := byID[post.ID]; ok {
+			posts = append(posts, full)
+		}
+	}
+	return posts, next, nil
+}
@@ -0,0 +1,253 @@
+package feed
+
+import (
+	"fmt"
+	"goblog/config"
+	"goblog/database/databasetest"
+	"goblog/models"
+	"goblog/pkg/cache"
+	"net"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"gorm.io/gorm"
+)
+
+// setup 准备测试库和 Redis：reader 关注一个关注者少的作者 small 和一个关注者超过 FanoutThreshold 的作者 large
+func setup(t *testing.T, driver string) (db *gorm.DB, mr *miniredis.Miniredis, reader, small, large models.User) {
+	t.Helper()
+
+	db = databasetest.Open(t, driver)
+	mr = miniredis.RunT(t)
+	host, port, _ := net.SplitHostPort(mr.Addr())
+	conf := config.Default()
+	conf.Redis.Host = host
+	conf.Redis.Port, _ = strconv.Atoi(port)
+	if err := cache.InitRedis(conf.Redis, conf.Cache); err != nil {
+		t.Fatal(err)
+	}
+
+	users := make([]models.User, FanoutThreshold+3)
+	for i := range users {
+		users[i] = models.User{Username: fmt.Sprintf("user%d", i), Email: fmt.Sprintf("user%d@example.com", i), Password: "hash"}
+	}
+	if err := db.CreateInBatches(users, 200).Error; err != nil {
+		t.Fatal(err)
+	}
+	reader, small, large = users[0], users[1], users[2]
+
+	follows := []models.Follow{
+		{FollowerID: reader.ID, TargetID: small.ID, TargetType: "user"},
+		{FollowerID: reader.ID, TargetID: large.ID, TargetType: "user"},
+	}
+	for _, u := range users[3:] {
+		follows = append(follows, models.Follow{FollowerID: u.ID, TargetID: large.ID, TargetType: "user"})
+	}
+	if err := db.CreateInBatches(follows, 200).Error; err != nil {
+		t.Fatal(err)
+	}
+	return db, mr, reader, small, large
+}
+
+var base = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+// publish 以 base 之后第 minute 分钟为发布时间创建文章
+func publish(t *testing.T, db *gorm.DB, author models.User, minute int) models.Post {
+	t.Helper()
+
+	post := models.Post{Title: fmt.Sprintf("文章 %d", minute), Content: "正文", UserID: author.ID, CreatedAt: base.Add(time.Duration(minute) * time.Minute)}
+	post.SetStatus(models.PostPublished)
+	if err := db.Create(&post).Error; err != nil {
+		t.Fatal(err)
+	}
+	return post
+}
+
+// readAll 按 next_cursor 翻页读完整个动态，返回文章 ID 和每页的篇数
+func readAll(t *testing.T, userID uint, limit int) (ids []uint, sizes []int) {
+	t.Helper()
+
+	cursor := ""
+	for range 100 {
+		c, err := ParseCursor(cursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		posts, next, err := Load(userID, c, limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(posts) > limit {
+			t.Fatalf("一页返回了 %d 篇，超过 limit %d", len(posts), limit)
+		}
+		for _, post := range posts {
+			ids = append(ids, post.ID)
+		}
+		sizes = append(sizes, len(posts))
+		if next == "" {
+			return ids, sizes
+		}
+		if next == cursor {
+			t.Fatalf("next_cursor 没有前进: %s", next)
+		}
+		cursor = next
+	}
+	t.Fatal("翻页没有结束")
+	return nil, nil
+}
+
+func newestFirst(posts []models.Post) []uint {
+	sorted := slices.Clone(posts)
+	slices.SortFunc(sorted, func(a, b models.Post) int { return b.CreatedAt.Compare(a.CreatedAt) })
+	ids := make([]uint, len(sorted))
+	for i, post := range sorted {
+		ids[i] = post.ID
+	}
+	return ids
+}
+
+func TestFollowing(t *testing.T) {
+	databasetest.ForEach(t, func(t *testing.T, driver string) {
+		_, _, reader, small, large := setup(t, driver)
+
+		s, err := following(reader.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(s.smallAuthors, []uint{small.ID}) || !slices.Equal(s.largeAuthors, []uint{large.ID}) {
+			t.Fatalf("small = %v, large = %v，期望 %d 写扩散、%d 读扩散", s.smallAuthors, s.largeAuthors, small.ID, large.ID)
+		}
+	})
+}
+
+func TestFanOut(t *testing.T) {
+	databasetest.ForEach(t, func(t *testing.T, driver string) {
+		db, mr, reader, small, large := setup(t, driver)
+		key := timelineKey(reader.ID)
+
+		// 时间线不存在时不推送，等读取时重建
+		FanOut(publish(t, db, small, 1))
+		if mr.Exists(key) {
+			t.Fatal("不应为没有时间线的用户创建时间线")
+		}
+
+		if _, _, err := Load(reader.ID, Cursor{Time: base.Add(time.Hour)}, 10); err != nil {
+			t.Fatal(err)
+		}
+		if ttl := mr.TTL(key); ttl != timelineTTL {
+			t.Fatalf("重建的时间线过期时间为 %v，期望 %v", ttl, timelineTTL)
+		}
+
+		// 关注者少的作者推送到时间线，关注者多的不推送，推送不延长过期时间
+		mr.FastForward(time.Hour)
+		pushed, pulled := publish(t, db, small, 2), publish(t, db, large, 3)
+		FanOut(pushed)
+		FanOut(pulled)
+		members, _ := mr.ZMembers(key)
+		if !slices.Contains(members, strconv.Itoa(int(pushed.ID))) {
+			t.Fatalf("关注者少的作者的文章应推送到时间线: %v", members)
+		}
+		if slices.Contains(members, strconv.Itoa(int(pulled.ID))) {
+			t.Fatalf("关注者超过阈值的作者的文章不应推送到时间线: %v", members)
+		}
+		if ttl := mr.TTL(key); ttl != timelineTTL-time.Hour {
+			t.Fatalf("推送后过期时间为 %v，期望不变", ttl)
+		}
+
+		// 读取时延长过期时间，两类文章都在动态中
+		posts, _, err := Load(reader.ID, Cursor{Time: base.Add(time.Hour)}, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ttl := mr.TTL(key); ttl != timelineTTL {
+			t.Fatalf("读取后过期时间为 %v，期望 %v", ttl, timelineTTL)
+		}
+		if got, want := []uint{posts[0].ID, posts[1].ID}, []uint{pulled.ID, pushed.ID}; len(posts) != 3 || !slices.Equal(got, want) {
+			t.Fatalf("动态为 %v，期望以 %v 开头共 3 篇", got, want)
+		}
+
+		// 过期后重新读取会重建
+		mr.FastForward(timelineTTL)
+		if mr.Exists(key) {
+			t.Fatal("时间线应在最后一次读取 timelineTTL 后过期")
+		}
+		FanOut(publish(t, db, small, 4))
+		if mr.Exists(key) {
+			t.Fatal("时间线过期后推送不应重新创建它")
+		}
+	})
+}
+
+func TestLoadPaging(t *testing.T) {
+	databasetest.ForEach(t, func(t *testing.T, driver string) {
+		db, _, reader, small, large := setup(t, driver)
+
+		// 两类作者的文章交替发布，翻页时时间线和直接查库的结果要按时间合并
+		var posts []models.Post
+		for minute := range 17 {
+			author := small
+			if minute%3 == 0 {
+				author = large
+			}
+			posts = append(posts, publish(t, db, author, minute))
+		}
+		draft := publish(t, db, small, 20)
+		db.Model(&draft).Updates(map[string]any{"is_draft": true, "status": models.PostDraft})
+
+		for _, limit := range []int{1, 4, 5, 17, 50} {
+			ids, sizes := readAll(t, reader.ID, limit)
+			if want := newestFirst(posts); !slices.Equal(ids, want) {
+				t.Fatalf("limit=%d 翻页结果为 %v，期望 %v", limit, ids, want)
+			}
+			// 最后一页没有更多时 next_cursor 为空，不需要再请求一个空页
+			if last := sizes[len(sizes)-1]; last == 0 && len(posts)%limit != 0 {
+				t.Fatalf("limit=%d 最后多返回了一个空页: %v", limit, sizes)
+			}
+		}
+	})
+}
+
+func TestLoadShortPage(t *testing.T) {
+	databasetest.ForEach(t, func(t *testing.T, driver string) {
+		db, _, reader, small, large := setup(t, driver)
+
+		// 读扩散的文章比时间线中的都旧
+		var want []models.Post
+		for minute := range 3 {
+			want = append(want, publish(t, db, large, minute))
+		}
+		// 时间线中较新的一批文章被删除，limit=1 时时间线一次读取的 12 篇都是已删除的文章
+		var trashed []uint
+		for minute := 10; minute < 24; minute++ {
+			post := publish(t, db, small, minute)
+			if minute >= 12 {
+				trashed = append(trashed, post.ID)
+			} else {
+				want = append(want, post)
+			}
+		}
+		if _, _, err := Load(reader.ID, Cursor{Time: base.Add(time.Hour)}, 1); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Delete(&models.Post{}, trashed).Error; err != nil {
+			t.Fatal(err)
+		}
+
+		// 第一页没有可显示的文章，但还有更多，next_cursor 不能为空，也不能跳到读扩散的旧文章
+		posts, next, err := Load(reader.ID, Cursor{Time: base.Add(time.Hour)}, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(posts) != 0 || next == "" {
+			t.Fatalf("第一页返回 %d 篇，next_cursor = %q，期望空页和非空的 next_cursor", len(posts), next)
+		}
+
+		ids, _ := readAll(t, reader.ID, 1)
+		if want := newestFirst(want); !slices.Equal(ids, want) {
+			t.Fatalf("翻页结果为 %v，期望 %v", ids, want)
+		}
+	})
+}
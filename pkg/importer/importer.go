@@ -0,0 +1,166 @@
+// Package importer 把其他博客的文章导入 goBlog：带 YAML/TOML front matter 的 Markdown 目录、
+// Hugo 和 Jekyll 站点，以及 WordPress 导出的 WXR 文件。
+// 每篇文章和评论按来源中的标识记录在 imported_items 中，重复导入同一来源时只更新有变化的文章
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	FormatAuto     = "auto"
+	FormatMarkdown = "markdown"
+	FormatHugo     = "hugo"
+	FormatJekyll   = "jekyll"
+	FormatWXR      = "wxr"
+)
+
+// Formats 是支持的导入格式，auto 表示根据目录结构或文件类型判断
+var Formats = []string{FormatAuto, FormatMarkdown, FormatHugo, FormatJekyll, FormatWXR}
+
+// Item 是从来源中解析出的一篇文章
+type Item struct {
+	Key      string // 来源中的唯一标识，如 wxr:<guid>、jekyll:_posts/2020-01-01-hello.md
+	Title    string
+	Content  string
+	Author   string // 来源中的作者用户名或邮箱，找不到对应用户时使用 Options.UserID
+	Date     time.Time
+	Updated  time.Time
+	Tags     []string
+	Draft    bool
+	Comments []Comment
+	// Skip 不为空时不导入该文章，例如 WordPress 回收站中的文章
+	Skip string
+}
+
+// Comment 是文章下的一条评论，ParentKey 为回复的评论的 Key
+type Comment struct {
+	Key       string
+	ParentKey string
+	Author    string
+	Email     string
+	Content   string
+	Date      time.Time
+}
+
+// checksum 是文章内容的摘要，来源中的文章没有变化时重复导入不会修改本地文章
+func (item Item) checksum() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00%t\x00%s",
+		item.Title, item.Content, item.Date.UTC().Format(time.RFC3339), item.Updated.UTC().Format(time.RFC3339),
+		item.Draft, strings.Join(item.Tags, ","))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Detect 根据目录结构判断站点类型：有 _posts 或 _config.yml 的是 Jekyll，
+// 有 content 目录或 Hugo 配置文件的是 Hugo，根目录只有 .xml 文件的是 WXR，其他按普通 Markdown 目录处理
+func Detect(fsys fs.FS) string {
+	exists := func(name string) bool {
+		_, err := fs.Stat(fsys, name)
+		return err == nil
+	}
+	switch {
+	case exists("_posts") || exists("_config.yml"):
+		return FormatJekyll
+	case exists("content") || exists("hugo.toml") || exists("hugo.yaml") || exists("config.toml"):
+		return FormatHugo
+	}
+
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil || len(entries) == 0 {
+		return FormatMarkdown
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".xml" {
+			return FormatMarkdown
+		}
+	}
+	return FormatWXR
+}
+
+// Root 在压缩包只包含一个顶层目录时返回该目录，方便直接上传整个站点目录的压缩包
+func Root(fsys fs.FS) fs.FS {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil || len(entries) != 1 || !entries[0].IsDir() {
+		return fsys
+	}
+	sub, err := fs.Sub(fsys, entries[0].Name())
+	if err != nil {
+		return fsys
+	}
+	return sub
+}
+
+// Load 按 format 解析 fsys 中的文章，format 为 auto 时自动判断，返回实际使用的格式。
+// 读取的文件受 MaxFileSize 和 MaxTotalSize 限制
+func Load(fsys fs.FS, format string) ([]Item, string, error) {
+	if format == "" || format == FormatAuto {
+		format = Detect(fsys)
+	}
+	var items []Item
+	var err error
+	switch format {
+	case FormatWXR:
+		items, err = loadWXRFiles(fsys, newBudget())
+	case FormatMarkdown, FormatHugo, FormatJekyll:
+		items, err = loadMarkdown(fsys, format, newBudget())
+	default:
+		return nil, format, fmt.Errorf("不支持的导入格式 %q", format)
+	}
+	return items, format, err
+}
+
+// LoadFile 解析单个文件：.xml 是 WXR 文件，.zip 是站点目录或 WXR 文件的压缩包。
+// 文件和压缩包中的文件受 MaxFileSize 和 MaxTotalSize 限制，超过时返回 ErrTooLarge
+func LoadFile(name string, data []byte, format string) ([]Item, string, error) {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".xml":
+		if format != "" && format != FormatAuto && format != FormatWXR {
+			return nil, format, fmt.Errorf("%s 格式不能导入 XML 文件", format)
+		}
+		if len(data) > MaxFileSize {
+			return nil, format, fmt.Errorf("%s: %w", path.Base(name), ErrTooLarge)
+		}
+		items, err := ParseWXR(bytes.NewReader(data))
+		return items, FormatWXR, err
+	case ".zip":
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, format, fmt.Errorf("读取压缩包失败: %w", err)
+		}
+		return Load(Root(zr), format)
+	}
+	return nil, format, fmt.Errorf("只支持 .xml 和 .zip 文件")
+}
+
+func loadWXRFiles(fsys fs.FS, b *budget) ([]Item, error) {
+	names, err := fs.Glob(fsys, "*.xml")
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("没有找到 WXR 文件")
+	}
+	var items []Item
+	for _, name := range names {
+		f, err := b.open(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := ParseWXR(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		items = append(items, parsed...)
+	}
+	return items, nil
+}
@@ -0,0 +1,361 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// describe 把文章压成一行便于比较，时间统一按 UTC 输出
+func describe(items []Item) []string {
+	format := func(t time.Time) string {
+		if t.IsZero() {
+			return "-"
+		}
+		return t.UTC().Format(time.RFC3339)
+	}
+	lines := make([]string, len(items))
+	for i, item := range items {
+		lines[i] = fmt.Sprintf("%s | %s | %q | author=%s | %s | %s | tags=%q | draft=%t | skip=%s",
+			item.Key, item.Title, item.Content, item.Author, format(item.Date), format(item.Updated), item.Tags, item.Draft, item.Skip)
+	}
+	return lines
+}
+
+func expectItems(t *testing.T, items []Item, want []string) {
+	t.Helper()
+	if got := describe(items); !slices.Equal(got, want) {
+		t.Errorf("解析结果:\n%s\n期望:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestDetect(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		fsys fs.FS
+		want string
+	}{
+		{"jekyll 目录", os.DirFS("testdata/jekyll"), FormatJekyll},
+		{"hugo 目录", os.DirFS("testdata/hugo"), FormatHugo},
+		{"markdown 目录", os.DirFS("testdata/markdown"), FormatMarkdown},
+		{"只有 _posts", fstest.MapFS{"_posts/a.md": {}}, FormatJekyll},
+		{"只有 content", fstest.MapFS{"content/a.md": {}}, FormatHugo},
+		{"hugo.yaml", fstest.MapFS{"hugo.yaml": {}, "posts/a.md": {}}, FormatHugo},
+		{"只有 xml", fstest.MapFS{"a.xml": {}, "b.xml": {}}, FormatWXR},
+		{"xml 和其他文件", fstest.MapFS{"a.xml": {}, "a.md": {}}, FormatMarkdown},
+		{"xml 和目录", fstest.MapFS{"a.xml": {}, "posts/a.md": {}}, FormatMarkdown},
+		{"空目录", fstest.MapFS{}, FormatMarkdown},
+	} {
+		if got := Detect(c.fsys); got != c.want {
+			t.Errorf("%s: Detect = %s，期望 %s", c.name, got, c.want)
+		}
+	}
+}
+
+func TestSplitFrontMatter(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		data  string
+		title any
+		body  string
+		err   string
+	}{
+		{"YAML", "---\ntitle: 标题\n---\n正文\n", "标题", "正文\n", ""},
+		{"TOML", "+++\ntitle = \"标题\"\n+++\n正文", "标题", "正文", ""},
+		{"CRLF", "---\r\ntitle: 标题\r\n---\r\n正文\r\n", "标题", "正文\n", ""},
+		{"结束符在文件末尾", "---\ntitle: 标题\n---", "标题", "", ""},
+		{"结束符后直接结束", "---\ntitle: 标题\n---\n", "标题", "", ""},
+		{"没有 front matter", "# 标题\n---\n正文", nil, "# 标题\n---\n正文", ""},
+		{"分隔符不在开头", "\n---\ntitle: 标题\n---\n", nil, "\n---\ntitle: 标题\n---\n", ""},
+		{"正文中的分隔线", "---\ntitle: 标题\n---\n上\n---\n下", "标题", "上\n---\n下", ""},
+		{"没有结束", "---\ntitle: 标题\n正文", nil, "", "front matter 没有结束的 ---"},
+		{"YAML 错误", "---\ntitle: [\n---\n", nil, "", "front matter: "},
+		{"TOML 错误", "+++\ntitle = \n+++\n", nil, "", "front matter: "},
+	} {
+		meta, body, err := splitFrontMatter([]byte(c.data))
+		if c.err != "" {
+			if err == nil || !strings.HasPrefix(err.Error(), c.err) {
+				t.Errorf("%s: 错误为 %v，期望以 %q 开头", c.name, err, c.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if meta["title"] != c.title || body != c.body {
+			t.Errorf("%s: title = %v, body = %q，期望 %v, %q", c.name, meta["title"], body, c.title, c.body)
+		}
+	}
+}
+
+func TestTimeValue(t *testing.T) {
+	shanghai := time.FixedZone("", 8*60*60)
+	for _, c := range []struct {
+		value any
+		want  time.Time
+	}{
+		{nil, time.Time{}},
+		{"", time.Time{}},
+		{time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{"2020-01-02T03:04:05+08:00", time.Date(2020, 1, 2, 3, 4, 5, 0, shanghai)},
+		{"2020-01-02 03:04:05 +0800", time.Date(2020, 1, 2, 3, 4, 5, 0, shanghai)},
+		{"2020-01-02 03:04:05 +08:00", time.Date(2020, 1, 2, 3, 4, 5, 0, shanghai)},
+		{"2020-01-02T03:04:05", time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{"2020-01-02 03:04:05", time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{"2020-01-02 03:04", time.Date(2020, 1, 2, 3, 4, 0, 0, time.UTC)},
+		{" 2020-01-02 ", time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)},
+	} {
+		got, err := timeValue(c.value)
+		if err != nil || !got.Equal(c.want) {
+			t.Errorf("timeValue(%q) = %v, %v，期望 %v", c.value, got, err, c.want)
+		}
+	}
+	for _, bad := range []any{"明天", "2020/01/02", 20200102} {
+		if _, err := timeValue(bad); err == nil {
+			t.Errorf("timeValue(%v) 应返回错误", bad)
+		}
+	}
+}
+
+func TestLoadJekyll(t *testing.T) {
+	items, format, err := Load(os.DirFS("testdata/jekyll"), FormatAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != FormatJekyll {
+		t.Fatalf("格式为 %s，期望 jekyll", format)
+	}
+	// 只读取 _posts 和 _drafts；没有 date 时使用文件名中的日期，标题取文件名去掉日期
+	expectItems(t, items, []string{
+		`jekyll:_drafts/wip.md | 写了一半 | "草稿" | author= | - | - | tags=[] | draft=true | skip=`,
+		`jekyll:_posts/2020-01-02-hello-world.md | hello world | "正文" | author= | 2020-01-02T00:00:00Z | - | tags=["go" "web"] | draft=false | skip=`,
+		`jekyll:_posts/2021-03-04-dated.markdown | 带时区的日期 | "第一段" | author=alice | 2021-03-05T02:00:00Z | 2021-03-06T00:00:00Z | tags=["go" "笔记"] | draft=false | skip=`,
+	})
+}
+
+func TestLoadHugo(t *testing.T) {
+	items, format, err := Load(os.DirFS("testdata/hugo"), FormatAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != FormatHugo {
+		t.Fatalf("格式为 %s，期望 hugo", format)
+	}
+	// 只读取 content，跳过 _index.md；页面包没有 title 时使用正文开头的一级标题
+	expectItems(t, items, []string{
+		`hugo:posts/bundle/index.md | 页面包标题 | "页面包正文" | author= | 2022-02-01T00:00:00Z | - | tags=[] | draft=false | skip=`,
+		`hugo:posts/first.md | 第一篇 | "Hugo 正文" | author= | 2022-01-02T03:04:05Z | 2022-01-03T00:00:00Z | tags=["hugo" "go" "建站"] | draft=true | skip=`,
+	})
+}
+
+func TestLoadMarkdown(t *testing.T) {
+	items, format, err := Load(os.DirFS("testdata/markdown"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != FormatMarkdown {
+		t.Fatalf("格式为 %s，期望 markdown", format)
+	}
+	// 解析失败的文件不中断导入，以路径为标题记为跳过
+	expectItems(t, items, []string{
+		`markdown:notes/bad-date.md | notes/bad-date.md | "" | author= | - | - | tags=[] | draft=false | skip=date: 无法解析时间 "明天"`,
+		`markdown:notes/broken.md | notes/broken.md | "" | author= | - | - | tags=[] | draft=false | skip=front matter 没有结束的 ---`,
+		`markdown:notes/my-note.md | 笔记 | "待审的笔记" | author= | - | - | tags=[] | draft=true | skip=`,
+		`markdown:notes/trashed.md | 回收站中的文章 | "" | author= | - | - | tags=[] | draft=false | skip=文章已删除`,
+		`markdown:plain.md | 没有 front matter | "正文" | author= | - | - | tags=[] | draft=false | skip=`,
+	})
+}
+
+func TestLoadWXR(t *testing.T) {
+	data, err := os.ReadFile("testdata/wordpress.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	items, format, err := LoadFile("wordpress.xml", data, FormatAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != FormatWXR {
+		t.Fatalf("格式为 %s，期望 wxr", format)
+	}
+	// 页面和自动草稿不导入；草稿没有 GMT 时间时使用站点时间；没有 guid 时用 post_id 作为标识
+	expectItems(t, items, []string{
+		`wxr:https://wp.example.com/?p=1 | WordPress 文章 | "<p>HTML 正文</p>" | author=alice | 2019-05-06T00:00:00Z | 2019-05-07T00:00:00Z | tags=["笔记" "go"] | draft=false | skip=`,
+		`wxr:post-2 | (无标题) | "" | author= | 2019-06-01T09:30:00Z | - | tags=[] | draft=true | skip=`,
+		`wxr:https://wp.example.com/?p=3 | 回收站 | "" | author= | - | - | tags=[] | draft=true | skip=WordPress 回收站中的文章`,
+	})
+
+	// 只导入已审核的普通评论，回复记录被回复评论的 Key
+	post := "wxr:https://wp.example.com/?p=1"
+	want := []Comment{
+		{Key: post + "#comment-11", Author: "bob", Email: "bob@example.com", Content: "第一条评论", Date: time.Date(2019, 5, 6, 1, 0, 0, 0, time.UTC)},
+		{Key: post + "#comment-12", ParentKey: post + "#comment-11", Author: "carol", Content: "回复 bob", Date: time.Date(2019, 5, 6, 2, 0, 0, 0, time.UTC)},
+	}
+	if got := items[0].Comments; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("评论为 %+v，期望 %+v", got, want)
+	}
+
+	if _, _, err := LoadFile("wordpress.xml", data, FormatHugo); err == nil {
+		t.Error("指定 hugo 格式时不应导入 XML 文件")
+	}
+	if _, err := ParseWXR(strings.NewReader("<rss><channel>")); err == nil {
+		t.Error("不完整的 XML 应返回错误")
+	}
+}
+
+// zipDir 把 dir 打包到压缩包中的 prefix 目录下
+func zipDir(t *testing.T, dir, prefix string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	err := filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, name)
+		w, err := zw.Create(prefix + filepath.ToSlash(rel))
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestLoadZip(t *testing.T) {
+	want, _, err := Load(os.DirFS("testdata/jekyll"), FormatAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 压缩包只有一个顶层目录时从该目录开始识别
+	for _, prefix := range []string{"", "my-site/"} {
+		items, format, err := LoadFile("site.zip", zipDir(t, "testdata/jekyll", prefix), FormatAuto)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if format != FormatJekyll {
+			t.Errorf("前缀 %q: 格式为 %s，期望 jekyll", prefix, format)
+		}
+		if !slices.Equal(describe(items), describe(want)) {
+			t.Errorf("前缀 %q: 压缩包的解析结果与目录不同:\n%s", prefix, strings.Join(describe(items), "\n"))
+		}
+	}
+
+	if _, _, err := LoadFile("site.zip", []byte("not a zip"), FormatAuto); err == nil {
+		t.Error("损坏的压缩包应返回错误")
+	}
+	if _, _, err := LoadFile("site.tar.gz", nil, FormatAuto); err == nil {
+		t.Error("不支持的文件类型应返回错误")
+	}
+}
+
+// TestZipBomb 压缩包中解压后超过 MaxFileSize 的文件在读取前就被拒绝
+func TestZipBomb(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("bomb.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.CopyN(w, zeros{}, MaxFileSize+1); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() > MaxFileSize/100 {
+		t.Fatalf("压缩包有 %d 字节，不是高压缩率的压缩包", buf.Len())
+	}
+
+	_, _, err = LoadFile("bomb.zip", buf.Bytes(), FormatAuto)
+	if !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("返回 %v，期望 ErrTooLarge", err)
+	}
+	if _, _, err := LoadFile("big.xml", make([]byte, MaxFileSize+1), FormatAuto); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("超过 MaxFileSize 的 XML 文件返回 %v，期望 ErrTooLarge", err)
+	}
+}
+
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+// lyingFS 的文件都声称大小为 0，模拟记录的解压后大小被伪造的压缩包
+type lyingFS struct{ fstest.MapFS }
+
+func (l lyingFS) Open(name string) (fs.File, error) {
+	f, err := l.MapFS.Open(name)
+	return lyingFile{f}, err
+}
+
+type lyingFile struct{ fs.File }
+
+func (f lyingFile) Stat() (fs.FileInfo, error) { return lyingInfo{}, nil }
+
+type lyingInfo struct{ fs.FileInfo }
+
+func (lyingInfo) Size() int64 { return 0 }
+
+func TestBudget(t *testing.T) {
+	files := fstest.MapFS{
+		"a.md": {Data: bytes.Repeat([]byte("a"), 10)},
+		"b.md": {Data: bytes.Repeat([]byte("b"), 10)},
+	}
+
+	// 记录的大小超过剩余额度时直接拒绝
+	b := &budget{remaining: 9}
+	if _, err := b.open(files, "a.md"); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("返回 %v，期望 ErrTooLarge", err)
+	}
+
+	// 恰好等于额度时可以读完
+	b = &budget{remaining: 10}
+	if data, err := b.readFile(files, "a.md"); err != nil || len(data) != 10 {
+		t.Fatalf("readFile = %d 字节, %v", len(data), err)
+	}
+	if b.remaining != 0 {
+		t.Fatalf("剩余额度为 %d，期望 0", b.remaining)
+	}
+
+	// 记录的大小是伪造的，读取时按实际字节数计数
+	b = &budget{remaining: 9}
+	if _, err := b.readFile(lyingFS{files}, "a.md"); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("伪造大小的文件返回 %v，期望 ErrTooLarge", err)
+	}
+
+	// 多个文件共用总额度
+	b = &budget{remaining: 15}
+	if _, err := b.readFile(lyingFS{files}, "a.md"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.readFile(lyingFS{files}, "b.md"); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("超过总额度时返回 %v，期望 ErrTooLarge", err)
+	}
+}
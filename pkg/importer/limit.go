@@ -0,0 +1,72 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+)
+
+const (
+	// MaxFileSize 是单个文件（压缩包中的文件按解压后计算）的最大字节数
+	MaxFileSize = 64 << 20
+	// MaxTotalSize 是一次导入读取的全部文件的最大字节数
+	MaxTotalSize = 512 << 20
+)
+
+// ErrTooLarge 表示文件或全部文件的总大小超过限制，防止压缩率极高的压缩包解压后耗尽内存
+var ErrTooLarge = errors.New("文件过大")
+
+// budget 记录一次导入还可以读取的字节数
+type budget struct {
+	remaining int64
+}
+
+func newBudget() *budget {
+	return &budget{remaining: MaxTotalSize}
+}
+
+// open 打开文件，读取时按 MaxFileSize 和剩余的总量限制字节数。
+// 压缩包中的文件先按记录的解压后大小检查，记录可以伪造，所以读取时仍然计数
+func (b *budget) open(fsys fs.FS, name string) (io.ReadCloser, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	limit := min(int64(MaxFileSize), b.remaining)
+	if info, err := f.Stat(); err == nil && info.Size() > limit {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", name, ErrTooLarge)
+	}
+	return &limitedFile{File: f, name: name, limit: limit, budget: b}, nil
+}
+
+func (b *budget) readFile(fsys fs.FS, name string) ([]byte, error) {
+	f, err := b.open(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+type limitedFile struct {
+	fs.File
+	name   string
+	limit  int64
+	budget *budget
+}
+
+// Read 最多多读一个字节，用来区分文件恰好等于限制和超过限制
+func (f *limitedFile) Read(p []byte) (int, error) {
+	if int64(len(p)) > f.limit+1 {
+		p = p[:f.limit+1]
+	}
+	n, err := f.File.Read(p)
+	f.limit -= int64(n)
+	f.budget.remaining -= int64(n)
+	if f.limit < 0 {
+		return n, fmt.Errorf("%s: %w", f.name, ErrTooLarge)
+	}
+	return n, err
+}
@@ -0,0 +1,256 @@
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// jekyllName 匹配 Jekyll 文章文件名中的日期，如 2020-01-02-hello-world.md
+var jekyllName = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})-(.+)$`)
+
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05 Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// loadMarkdown 读取目录中的 Markdown 文件。Hugo 只读取 content 目录（不存在时为整个目录），跳过章节页 _index.md；
+// Jekyll 只读取 _posts 和 _drafts 中的文件，_drafts 中的文章导入为草稿
+func loadMarkdown(fsys fs.FS, format string, b *budget) ([]Item, error) {
+	root := "."
+	if format == FormatHugo {
+		if info, err := fs.Stat(fsys, "content"); err == nil && info.IsDir() {
+			root = "content"
+		}
+	}
+
+	var items []Item
+	err := fs.WalkDir(fsys, root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		base := d.Name()
+		if d.IsDir() {
+			if name != root && strings.HasPrefix(base, ".") {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if ext := path.Ext(base); ext != ".md" && ext != ".markdown" {
+			return nil
+		}
+
+		rel := strings.TrimPrefix(strings.TrimPrefix(name, root), "/")
+		dirs := strings.Split(path.Dir(rel), "/")
+		switch format {
+		case FormatHugo:
+			if base == "_index.md" {
+				return nil
+			}
+		case FormatJekyll:
+			if !slices.Contains(dirs, "_posts") && !slices.Contains(dirs, "_drafts") {
+				return nil
+			}
+		}
+
+		data, err := b.readFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		item, err := parseMarkdown(rel, data, format)
+		if err != nil {
+			item = Item{Key: format + ":" + rel, Title: rel, Skip: err.Error()}
+		}
+		if format == FormatJekyll && slices.Contains(dirs, "_drafts") {
+			item.Draft = true
+		}
+		items = append(items, item)
+		return nil
+	})
+	return items, err
+}
+
+// parseMarkdown 解析一篇带 front matter 的 Markdown，--- 包围的是 YAML，+++ 包围的是 TOML（Hugo）
+func parseMarkdown(rel string, data []byte, format string) (Item, error) {
+	item := Item{Key: format + ":" + rel}
+
+	meta, body, err := splitFrontMatter(data)
+	if err != nil {
+		return item, err
+	}
+	item.Content = strings.TrimSpace(body)
+
+	item.Title = stringValue(meta["title"])
+	if item.Title == "" {
+		item.Title, item.Content = headingTitle(item.Content)
+	}
+	if item.Title == "" {
+		item.Title = fileTitle(rel)
+	}
+
+	if item.Date, err = timeValue(meta["date"]); err != nil {
+		return item, fmt.Errorf("date: %w", err)
+	}
+	if item.Date.IsZero() {
+		if m := jekyllName.FindStringSubmatch(path.Base(rel)); m != nil {
+			item.Date, _ = time.Parse("2006-01-02", m[1])
+		}
+	}
+	for _, key := range []string{"updated", "lastmod", "last_modified_at", "modified"} {
+		if item.Updated, err = timeValue(meta[key]); err != nil {
+			return item, fmt.Errorf("%s: %w", key, err)
+		}
+		if !item.Updated.IsZero() {
+			break
+		}
+	}
+
+	for _, key := range []string{"tags", "categories", "category"} {
+		item.Tags = appendUnique(item.Tags, listValue(meta[key])...)
+	}
+	item.Author = stringValue(meta["author"])
+
+	if draft, ok := meta["draft"].(bool); ok && draft {
+		item.Draft = true
+	}
+	if published, ok := meta["published"].(bool); ok && !published {
+		item.Draft = true
+	}
+	// goBlog 自己导出的文章带 status，回收站中的文章带 deleted
+	if status := stringValue(meta["status"]); status != "" && status != "published" {
+		item.Draft = true
+	}
+	if _, ok := meta["deleted"]; ok {
+		item.Skip = "文章已删除"
+	}
+	return item, nil
+}
+
+func splitFrontMatter(data []byte) (map[string]any, string, error) {
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	meta := map[string]any{}
+	for _, delim := range []string{"---", "+++"} {
+		if !bytes.HasPrefix(data, []byte(delim+"\n")) {
+			continue
+		}
+		rest := data[len(delim)+1:]
+		end := bytes.Index(rest, []byte("\n"+delim+"\n"))
+		if end < 0 {
+			if !bytes.HasSuffix(rest, []byte("\n"+delim)) {
+				return nil, "", fmt.Errorf("front matter 没有结束的 %s", delim)
+			}
+			end = len(rest) - len(delim) - 1
+		}
+		header := rest[:end]
+		body := ""
+		if end+len(delim)+2 <= len(rest) {
+			body = string(rest[end+len(delim)+2:])
+		}
+
+		var err error
+		if delim == "---" {
+			err = yaml.Unmarshal(header, &meta)
+		} else {
+			err = toml.Unmarshal(header, &meta)
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("front matter: %w", err)
+		}
+		return meta, body, nil
+	}
+	return meta, string(data), nil
+}
+
+// headingTitle 没有 title 时使用正文开头的一级标题，并把它从正文中去掉
+func headingTitle(content string) (string, string) {
+	line, rest, _ := strings.Cut(content, "\n")
+	if !strings.HasPrefix(line, "# ") {
+		return "", content
+	}
+	return strings.TrimSpace(line[2:]), strings.TrimSpace(rest)
+}
+
+// fileTitle 用文件名作为标题，去掉 Jekyll 的日期前缀；Hugo 的 page bundle（index.md）使用目录名
+func fileTitle(rel string) string {
+	name := strings.TrimSuffix(path.Base(rel), path.Ext(rel))
+	if name == "index" && path.Dir(rel) != "." {
+		name = path.Base(path.Dir(rel))
+	}
+	if m := jekyllName.FindStringSubmatch(name); m != nil {
+		name = m[2]
+	}
+	return strings.ReplaceAll(name, "-", " ")
+}
+
+func stringValue(v any) string {
+	s, _ := v.(string)
+	return strings.TrimSpace(s)
+}
+
+// timeValue 解析 front matter 中的时间，YAML/TOML 已解析的时间直接使用，字符串依次尝试常见格式
+func timeValue(v any) (time.Time, error) {
+	switch v := v.(type) {
+	case nil:
+		return time.Time{}, nil
+	case time.Time:
+		return v, nil
+	}
+	s := strings.TrimSpace(fmt.Sprint(v))
+	if s == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无法解析时间 %q", s)
+}
+
+// listValue 支持列表和字符串两种写法，字符串中有逗号时按逗号分隔，否则按空白分隔（Jekyll 的写法）
+func listValue(v any) []string {
+	switch v := v.(type) {
+	case []any:
+		var list []string
+		for _, e := range v {
+			if s := stringValue(fmt.Sprint(e)); s != "" {
+				list = append(list, s)
+			}
+		}
+		return list
+	case string:
+		if strings.Contains(v, ",") {
+			var list []string
+			for _, s := range strings.Split(v, ",") {
+				if s = strings.TrimSpace(s); s != "" {
+					list = append(list, s)
+				}
+			}
+			return list
+		}
+		return strings.Fields(v)
+	}
+	return nil
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		if !slices.Contains(list, v) {
+			list = append(list, v)
+		}
+	}
+	return list
+}
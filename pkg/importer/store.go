@@ -0,0 +1,457 @@
+package importer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"goblog/database"
+	"goblog/models"
+	"goblog/pkg/cache"
+	"goblog/pkg/ranking"
+	"goblog/pkg/related"
+	"goblog/service"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	ActionCreate    = "create"
+	ActionUpdate    = "update"
+	ActionUnchanged = "unchanged"
+	ActionSkip      = "skip"
+)
+
+// Options 是导入选项，UserID 为来源中没有作者或作者不是本站用户时文章的归属用户
+type Options struct {
+	UserID uint
+	DryRun bool
+}
+
+// Result 是一篇文章的导入结果，Comments 为新导入的评论数
+type Result struct {
+	Key      string `json:"key"`
+	Title    string `json:"title"`
+	Action   string `json:"action"`
+	Reason   string `json:"reason,omitempty"`
+	PostID   uint   `json:"post_id,omitempty"`
+	Comments int    `json:"comments"`
+}
+
+// Report 汇总一次导入，DryRun 时只统计将会执行的操作，不写入数据库
+type Report struct {
+	Format    string   `json:"format"`
+	DryRun    bool     `json:"dry_run"`
+	Created   int      `json:"created"`
+	Updated   int      `json:"updated"`
+	Unchanged int      `json:"unchanged"`
+	Skipped   int      `json:"skipped"`
+	Comments  int      `json:"comments"`
+	Items     []Result `json:"items"`
+}
+
+type importer struct {
+	db         *gorm.DB
+	opts       Options
+	authors    map[string]uint
+	commenters map[string]uint
+}
+
+// Import 导入解析出的文章。来源中的文章已经导入过时：内容没有变化的跳过；导入后在本站修改过或已删除的也跳过，
+// 避免覆盖本站的修改；其他情况用来源中的内容更新文章。评论只新增，不修改已导入的评论。
+// 导入直接写入数据库，不触发动态、webhook 等事件，完成后调用 Refresh 刷新缓存和排行
+func Import(ctx context.Context, items []Item, opts Options) (Report, error) {
+	im := &importer{
+		db:         database.DB.WithContext(ctx),
+		opts:       opts,
+		authors:    map[string]uint{},
+		commenters: map[string]uint{},
+	}
+	report := Report{DryRun: opts.DryRun}
+	for _, item := range items {
+		result, err := im.importItem(item)
+		if err != nil {
+			return report, fmt.Errorf("%s: %w", item.Key, err)
+		}
+		switch result.Action {
+		case ActionCreate:
+			report.Created++
+		case ActionUpdate:
+			report.Updated++
+		case ActionUnchanged:
+			report.Unchanged++
+		case ActionSkip:
+			report.Skipped++
+		}
+		report.Comments += result.Comments
+		report.Items = append(report.Items, result)
+	}
+	return report, nil
+}
+
+// Changed 报告这次导入是否写入了文章或评论
+func (r Report) Changed() bool {
+	return !r.DryRun && r.Created+r.Updated+r.Comments > 0
+}
+
+// Refresh 让导入或更新的文章的缓存失效，并重建排行和相关文章
+func Refresh(report Report) {
+	if !report.Changed() {
+		return
+	}
+	var posts []uint
+	for _, result := range report.Items {
+		changed := result.Action == ActionCreate || result.Action == ActionUpdate || result.Comments > 0
+		if result.PostID == 0 || !changed {
+			continue
+		}
+		service.InvalidatePost(result.PostID)
+		cache.Del(service.CommentsKey(result.PostID))
+		posts = append(posts, result.PostID)
+	}
+	if err := ranking.Rebuild(); err != nil {
+		slog.Error("import: 重建排行失败", "err", err)
+	}
+	related.MarkDirty(posts...)
+}
+
+func (im *importer) importItem(item Item) (Result, error) {
+	result := Result{Key: item.Key, Title: item.Title}
+	if item.Skip != "" {
+		result.Action, result.Reason = ActionSkip, item.Skip
+		return result, nil
+	}
+	checksum := item.checksum()
+
+	var mapping models.ImportedItem
+	err := im.db.Where("source_key = ?", sourceKey(item.Key)).First(&mapping).Error
+	imported := err == nil
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return result, err
+	}
+
+	var post models.Post
+	if imported {
+		err := im.db.Unscoped().First(&post, mapping.TargetID).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			// 导入后被永久删除，重新导入
+			imported = false
+		case err != nil:
+			return result, err
+		case post.DeletedAt.Valid:
+			result.Action, result.Reason, result.PostID = ActionSkip, "文章在回收站中", post.ID
+			return result, nil
+		}
+	}
+
+	switch {
+	case !imported:
+		result.Action = ActionCreate
+	case mapping.Checksum == checksum:
+		result.Action = ActionUnchanged
+	case post.UpdatedAt.After(mapping.UpdatedAt):
+		result.Action, result.Reason = ActionSkip, "导入后在本站修改过"
+	default:
+		result.Action = ActionUpdate
+	}
+	result.PostID = post.ID
+
+	if im.opts.DryRun {
+		comments, err := pendingComments(im.db, item.Comments)
+		result.Comments = len(comments)
+		return result, err
+	}
+
+	err = im.db.Transaction(func(tx *gorm.DB) error {
+		switch result.Action {
+		case ActionCreate:
+			if err := im.createPost(tx, item, &post); err != nil {
+				return err
+			}
+			mapping.SourceKey, mapping.TargetType, mapping.TargetID, mapping.Checksum = sourceKey(item.Key), "post", post.ID, checksum
+			if err := tx.Save(&mapping).Error; err != nil {
+				return err
+			}
+		case ActionUpdate:
+			if err := im.updatePost(tx, item, &post); err != nil {
+				return err
+			}
+			if err := tx.Model(&mapping).Update("checksum", checksum).Error; err != nil {
+				return err
+			}
+		}
+		n, err := im.importComments(tx, post.ID, item.Comments)
+		result.Comments = n
+		return err
+	})
+	result.PostID = post.ID
+	return result, err
+}
+
+func (im *importer) createPost(tx *gorm.DB, item Item, post *models.Post) error {
+	tags, err := findOrCreateTags(tx, item.Tags)
+	if err != nil {
+		return err
+	}
+	authorID, err := im.author(tx, item.Author)
+	if err != nil {
+		return err
+	}
+	created, updated := itemTimes(item)
+	*post = models.Post{
+		Title:     item.Title,
+		Content:   item.Content,
+		UserID:    authorID,
+		Tags:      tags,
+		CreatedAt: created,
+		UpdatedAt: updated,
+	}
+	post.SetStatus(status(item))
+	return tx.Create(post).Error
+}
+
+func (im *importer) updatePost(tx *gorm.DB, item Item, post *models.Post) error {
+	tags, err := findOrCreateTags(tx, item.Tags)
+	if err != nil {
+		return err
+	}
+	created, updated := itemTimes(item)
+	post.SetStatus(status(item))
+	if err := tx.Model(post).Omit(clause.Associations).Updates(map[string]any{
+		"title":      item.Title,
+		"content":    item.Content,
+		"status":     post.Status,
+		"is_draft":   post.IsDraft,
+		"created_at": created,
+		"updated_at": updated,
+	}).Error; err != nil {
+		return err
+	}
+	return tx.Model(post).Association("Tags").Replace(tags)
+}
+
+// pendingComments 返回还没有导入或导入后已被删除的评论
+func pendingComments(db *gorm.DB, comments []Comment) ([]Comment, error) {
+	if len(comments) == 0 {
+		return nil, nil
+	}
+	keys := make([]string, len(comments))
+	for i, c := range comments {
+		keys[i] = sourceKey(c.Key)
+	}
+	// 评论导入后可能随文章被永久删除，这样的评论重新导入
+	var existing []string
+	if err := db.Model(&models.ImportedItem{}).Where("source_key IN ? AND target_id IN (?)", keys,
+		db.Model(&models.Comment{}).Select("id")).Pluck("source_key", &existing).Error; err != nil {
+		return nil, err
+	}
+	var pending []Comment
+	for _, c := range comments {
+		if !slices.Contains(existing, sourceKey(c.Key)) {
+			pending = append(pending, c)
+		}
+	}
+	return pending, nil
+}
+
+// importComments 导入还没有导入的评论，回复在被回复的评论之后导入；被回复的评论不存在时作为顶层评论
+func (im *importer) importComments(tx *gorm.DB, postID uint, comments []Comment) (int, error) {
+	pending, err := pendingComments(tx, comments)
+	if err != nil || len(pending) == 0 {
+		return 0, err
+	}
+
+	ids := map[string]uint{}
+	var parentKeys []string
+	for _, c := range comments {
+		if c.ParentKey != "" {
+			parentKeys = append(parentKeys, sourceKey(c.ParentKey))
+		}
+	}
+	if len(parentKeys) > 0 {
+		var parents []models.ImportedItem
+		if err := tx.Where("source_key IN ?", parentKeys).Find(&parents).Error; err != nil {
+			return 0, err
+		}
+		for _, p := range parents {
+			ids[p.SourceKey] = p.TargetID
+		}
+	}
+	for _, c := range pending {
+		delete(ids, sourceKey(c.Key))
+	}
+
+	// 来源中的评论不一定按回复关系排序，每一轮导入被回复的评论已经导入的评论
+	imported := 0
+	for len(pending) > 0 {
+		var next []Comment
+		for _, c := range pending {
+			parentKey := sourceKey(c.ParentKey)
+			if c.ParentKey != "" && ids[parentKey] == 0 && slices.ContainsFunc(pending, func(p Comment) bool { return p.Key == c.ParentKey }) {
+				next = append(next, c)
+				continue
+			}
+			id, err := im.createComment(tx, postID, c, ids[parentKey])
+			if err != nil {
+				return imported, err
+			}
+			ids[sourceKey(c.Key)] = id
+			imported++
+		}
+		if len(next) == len(pending) {
+			// 回复关系成环，剩下的作为顶层评论导入
+			for i := range next {
+				next[i].ParentKey = ""
+			}
+		}
+		pending = next
+	}
+	return imported, nil
+}
+
+func (im *importer) createComment(tx *gorm.DB, postID uint, c Comment, parentID uint) (uint, error) {
+	userID, err := im.commenter(tx, c)
+	if err != nil {
+		return 0, err
+	}
+	date := c.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+	comment := models.Comment{Content: c.Content, UserID: userID, PostID: postID, CreatedAt: date, UpdatedAt: date}
+	if parentID != 0 {
+		comment.ParentID = &parentID
+	}
+	if err := tx.Omit(clause.Associations).Create(&comment).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Where("source_key = ?", sourceKey(c.Key)).Delete(&models.ImportedItem{}).Error; err != nil {
+		return 0, err
+	}
+	mapping := models.ImportedItem{SourceKey: sourceKey(c.Key), TargetType: "comment", TargetID: comment.ID}
+	return comment.ID, tx.Create(&mapping).Error
+}
+
+// author 按用户名或邮箱查找文章作者，找不到时使用 Options.UserID
+func (im *importer) author(tx *gorm.DB, name string) (uint, error) {
+	if name == "" {
+		return im.opts.UserID, nil
+	}
+	if id, ok := im.authors[name]; ok {
+		return id, nil
+	}
+	var user models.User
+	err := tx.Select("id").Where("username = ? OR email = ?", name, name).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		user.ID = im.opts.UserID
+	} else if err != nil {
+		return 0, err
+	}
+	im.authors[name] = user.ID
+	return user.ID, nil
+}
+
+// commenter 返回评论者对应的用户：邮箱是本站用户的直接使用，否则为评论者创建一个不能登录的用户，
+// 邮箱为 imported-<摘要>@import.invalid，重复导入时复用
+func (im *importer) commenter(tx *gorm.DB, c Comment) (uint, error) {
+	identity := strings.ToLower(c.Email)
+	if identity == "" {
+		identity = "name:" + c.Author
+	}
+	if id, ok := im.commenters[identity]; ok {
+		return id, nil
+	}
+
+	sum := sha256.Sum256([]byte(identity))
+	placeholder := "imported-" + hex.EncodeToString(sum[:6]) + "@import.invalid"
+	var user models.User
+	err := tx.Select("id").Where("email IN ?", []string{c.Email, placeholder}).Order("id").First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		var name string
+		if name, err = freeUsername(tx, c.Author); err != nil {
+			return 0, err
+		}
+		user = models.User{Username: name, Email: placeholder, Role: models.RoleUser}
+		err = tx.Create(&user).Error
+	}
+	if err != nil {
+		return 0, err
+	}
+	im.commenters[identity] = user.ID
+	return user.ID, nil
+}
+
+// freeUsername 返回没有被占用的用户名，已被占用时依次加上 -2、-3 ...
+func freeUsername(tx *gorm.DB, name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "guest"
+	}
+	for utf8.RuneCountInString(name) > 50 {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	candidate := name
+	for i := 2; ; i++ {
+		var count int64
+		if err := tx.Model(&models.User{}).Where("username = ?", candidate).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s-%d", name, i)
+	}
+}
+
+func findOrCreateTags(tx *gorm.DB, names []string) ([]*models.Tag, error) {
+	tags := make([]*models.Tag, 0, len(names))
+	for _, name := range names {
+		var tag models.Tag
+		if err := tx.FirstOrCreate(&tag, models.Tag{Name: name}).Error; err != nil {
+			return nil, err
+		}
+		tags = append(tags, &tag)
+	}
+	return tags, nil
+}
+
+// itemTimes 返回文章的创建和修改时间，来源中没有时间时使用当前时间
+func itemTimes(item Item) (time.Time, time.Time) {
+	created, updated := item.Date, item.Updated
+	if created.IsZero() {
+		created = time.Now()
+	}
+	if updated.IsZero() || updated.Before(created) {
+		updated = created
+	}
+	return created, updated
+}
+
+func status(item Item) string {
+	if item.Draft {
+		return models.PostDraft
+	}
+	return models.PostPublished
+}
+
+// sourceKey 把过长的来源标识截断并加上摘要，保证不超过 source_key 列的长度
+func sourceKey(key string) string {
+	if len(key) <= 500 {
+		return key
+	}
+	sum := sha256.Sum256([]byte(key))
+	cut := 500 - 65
+	for !utf8.RuneStart(key[cut]) {
+		cut--
+	}
+	return key[:cut] + "#" + hex.EncodeToString(sum[:])
+}
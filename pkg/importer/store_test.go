@@ -0,0 +1,162 @@
+package importer
+
+import (
+	"context"
+	"goblog/database/databasetest"
+	"goblog/models"
+	"os"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// counts 返回文章、评论、用户、标签和导入记录的数量
+func counts(t *testing.T, db *gorm.DB) [5]int64 {
+	t.Helper()
+
+	var n [5]int64
+	for i, model := range []any{&models.Post{}, &models.Comment{}, &models.User{}, &models.Tag{}, &models.ImportedItem{}} {
+		if err := db.Model(model).Count(&n[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return n
+}
+
+func TestImport(t *testing.T) {
+	databasetest.ForEach(t, func(t *testing.T, driver string) {
+		db := databasetest.Open(t, driver)
+
+		owner := models.User{Username: "owner", Email: "owner@example.com", Password: "hash"}
+		alice := models.User{Username: "alice", Email: "alice@example.com", Password: "hash"}
+		for _, u := range []*models.User{&owner, &alice} {
+			if err := db.Create(u).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		data, err := os.ReadFile("testdata/wordpress.xml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		wxr, _, err := LoadFile("wordpress.xml", data, FormatAuto)
+		if err != nil {
+			t.Fatal(err)
+		}
+		jekyll, _, err := Load(os.DirFS("testdata/jekyll"), FormatAuto)
+		if err != nil {
+			t.Fatal(err)
+		}
+		items := append(wxr, jekyll...)
+		opts := Options{UserID: owner.ID}
+		ctx := context.Background()
+
+		// 试运行只统计，不写入
+		before := counts(t, db)
+		report, err := Import(ctx, items, Options{UserID: owner.ID, DryRun: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Created != 5 || report.Skipped != 1 || report.Comments != 2 || report.Changed() {
+			t.Fatalf("试运行报告 %+v", report)
+		}
+		if counts(t, db) != before {
+			t.Fatal("试运行不应写入数据库")
+		}
+
+		report, err = Import(ctx, items, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Created != 5 || report.Skipped != 1 || report.Comments != 2 || !report.Changed() {
+			t.Fatalf("首次导入报告 %+v", report)
+		}
+		imported := counts(t, db)
+
+		// 作者是本站用户的归到该用户，否则归到 Options.UserID；回复保留回复关系；评论者创建为占位用户
+		var post models.Post
+		if err := db.Preload("Tags").First(&post, report.Items[0].PostID).Error; err != nil {
+			t.Fatal(err)
+		}
+		if post.UserID != alice.ID || post.Title != "WordPress 文章" || post.IsDraft || len(post.Tags) != 2 {
+			t.Fatalf("导入的文章为 %+v", post)
+		}
+		if !post.CreatedAt.Equal(time.Date(2019, 5, 6, 0, 0, 0, 0, time.UTC)) || !post.UpdatedAt.Equal(time.Date(2019, 5, 7, 0, 0, 0, 0, time.UTC)) {
+			t.Fatalf("导入的文章时间为 %v / %v", post.CreatedAt, post.UpdatedAt)
+		}
+		var comments []models.Comment
+		if err := db.Where("post_id = ?", post.ID).Order("id").Find(&comments).Error; err != nil {
+			t.Fatal(err)
+		}
+		if len(comments) != 2 || comments[0].ParentID != nil || comments[1].ParentID == nil || *comments[1].ParentID != comments[0].ID {
+			t.Fatalf("导入的评论为 %+v", comments)
+		}
+		var draft models.Post
+		if err := db.First(&draft, report.Items[1].PostID).Error; err != nil {
+			t.Fatal(err)
+		}
+		if draft.UserID != owner.ID || !draft.IsDraft || draft.Status != models.PostDraft {
+			t.Fatalf("导入的草稿为 %+v", draft)
+		}
+
+		// 重复导入同一来源不产生任何变化
+		report, err = Import(ctx, items, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Unchanged != 5 || report.Skipped != 1 || report.Created+report.Updated+report.Comments != 0 || report.Changed() {
+			t.Fatalf("重复导入报告 %+v", report)
+		}
+		if counts(t, db) != imported {
+			t.Fatalf("重复导入后记录数为 %v，期望 %v", counts(t, db), imported)
+		}
+
+		// 来源中修改过的文章更新，在本站修改过的不覆盖，删除的评论重新导入
+		if err := db.Model(&models.Post{}).Where("id = ?", report.Items[3].PostID).Update("title", "本站修改").Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Unscoped().Delete(&comments[1]).Error; err != nil {
+			t.Fatal(err)
+		}
+		items[0].Title = "来源中修改"
+		items[3].Title = "来源中也修改了"
+		report, err = Import(ctx, items, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := report.Items[0]; got.Action != ActionUpdate || got.Comments != 1 {
+			t.Fatalf("来源中修改的文章 %+v", got)
+		}
+		if got := report.Items[3]; got.Action != ActionSkip || got.Reason != "导入后在本站修改过" {
+			t.Fatalf("本站修改过的文章 %+v", got)
+		}
+		if err := db.First(&post, report.Items[0].PostID).Error; err != nil {
+			t.Fatal(err)
+		}
+		if post.Title != "来源中修改" {
+			t.Fatalf("更新后的标题为 %q", post.Title)
+		}
+		if counts(t, db) != imported {
+			t.Fatalf("更新后记录数为 %v，期望 %v", counts(t, db), imported)
+		}
+
+		// 回收站中的文章不恢复，永久删除的重新导入
+		if err := db.Delete(&models.Post{}, report.Items[0].PostID).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Unscoped().Delete(&models.Post{}, report.Items[1].PostID).Error; err != nil {
+			t.Fatal(err)
+		}
+		report, err = Import(ctx, items, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := report.Items[0]; got.Action != ActionSkip || got.Reason != "文章在回收站中" {
+			t.Fatalf("回收站中的文章 %+v", got)
+		}
+		if got := report.Items[1]; got.Action != ActionCreate {
+			t.Fatalf("永久删除的文章 %+v", got)
+		}
+	})
+}
@@ -0,0 +1,117 @@
+package importer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const wxrDateLayout = "2006-01-02 15:04:05"
+
+type wxrRSS struct {
+	Items []wxrItem `xml:"channel>item"`
+}
+
+type wxrItem struct {
+	Title      string        `xml:"title"`
+	GUID       string        `xml:"guid"`
+	Creator    string        `xml:"creator"`
+	Content    string        `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	PostID     string        `xml:"post_id"`
+	Date       string        `xml:"post_date"`
+	DateGMT    string        `xml:"post_date_gmt"`
+	ModGMT     string        `xml:"post_modified_gmt"`
+	Status     string        `xml:"status"`
+	PostType   string        `xml:"post_type"`
+	Categories []wxrCategory `xml:"category"`
+	Comments   []wxrComment  `xml:"comment"`
+}
+
+type wxrCategory struct {
+	Domain   string `xml:"domain,attr"`
+	Nicename string `xml:"nicename,attr"`
+	Name     string `xml:",chardata"`
+}
+
+type wxrComment struct {
+	ID       string `xml:"comment_id"`
+	Author   string `xml:"comment_author"`
+	Email    string `xml:"comment_author_email"`
+	DateGMT  string `xml:"comment_date_gmt"`
+	Content  string `xml:"comment_content"`
+	Approved string `xml:"comment_approved"`
+	Type     string `xml:"comment_type"`
+	Parent   string `xml:"comment_parent"`
+}
+
+// ParseWXR 解析 WordPress 导出的 WXR 文件，只导入文章（不含页面、附件等），
+// 分类和标签都作为标签导入，只导入已通过审核的评论，不导入 pingback/trackback。
+// 正文保持 WordPress 的 HTML，Markdown 中可以直接使用
+func ParseWXR(r io.Reader) ([]Item, error) {
+	var rss wxrRSS
+	if err := xml.NewDecoder(r).Decode(&rss); err != nil {
+		return nil, fmt.Errorf("解析 WXR 失败: %w", err)
+	}
+
+	var items []Item
+	for _, post := range rss.Items {
+		if post.PostType != "post" || post.Status == "auto-draft" {
+			continue
+		}
+
+		key := "wxr:" + strings.TrimSpace(post.GUID)
+		if key == "wxr:" {
+			key = "wxr:post-" + strings.TrimSpace(post.PostID)
+		}
+		item := Item{
+			Key:     key,
+			Title:   strings.TrimSpace(post.Title),
+			Content: strings.TrimSpace(post.Content),
+			Author:  strings.TrimSpace(post.Creator),
+			Date:    wxrTime(post.DateGMT, post.Date),
+			Updated: wxrTime(post.ModGMT, ""),
+			Draft:   post.Status != "publish",
+		}
+		if item.Title == "" {
+			item.Title = "(无标题)"
+		}
+		if post.Status == "trash" {
+			item.Skip = "WordPress 回收站中的文章"
+		}
+		for _, category := range post.Categories {
+			if (category.Domain == "category" || category.Domain == "post_tag") && category.Nicename != "uncategorized" {
+				item.Tags = appendUnique(item.Tags, strings.TrimSpace(category.Name))
+			}
+		}
+
+		for _, comment := range post.Comments {
+			if comment.Approved != "1" || (comment.Type != "" && comment.Type != "comment") {
+				continue
+			}
+			c := Comment{
+				Key:     fmt.Sprintf("%s#comment-%s", key, strings.TrimSpace(comment.ID)),
+				Author:  strings.TrimSpace(comment.Author),
+				Email:   strings.TrimSpace(comment.Email),
+				Content: strings.TrimSpace(comment.Content),
+				Date:    wxrTime(comment.DateGMT, ""),
+			}
+			if parent := strings.TrimSpace(comment.Parent); parent != "" && parent != "0" {
+				c.ParentKey = fmt.Sprintf("%s#comment-%s", key, parent)
+			}
+			item.Comments = append(item.Comments, c)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// wxrTime 优先使用 GMT 时间，草稿的 GMT 时间为 0000-00-00 00:00:00，此时使用站点时区的时间
+func wxrTime(gmt, local string) time.Time {
+	if t, err := time.Parse(wxrDateLayout, strings.TrimSpace(gmt)); err == nil {
+		return t
+	}
+	t, _ := time.Parse(wxrDateLayout, strings.TrimSpace(local))
+	return t
+}
@@ -0,0 +1,126 @@
+// Package lifecycle 按顺序启动应用的各个组件，关闭时按相反顺序停止。
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// Hook 是一个组件的启动和停止逻辑，两者都可以为空
+type Hook struct {
+	Name    string
+	OnStart func(ctx context.Context) error
+	OnStop  func(ctx context.Context) error
+}
+
+type Lifecycle struct {
+	hooks   []Hook
+	started int
+}
+
+func New() *Lifecycle {
+	return &Lifecycle{}
+}
+
+func (l *Lifecycle) Append(hooks ...Hook) {
+	l.hooks = append(l.hooks, hooks...)
+}
+
+// Start 依次启动各组件，某个组件启动失败时停止已启动的组件并返回错误
+func (l *Lifecycle) Start(ctx context.Context) error {
+	for _, hook := range l.hooks {
+		if hook.OnStart != nil {
+			if err := hook.OnStart(ctx); err != nil {
+				startErr := fmt.Errorf("启动 %s 失败: %w", hook.Name, err)
+				if stopErr := l.Stop(ctx); stopErr != nil {
+					return errors.Join(startErr, stopErr)
+				}
+				return startErr
+			}
+		}
+		l.started++
+		slog.Info("lifecycle: 已启动", "component", hook.Name)
+	}
+	return nil
+}
+
+// Stop 按启动的相反顺序停止已启动的组件，ctx 超时后剩余组件仍会收到已取消的 ctx
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	var errs []error
+	for ; l.started > 0; l.started-- {
+		hook := l.hooks[l.started-1]
+		if hook.OnStop == nil {
+			continue
+		}
+		if err := hook.OnStop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("停止 %s 失败: %w", hook.Name, err))
+			continue
+		}
+		slog.Info("lifecycle: 已停止", "component", hook.Name)
+	}
+	return errors.Join(errs...)
+}
+
+// Background 把阻塞运行直到 ctx 取消的后台任务包装为 Hook，停止时取消 ctx 并等待任务退出
+func Background(name string, run func(ctx context.Context)) Hook {
+	var cancel context.CancelFunc
+	done := make(chan struct{})
+
+	return Hook{
+		Name: name,
+		OnStart: func(context.Context) error {
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(context.Background())
+			go func() {
+				defer close(done)
+				run(ctx)
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}
+}
+
+// tasks 记录请求处理过程中派生的后台任务，如记录浏览、更新排行和写入 webhook 队列
+var tasks sync.WaitGroup
+
+// Go 在新的 goroutine 中运行 fn，关闭时 Drain 会等待它完成后再关闭 Redis 和数据库
+func Go(fn func()) {
+	tasks.Add(1)
+	go func() {
+		defer tasks.Done()
+		fn()
+	}()
+}
+
+// Drain 返回在停止时等待 Go 启动的任务全部完成的 Hook，应放在 HTTP 服务之前、数据库和 Redis 之后，
+// 这样停止时 HTTP 服务已不再派生新任务，而任务用到的连接还没有关闭
+func Drain(name string) Hook {
+	return Hook{
+		Name: name,
+		OnStop: func(ctx context.Context) error {
+			done := make(chan struct{})
+			go func() {
+				tasks.Wait()
+				close(done)
+			}()
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}
+}
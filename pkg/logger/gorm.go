@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// Gorm 把 GORM 的日志写入 slog：执行出错记 warn，超过 slowThreshold 的查询记 warn，
+// debug 级别下记录每条 SQL。slowThreshold 为 0 时不记录慢查询
+func Gorm(slowThreshold time.Duration) gormlogger.Interface {
+	return gormLogger{slowThreshold: slowThreshold, level: gormlogger.Info}
+}
+
+type gormLogger struct {
+	slowThreshold time.Duration
+	level         gormlogger.LogLevel
+}
+
+// LogMode 只用来支持 Session(&gorm.Session{Logger: ...LogMode(logger.Silent)}) 这类局部静默，
+// 其余级别以 slog 的配置为准
+func (l gormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	l.level = level
+	return l
+}
+
+func (l gormLogger) Info(ctx context.Context, msg string, data ...any) {
+	if l.level >= gormlogger.Info {
+		slog.InfoContext(ctx, "gorm: "+fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l gormLogger) Warn(ctx context.Context, msg string, data ...any) {
+	if l.level >= gormlogger.Warn {
+		slog.WarnContext(ctx, "gorm: "+fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l gormLogger) Error(ctx context.Context, msg string, data ...any) {
+	if l.level >= gormlogger.Error {
+		slog.ErrorContext(ctx, "gorm: "+fmt.Sprintf(msg, data...))
+	}
+}
+
+// ParamsFilter 只在 debug 级别把参数值写进 SQL，避免密码哈希、邮箱等出现在慢查询和出错日志里
+func (l gormLogger) ParamsFilter(ctx context.Context, sql string, params ...any) (string, []any) {
+	if slog.Default().Enabled(ctx, slog.LevelDebug) {
+		return sql, params
+	}
+	return sql, nil
+}
+
+func (l gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+	attrs := func() []any {
+		sql, rows := fc()
+		return []any{"sql", sql, "rows", rows, "elapsed_ms", float64(elapsed.Microseconds()) / 1000}
+	}
+
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && l.level >= gormlogger.Error:
+		slog.WarnContext(ctx, "SQL 执行出错", append(attrs(), "err", err)...)
+	case l.slowThreshold > 0 && elapsed > l.slowThreshold && l.level >= gormlogger.Warn:
+		slog.WarnContext(ctx, "慢查询", append(attrs(), "threshold_ms", l.slowThreshold.Milliseconds())...)
+	case slog.Default().Enabled(ctx, slog.LevelDebug):
+		slog.DebugContext(ctx, "SQL", attrs()...)
+	}
+}
@@ -0,0 +1,65 @@
+package logger
+
+import (
+	"context"
+	"goblog/config"
+	"io"
+	"log/slog"
+	"os"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+var level = new(slog.LevelVar)
+
+// Init 按配置创建 slog 日志并设为默认，log 包的输出也会经过它。
+// 使用 slog.InfoContext 等带 ctx 的函数时，日志会带上请求 ID 和 trace ID
+func Init(conf config.LogConfig) {
+	slog.SetDefault(slog.New(newHandler(os.Stdout, conf)))
+}
+
+func newHandler(w io.Writer, conf config.LogConfig) slog.Handler {
+	// 配置已经校验过，这里不会失败
+	_ = level.UnmarshalText([]byte(conf.Level))
+	opts := &slog.HandlerOptions{Level: level}
+	if conf.Format == "text" {
+		return contextHandler{slog.NewTextHandler(w, opts)}
+	}
+	return contextHandler{slog.NewJSONHandler(w, opts)}
+}
+
+type requestIDKey struct{}
+
+// WithRequestID 把请求 ID 放进 ctx，之后用这个 ctx 记录的日志都会带上它
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID 返回 ctx 中的请求 ID，没有时返回空字符串
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// contextHandler 从 ctx 中取出请求 ID 和 trace ID 附加到每条日志
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,46 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var nonWord = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Create 在 root 下每个 dirs 子目录中创建同一版本的 up/down 空迁移文件，返回创建的文件路径。
+// 版本号取所有子目录中最大的版本加一，各数据库的迁移版本保持一致
+func Create(root string, dirs []string, name string) ([]string, error) {
+	name = strings.Trim(nonWord.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return nil, errors.New("迁移名称只能包含字母、数字和下划线")
+	}
+
+	var version int64 = 1
+	for _, dir := range dirs {
+		existing, err := Load(os.DirFS(filepath.Join(root, dir)))
+		if err != nil {
+			return nil, err
+		}
+		if len(existing) > 0 && existing[len(existing)-1].Version >= version {
+			version = existing[len(existing)-1].Version + 1
+		}
+	}
+
+	var created []string
+	for _, dir := range dirs {
+		base := filepath.Join(root, dir, fmt.Sprintf("%04d_%s", version, name))
+		up, down := base+".up.sql", base+".down.sql"
+		if err := os.WriteFile(up, []byte("-- "+name+"\n"), 0o644); err != nil {
+			return created, err
+		}
+		if err := os.WriteFile(down, []byte("-- 回滚 "+name+"\n"), 0o644); err != nil {
+			return created, err
+		}
+		created = append(created, up, down)
+	}
+	return created, nil
+}
@@ -0,0 +1,83 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// lockKey 是迁移使用的锁编号，多个实例同时启动时只有一个在执行迁移
+const lockKey int64 = 0x676f626c6f67 // "goblog"
+
+// Dialect 是迁移器需要区分的数据库差异：参数占位符、schema_migrations 建表语句和迁移锁
+type Dialect struct {
+	Name        string
+	placeholder func(n int) string
+	createTable string
+	// lock 在 conn 上获取会话级的迁移锁，返回的函数用于释放
+	lock func(ctx context.Context, conn *sql.Conn) (func(), error)
+	// TransactionalDDL 为 true 时每个迁移在事务中执行，由调用方按数据库特性设置
+	TransactionalDDL bool
+}
+
+var (
+	Postgres = Dialect{
+		Name:        "postgres",
+		placeholder: func(n int) string { return fmt.Sprintf("$%d", n) },
+		createTable: `CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    bigint PRIMARY KEY,
+			name       text NOT NULL,
+			applied_at timestamptz NOT NULL
+		)`,
+		lock: func(ctx context.Context, conn *sql.Conn) (func(), error) {
+			if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+				return nil, err
+			}
+			return func() { conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey) }, nil
+		},
+	}
+
+	// MySQL 执行 DDL 时会隐式提交事务，迁移中途失败时已执行的语句不会回滚，需要手动处理
+	MySQL = Dialect{
+		Name:        "mysql",
+		placeholder: func(int) string { return "?" },
+		createTable: `CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    bigint NOT NULL PRIMARY KEY,
+			name       varchar(255) NOT NULL,
+			applied_at datetime(6) NOT NULL
+		)`,
+		lock: func(ctx context.Context, conn *sql.Conn) (func(), error) {
+			name := fmt.Sprintf("goblog_migrate_%d", lockKey)
+			var got sql.NullInt64
+			if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, -1)", name).Scan(&got); err != nil {
+				return nil, err
+			}
+			if got.Int64 != 1 {
+				return nil, fmt.Errorf("GET_LOCK 返回 %v", got)
+			}
+			return func() { conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", name) }, nil
+		},
+	}
+
+	// SQLite 没有命名锁，同一个数据库文件的写事务本身是串行的，迁移不再额外加锁
+	SQLite = Dialect{
+		Name:        "sqlite",
+		placeholder: func(int) string { return "?" },
+		createTable: `CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    integer PRIMARY KEY,
+			name       text NOT NULL,
+			applied_at datetime NOT NULL
+		)`,
+		lock: func(context.Context, *sql.Conn) (func(), error) { return func() {}, nil },
+	}
+)
+
+// DialectFor 按驱动名返回对应的 Dialect
+func DialectFor(name string) (Dialect, error) {
+	for _, d := range []Dialect{Postgres, MySQL, SQLite} {
+		if d.Name == name {
+			return d, nil
+		}
+	}
+	return Dialect{}, fmt.Errorf("迁移不支持数据库驱动 %q", name)
+}
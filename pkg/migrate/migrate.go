@@ -0,0 +1,296 @@
+// Package migrate 执行嵌入在二进制中的版本化 SQL 迁移。
+//
+// 迁移文件命名为 0001_name.up.sql 和 0001_name.down.sql，版本号递增，
+// 支持事务性 DDL 的数据库上每个迁移在一个事务中执行，执行后写入 schema_migrations 表。
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string // 为空表示不可回滚
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// Status 是一个迁移的执行状态，AppliedAt 为空表示尚未执行
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+	Missing   bool // 数据库中已执行，但当前程序中没有这个迁移，通常是数据库被更新版本的程序迁移过
+}
+
+// Load 读取 fsys 根目录下的迁移文件，按版本号排序
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("迁移文件名 %s 不符合 0001_name.up.sql 格式", entry.Name())
+		}
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("版本 %d 有多个迁移: %s 和 %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("迁移 %s 缺少 up 文件", m)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration
+}
+
+func New(db *sql.DB, dialect Dialect, migrations []Migration) *Migrator {
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}
+}
+
+// Up 按版本顺序执行最多 n 个未执行的迁移，n <= 0 时执行全部，返回本次执行的迁移
+func (m *Migrator) Up(ctx context.Context, n int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if n > 0 && len(done) == n {
+				break
+			}
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			err := m.run(ctx, conn, migration.Up, m.bind("INSERT INTO schema_migrations (version, name, applied_at) VALUES (%s, %s, %s)", 3),
+				migration.Version, migration.Name, time.Now().UTC())
+			if err != nil {
+				return fmt.Errorf("执行迁移 %s 失败: %w", migration, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down 按版本倒序回滚最近执行的 n 个迁移，返回本次回滚的迁移
+func (m *Migrator) Down(ctx context.Context, n int) ([]Migration, error) {
+	if n <= 0 {
+		return nil, errors.New("回滚数量必须大于 0")
+	}
+
+	known := make(map[int64]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = migration
+	}
+
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		versions := make([]int64, 0, len(applied))
+		for version := range applied {
+			versions = append(versions, version)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+		for _, version := range versions {
+			if len(done) == n {
+				break
+			}
+			migration, ok := known[version]
+			if !ok {
+				return fmt.Errorf("版本 %d 已执行，但当前程序中没有这个迁移，无法回滚", version)
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("迁移 %s 没有 down 文件，不能回滚", migration)
+			}
+			err := m.run(ctx, conn, migration.Down, m.bind("DELETE FROM schema_migrations WHERE version = %s", 1), migration.Version)
+			if err != nil {
+				return fmt.Errorf("回滚迁移 %s 失败: %w", migration, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status 返回所有迁移的执行状态，包括数据库中有记录但程序中不存在的迁移
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			s := Status{Migration: migration}
+			if record, ok := applied[migration.Version]; ok {
+				s.AppliedAt = &record.appliedAt
+				delete(applied, migration.Version)
+			}
+			statuses = append(statuses, s)
+		}
+		for version, record := range applied {
+			statuses = append(statuses, Status{
+				Migration: Migration{Version: version, Name: record.name},
+				AppliedAt: &record.appliedAt,
+				Missing:   true,
+			})
+		}
+		return nil
+	})
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, err
+}
+
+// Pending 返回尚未执行的迁移数量
+func (m *Migrator) Pending(ctx context.Context) (int, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+	pending := 0
+	for _, s := range statuses {
+		if s.AppliedAt == nil {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+// withLock 在持有迁移锁的连接上执行 fn。锁属于会话，所以加锁、迁移和解锁必须用同一个连接
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	unlock, err := m.dialect.lock(ctx, conn)
+	if err != nil {
+		return fmt.Errorf("获取迁移锁失败: %w", err)
+	}
+	defer unlock()
+
+	if _, err := conn.ExecContext(ctx, m.dialect.createTable); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+// bind 把 query 中的 n 个 %s 替换为当前数据库的参数占位符
+func (m *Migrator) bind(query string, n int) string {
+	args := make([]any, n)
+	for i := range args {
+		args[i] = m.dialect.placeholder(i + 1)
+	}
+	return fmt.Sprintf(query, args...)
+}
+
+type appliedRecord struct {
+	name      string
+	appliedAt time.Time
+}
+
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int64]appliedRecord, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, name, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int64]appliedRecord{}
+	for rows.Next() {
+		var version int64
+		var record appliedRecord
+		if err := rows.Scan(&version, &record.name, &record.appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = record
+	}
+	return applied, rows.Err()
+}
+
+// run 执行迁移脚本，再用 record 更新 schema_migrations。
+// 不支持事务性 DDL 的数据库放进事务也会被隐式提交，所以直接执行，失败时已执行的语句需要手动处理
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn, script, record string, args ...any) error {
+	if !m.dialect.TransactionalDDL {
+		if _, err := conn.ExecContext(ctx, script); err != nil {
+			return fmt.Errorf("%w（%s 不能回滚 DDL，失败前已执行的语句需要手动处理）", err, m.dialect.Name)
+		}
+		_, err := conn.ExecContext(ctx, record, args...)
+		return err
+	}
+	return inTx(ctx, conn, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, script); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, record, args...)
+		return err
+	})
+}
+
+func inTx(ctx context.Context, conn *sql.Conn, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
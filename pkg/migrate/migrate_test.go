@@ -0,0 +1,51 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	_ "github.com/glebarez/go-sqlite"
+)
+
+// broken 的第二条语句会失败
+var broken = fstest.MapFS{
+	"0001_users.up.sql":   {Data: []byte("CREATE TABLE users (id integer PRIMARY KEY);")},
+	"0001_users.down.sql": {Data: []byte("DROP TABLE users;")},
+	"0002_posts.up.sql":   {Data: []byte("CREATE TABLE posts (id integer PRIMARY KEY); CREATE TABLE users (id integer);")},
+}
+
+func TestRun(t *testing.T) {
+	for _, transactional := range []bool{true, false} {
+		db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+
+		migrations, err := Load(broken)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dialect := SQLite
+		dialect.TransactionalDDL = transactional
+		m := New(db, dialect, migrations)
+
+		applied, err := m.Up(context.Background(), 0)
+		if err == nil || len(applied) != 1 {
+			t.Fatalf("TransactionalDDL=%v: 第二个迁移应失败，得到 %v, %v", transactional, applied, err)
+		}
+		if pending, _ := m.Pending(context.Background()); pending != 1 {
+			t.Fatalf("TransactionalDDL=%v: 失败的迁移不应记录为已执行，未执行 %d 个", transactional, pending)
+		}
+
+		// 事务中执行时失败的迁移整体回滚，否则失败前的语句已经生效
+		var n int
+		db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'posts'").Scan(&n)
+		if exists := n == 1; exists == transactional {
+			t.Fatalf("TransactionalDDL=%v: posts 表存在 = %v", transactional, exists)
+		}
+	}
+}
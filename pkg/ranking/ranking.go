@@ -0,0 +1,200 @@
+package ranking
+
+import (
+	"errors"
+	"fmt"
+	"goblog/database"
+	"goblog/models"
+	"goblog/pkg/cache"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// 各类互动在热度中的权重
+const (
+	WeightView    = 0.1
+	WeightLike    = 3
+	WeightComment = 5
+)
+
+const (
+	WindowDay   = "day"
+	WindowWeek  = "week"
+	WindowMonth = "month"
+	WindowAll   = "all"
+)
+
+const (
+	hotKey     = "ranking:hot"
+	pointsKey  = "ranking:points"
+	createdKey = "ranking:created"
+	allTimeKey = "ranking:top:all"
+
+	// 每过 decaySeconds，新文章需要的互动量就是旧文章的 10 倍才能排在一起
+	decaySeconds = 45000
+	dailyTTL     = 32 * 24 * time.Hour
+	windowTTL    = time.Minute
+)
+
+var ErrInvalidWindow = errors.New("window 只能是 day、week、month 或 all")
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+
+func dailyKey(t time.Time) string {
+	return "ranking:top:" + t.UTC().Format("2006-01-02")
+}
+
+// HotScore 随时间衰减的热度：互动量取对数，加上发布时间带来的基础分
+func HotScore(points float64, createdAt time.Time) float64 {
+	return math.Log10(math.Max(points, 1)) + float64(createdAt.Unix()-epoch)/decaySeconds
+}
+
+func member(postID uint) string {
+	return strconv.FormatUint(uint64(postID), 10)
+}
+
+// Add 把新发布的文章加入热度榜
+func Add(post models.Post) {
+	if post.IsDraft {
+		return
+	}
+	pipe := cache.Rdb.Pipeline()
+	pipe.HSet(cache.Ctx, createdKey, member(post.ID), post.CreatedAt.Unix())
+	pipe.ZAddNX(cache.Ctx, hotKey, redis.Z{Score: HotScore(0, post.CreatedAt), Member: member(post.ID)})
+	if _, err := pipe.Exec(cache.Ctx); err != nil {
+		slog.Warn("ranking: 添加文章失败", "post_id", post.ID, "err", err)
+	}
+}
+
+// bumpScript 只给排行中的文章累加互动量，文章已被 Remove 移出时返回 false，避免留下孤立的 points 字段
+var bumpScript = redis.NewScript(`
+if redis.call("HEXISTS", KEYS[1], ARGV[1]) == 0 then
+	return false
+end
+return redis.call("HINCRBYFLOAT", KEYS[2], ARGV[1], ARGV[2])
+`)
+
+// Bump 在文章获得一次互动后增量更新热度和各时间窗口的排行，草稿和已删除的文章不计入
+func Bump(postID uint, weight float64) {
+	id := member(postID)
+
+	createdAt, err := postCreatedAt(postID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return
+	}
+	if err != nil {
+		slog.Warn("ranking: 查询文章失败", "post_id", postID, "err", err)
+		return
+	}
+
+	points, err := bumpScript.Run(cache.Ctx, cache.Rdb, []string{createdKey, pointsKey}, id, weight).Float64()
+	if errors.Is(err, redis.Nil) {
+		return
+	}
+	if err != nil {
+		slog.Warn("ranking: 更新文章热度失败", "post_id", postID, "err", err)
+		return
+	}
+
+	today := dailyKey(time.Now())
+	pipe := cache.Rdb.Pipeline()
+	pipe.ZAdd(cache.Ctx, hotKey, redis.Z{Score: HotScore(points, createdAt), Member: id})
+	pipe.ZIncrBy(cache.Ctx, today, weight, id)
+	pipe.Expire(cache.Ctx, today, dailyTTL)
+	pipe.ZIncrBy(cache.Ctx, allTimeKey, weight, id)
+	if _, err := pipe.Exec(cache.Ctx); err != nil {
+		slog.Warn("ranking: 更新文章热度失败", "post_id", postID, "err", err)
+	}
+}
+
+// postCreatedAt 返回已发布文章的发布时间，createdKey 中只有已发布的文章，
+// 不在其中时查库，草稿和已删除的文章返回 gorm.ErrRecordNotFound
+func postCreatedAt(postID uint) (time.Time, error) {
+	id := member(postID)
+	if v, err := cache.Rdb.HGet(cache.Ctx, createdKey, id).Int64(); err == nil {
+		return time.Unix(v, 0), nil
+	}
+
+	var post models.Post
+	if err := database.DB.Select("id", "created_at").Where("is_draft = ?", false).First(&post, postID).Error; err != nil {
+		return time.Time{}, err
+	}
+	cache.Rdb.HSet(cache.Ctx, createdKey, id, post.CreatedAt.Unix())
+	return post.CreatedAt, nil
+}
+
+// Remove 把删除或撤回为草稿的文章移出所有排行
+func Remove(postID uint) {
+	id := member(postID)
+	pipe := cache.Rdb.Pipeline()
+	pipe.ZRem(cache.Ctx, hotKey, id)
+	pipe.ZRem(cache.Ctx, allTimeKey, id)
+	for i := 0; i < 31; i++ {
+		pipe.ZRem(cache.Ctx, dailyKey(time.Now().AddDate(0, 0, -i)), id)
+	}
+	pipe.HDel(cache.Ctx, pointsKey, id)
+	pipe.HDel(cache.Ctx, createdKey, id)
+	if _, err := pipe.Exec(cache.Ctx); err != nil {
+		slog.Warn("ranking: 移除文章失败", "post_id", postID, "err", err)
+	}
+}
+
+// Hot 返回热度榜中第 start 名起的 count 篇文章 ID
+func Hot(start, count int64) ([]uint, error) {
+	return rangeIDs(hotKey, start, count)
+}
+
+// Top 返回时间窗口内互动最多的文章 ID
+func Top(window string, start, count int64) ([]uint, error) {
+	days := 0
+	switch window {
+	case WindowAll:
+		return rangeIDs(allTimeKey, start, count)
+	case WindowDay:
+		return rangeIDs(dailyKey(time.Now()), start, count)
+	case WindowWeek:
+		days = 7
+	case WindowMonth:
+		days = 30
+	default:
+		return nil, ErrInvalidWindow
+	}
+
+	key := fmt.Sprintf("ranking:top:%s:%s", window, time.Now().UTC().Format("2006-01-02"))
+	exists, err := cache.Rdb.Exists(cache.Ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	if exists == 0 {
+		keys := make([]string, days)
+		for i := range keys {
+			keys[i] = dailyKey(time.Now().AddDate(0, 0, -i))
+		}
+		pipe := cache.Rdb.TxPipeline()
+		pipe.ZUnionStore(cache.Ctx, key, &redis.ZStore{Keys: keys})
+		pipe.Expire(cache.Ctx, key, windowTTL)
+		if _, err := pipe.Exec(cache.Ctx); err != nil {
+			return nil, err
+		}
+	}
+	return rangeIDs(key, start, count)
+}
+
+func rangeIDs(key string, start, count int64) ([]uint, error) {
+	members, err := cache.Rdb.ZRevRange(cache.Ctx, key, start, start+count-1).Result()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(members))
+	for _, m := range members {
+		if id, err := strconv.ParseUint(m, 10, 64); err == nil {
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,250 @@
+package ranking
+
+import (
+	"goblog/config"
+	"goblog/database/databasetest"
+	"goblog/models"
+	"goblog/pkg/cache"
+	"math"
+	"net"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"gorm.io/gorm"
+)
+
+func setupRedis(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	host, port, _ := net.SplitHostPort(mr.Addr())
+	conf := config.Default()
+	conf.Redis.Host = host
+	conf.Redis.Port, _ = strconv.Atoi(port)
+	if err := cache.InitRedis(conf.Redis, conf.Cache); err != nil {
+		t.Fatal(err)
+	}
+	return mr
+}
+
+func TestHotScore(t *testing.T) {
+	created := time.Unix(epoch, 0)
+
+	// 没有互动和只有 1 分互动的文章得分相同，不会出现负的对数
+	if got := HotScore(0, created); got != 0 {
+		t.Errorf("HotScore(0, epoch) = %v，期望 0", got)
+	}
+	if HotScore(0.5, created) != HotScore(1, created) {
+		t.Error("互动量不足 1 时应按 1 计算")
+	}
+	if got := HotScore(100, created); math.Abs(got-2) > 1e-9 {
+		t.Errorf("HotScore(100, epoch) = %v，期望 2", got)
+	}
+
+	// 晚发布 decaySeconds 的文章只需要十分之一的互动量就能排在一起
+	later := created.Add(decaySeconds * time.Second)
+	if math.Abs(HotScore(10, later)-HotScore(100, created)) > 1e-9 {
+		t.Errorf("HotScore(10, +decay) = %v，HotScore(100, epoch) = %v，期望相等", HotScore(10, later), HotScore(100, created))
+	}
+	if HotScore(10, later.Add(time.Second)) <= HotScore(100, created) {
+		t.Error("互动量相当时新文章应排在前面")
+	}
+}
+
+func TestTop(t *testing.T) {
+	mr := setupRedis(t)
+
+	now := time.Now()
+	for _, c := range []struct {
+		daysAgo int
+		id      string
+		score   float64
+	}{
+		{0, "1", 1},
+		{3, "2", 5},
+		{10, "3", 8},
+		{10, "1", 3},
+		{40, "4", 100}, // 超出月榜范围，只计入总榜
+	} {
+		cache.Rdb.ZIncrBy(cache.Ctx, dailyKey(now.AddDate(0, 0, -c.daysAgo)), c.score, c.id)
+		cache.Rdb.ZIncrBy(cache.Ctx, allTimeKey, c.score, c.id)
+	}
+
+	for _, c := range []struct {
+		window string
+		want   []uint
+	}{
+		{WindowDay, []uint{1}},
+		{WindowWeek, []uint{2, 1}},
+		{WindowMonth, []uint{3, 2, 1}}, // 1 在 30 天内共 4 分
+		{WindowAll, []uint{4, 3, 2, 1}},
+	} {
+		got, err := Top(c.window, 0, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(got, c.want) {
+			t.Errorf("Top(%s) = %v，期望 %v", c.window, got, c.want)
+		}
+	}
+
+	// 分页
+	if got, _ := Top(WindowAll, 1, 2); !slices.Equal(got, []uint{3, 2}) {
+		t.Errorf("Top(all, 1, 2) = %v，期望 [3 2]", got)
+	}
+
+	// 周榜和月榜的合并结果缓存 windowTTL，过期后重新合并
+	key := "ranking:top:" + WindowWeek + ":" + now.UTC().Format("2006-01-02")
+	if ttl := mr.TTL(key); ttl != windowTTL {
+		t.Errorf("周榜缓存的过期时间为 %v，期望 %v", ttl, windowTTL)
+	}
+	cache.Rdb.ZIncrBy(cache.Ctx, dailyKey(now), 10, "3")
+	if got, _ := Top(WindowWeek, 0, 10); !slices.Equal(got, []uint{2, 1}) {
+		t.Errorf("缓存期内周榜 = %v，期望不变", got)
+	}
+	mr.FastForward(windowTTL)
+	if got, _ := Top(WindowWeek, 0, 10); !slices.Equal(got, []uint{3, 2, 1}) {
+		t.Errorf("缓存过期后周榜 = %v，期望 [3 2 1]", got)
+	}
+
+	if _, err := Top("year", 0, 10); err != ErrInvalidWindow {
+		t.Errorf("无效的窗口返回 %v，期望 ErrInvalidWindow", err)
+	}
+}
+
+// seed 创建一个作者和 n 篇文章，第 i 篇在 i 天前发布
+func seed(t *testing.T, db *gorm.DB, n int) (models.User, []models.Post) {
+	t.Helper()
+
+	author := models.User{Username: "author", Email: "author@example.com", Password: "hash"}
+	if err := db.Create(&author).Error; err != nil {
+		t.Fatal(err)
+	}
+	posts := make([]models.Post, n)
+	for i := range posts {
+		posts[i] = models.Post{Title: "文章", Content: "正文", UserID: author.ID, CreatedAt: time.Now().AddDate(0, 0, -i).Truncate(time.Second)}
+		posts[i].SetStatus(models.PostPublished)
+		if err := db.Create(&posts[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return author, posts
+}
+
+func TestBump(t *testing.T) {
+	databasetest.ForEach(t, func(t *testing.T, driver string) {
+		db := databasetest.Open(t, driver)
+		mr := setupRedis(t)
+		_, posts := seed(t, db, 3)
+		published, draft, deleted := posts[0], posts[1], posts[2]
+		if err := db.Model(&draft).Updates(map[string]any{"is_draft": true, "status": models.PostDraft}).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Delete(&deleted).Error; err != nil {
+			t.Fatal(err)
+		}
+
+		for _, post := range posts {
+			Bump(post.ID, WeightLike)
+			Bump(post.ID, WeightComment)
+		}
+
+		if got := mr.HGet(pointsKey, member(published.ID)); got != "8" {
+			t.Errorf("已发布文章的互动量为 %q，期望 8", got)
+		}
+		score, _ := mr.ZScore(hotKey, member(published.ID))
+		if want := HotScore(8, published.CreatedAt); math.Abs(score-want) > 1e-9 {
+			t.Errorf("已发布文章的热度为 %v，期望 %v", score, want)
+		}
+		if got, _ := Top(WindowDay, 0, 10); !slices.Equal(got, []uint{published.ID}) {
+			t.Errorf("日榜 = %v，期望只有已发布的文章", got)
+		}
+
+		// 草稿和已删除的文章不累加互动量，也不进入任何排行
+		for _, post := range []models.Post{draft, deleted} {
+			id := member(post.ID)
+			for _, key := range []string{pointsKey, createdKey} {
+				if mr.HGet(key, id) != "" {
+					t.Errorf("文章 %d 不应出现在 %s 中", post.ID, key)
+				}
+			}
+			for _, key := range []string{hotKey, allTimeKey, dailyKey(time.Now())} {
+				if members, _ := mr.ZMembers(key); slices.Contains(members, id) {
+					t.Errorf("文章 %d 不应出现在 %s 中", post.ID, key)
+				}
+			}
+		}
+
+		// 移出排行后的互动不再留下 points 字段
+		Remove(published.ID)
+		if err := db.Delete(&published).Error; err != nil {
+			t.Fatal(err)
+		}
+		Bump(published.ID, WeightLike)
+		if mr.Exists(pointsKey) {
+			t.Error("移出排行的文章不应再有互动量")
+		}
+	})
+}
+
+func TestRebuild(t *testing.T) {
+	databasetest.ForEach(t, func(t *testing.T, driver string) {
+		db := databasetest.Open(t, driver)
+		mr := setupRedis(t)
+		author, posts := seed(t, db, 3)
+		old, recent, draft := posts[0], posts[1], posts[2]
+		if err := db.Model(&draft).Updates(map[string]any{"is_draft": true, "status": models.PostDraft}).Error; err != nil {
+			t.Fatal(err)
+		}
+
+		// old 的互动都在 60 天前，只计入总榜；recent 的互动同时计入当天的日榜
+		now := time.Now()
+		for _, record := range []any{
+			&models.Like{UserID: author.ID, TargetID: old.ID, TargetType: "post", CreatedAt: now.AddDate(0, 0, -60)},
+			&models.Comment{Content: "评论", UserID: author.ID, PostID: old.ID, CreatedAt: now.AddDate(0, 0, -60)},
+			&models.Like{UserID: author.ID, TargetID: recent.ID, TargetType: "post", CreatedAt: now},
+			&models.PostView{PostID: recent.ID, Date: now.UTC().Truncate(24 * time.Hour), Views: 20},
+			&models.Like{UserID: author.ID, TargetID: draft.ID, TargetType: "post", CreatedAt: now},
+		} {
+			if err := db.Create(record).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+		// Rebuild 会清掉残留的数据
+		mr.HSet(pointsKey, "999", "1")
+		mr.ZAdd(hotKey, 1, "999")
+
+		if err := Rebuild(); err != nil {
+			t.Fatal(err)
+		}
+
+		if got, _ := Top(WindowAll, 0, 10); !slices.Equal(got, []uint{old.ID, recent.ID}) {
+			t.Errorf("总榜 = %v，期望 [%d %d]", got, old.ID, recent.ID)
+		}
+		if got, _ := Top(WindowDay, 0, 10); !slices.Equal(got, []uint{recent.ID}) {
+			t.Errorf("日榜 = %v，期望 [%d]", got, recent.ID)
+		}
+		for _, c := range []struct {
+			post   models.Post
+			points float64
+		}{
+			{old, WeightLike + WeightComment},
+			{recent, WeightLike + 20*WeightView},
+		} {
+			got, _ := strconv.ParseFloat(mr.HGet(pointsKey, member(c.post.ID)), 64)
+			if math.Abs(got-c.points) > 1e-9 {
+				t.Errorf("文章 %d 的互动量为 %v，期望 %v", c.post.ID, got, c.points)
+			}
+		}
+		hot, _ := Hot(0, 10)
+		if len(hot) != 2 || slices.Contains(hot, draft.ID) || slices.Contains(hot, 999) {
+			t.Errorf("热度榜 = %v，期望只有两篇已发布的文章", hot)
+		}
+		if mr.HGet(pointsKey, "999") != "" {
+			t.Error("重建后应清掉残留的互动量")
+		}
+	})
+}
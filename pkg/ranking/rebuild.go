@@ -0,0 +1,125 @@
+package ranking
+
+import (
+	"goblog/database"
+	"goblog/models"
+	"goblog/pkg/cache"
+	"goblog/pkg/lifecycle"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Init 在 Redis 中没有排行数据时（首次部署或 Redis 被清空）从数据库重建
+func Init() {
+	lifecycle.Go(func() {
+		n, err := cache.Rdb.Exists(cache.Ctx, hotKey).Result()
+		if err != nil || n > 0 {
+			return
+		}
+		if err := Rebuild(); err != nil {
+			slog.Error("ranking: 重建排行失败", "err", err)
+		}
+	})
+}
+
+// Rebuild 根据数据库中的点赞、评论和浏览数据重新计算所有排行
+func Rebuild() error {
+	var posts []models.Post
+	if err := database.DB.Select("id", "created_at").Where("is_draft = ?", false).Find(&posts).Error; err != nil {
+		return err
+	}
+
+	type total struct {
+		ID    uint
+		Count float64
+	}
+	var likes, comments, views []total
+	if err := database.DB.Model(&models.Like{}).Select("target_id AS id, COUNT(*) AS count").
+		Where("target_type = ?", "post").Group("target_id").Scan(&likes).Error; err != nil {
+		return err
+	}
+	if err := database.DB.Model(&models.Comment{}).Select("post_id AS id, COUNT(*) AS count").
+		Group("post_id").Scan(&comments).Error; err != nil {
+		return err
+	}
+	if err := database.DB.Model(&models.PostView{}).Select("post_id AS id, SUM(views) AS count").
+		Group("post_id").Scan(&views).Error; err != nil {
+		return err
+	}
+
+	points := map[uint]float64{}
+	for _, t := range likes {
+		points[t.ID] += t.Count * WeightLike
+	}
+	for _, t := range comments {
+		points[t.ID] += t.Count * WeightComment
+	}
+	for _, t := range views {
+		points[t.ID] += t.Count * WeightView
+	}
+
+	since := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -30)
+	daily := map[string]map[uint]float64{}
+	addDaily := func(postID uint, at time.Time, weight float64) {
+		key := dailyKey(at)
+		if daily[key] == nil {
+			daily[key] = map[uint]float64{}
+		}
+		daily[key][postID] += weight
+	}
+
+	var recentLikes []models.Like
+	if err := database.DB.Select("target_id", "created_at").Where("target_type = ? AND created_at >= ?", "post", since).Find(&recentLikes).Error; err != nil {
+		return err
+	}
+	for _, l := range recentLikes {
+		addDaily(l.TargetID, l.CreatedAt, WeightLike)
+	}
+
+	var recentComments []models.Comment
+	if err := database.DB.Select("post_id", "created_at").Where("created_at >= ?", since).Find(&recentComments).Error; err != nil {
+		return err
+	}
+	for _, cm := range recentComments {
+		addDaily(cm.PostID, cm.CreatedAt, WeightComment)
+	}
+
+	var recentViews []models.PostView
+	if err := database.DB.Where("date >= ?", since).Find(&recentViews).Error; err != nil {
+		return err
+	}
+	for _, v := range recentViews {
+		addDaily(v.PostID, v.Date, float64(v.Views)*WeightView)
+	}
+
+	pipe := cache.Rdb.TxPipeline()
+	pipe.Del(cache.Ctx, hotKey, pointsKey, createdKey, allTimeKey)
+	for i := 0; i <= 31; i++ {
+		pipe.Del(cache.Ctx, dailyKey(time.Now().AddDate(0, 0, -i)))
+	}
+
+	published := make(map[uint]bool, len(posts))
+	for _, post := range posts {
+		published[post.ID] = true
+		id := member(post.ID)
+		pipe.HSet(cache.Ctx, createdKey, id, post.CreatedAt.Unix())
+		pipe.ZAdd(cache.Ctx, hotKey, redis.Z{Score: HotScore(points[post.ID], post.CreatedAt), Member: id})
+		if p := points[post.ID]; p > 0 {
+			pipe.HSet(cache.Ctx, pointsKey, id, p)
+			pipe.ZAdd(cache.Ctx, allTimeKey, redis.Z{Score: p, Member: id})
+		}
+	}
+	for key, scores := range daily {
+		for postID, score := range scores {
+			if published[postID] {
+				pipe.ZIncrBy(cache.Ctx, key, score, member(postID))
+			}
+		}
+		pipe.Expire(cache.Ctx, key, dailyTTL)
+	}
+
+	_, err := pipe.Exec(cache.Ctx)
+	return err
+}
@@ -0,0 +1,418 @@
+// Package related 计算相关文章：标签重合度、正文 TF-IDF 相似度和共同点赞用户按权重相加。
+//
+// 只重新计算有变化的文章：MarkDirty 把文章 ID 记到 Redis 的集合中，worker 持锁取出后重新计算这些文章，
+// 以及相关文章列表受影响的其他文章，每次计算量与变化的文章数乘以文章总数成正比，不做全量两两比较。
+// 缓存过期或缺失的文章在被访问时标记为有变化，下一轮重新计算
+package related
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"goblog/database"
+	"goblog/models"
+	"goblog/pkg/cache"
+	"log/slog"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 三种相似度在综合得分中的权重
+const (
+	weightTags   = 0.4
+	weightText   = 0.4
+	weightCoLike = 0.2
+)
+
+const (
+	MaxRelated      = 10
+	RebuildInterval = 30 * time.Minute
+
+	// 相关文章缓存的有效期，过期后下次访问时重新计算，其他文章增删带来的 IDF 变化最多滞后这么久
+	cacheTTL = 24 * time.Hour
+
+	dirtyKey = "related:dirty"
+	lockKey  = "related:rebuild:lock"
+	lockTTL  = 5 * time.Minute
+
+	// 修改后 debounceDelay 内没有新的修改才重建，持续有修改时最多推迟 maxDebounce
+	debounceDelay = time.Minute
+	maxDebounce   = 10 * time.Minute
+
+	// 只取正文前若干字参与计算，避免超长文章拖慢构建
+	maxTextLength = 20000
+)
+
+var wakeup = make(chan struct{}, 1)
+
+func cacheKey(postID uint) string {
+	return fmt.Sprintf("related:post:%d", postID)
+}
+
+type document struct {
+	id     uint
+	tags   map[uint]bool
+	vector map[string]float64
+	likers map[uint]bool
+}
+
+// MarkDirty 在文章发布、修改、删除或被点赞后调用，记录需要重新计算的文章并让 worker 尽快处理
+func MarkDirty(postIDs ...uint) {
+	if len(postIDs) == 0 {
+		return
+	}
+	members := make([]any, len(postIDs))
+	for i, id := range postIDs {
+		members[i] = id
+	}
+	if err := cache.Rdb.SAdd(cache.Ctx, dirtyKey, members...).Err(); err != nil {
+		slog.Warn("related: 记录待重建的文章失败", "post_ids", postIDs, "err", err)
+		return
+	}
+	select {
+	case wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// RunWorker 定期处理有变化的文章，阻塞直到 ctx 被取消
+func RunWorker(ctx context.Context) {
+	ticker := time.NewTicker(RebuildInterval)
+	defer ticker.Stop()
+	for {
+		if err := Rebuild(); err != nil {
+			slog.Error("related: 重建相关文章失败", "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-wakeup:
+			if !debounce(ctx) {
+				return
+			}
+		}
+	}
+}
+
+// debounce 合并连续的修改，等到 debounceDelay 内没有新的修改或已等待 maxDebounce 时返回 true，ctx 取消时返回 false
+func debounce(ctx context.Context) bool {
+	quiet := time.NewTimer(debounceDelay)
+	defer quiet.Stop()
+	deadline := time.NewTimer(maxDebounce)
+	defer deadline.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-wakeup:
+			quiet.Reset(debounceDelay)
+		case <-quiet.C:
+			return true
+		case <-deadline.C:
+			return true
+		}
+	}
+}
+
+// Rebuild 重新计算有变化的文章及受其影响的文章。多个副本同时运行时同一时刻只有一个在计算，
+// 没有拿到锁时直接返回，变化的文章留在集合中由持锁的副本处理
+func Rebuild() error {
+	token := strconv.FormatInt(time.Now().UnixNano(), 36)
+	ok, err := cache.Rdb.SetNX(cache.Ctx, lockKey, token, lockTTL).Result()
+	if err != nil || !ok {
+		return err
+	}
+	defer unlockScript.Run(cache.Ctx, cache.Rdb, []string{lockKey}, token)
+
+	// 取出后立即清空集合，计算期间新的修改留给下一轮
+	var members *redis.StringSliceCmd
+	if _, err := cache.Rdb.TxPipelined(cache.Ctx, func(pipe redis.Pipeliner) error {
+		members = pipe.SMembers(cache.Ctx, dirtyKey)
+		pipe.Del(cache.Ctx, dirtyKey)
+		return nil
+	}); err != nil {
+		return err
+	}
+	dirty := map[uint]bool{}
+	for _, m := range members.Val() {
+		if id, err := strconv.ParseUint(m, 10, 64); err == nil {
+			dirty[uint(id)] = true
+		}
+	}
+	if len(dirty) == 0 {
+		return nil
+	}
+
+	if err := rebuild(dirty); err != nil {
+		// 失败的文章放回集合，下一轮重试
+		ids := make([]uint, 0, len(dirty))
+		for id := range dirty {
+			ids = append(ids, id)
+		}
+		MarkDirty(ids...)
+		return err
+	}
+	return nil
+}
+
+// unlockScript 只删除自己持有的锁，计算超过锁的有效期时不会删掉其他副本的锁
+var unlockScript = redis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) end return 0`)
+
+func rebuild(dirty map[uint]bool) error {
+	docs, err := load()
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, len(docs))
+	for i, doc := range docs {
+		keys[i] = cacheKey(doc.id)
+	}
+	cached := map[uint][]uint{}
+	values := []any{}
+	if len(keys) > 0 {
+		if values, err = cache.Rdb.MGet(cache.Ctx, keys...).Result(); err != nil {
+			return err
+		}
+	}
+	for i, v := range values {
+		var ids []uint
+		if data, ok := v.(string); ok && json.Unmarshal([]byte(data), &ids) == nil {
+			cached[docs[i].id] = ids
+		}
+	}
+
+	published := map[uint]bool{}
+	for _, doc := range docs {
+		published[doc.id] = true
+	}
+	pipe := cache.Rdb.Pipeline()
+	// 已删除或撤回的文章不再有相关文章
+	for id := range dirty {
+		if !published[id] {
+			pipe.Del(cache.Ctx, cacheKey(id))
+		}
+	}
+	for _, i := range affected(docs, cached, dirty) {
+		data, _ := json.Marshal(rank(docs[i], docs))
+		pipe.Set(cache.Ctx, cacheKey(docs[i].id), data, cacheTTL)
+	}
+	_, err = pipe.Exec(cache.Ctx)
+	return err
+}
+
+// affected 返回需要重新计算的文章在 docs 中的下标：有变化的文章本身，相关文章中包含有变化文章的文章，
+// 以及与有变化文章的相似度超过自己当前最后一名、变化后可能进入列表的文章。
+// 没有缓存的文章不在这里计算，等被访问时再标记
+func affected(docs []document, cached map[uint][]uint, dirty map[uint]bool) []int {
+	byID := make(map[uint]*document, len(docs))
+	var changed []*document
+	for i := range docs {
+		byID[docs[i].id] = &docs[i]
+		if dirty[docs[i].id] {
+			changed = append(changed, &docs[i])
+		}
+	}
+
+	var result []int
+	for i, doc := range docs {
+		if dirty[doc.id] {
+			result = append(result, i)
+			continue
+		}
+		list, ok := cached[doc.id]
+		if !ok {
+			continue
+		}
+		if stale(doc, list, changed, byID, dirty) {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+func stale(doc document, list []uint, changed []*document, byID map[uint]*document, dirty map[uint]bool) bool {
+	for _, id := range list {
+		if dirty[id] || byID[id] == nil {
+			return true
+		}
+	}
+	if len(changed) == 0 {
+		return false
+	}
+	threshold := 0.0
+	if len(list) >= MaxRelated {
+		threshold = score(doc, *byID[list[len(list)-1]])
+	}
+	for _, other := range changed {
+		if score(doc, *other) > threshold {
+			return true
+		}
+	}
+	return false
+}
+
+// Get 返回缓存中的相关文章 ID。缓存未命中时不在请求中计算，返回空列表并让 worker 计算这篇文章
+func Get(postID uint) []uint {
+	ids, ok := cache.Lookup[[]uint](cache.Ctx, cacheKey(postID))
+	if !ok {
+		MarkDirty(postID)
+		return []uint{}
+	}
+	return ids
+}
+
+func load() ([]document, error) {
+	var posts []models.Post
+	if err := database.DB.Select("id", "title", "content").Preload("Tags").
+		Where("is_draft = ?", false).Find(&posts).Error; err != nil {
+		return nil, err
+	}
+
+	var likes []models.Like
+	if err := database.DB.Select("user_id", "target_id").Where("target_type = ?", "post").Find(&likes).Error; err != nil {
+		return nil, err
+	}
+	likers := map[uint]map[uint]bool{}
+	for _, l := range likes {
+		if likers[l.TargetID] == nil {
+			likers[l.TargetID] = map[uint]bool{}
+		}
+		likers[l.TargetID][l.UserID] = true
+	}
+
+	terms := make([]map[string]int, len(posts))
+	df := map[string]int{}
+	for i, post := range posts {
+		text := post.Title + " " + post.Title + " " + post.Content
+		text = truncate(text, maxTextLength)
+		counts := map[string]int{}
+		for _, t := range tokenize(text) {
+			counts[t]++
+		}
+		for t := range counts {
+			df[t]++
+		}
+		terms[i] = counts
+	}
+
+	docs := make([]document, len(posts))
+	n := float64(len(posts))
+	for i, post := range posts {
+		vector := map[string]float64{}
+		var norm float64
+		for t, count := range terms[i] {
+			w := float64(count) * (math.Log(n/float64(1+df[t])) + 1)
+			vector[t] = w
+			norm += w * w
+		}
+		if norm > 0 {
+			norm = math.Sqrt(norm)
+			for t := range vector {
+				vector[t] /= norm
+			}
+		}
+
+		tags := map[uint]bool{}
+		for _, tag := range post.Tags {
+			tags[tag.ID] = true
+		}
+
+		docs[i] = document{id: post.ID, tags: tags, vector: vector, likers: likers[post.ID]}
+	}
+	return docs, nil
+}
+
+// truncate 截取 text 的前 n 个字节，截断位置落在多字节字符中间时退到该字符之前
+func truncate(text string, n int) string {
+	if len(text) <= n {
+		return text
+	}
+	for n > 0 && !utf8.RuneStart(text[n]) {
+		n--
+	}
+	return text[:n]
+}
+
+func rank(doc document, docs []document) []uint {
+	type scored struct {
+		id    uint
+		score float64
+	}
+	var candidates []scored
+	for _, other := range docs {
+		if other.id == doc.id {
+			continue
+		}
+		if s := score(doc, other); s > 0 {
+			candidates = append(candidates, scored{other.id, s})
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].id > candidates[j].id
+	})
+
+	ids := []uint{}
+	for i := 0; i < len(candidates) && i < MaxRelated; i++ {
+		ids = append(ids, candidates[i].id)
+	}
+	return ids
+}
+
+// score 是两篇文章的综合相似度
+func score(a, b document) float64 {
+	return weightTags*jaccard(a.tags, b.tags) +
+		weightText*cosine(a.vector, b.vector) +
+		weightCoLike*overlap(a.likers, b.likers)
+}
+
+func jaccard(a, b map[uint]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for id := range a {
+		if b[id] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+func cosine(a, b map[string]float64) float64 {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	var dot float64
+	for t, w := range a {
+		dot += w * b[t]
+	}
+	return dot
+}
+
+// overlap 共同点赞用户数的余弦相似度
+func overlap(a, b map[uint]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	shared := 0
+	for id := range a {
+		if b[id] {
+			shared++
+		}
+	}
+	return float64(shared) / math.Sqrt(float64(len(a)*len(b)))
+}
@@ -0,0 +1,231 @@
+package related
+
+import (
+	"encoding/json"
+	"fmt"
+	"goblog/config"
+	"goblog/database/databasetest"
+	"goblog/models"
+	"goblog/pkg/cache"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestTokenize(t *testing.T) {
+	for _, c := range []struct {
+		text string
+		want []string
+	}{
+		{"Hello, World!", []string{"hello", "world"}},
+		// 停用词和单个字母被丢弃
+		{"the Go and a DB", []string{"go", "db"}},
+		// 中文按相邻两个字切分，只有一个字时保留这个字
+		{"我爱北京", []string{"我爱", "爱北", "北京"}},
+		{"我", []string{"我"}},
+		{"Go语言2024，并发", []string{"go", "语言", "2024", "并发"}},
+		{"", nil},
+	} {
+		if got := tokenize(c.text); !slices.Equal(got, c.want) {
+			t.Errorf("tokenize(%q) = %q，期望 %q", c.text, got, c.want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	for _, c := range []struct {
+		text string
+		n    int
+		want string
+	}{
+		{"hello", 10, "hello"},
+		{"hello", 5, "hello"},
+		{"hello", 3, "hel"},
+		// “中”和“文”各占 3 个字节，截断位置在字符中间时退到字符之前
+		{"中文", 4, "中"},
+		{"中文", 3, "中"},
+		{"中文", 2, ""},
+		{"a中", 2, "a"},
+	} {
+		if got := truncate(c.text, c.n); got != c.want {
+			t.Errorf("truncate(%q, %d) = %q，期望 %q", c.text, c.n, got, c.want)
+		}
+	}
+}
+
+// doc 构造一篇文章，vector 中每个词的权重相同
+func doc(id uint, tags []uint, words string, likers ...uint) document {
+	d := document{id: id, tags: map[uint]bool{}, vector: map[string]float64{}, likers: map[uint]bool{}}
+	for _, tag := range tags {
+		d.tags[tag] = true
+	}
+	fields := strings.Fields(words)
+	for _, w := range fields {
+		d.vector[w] = 1 / float64(len(fields))
+	}
+	for _, u := range likers {
+		d.likers[u] = true
+	}
+	return d
+}
+
+func TestRank(t *testing.T) {
+	docs := []document{
+		doc(1, []uint{1}, "go concurrency", 100),
+		doc(2, []uint{1}, "go concurrency", 100), // 标签、正文和点赞都相同
+		doc(3, []uint{1}, "rust"),                // 只有标签相同
+		doc(4, []uint{2}, "go concurrency rust"), // 只有部分正文相同
+		doc(5, []uint{3}, "python", 100),         // 只有共同点赞
+		doc(6, []uint{4}, "java"),                // 完全无关
+		doc(7, []uint{1}, "rust"),                // 与 3 得分相同
+	}
+
+	// 按得分从高到低，得分相同时新文章在前，不包含自己和得分为 0 的文章
+	if got, want := rank(docs[0], docs), []uint{2, 7, 3, 5, 4}; !slices.Equal(got, want) {
+		t.Errorf("rank = %v，期望 %v", got, want)
+	}
+	if got := rank(docs[5], docs); len(got) != 0 {
+		t.Errorf("无关文章的相关文章应为空，得到 %v", got)
+	}
+
+	// 最多返回 MaxRelated 篇
+	many := []document{doc(1, []uint{1}, "go")}
+	for id := uint(2); id < MaxRelated+5; id++ {
+		many = append(many, doc(id, []uint{1}, "go"))
+	}
+	if got := rank(many[0], many); len(got) != MaxRelated {
+		t.Errorf("rank 返回 %d 篇，期望 %d 篇", len(got), MaxRelated)
+	}
+}
+
+func TestAffected(t *testing.T) {
+	docs := []document{
+		doc(1, []uint{1}, "go"),   // 有变化
+		doc(2, []uint{1}, "go"),   // 相关文章中有 1
+		doc(3, []uint{2}, "rust"), // 相关文章已满，1 的得分不如最后一名
+		doc(4, []uint{1}, "db"),   // 相关文章未满，1 的得分大于 0，可能进入列表
+		doc(5, []uint{2}, "rust"), // 相关文章中有已删除的 9
+		doc(6, []uint{1}, "go"),   // 没有缓存，等被访问时再计算
+		doc(7, []uint{3}, "java"), // 与 1 无关
+	}
+	full := make([]uint, MaxRelated)
+	for i := range full {
+		full[i] = 5
+	}
+	cached := map[uint][]uint{
+		1: {2},
+		2: {1, 6},
+		3: full,
+		4: {},
+		5: {3, 9},
+		7: {},
+	}
+
+	var got []uint
+	for _, i := range affected(docs, cached, map[uint]bool{1: true}) {
+		got = append(got, docs[i].id)
+	}
+	if want := []uint{1, 2, 4, 5}; !slices.Equal(got, want) {
+		t.Errorf("affected = %v，期望 %v", got, want)
+	}
+
+	// 没有变化时只处理引用了已删除文章的列表
+	got = got[:0]
+	for _, i := range affected(docs, cached, map[uint]bool{}) {
+		got = append(got, docs[i].id)
+	}
+	if want := []uint{5}; !slices.Equal(got, want) {
+		t.Errorf("没有变化时 affected = %v，期望 %v", got, want)
+	}
+}
+
+func TestRebuild(t *testing.T) {
+	databasetest.ForEach(t, func(t *testing.T, driver string) {
+		db := databasetest.Open(t, driver)
+		mr := miniredis.RunT(t)
+		host, port, _ := net.SplitHostPort(mr.Addr())
+		conf := config.Default()
+		conf.Redis.Host = host
+		conf.Redis.Port, _ = strconv.Atoi(port)
+		if err := cache.InitRedis(conf.Redis, conf.Cache); err != nil {
+			t.Fatal(err)
+		}
+
+		author := models.User{Username: "author", Email: "author@example.com", Password: "hash"}
+		if err := db.Create(&author).Error; err != nil {
+			t.Fatal(err)
+		}
+		tag := models.Tag{Name: "go"}
+		newPost := func(title string, tags ...*models.Tag) models.Post {
+			post := models.Post{Title: title, Content: title, UserID: author.ID, Status: models.PostPublished, Tags: tags}
+			if err := db.Create(&post).Error; err != nil {
+				t.Fatal(err)
+			}
+			return post
+		}
+		a, b, c := newPost("goroutine channel", &tag), newPost("goroutine select", &tag), newPost("菜谱")
+
+		related := func(id uint) []uint {
+			t.Helper()
+			data, err := mr.Get(cacheKey(id))
+			if err != nil {
+				return nil
+			}
+			var ids []uint
+			if err := json.Unmarshal([]byte(data), &ids); err != nil {
+				t.Fatal(err)
+			}
+			return ids
+		}
+		rebuild := func() {
+			t.Helper()
+			if err := Rebuild(); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		MarkDirty(a.ID, b.ID, c.ID)
+		rebuild()
+		if got := related(a.ID); !slices.Equal(got, []uint{b.ID}) {
+			t.Fatalf("a 的相关文章 = %v，期望 %v", got, []uint{b.ID})
+		}
+		if got := related(c.ID); got == nil || len(got) != 0 {
+			t.Fatalf("c 的相关文章 = %v，期望空列表", got)
+		}
+		if mr.Exists(dirtyKey) {
+			t.Fatal("处理后应清空待重建集合")
+		}
+
+		// 其他副本持有锁时不处理，变化的文章留给持锁的副本
+		mr.Set(lockKey, "other")
+		MarkDirty(b.ID)
+		rebuild()
+		if ok, _ := mr.SIsMember(dirtyKey, strconv.Itoa(int(b.ID))); !ok {
+			t.Fatal("没有拿到锁时不应取走待重建的文章")
+		}
+		mr.Del(lockKey)
+
+		// b 撤回后，b 的缓存被删除，列表中有 b 的 a 重新计算；没有变化也不受影响的 c 不重新计算
+		if err := db.Model(&b).Updates(map[string]any{"is_draft": true, "status": models.PostDraft}).Error; err != nil {
+			t.Fatal(err)
+		}
+		mr.Set(cacheKey(c.ID), fmt.Sprintf("[%d]", a.ID))
+		rebuild()
+		if got := related(a.ID); got == nil || len(got) != 0 {
+			t.Fatalf("b 撤回后 a 的相关文章 = %v，期望空列表", got)
+		}
+		if mr.Exists(cacheKey(b.ID)) {
+			t.Fatal("撤回的文章不应再有相关文章缓存")
+		}
+		if got := related(c.ID); !slices.Equal(got, []uint{a.ID}) {
+			t.Fatalf("c 不受影响，不应重新计算，得到 %v", got)
+		}
+		if mr.Exists(lockKey) {
+			t.Fatal("处理完成后应释放锁")
+		}
+	})
+}
@@ -0,0 +1,56 @@
+package related
+
+import (
+	"strings"
+	"unicode"
+)
+
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "are": true, "with": true, "this": true,
+	"that": true, "from": true, "you": true, "your": true, "was": true, "were": true,
+	"not": true, "but": true, "have": true, "has": true, "can": true, "will": true,
+	"its": true, "into": true, "our": true, "use": true, "using": true, "how": true,
+}
+
+// tokenize 把文本切分为词：英文和数字按单词切分，中文按相邻两个字切分（bigram）
+func tokenize(text string) []string {
+	var tokens []string
+	var word []rune
+	var han []rune
+
+	flushWord := func() {
+		if len(word) >= 2 {
+			w := string(word)
+			if !stopWords[w] {
+				tokens = append(tokens, w)
+			}
+		}
+		word = word[:0]
+	}
+	flushHan := func() {
+		if len(han) == 1 {
+			tokens = append(tokens, string(han))
+		}
+		for i := 0; i+1 < len(han); i++ {
+			tokens = append(tokens, string(han[i:i+2]))
+		}
+		han = han[:0]
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flushWord()
+			han = append(han, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushHan()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushHan()
+		}
+	}
+	flushWord()
+	flushHan()
+	return tokens
+}
@@ -0,0 +1,265 @@
+package staticsite
+
+import (
+	"cmp"
+	"encoding/xml"
+	"fmt"
+	"goblog/models"
+	"slices"
+	"time"
+)
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Link       atomLink       `xml:"link"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Authors    []atomAuthor   `xml:"author"`
+	Categories []atomCategory `xml:"category"`
+	Content    atomContent    `xml:"content"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type sitemap struct {
+	XMLName xml.Name     `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// lists 生成首页、标签和作者的分页列表、它们的 feed、标签索引页和 sitemap。
+// 这些页面依赖多篇文章，每次都重新生成，内容没有变化的文件不会重写
+func (b *builder) lists(posts []*postView) error {
+	byTag := map[uint][]*postView{}
+	byAuthor := map[uint][]*postView{}
+	for _, post := range posts {
+		for _, id := range post.tagIDs {
+			byTag[id] = append(byTag[id], post)
+		}
+		for _, id := range post.authorIDs {
+			byAuthor[id] = append(byAuthor[id], post)
+		}
+	}
+
+	content, err := b.feedContent(posts, byTag, byAuthor)
+	if err != nil {
+		return err
+	}
+
+	urls := []sitemapURL{{Loc: b.abs(b.path("")), LastMod: lastMod(posts)}}
+	if err := b.list("", "", posts, content); err != nil {
+		return err
+	}
+	for _, post := range posts {
+		urls = append(urls, sitemapURL{Loc: b.abs(post.URL), LastMod: post.Updated.UTC().Format(time.RFC3339)})
+	}
+
+	var tags []tagCount
+	for id, tagged := range byTag {
+		tag := b.tags[id]
+		if err := b.list(tag.dir, "标签："+tag.Name, tagged, content); err != nil {
+			return err
+		}
+		tags = append(tags, tagCount{link: tag.link, Count: len(tagged)})
+		urls = append(urls, sitemapURL{Loc: b.abs(tag.URL), LastMod: lastMod(tagged)})
+	}
+	slices.SortFunc(tags, func(a, c tagCount) int {
+		return cmp.Or(c.Count-a.Count, cmp.Compare(a.Name, c.Name))
+	})
+	data, err := b.render.page("tags.html", &page{Site: b.site, Title: "标签", URL: b.path("tags/"), Feed: b.path("feed.xml"), Tags: tags})
+	if err != nil {
+		return err
+	}
+	if err := b.out.write("tags/index.html", data); err != nil {
+		return err
+	}
+	urls = append(urls, sitemapURL{Loc: b.abs(b.path("tags/"))})
+
+	for id, authored := range byAuthor {
+		author := b.authors[id]
+		if err := b.list(author.dir, "作者："+author.Name, authored, content); err != nil {
+			return err
+		}
+		urls = append(urls, sitemapURL{Loc: b.abs(author.URL), LastMod: lastMod(authored)})
+	}
+
+	slices.SortFunc(urls, func(a, c sitemapURL) int { return cmp.Compare(a.Loc, c.Loc) })
+	return b.writeXML("sitemap.xml", sitemap{URLs: urls})
+}
+
+// list 生成 dir 下的分页列表和 feed.xml，第一页为 dir/index.html，之后为 dir/page/<n>/index.html
+func (b *builder) list(dir, title string, posts []*postView, content map[uint]string) error {
+	size := b.conf.PageSize
+	total := max(1, (len(posts)+size-1)/size)
+	pageURL := func(n int) string {
+		if n == 1 {
+			return b.path(dir)
+		}
+		return b.path(fmt.Sprintf("%spage/%d/", dir, n))
+	}
+
+	for n := 1; n <= total; n++ {
+		p := &page{
+			Site:       b.site,
+			Title:      title,
+			URL:        pageURL(n),
+			Feed:       b.path(dir + "feed.xml"),
+			Posts:      posts[(n-1)*size : min(n*size, len(posts))],
+			Page:       n,
+			TotalPages: total,
+		}
+		if n > 1 {
+			p.Prev = pageURL(n - 1)
+		}
+		if n < total {
+			p.Next = pageURL(n + 1)
+		}
+		data, err := b.render.page("list.html", p)
+		if err != nil {
+			return err
+		}
+		file := dir + "index.html"
+		if n > 1 {
+			file = fmt.Sprintf("%spage/%d/index.html", dir, n)
+		}
+		if err := b.out.write(file, data); err != nil {
+			return err
+		}
+		b.stats.Pages++
+	}
+	feedTitle := b.site.Title
+	if title != "" {
+		feedTitle = title + " - " + b.site.Title
+	}
+	return b.feed(dir, feedTitle, posts, content)
+}
+
+// feed 生成 Atom feed，包含最新的 feedSize 篇文章的全文
+func (b *builder) feed(dir, title string, posts []*postView, content map[uint]string) error {
+	posts = posts[:min(feedSize, len(posts))]
+	feed := atomFeed{
+		Title:   title,
+		ID:      b.abs(b.path(dir)),
+		Updated: lastMod(posts),
+		Links: []atomLink{
+			{Href: b.abs(b.path(dir))},
+			{Href: b.abs(b.path(dir + "feed.xml")), Rel: "self"},
+		},
+	}
+	if feed.Updated == "" {
+		feed.Updated = time.Unix(0, 0).UTC().Format(time.RFC3339)
+	}
+	for _, post := range posts {
+		entry := atomEntry{
+			Title:     post.Title,
+			ID:        b.abs(post.URL),
+			Link:      atomLink{Href: b.abs(post.URL)},
+			Published: post.Date.UTC().Format(time.RFC3339),
+			Updated:   post.Updated.UTC().Format(time.RFC3339),
+			Content:   atomContent{Type: "html", Body: content[post.ID]},
+		}
+		for _, author := range post.Authors {
+			entry.Authors = append(entry.Authors, atomAuthor{Name: author.Name, URI: b.abs(author.URL)})
+		}
+		for _, tag := range post.Tags {
+			entry.Categories = append(entry.Categories, atomCategory{Term: tag.Name})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return b.writeXML(dir+"feed.xml", feed)
+}
+
+// feedContent 渲染出现在各个 feed 中的文章正文
+func (b *builder) feedContent(posts []*postView, byTag, byAuthor map[uint][]*postView) (map[uint]string, error) {
+	ids := map[uint]bool{}
+	add := func(posts []*postView) {
+		for _, post := range posts[:min(feedSize, len(posts))] {
+			ids[post.ID] = true
+		}
+	}
+	add(posts)
+	for _, tagged := range byTag {
+		add(tagged)
+	}
+	for _, authored := range byAuthor {
+		add(authored)
+	}
+
+	content := make(map[uint]string, len(ids))
+	list := make([]uint, 0, len(ids))
+	for id := range ids {
+		list = append(list, id)
+	}
+	for chunk := range slices.Chunk(list, 200) {
+		var rows []models.Post
+		if err := b.db.Select("id", "content").Where("id IN ?", chunk).Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			html, err := b.render.markdown(row.Content)
+			if err != nil {
+				return nil, fmt.Errorf("文章 %d: %w", row.ID, err)
+			}
+			content[row.ID] = string(html)
+		}
+	}
+	return content, nil
+}
+
+func (b *builder) writeXML(name string, v any) error {
+	data, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return b.out.write(name, append([]byte(xml.Header), append(data, '\n')...))
+}
+
+// abs 把站点内的路径转为绝对地址
+func (b *builder) abs(path string) string {
+	return b.site.origin + path
+}
+
+// lastMod 返回文章中最晚的修改时间
+func lastMod(posts []*postView) string {
+	var latest time.Time
+	for _, post := range posts {
+		if post.Updated.After(latest) {
+			latest = post.Updated
+		}
+	}
+	if latest.IsZero() {
+		return ""
+	}
+	return latest.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,123 @@
+package staticsite
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// manifestName 是输出目录中记录上次生成结果的文件
+const manifestName = ".goblog-static.json"
+
+type manifest struct {
+	Site  string          `json:"site"`  // 模板和站点配置的摘要
+	Posts map[uint]string `json:"posts"` // 文章 ID 到内容摘要
+	Files []string        `json:"files"` // 生成的文件，下次生成时删除不再生成的文件
+}
+
+func loadManifest(dir string) (manifest, error) {
+	var m manifest
+	data, err := os.ReadFile(filepath.Join(dir, manifestName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("%s 已损坏，删除后重新生成: %w", manifestName, err)
+	}
+	return m, nil
+}
+
+func saveManifest(dir string, m manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(dir, manifestName), data)
+}
+
+// writer 把文件写入输出目录，并记录本次生成的全部文件
+type writer struct {
+	dir     string
+	files   map[string]bool
+	written int
+}
+
+// write 写入 rel（以 / 分隔的相对路径），内容没有变化时不重写，保持文件的修改时间
+func (w *writer) write(rel string, data []byte) error {
+	w.files[rel] = true
+	name := filepath.Join(w.dir, filepath.FromSlash(rel))
+	if old, err := os.ReadFile(name); err == nil && bytes.Equal(old, data) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	if err := writeFile(name, data); err != nil {
+		return err
+	}
+	w.written++
+	return nil
+}
+
+func (w *writer) exists(rel string) bool {
+	_, err := os.Stat(filepath.Join(w.dir, filepath.FromSlash(rel)))
+	return err == nil
+}
+
+// removeStale 删除上次生成、这次没有生成的文件，例如取消发布的文章和没有文章的标签。
+// 只删除记录中的文件，输出目录中的其他文件不受影响
+func (w *writer) removeStale(previous []string) (int, error) {
+	removed := 0
+	for _, rel := range previous {
+		if w.files[rel] || !filepath.IsLocal(filepath.FromSlash(rel)) {
+			continue
+		}
+		name := filepath.Join(w.dir, filepath.FromSlash(rel))
+		if err := os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return removed, err
+		}
+		removed++
+		removeEmptyDirs(filepath.Clean(w.dir), filepath.Dir(name))
+	}
+	return removed, nil
+}
+
+// removeEmptyDirs 删除文件后清理空目录，直到输出目录为止
+func removeEmptyDirs(root, dir string) {
+	for dir != root && strings.HasPrefix(dir, root+string(filepath.Separator)) {
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+func (w *writer) list() []string {
+	files := make([]string, 0, len(w.files))
+	for rel := range w.files {
+		files = append(files, rel)
+	}
+	slices.Sort(files)
+	return files
+}
+
+// writeFile 先写临时文件再改名，正在提供服务的镜像不会读到写了一半的文件
+func writeFile(name string, data []byte) error {
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
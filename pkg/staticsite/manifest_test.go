@@ -0,0 +1,165 @@
+package staticsite
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func touch(t *testing.T, name string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func exists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
+
+func TestRemoveStale(t *testing.T) {
+	root := t.TempDir()
+	out := filepath.Join(root, "public")
+	for _, rel := range []string{
+		"index.html",
+		"posts/1/index.html",
+		"posts/2/index.html",
+		"tags/go/index.html",
+		"tags/go/page/2/index.html",
+		"tags/go/custom.txt", // 不是生成的文件
+		"robots.txt",         // 不是生成的文件
+	} {
+		touch(t, filepath.Join(out, rel))
+	}
+	touch(t, filepath.Join(root, "outside.txt"))
+
+	w := &writer{dir: out, files: map[string]bool{"index.html": true, "posts/1/index.html": true}}
+	removed, err := w.removeStale([]string{
+		"index.html",
+		"posts/1/index.html",
+		"posts/2/index.html",
+		"tags/go/index.html",
+		"tags/go/page/2/index.html",
+		"tags/rust/index.html", // 已经不存在
+		// 记录被篡改时不删除输出目录之外的文件
+		"../outside.txt",
+		filepath.ToSlash(filepath.Join(root, "outside.txt")),
+		"",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 4 {
+		t.Errorf("删除了 %d 个文件，期望 4", removed)
+	}
+
+	for rel, want := range map[string]bool{
+		"index.html":                true,
+		"posts/1/index.html":        true,
+		"posts/2/index.html":        false,
+		"posts/2":                   false, // 空目录一并删除
+		"posts":                     true,
+		"tags/go/index.html":        false,
+		"tags/go/page":              false,
+		"tags/go/custom.txt":        true, // 目录中还有其他文件，保留目录
+		"robots.txt":                true,
+		"../outside.txt":            true,
+		"../public":                 true,
+		"tags/go/page/2/index.html": false,
+	} {
+		if got := exists(filepath.Join(out, rel)); got != want {
+			t.Errorf("%s 存在 = %v，期望 %v", rel, got, want)
+		}
+	}
+
+	// 删除所有文件后只清理到输出目录为止
+	w = &writer{dir: out + string(filepath.Separator), files: map[string]bool{}}
+	if _, err := w.removeStale([]string{"index.html", "posts/1/index.html", "tags/go/custom.txt", "robots.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	if !exists(out) {
+		t.Error("不应删除输出目录本身")
+	}
+	if entries, _ := os.ReadDir(out); len(entries) != 0 {
+		t.Errorf("输出目录中还剩 %d 项", len(entries))
+	}
+}
+
+func TestWriter(t *testing.T) {
+	out := t.TempDir()
+	w := &writer{dir: out, files: map[string]bool{}}
+
+	if err := w.write("posts/1/index.html", []byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(out, "posts", "1", "index.html")
+	info, err := os.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 内容相同时不重写，保留修改时间
+	if err := w.write("posts/1/index.html", []byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := os.Stat(name); !again.ModTime().Equal(info.ModTime()) || w.written != 1 {
+		t.Errorf("内容相同时重写了文件，written = %d", w.written)
+	}
+	if err := w.write("posts/1/index.html", []byte("b")); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(name); string(data) != "b" || w.written != 2 {
+		t.Errorf("内容为 %q，written = %d", data, w.written)
+	}
+	if exists(name + ".tmp") {
+		t.Error("不应留下临时文件")
+	}
+	if !w.exists("posts/1/index.html") || w.exists("posts/2/index.html") {
+		t.Error("exists 结果错误")
+	}
+}
+
+func TestManifest(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "public")
+
+	// 第一次生成时没有记录
+	m, err := loadManifest(dir)
+	if err != nil || m.Site != "" || m.Posts != nil {
+		t.Fatalf("loadManifest = %+v, %v", m, err)
+	}
+
+	want := manifest{Site: "abc", Posts: map[uint]string{1: "h1", 2: "h2"}, Files: []string{"index.html", "posts/1/index.html"}}
+	if err := saveManifest(dir, want); err != nil {
+		t.Fatal(err)
+	}
+	m, err = loadManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Site != want.Site || len(m.Posts) != 2 || m.Posts[2] != "h2" || strings.Join(m.Files, ",") != strings.Join(want.Files, ",") {
+		t.Errorf("读回的记录为 %+v", m)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, manifestName), []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadManifest(dir); err == nil || !strings.Contains(err.Error(), "已损坏") {
+		t.Errorf("损坏的记录返回 %v", err)
+	}
+	if err := os.Remove(filepath.Join(dir, manifestName)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, manifestName), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadManifest(dir); err == nil || errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("无法读取的记录返回 %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package staticsite
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"goblog/config"
+	"html/template"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"time"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer/html"
+)
+
+//go:embed templates
+var defaultTemplates embed.FS
+
+// pageTemplates 是生成页面时使用的模板，自定义模板目录中的同名文件覆盖内置模板
+var pageTemplates = []string{"post.html", "list.html", "tags.html"}
+
+type renderer struct {
+	tmpl   *template.Template
+	md     goldmark.Markdown
+	assets map[string][]byte // 原样复制到输出目录的文件，如 style.css
+	hash   string            // 模板和站点配置的摘要，变化时重新渲染全部文章
+}
+
+func newRenderer(conf config.StaticConfig) (*renderer, error) {
+	r := &renderer{assets: map[string][]byte{}}
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%t\x00", conf.BaseURL, conf.Title, conf.Description, conf.RawHTML)
+
+	// 内置模板和自定义模板按文件名合并，.html 是模板，其他文件原样复制
+	files := map[string][]byte{}
+	sub, _ := fs.Sub(defaultTemplates, "templates")
+	if err := readTemplates(sub, files); err != nil {
+		return nil, err
+	}
+	if conf.Templates != "" {
+		if err := readTemplates(os.DirFS(conf.Templates), files); err != nil {
+			return nil, fmt.Errorf("读取模板目录 %s 失败: %w", conf.Templates, err)
+		}
+	}
+
+	r.tmpl = template.New("").Funcs(template.FuncMap{
+		"date": func(t time.Time) string { return t.Format("2006-01-02") },
+	})
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		fmt.Fprintf(h, "%s\x00%d\x00", name, len(files[name]))
+		h.Write(files[name])
+		if path.Ext(name) != ".html" || path.Dir(name) != "." {
+			r.assets[name] = files[name]
+			continue
+		}
+		if _, err := r.tmpl.New(name).Parse(string(files[name])); err != nil {
+			return nil, fmt.Errorf("模板 %s: %w", name, err)
+		}
+	}
+	for _, name := range pageTemplates {
+		if r.tmpl.Lookup(name) == nil {
+			return nil, fmt.Errorf("缺少模板 %s", name)
+		}
+	}
+	r.hash = hex.EncodeToString(h.Sum(nil))
+
+	var opts []goldmark.Option
+	opts = append(opts,
+		goldmark.WithExtensions(extension.GFM),
+		goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+	)
+	if conf.RawHTML {
+		opts = append(opts, goldmark.WithRendererOptions(html.WithUnsafe()))
+	}
+	r.md = goldmark.New(opts...)
+	return r, nil
+}
+
+func readTemplates(fsys fs.FS, files map[string][]byte) error {
+	return fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(fsys, name)
+		files[name] = data
+		return err
+	})
+}
+
+// markdown 把文章正文渲染为 HTML，没有开启 raw_html 时正文中的 HTML 会被去掉
+func (r *renderer) markdown(content string) (template.HTML, error) {
+	var buf bytes.Buffer
+	if err := r.md.Convert([]byte(content), &buf); err != nil {
+		return "", err
+	}
+	return template.HTML(buf.String()), nil
+}
+
+func (r *renderer) page(name string, p *page) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := r.tmpl.ExecuteTemplate(&buf, name, p); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
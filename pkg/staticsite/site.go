@@ -0,0 +1,312 @@
+// Package staticsite 把已发布的文章生成静态 HTML 站点：文章页、首页、标签和作者的分页列表、Atom feed 和 sitemap，
+// 用作站点的只读镜像。输出目录中的 .goblog-static.json 记录每篇文章的摘要和生成的文件，
+// 再次生成时只渲染有变化的文章，内容没有变化的文件不会重写，不再存在的页面会被删除
+package staticsite
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"goblog/config"
+	"goblog/database"
+	"goblog/models"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// feedSize 是每个 feed 中的文章数
+const feedSize = 20
+
+// Stats 汇总一次生成
+type Stats struct {
+	Posts    int // 已发布的文章数
+	Rendered int // 重新渲染的文章页数
+	Pages    int // 首页、标签和作者的列表页数
+	Written  int // 内容有变化、实际写入的文件数
+	Removed  int // 删除的已不存在的页面数
+}
+
+type siteView struct {
+	Title       string
+	Description string
+	Root        string // 站点路径前缀，部署在子目录时如 /blog，部署在根目录时为空
+	origin      string // scheme://host
+}
+
+type link struct {
+	Name string
+	URL  string
+}
+
+// named 是有列表页的标签或作者，dir 为列表页在输出目录中的位置
+type named struct {
+	link
+	dir string
+}
+
+// postView 是模板中的一篇文章，Content 只在渲染文章页和 feed 时设置
+type postView struct {
+	ID      uint
+	Title   string
+	URL     string
+	Date    time.Time
+	Updated time.Time
+	Authors []link
+	Tags    []link
+	Content any `json:"-"`
+
+	hash      string
+	authorIDs []uint
+	tagIDs    []uint
+}
+
+// page 是传给模板的数据，所有模板使用同一个结构
+type page struct {
+	Site  *siteView
+	Title string
+	URL   string // 页面路径，abs 转为绝对地址
+	Feed  string // 页面对应的 feed 路径
+
+	Post  *postView // post.html
+	Posts []*postView
+	Tags  []tagCount // tags.html
+
+	Page, TotalPages int
+	Prev, Next       string
+}
+
+type tagCount struct {
+	link
+	Count int
+}
+
+type builder struct {
+	db       *gorm.DB
+	conf     config.StaticConfig
+	site     *siteView
+	render   *renderer
+	out      *writer
+	old      manifest
+	all      bool // 忽略上次的记录，重新渲染全部文章
+	tags     map[uint]named
+	authors  map[uint]named
+	coAuthor map[uint][]uint
+	stats    Stats
+}
+
+// Build 生成静态站点到 conf.OutDir，full 为 true 时重新渲染全部文章
+func Build(ctx context.Context, conf config.StaticConfig, full bool) (Stats, error) {
+	if conf.BaseURL == "" {
+		return Stats{}, errors.New("请设置静态站点的访问地址 static.base_url (STATIC_BASE_URL)")
+	}
+	base, err := url.Parse(conf.BaseURL)
+	if err != nil {
+		return Stats{}, fmt.Errorf("static.base_url: %w", err)
+	}
+	render, err := newRenderer(conf)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	b := &builder{
+		db:   database.DB.WithContext(ctx),
+		conf: conf,
+		site: &siteView{
+			Title:       conf.Title,
+			Description: conf.Description,
+			Root:        strings.TrimSuffix(base.Path, "/"),
+			origin:      base.Scheme + "://" + base.Host,
+		},
+		render: render,
+		out:    &writer{dir: conf.OutDir, files: map[string]bool{}},
+	}
+	b.old, err = loadManifest(conf.OutDir)
+	if err != nil {
+		return Stats{}, err
+	}
+	b.all = full || b.old.Site != render.hash
+
+	if err := b.loadNames(); err != nil {
+		return b.stats, err
+	}
+	posts, err := b.posts()
+	if err != nil {
+		return b.stats, err
+	}
+	if err := b.lists(posts); err != nil {
+		return b.stats, err
+	}
+	for name, data := range render.assets {
+		if err := b.out.write(name, data); err != nil {
+			return b.stats, err
+		}
+	}
+
+	b.stats.Removed, err = b.out.removeStale(b.old.Files)
+	if err != nil {
+		return b.stats, err
+	}
+	b.stats.Written = b.out.written
+	return b.stats, saveManifest(conf.OutDir, manifest{Site: render.hash, Posts: b.postHashes(posts), Files: b.out.list()})
+}
+
+// loadNames 为标签和作者分配页面路径，按 ID 顺序分配，重名时后面的加上 ID
+func (b *builder) loadNames() error {
+	var tags []models.Tag
+	if err := b.db.Select("id", "name").Order("id").Find(&tags).Error; err != nil {
+		return err
+	}
+	used := map[string]bool{}
+	b.tags = make(map[uint]named, len(tags))
+	for _, tag := range tags {
+		b.tags[tag.ID] = b.named("tags/", tag.Name, uniqueSlug(used, tag.Name, tag.ID))
+	}
+
+	var users []models.User
+	if err := b.db.Select("id", "username").Order("id").Find(&users).Error; err != nil {
+		return err
+	}
+	used = map[string]bool{}
+	b.authors = make(map[uint]named, len(users))
+	for _, user := range users {
+		b.authors[user.ID] = b.named("authors/", user.Username, uniqueSlug(used, user.Username, user.ID))
+	}
+
+	var coAuthors []models.PostCollaborator
+	if err := b.db.Select("post_id", "user_id").Where("role = ? AND status = ?", models.CollaboratorCoAuthor, models.InvitationAccepted).
+		Order("accepted_at").Find(&coAuthors).Error; err != nil {
+		return err
+	}
+	b.coAuthor = map[uint][]uint{}
+	for _, ca := range coAuthors {
+		b.coAuthor[ca.PostID] = append(b.coAuthor[ca.PostID], ca.UserID)
+	}
+	return nil
+}
+
+// posts 分批读取已发布的文章，重新渲染有变化的文章页，返回按发布时间倒序排列的文章（不含正文）
+func (b *builder) posts() ([]*postView, error) {
+	var views []*postView
+	var batch []models.Post
+	err := b.db.Preload("Tags").Where("status = ?", models.PostPublished).FindInBatches(&batch, 200, func(tx *gorm.DB, _ int) error {
+		for i := range batch {
+			view := b.postView(&batch[i])
+			views = append(views, view)
+
+			rel := postFile(view.ID)
+			b.out.files[rel] = true
+			if !b.all && b.old.Posts[view.ID] == view.hash && b.out.exists(rel) {
+				continue
+			}
+			if err := b.renderPost(view, batch[i].Content); err != nil {
+				return err
+			}
+			b.stats.Rendered++
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(views, func(a, c *postView) int {
+		if n := c.Date.Compare(a.Date); n != 0 {
+			return n
+		}
+		return int(c.ID) - int(a.ID)
+	})
+	b.stats.Posts = len(views)
+	return views, nil
+}
+
+func (b *builder) postView(post *models.Post) *postView {
+	view := &postView{
+		ID:      post.ID,
+		Title:   post.Title,
+		URL:     b.path(strings.TrimSuffix(postFile(post.ID), "index.html")),
+		Date:    post.CreatedAt,
+		Updated: post.UpdatedAt,
+	}
+	view.authorIDs = append([]uint{post.UserID}, b.coAuthor[post.ID]...)
+	for _, id := range view.authorIDs {
+		view.Authors = append(view.Authors, b.authors[id].link)
+	}
+	for _, tag := range post.Tags {
+		view.tagIDs = append(view.tagIDs, tag.ID)
+		view.Tags = append(view.Tags, b.tags[tag.ID].link)
+	}
+
+	// 摘要包含文章页上显示的全部内容，标签或作者改名时文章页也会重新渲染
+	h := sha256.New()
+	json.NewEncoder(h).Encode(view)
+	h.Write([]byte(post.Content))
+	view.hash = hex.EncodeToString(h.Sum(nil))
+	return view
+}
+
+func (b *builder) renderPost(view *postView, content string) error {
+	html, err := b.render.markdown(content)
+	if err != nil {
+		return fmt.Errorf("文章 %d: %w", view.ID, err)
+	}
+	view.Content = html
+	data, err := b.render.page("post.html", &page{Site: b.site, Title: view.Title, URL: view.URL, Feed: b.path("feed.xml"), Post: view})
+	view.Content = nil
+	if err != nil {
+		return fmt.Errorf("文章 %d: %w", view.ID, err)
+	}
+	return b.out.write(postFile(view.ID), data)
+}
+
+func (b *builder) postHashes(posts []*postView) map[uint]string {
+	hashes := make(map[uint]string, len(posts))
+	for _, post := range posts {
+		hashes[post.ID] = post.hash
+	}
+	return hashes
+}
+
+// path 把站点内的相对路径转为带路径前缀的链接
+func (b *builder) path(rel string) string {
+	return b.site.Root + "/" + rel
+}
+
+func (b *builder) named(section, name, slug string) named {
+	return named{link: link{Name: name, URL: b.path(section + url.PathEscape(slug) + "/")}, dir: section + slug + "/"}
+}
+
+func postFile(id uint) string {
+	return fmt.Sprintf("posts/%d/index.html", id)
+}
+
+// uniqueSlug 生成标签或作者的路径片段，只保留字母、数字和下划线，其他字符替换为 -；
+// 为空或与之前的重复时加上 ID
+func uniqueSlug(used map[string]bool, name string, id uint) string {
+	var sb strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			dash = true
+			continue
+		}
+		if dash && sb.Len() > 0 {
+			sb.WriteByte('-')
+		}
+		sb.WriteRune(r)
+		dash = false
+	}
+	slug := sb.String()
+	for slug == "" || used[slug] {
+		slug = strings.TrimPrefix(fmt.Sprintf("%s-%d", slug, id), "-")
+	}
+	used[slug] = true
+	return slug
+}
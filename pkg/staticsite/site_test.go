@@ -0,0 +1,153 @@
+package staticsite
+
+import (
+	"context"
+	"goblog/config"
+	"goblog/database/databasetest"
+	"goblog/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUniqueSlug(t *testing.T) {
+	used := map[string]bool{"tags": true}
+	// 同一个 used 中依次分配，后面的与前面的重复时加上 ID
+	for _, c := range []struct {
+		name string
+		id   uint
+		want string
+	}{
+		{"Go", 1, "go"},
+		{"Hello, World!", 2, "hello-world"},
+		{"  C++ / Rust  ", 3, "c-rust"},
+		{"中文 标签", 4, "中文-标签"},
+		{"snake_case", 5, "snake_case"},
+		{"GO", 6, "go-6"},
+		{"go!", 7, "go-7"},
+		{"!!!", 8, "8"},
+		{"", 9, "9"},
+		{"8", 10, "8-10"},
+		{"go-6", 11, "go-6-11"},
+		{"tags", 12, "tags-12"},
+	} {
+		if got := uniqueSlug(used, c.name, c.id); got != c.want {
+			t.Errorf("uniqueSlug(%q, %d) = %q，期望 %q", c.name, c.id, got, c.want)
+		}
+	}
+}
+
+func TestBuild(t *testing.T) {
+	databasetest.ForEach(t, func(t *testing.T, driver string) {
+		db := databasetest.Open(t, driver)
+		out := t.TempDir()
+		conf := config.StaticConfig{BaseURL: "https://example.com/blog/", Title: "测试站点", OutDir: out, PageSize: 2}
+
+		alice := models.User{Username: "alice", Email: "alice@example.com", Password: "hash"}
+		if err := db.Create(&alice).Error; err != nil {
+			t.Fatal(err)
+		}
+		golang, rust := models.Tag{Name: "Go"}, models.Tag{Name: "Rust"}
+		base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		var posts []models.Post
+		for i, tags := range [][]*models.Tag{{&golang}, {&golang}, {&golang, &rust}} {
+			post := models.Post{Title: "文章", Content: "正文", UserID: alice.ID, Tags: tags, CreatedAt: base.AddDate(0, 0, i)}
+			post.SetStatus(models.PostPublished)
+			if err := db.Create(&post).Error; err != nil {
+				t.Fatal(err)
+			}
+			posts = append(posts, post)
+		}
+		draft := models.Post{Title: "草稿", Content: "正文", UserID: alice.ID}
+		draft.SetStatus(models.PostDraft)
+		if err := db.Create(&draft).Error; err != nil {
+			t.Fatal(err)
+		}
+
+		build := func(full bool) Stats {
+			t.Helper()
+			stats, err := Build(context.Background(), conf, full)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return stats
+		}
+		file := func(rel string) string { return filepath.Join(out, filepath.FromSlash(rel)) }
+
+		stats := build(false)
+		if stats.Posts != 3 || stats.Rendered != 3 || stats.Removed != 0 {
+			t.Fatalf("首次生成 %+v", stats)
+		}
+		for _, rel := range []string{
+			postFile(posts[0].ID), "index.html", "page/2/index.html", "feed.xml", "sitemap.xml", "style.css",
+			"tags/index.html", "tags/go/index.html", "tags/go/page/2/index.html", "tags/rust/index.html", "authors/alice/index.html",
+		} {
+			if !exists(file(rel)) {
+				t.Errorf("缺少 %s", rel)
+			}
+		}
+		if exists(file(postFile(draft.ID))) {
+			t.Error("草稿不应生成页面")
+		}
+		if page, _ := os.ReadFile(file(postFile(posts[2].ID))); !strings.Contains(string(page), "/blog/tags/rust/") {
+			t.Error("文章页应带路径前缀链接到标签页")
+		}
+
+		// 没有变化时不渲染文章，也不重写任何文件
+		if stats := build(false); stats.Rendered != 0 || stats.Written != 0 || stats.Removed != 0 {
+			t.Fatalf("没有变化时 %+v", stats)
+		}
+
+		// 只重新渲染正文有变化的文章
+		if err := db.Model(&posts[0]).Update("content", "新的正文").Error; err != nil {
+			t.Fatal(err)
+		}
+		if stats := build(false); stats.Rendered != 1 || stats.Written == 0 {
+			t.Fatalf("修改一篇文章后 %+v", stats)
+		}
+
+		// 标签改名时文章页上的链接变化，带这个标签的文章都重新渲染，旧的列表页和 feed 被删除
+		if err := db.Model(&rust).Update("name", "Rust 语言").Error; err != nil {
+			t.Fatal(err)
+		}
+		if stats := build(false); stats.Rendered != 1 || stats.Removed != 2 || exists(file("tags/rust/index.html")) || !exists(file("tags/rust-语言/index.html")) {
+			t.Fatalf("标签改名后 %+v", stats)
+		}
+
+		// 文章页被删除时重新生成
+		if err := os.Remove(file(postFile(posts[1].ID))); err != nil {
+			t.Fatal(err)
+		}
+		if stats := build(false); stats.Rendered != 1 || !exists(file(postFile(posts[1].ID))) {
+			t.Fatalf("文章页被删除后 %+v", stats)
+		}
+
+		// 撤回的文章和不再有文章的标签页被删除，只剩一页时删除第二页
+		if err := db.Model(&posts[2]).Updates(map[string]any{"is_draft": true, "status": models.PostDraft}).Error; err != nil {
+			t.Fatal(err)
+		}
+		stats = build(false)
+		if stats.Posts != 2 || stats.Rendered != 0 {
+			t.Fatalf("撤回文章后 %+v", stats)
+		}
+		for _, rel := range []string{postFile(posts[2].ID), "tags/rust-语言/index.html", "tags/rust-语言/feed.xml", "page/2/index.html", "tags/go/page/2/index.html"} {
+			if exists(file(rel)) {
+				t.Errorf("%s 应被删除", rel)
+			}
+		}
+		if _, err := os.Stat(file("tags/rust-语言")); err == nil {
+			t.Error("空的标签目录应被删除")
+		}
+
+		// 站点配置变化或 full 时重新渲染全部文章
+		conf.Title = "新标题"
+		if stats := build(false); stats.Rendered != 2 {
+			t.Fatalf("修改站点标题后 %+v", stats)
+		}
+		if stats := build(true); stats.Rendered != 2 || stats.Written != 0 {
+			t.Fatalf("full 生成 %+v", stats)
+		}
+	})
+}
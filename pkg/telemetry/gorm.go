@@ -0,0 +1,87 @@
+package telemetry
+
+import (
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const (
+	startKey = "telemetry:start"
+	spanKey  = "telemetry:span"
+)
+
+// GormPlugin 记录每条 SQL 的耗时，请求上下文中有 span 时再为它创建子 span。
+// 需要通过 db.WithContext 传入请求的 ctx 才能和 HTTP 请求串成一条 trace
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string { return "telemetry" }
+
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("*").Register("telemetry:before_create", beforeQuery("create")),
+		cb.Create().After("*").Register("telemetry:after_create", afterQuery("create")),
+		cb.Query().Before("*").Register("telemetry:before_query", beforeQuery("query")),
+		cb.Query().After("*").Register("telemetry:after_query", afterQuery("query")),
+		cb.Update().Before("*").Register("telemetry:before_update", beforeQuery("update")),
+		cb.Update().After("*").Register("telemetry:after_update", afterQuery("update")),
+		cb.Delete().Before("*").Register("telemetry:before_delete", beforeQuery("delete")),
+		cb.Delete().After("*").Register("telemetry:after_delete", afterQuery("delete")),
+		cb.Row().Before("*").Register("telemetry:before_row", beforeQuery("row")),
+		cb.Row().After("*").Register("telemetry:after_row", afterQuery("row")),
+		cb.Raw().Before("*").Register("telemetry:before_raw", beforeQuery("raw")),
+		cb.Raw().After("*").Register("telemetry:after_raw", afterQuery("raw")),
+	)
+}
+
+func beforeQuery(op string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		db.InstanceSet(startKey, time.Now())
+		ctx, span, ok := startChildSpan(db.Statement.Context, "gorm."+op,
+			attribute.String("db.system", db.Dialector.Name()),
+			attribute.String("db.operation", op),
+		)
+		if ok {
+			db.Statement.Context = ctx
+			db.InstanceSet(spanKey, span)
+		}
+	}
+}
+
+func afterQuery(op string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		failed := db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound)
+		status := "ok"
+		if failed {
+			status = "error"
+		}
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		if v, ok := db.InstanceGet(startKey); ok {
+			DBQueryDuration.WithLabelValues(op, table, status).Observe(time.Since(v.(time.Time)).Seconds())
+		}
+
+		v, ok := db.InstanceGet(spanKey)
+		if !ok {
+			return
+		}
+		span := v.(trace.Span)
+		span.SetAttributes(
+			attribute.String("db.sql.table", db.Statement.Table),
+			attribute.String("db.statement", db.Statement.SQL.String()),
+			attribute.Int64("db.rows_affected", db.RowsAffected),
+		)
+		if failed {
+			span.RecordError(db.Error)
+			span.SetStatus(codes.Error, db.Error.Error())
+		}
+		span.End()
+	}
+}
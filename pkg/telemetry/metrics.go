@@ -0,0 +1,87 @@
+package telemetry
+
+import (
+	"goblog/pkg/cache"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "goblog"
+
+var (
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP 请求耗时，route 为注册的路由模板，未匹配的请求记为 unmatched",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "GORM 执行 SQL 的耗时",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table", "status"})
+
+	PostsCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "posts_created_total",
+		Help:      "创建的文章数，包括草稿",
+	})
+	CommentsCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "comments_created_total",
+		Help:      "发表的评论数",
+	})
+	LikesCreated = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "likes_created_total",
+		Help:      "点赞数，target_type 为 post 或 comment",
+	}, []string{"target_type"})
+)
+
+func init() {
+	prometheus.MustRegister(cacheCollector{})
+}
+
+var (
+	cacheLocalHits   = cacheDesc("local_hits_total", "本地缓存命中次数")
+	cacheLocalMisses = cacheDesc("local_misses_total", "本地缓存未命中次数")
+	cacheRedisHits   = cacheDesc("redis_hits_total", "本地未命中、Redis 命中的次数")
+	cacheLoads       = cacheDesc("loads_total", "两级缓存都未命中、回源加载的次数")
+	cacheEntries     = cacheDesc("local_entries", "本地缓存当前条数")
+	cacheDegraded    = cacheDesc("degraded", "Redis 是否处于熔断降级状态，1 为降级")
+	cacheTrips       = cacheDesc("breaker_trips_total", "Redis 熔断次数")
+	cacheRejected    = cacheDesc("breaker_rejected_total", "熔断期间没有发送给 Redis 的命令数")
+)
+
+func cacheDesc(name, help string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "cache", name), help, nil, nil)
+}
+
+// cacheCollector 在抓取时读取 cache.GetStats，命中率可以用
+// redis_hits_total / (redis_hits_total + loads_total) 这类表达式在 Prometheus 中计算
+type cacheCollector struct{}
+
+func (cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{cacheLocalHits, cacheLocalMisses, cacheRedisHits, cacheLoads, cacheEntries, cacheDegraded, cacheTrips, cacheRejected} {
+		ch <- d
+	}
+}
+
+func (cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	s := cache.GetStats()
+	degraded := 0.0
+	if s.Degraded {
+		degraded = 1
+	}
+	ch <- prometheus.MustNewConstMetric(cacheLocalHits, prometheus.CounterValue, float64(s.LocalHits))
+	ch <- prometheus.MustNewConstMetric(cacheLocalMisses, prometheus.CounterValue, float64(s.LocalMisses))
+	ch <- prometheus.MustNewConstMetric(cacheRedisHits, prometheus.CounterValue, float64(s.RedisHits))
+	ch <- prometheus.MustNewConstMetric(cacheLoads, prometheus.CounterValue, float64(s.Loads))
+	ch <- prometheus.MustNewConstMetric(cacheEntries, prometheus.GaugeValue, float64(s.LocalEntries))
+	ch <- prometheus.MustNewConstMetric(cacheDegraded, prometheus.GaugeValue, degraded)
+	ch <- prometheus.MustNewConstMetric(cacheTrips, prometheus.CounterValue, float64(s.Trips))
+	ch <- prometheus.MustNewConstMetric(cacheRejected, prometheus.CounterValue, float64(s.Rejected))
+}
@@ -0,0 +1,80 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"goblog/config"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress 表示订阅地址解析到了回环、内网、链路本地等不允许访问的地址
+var ErrForbiddenAddress = errors.New("不允许投递到回环、内网或链路本地地址")
+
+// client 在建立连接时再次检查实际连接的 IP，防止创建订阅后 DNS 解析结果被换成内网地址；
+// 不使用环境变量中的代理，否则检查的是代理的地址
+var client = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: func(_, address string, _ syscall.RawConn) error {
+				addr, err := netip.ParseAddrPort(address)
+				if err != nil {
+					return err
+				}
+				if !allowedAddr(addr.Addr()) {
+					return ErrForbiddenAddress
+				}
+				return nil
+			},
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+	},
+}
+
+// ValidateURL 检查订阅地址：只允许 http 和 https，主机名解析出的所有地址都必须是公网地址
+func ValidateURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.New("只支持 http 和 https 地址")
+	}
+	host := u.Hostname()
+	if host == "" {
+		return errors.New("缺少主机名")
+	}
+	if u.User != nil {
+		return errors.New("地址中不能包含用户名和密码")
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("无法解析主机名 %s", host)
+	}
+	for _, addr := range addrs {
+		if !allowedAddr(addr) {
+			return ErrForbiddenAddress
+		}
+	}
+	return nil
+}
+
+func allowedAddr(addr netip.Addr) bool {
+	if config.AppConfig.Webhook.AllowPrivate {
+		return true
+	}
+	addr = addr.Unmap()
+	// IsGlobalUnicast 已排除回环、链路本地、组播和未指定地址
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !sharedAddressSpace.Contains(addr)
+}
+
+// sharedAddressSpace 是运营商级 NAT 使用的 100.64.0.0/10，IsPrivate 不包含它
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
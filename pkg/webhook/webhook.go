@@ -0,0 +1,140 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"goblog/database"
+	"goblog/models"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	EventPostPublished  = "post.published"
+	EventCommentCreated = "comment.created"
+	EventLikeCreated    = "like.created"
+)
+
+var Events = []string{EventPostPublished, EventCommentCreated, EventLikeCreated}
+
+const SignatureHeader = "X-GoBlog-Signature"
+
+type Payload struct {
+	Event     string    `json:"event"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+func IsValidEvent(event string) bool {
+	return slices.Contains(Events, event)
+}
+
+// Subscribes 判断订阅的事件列表中是否包含 event，"*" 表示订阅全部事件
+func Subscribes(hook models.Webhook, event string) bool {
+	for _, e := range strings.Split(hook.Events, ",") {
+		e = strings.TrimSpace(e)
+		if e == event || e == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// Sign 使用订阅的 secret 对请求体做 HMAC-SHA256 签名
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify 供接收方校验签名头
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// Publish 为订阅了该事件的 webhook 写入待投递记录，实际发送由 worker 异步完成。
+// 订阅只收到与自己内容有关的事件：自己发布的文章、自己文章下的评论、自己的文章或评论收到的点赞
+func Publish(event string, data any) {
+	owner, err := ownerOf(data)
+	if err != nil {
+		slog.Error("webhook: 查询事件所属用户失败", "event", event, "err", err)
+		return
+	}
+
+	var hooks []models.Webhook
+	if err := database.DB.Where("active = ? AND user_id = ?", true, owner).Find(&hooks).Error; err != nil {
+		slog.Error("webhook: 查询订阅失败", "err", err)
+		return
+	}
+
+	body, err := json.Marshal(Payload{Event: event, CreatedAt: time.Now(), Data: data})
+	if err != nil {
+		slog.Error("webhook: 序列化事件失败", "event", event, "err", err)
+		return
+	}
+
+	queued := false
+	for _, hook := range hooks {
+		if !Subscribes(hook, event) {
+			continue
+		}
+		delivery := models.WebhookDelivery{
+			WebhookID:     hook.ID,
+			Event:         event,
+			Payload:       string(body),
+			Status:        models.DeliveryPending,
+			NextAttemptAt: time.Now(),
+		}
+		if err := database.DB.Create(&delivery).Error; err != nil {
+			slog.Error("webhook: 写入投递队列失败", "err", err)
+			continue
+		}
+		queued = true
+	}
+
+	if queued {
+		Notify()
+	}
+}
+
+// ownerOf 返回事件数据所属内容的作者
+func ownerOf(data any) (uint, error) {
+	var owner uint
+	var err error
+	switch v := data.(type) {
+	case models.Post:
+		return v.UserID, nil
+	case models.Comment:
+		err = database.DB.Model(&models.Post{}).Unscoped().Where("id = ?", v.PostID).Pluck("user_id", &owner).Error
+	case models.Like:
+		target := any(&models.Post{})
+		if v.TargetType == "comment" {
+			target = &models.Comment{}
+		}
+		err = database.DB.Model(target).Unscoped().Where("id = ?", v.TargetID).Pluck("user_id", &owner).Error
+	default:
+		err = fmt.Errorf("未知的事件数据类型 %T", data)
+	}
+	return owner, err
+}
+
+// Redeliver 复制一条历史投递并重新放入队列
+func Redeliver(delivery models.WebhookDelivery) (models.WebhookDelivery, error) {
+	retry := models.WebhookDelivery{
+		WebhookID:     delivery.WebhookID,
+		Event:         delivery.Event,
+		Payload:       delivery.Payload,
+		Status:        models.DeliveryPending,
+		NextAttemptAt: time.Now(),
+	}
+	if err := database.DB.Create(&retry).Error; err != nil {
+		return retry, err
+	}
+	Notify()
+	return retry, nil
+}
@@ -0,0 +1,32 @@
+package webhook
+
+import "testing"
+
+func TestSignVerify(t *testing.T) {
+	// RFC 4231 测试用例 2
+	secret, body := "Jefe", []byte("what do ya want for nothing?")
+	const want = "sha256=5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"
+	if got := Sign(secret, body); got != want {
+		t.Fatalf("Sign = %q，期望 %q", got, want)
+	}
+
+	for _, c := range []struct {
+		name      string
+		secret    string
+		body      string
+		signature string
+		want      bool
+	}{
+		{"正确的签名", secret, string(body), want, true},
+		{"密钥错误", "jefe", string(body), want, false},
+		{"内容被修改", secret, string(body) + " ", want, false},
+		{"缺少前缀", secret, string(body), want[len("sha256="):], false},
+		{"十六进制大写", secret, string(body), "sha256=5BDCC146BF60754E6A042426089575C75A003F089D2739839DEC58B964EC3843", false},
+		{"截断的签名", secret, string(body), want[:len(want)-2], false},
+		{"空签名", secret, string(body), "", false},
+	} {
+		if got := Verify(c.secret, []byte(c.body), c.signature); got != c.want {
+			t.Errorf("%s: Verify = %v，期望 %v", c.name, got, c.want)
+		}
+	}
+}
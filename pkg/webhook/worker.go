@@ -0,0 +1,153 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"goblog/database"
+	"goblog/models"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	MaxAttempts  = 8
+	baseBackoff  = 30 * time.Second
+	maxBackoff   = 6 * time.Hour
+	pollInterval = 5 * time.Second
+	batchSize    = 50
+	// claimLease 是认领一条投递后其他 worker 不会再处理它的时间，需要长于发送请求的超时；
+	// 发送过程中进程退出的投递在租约到期后由任一 worker 重试
+	claimLease = time.Minute
+)
+
+var wakeup = make(chan struct{}, 1)
+
+// Notify 唤醒 worker 立即处理队列
+func Notify() {
+	select {
+	case wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// Backoff 返回第 attempt 次失败后的等待时间：30s、1m、2m ... 最长 6h
+func Backoff(attempt int) time.Duration {
+	d := baseBackoff << (attempt - 1)
+	if d <= 0 || d > maxBackoff {
+		return maxBackoff
+	}
+	return d
+}
+
+// RunWorker 轮询投递队列，阻塞直到 ctx 被取消。多个副本可以同时运行，每条投递发送前先被认领，不会重复发送
+func RunWorker(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		processDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-wakeup:
+		}
+	}
+}
+
+func processDue(ctx context.Context) {
+	var deliveries []models.WebhookDelivery
+	if err := database.DB.Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, time.Now()).
+		Order("next_attempt_at asc").Limit(batchSize).Find(&deliveries).Error; err != nil {
+		slog.Error("webhook: 读取投递队列失败", "err", err)
+		return
+	}
+
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			return
+		}
+		if !claim(delivery) {
+			continue
+		}
+		attempt(ctx, delivery)
+	}
+}
+
+// claim 把投递的下次尝试时间推迟 claimLease，只有条件更新成功的 worker 发送这条投递
+func claim(delivery models.WebhookDelivery) bool {
+	now := time.Now()
+	result := database.DB.Model(&models.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND attempts = ? AND next_attempt_at <= ?", delivery.ID, models.DeliveryPending, delivery.Attempts, now).
+		Update("next_attempt_at", now.Add(claimLease))
+	if result.Error != nil {
+		slog.Error("webhook: 认领投递失败", "delivery_id", delivery.ID, "err", result.Error)
+		return false
+	}
+	return result.RowsAffected == 1
+}
+
+func attempt(ctx context.Context, delivery models.WebhookDelivery) {
+	var hook models.Webhook
+	if err := database.DB.First(&hook, delivery.WebhookID).Error; err != nil {
+		database.DB.Model(&delivery).Updates(map[string]any{
+			"status": models.DeliveryFailed,
+			"error":  "webhook 已删除",
+		})
+		return
+	}
+
+	status, body, err := send(ctx, hook, delivery)
+
+	updates := map[string]any{
+		"attempts":        delivery.Attempts + 1,
+		"response_status": status,
+		"response_body":   body,
+		"error":           "",
+	}
+	switch {
+	case err == nil:
+		now := time.Now()
+		updates["status"] = models.DeliverySuccess
+		updates["delivered_at"] = &now
+	case delivery.Attempts+1 >= MaxAttempts:
+		updates["status"] = models.DeliveryFailed
+		updates["error"] = err.Error()
+	default:
+		updates["error"] = err.Error()
+		updates["next_attempt_at"] = time.Now().Add(Backoff(delivery.Attempts + 1))
+	}
+
+	if err := database.DB.Model(&delivery).Updates(updates).Error; err != nil {
+		slog.Error("webhook: 更新投递状态失败", "delivery_id", delivery.ID, "err", err)
+	}
+}
+
+func send(ctx context.Context, hook models.Webhook, delivery models.WebhookDelivery) (int, string, error) {
+	payload := []byte(delivery.Payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "GoBlog-Webhook")
+	req.Header.Set("X-GoBlog-Event", delivery.Event)
+	req.Header.Set("X-GoBlog-Delivery", strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(SignatureHeader, Sign(hook.Secret, payload))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, string(body), fmt.Errorf("接收方返回状态码 %d", resp.StatusCode)
+	}
+	return resp.StatusCode, string(body), nil
+}
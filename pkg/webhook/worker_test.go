@@ -0,0 +1,126 @@
+package webhook
+
+import (
+	"context"
+	"goblog/database/databasetest"
+	"goblog/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	for _, c := range []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{4, 4 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, 64 * time.Minute},
+		{10, 256 * time.Minute},
+		{11, 6 * time.Hour},
+		{40, 6 * time.Hour},
+		// 移位溢出为负数或 0 时也取上限
+		{64, 6 * time.Hour},
+		{100, 6 * time.Hour},
+	} {
+		if got := Backoff(c.attempt); got != c.want {
+			t.Errorf("Backoff(%d) = %v，期望 %v", c.attempt, got, c.want)
+		}
+	}
+}
+
+func TestAttempt(t *testing.T) {
+	databasetest.ForEach(t, func(t *testing.T, driver string) {
+		db := databasetest.Open(t, driver)
+
+		var status atomic.Int32
+		var hits atomic.Int32
+		var signature atomic.Value
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits.Add(1)
+			signature.Store(r.Header.Get(SignatureHeader))
+			w.WriteHeader(int(status.Load()))
+			w.Write([]byte("ok"))
+		}))
+		defer srv.Close()
+
+		hook := models.Webhook{UserID: 1, URL: srv.URL, Secret: "s3cret", Events: EventPostPublished, Active: true}
+		if err := db.Create(&hook).Error; err != nil {
+			t.Fatal(err)
+		}
+		newDelivery := func(attempts int) models.WebhookDelivery {
+			t.Helper()
+			d := models.WebhookDelivery{WebhookID: hook.ID, Event: EventPostPublished, Payload: `{"id":1}`,
+				Status: models.DeliveryPending, Attempts: attempts, NextAttemptAt: time.Now()}
+			if err := db.Create(&d).Error; err != nil {
+				t.Fatal(err)
+			}
+			return d
+		}
+		reload := func(d models.WebhookDelivery) models.WebhookDelivery {
+			t.Helper()
+			if err := db.First(&d, d.ID).Error; err != nil {
+				t.Fatal(err)
+			}
+			return d
+		}
+		ctx := context.Background()
+
+		// 接收方返回错误时按退避时间重新排队
+		allowPrivate(t, true)
+		status.Store(http.StatusInternalServerError)
+		d := newDelivery(0)
+		before := time.Now()
+		attempt(ctx, d)
+		d = reload(d)
+		if d.Status != models.DeliveryPending || d.Attempts != 1 || d.ResponseStatus != 500 || !strings.Contains(d.Error, "500") {
+			t.Fatalf("第一次失败后 %+v", d)
+		}
+		if next := d.NextAttemptAt.Sub(before); next < Backoff(1) || next > Backoff(1)+5*time.Second {
+			t.Errorf("第一次失败后 %v 后重试，期望 %v", next, Backoff(1))
+		}
+		attempt(ctx, d)
+		d = reload(d)
+		if next := d.NextAttemptAt.Sub(before); d.Attempts != 2 || next < Backoff(2) || next > Backoff(2)+5*time.Second {
+			t.Errorf("第二次失败后 attempts = %d，%v 后重试，期望 %v", d.Attempts, next, Backoff(2))
+		}
+
+		// 达到最大次数后不再重试
+		d = newDelivery(MaxAttempts - 1)
+		attempt(ctx, d)
+		if d = reload(d); d.Status != models.DeliveryFailed || d.Attempts != MaxAttempts || d.Error == "" {
+			t.Fatalf("最后一次失败后 %+v", d)
+		}
+
+		// 成功时记录响应，签名可以用订阅的密钥校验
+		status.Store(http.StatusOK)
+		d = newDelivery(3)
+		attempt(ctx, d)
+		if d = reload(d); d.Status != models.DeliverySuccess || d.Attempts != 4 || d.ResponseBody != "ok" || d.Error != "" || d.DeliveredAt == nil {
+			t.Fatalf("成功后 %+v", d)
+		}
+		if sig, _ := signature.Load().(string); !Verify(hook.Secret, []byte(d.Payload), sig) {
+			t.Errorf("签名 %q 校验失败", sig)
+		}
+
+		// 订阅地址在发送时解析到内网地址：不发出请求，作为一次失败重试
+		allowPrivate(t, false)
+		client.CloseIdleConnections()
+		sent := hits.Load()
+		d = newDelivery(0)
+		attempt(ctx, d)
+		if d = reload(d); d.Status != models.DeliveryPending || d.Attempts != 1 || !strings.Contains(d.Error, ErrForbiddenAddress.Error()) {
+			t.Fatalf("投递到内网地址后 %+v", d)
+		}
+		if hits.Load() != sent {
+			t.Error("不应向内网地址发出请求")
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package workflow
+
+import (
+	"errors"
+	"goblog/models"
+)
+
+const (
+	ActionSubmit         = "submit"
+	ActionWithdraw       = "withdraw"
+	ActionApprove        = "approve"
+	ActionRequestChanges = "request_changes"
+	ActionPublish        = "publish"
+	ActionUnpublish      = "unpublish"
+	// ActionRevise 已审核通过的文章内容被修改后自动退回审核
+	ActionRevise = "revise"
+)
+
+var (
+	ErrInvalidTransition = errors.New("当前状态不允许该操作")
+	ErrForbidden         = errors.New("无权执行该操作")
+)
+
+// Actor 执行状态变更的用户：PostRole 为其在文章中的角色，SiteRole 为站点角色
+type Actor struct {
+	PostRole string
+	SiteRole string
+}
+
+func (a Actor) isAuthor() bool {
+	return a.PostRole == models.CollaboratorOwner || a.PostRole == models.CollaboratorCoAuthor
+}
+
+func (a Actor) isEditor() bool {
+	return a.SiteRole == models.RoleEditor || a.SiteRole == models.RoleAdmin
+}
+
+type transition struct {
+	from, action string
+}
+
+var transitions = map[transition]string{
+	{models.PostDraft, ActionSubmit}:            models.PostInReview,
+	{models.PostInReview, ActionWithdraw}:       models.PostDraft,
+	{models.PostInReview, ActionApprove}:        models.PostApproved,
+	{models.PostInReview, ActionRequestChanges}: models.PostDraft,
+	{models.PostApproved, ActionWithdraw}:       models.PostDraft,
+	{models.PostApproved, ActionRequestChanges}: models.PostDraft,
+	{models.PostApproved, ActionRevise}:         models.PostInReview,
+	{models.PostApproved, ActionPublish}:        models.PostPublished,
+	{models.PostPublished, ActionUnpublish}:     models.PostDraft,
+}
+
+// Next 返回执行 action 后的状态。enforced 为 false 时（未开启审稿流程）作者可以从任意未发布状态直接发布
+func Next(status, action string, enforced bool) (string, error) {
+	if to, ok := transitions[transition{status, action}]; ok {
+		return to, nil
+	}
+	if !enforced && action == ActionPublish && status != models.PostPublished {
+		return models.PostPublished, nil
+	}
+	return "", ErrInvalidTransition
+}
+
+// Can 判断 actor 是否可以执行 action
+func Can(action string, actor Actor, enforced bool) bool {
+	switch action {
+	case ActionSubmit, ActionWithdraw, ActionRevise:
+		return actor.isAuthor()
+	case ActionApprove, ActionRequestChanges:
+		// 不能审核自己的文章
+		if actor.isAuthor() {
+			return false
+		}
+		return actor.PostRole == models.CollaboratorReviewer || actor.isEditor()
+	case ActionPublish:
+		if enforced {
+			return actor.isEditor()
+		}
+		return actor.isAuthor() || actor.isEditor()
+	case ActionUnpublish:
+		return actor.PostRole == models.CollaboratorOwner || actor.isEditor()
+	}
+	return false
+}
+
+// Transition 校验权限和状态后返回新状态
+func Transition(status, action string, actor Actor, enforced bool) (string, error) {
+	to, err := Next(status, action, enforced)
+	if err != nil {
+		return "", err
+	}
+	if !Can(action, actor, enforced) {
+		return "", ErrForbidden
+	}
+	return to, nil
+}
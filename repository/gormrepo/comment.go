@@ -0,0 +1,67 @@
+package gormrepo
+
+import (
+	"context"
+	"goblog/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CommentRepository struct {
+	db *gorm.DB
+}
+
+func (r *CommentRepository) Create(ctx context.Context, comment *models.Comment) error {
+	return r.db.WithContext(ctx).Create(comment).Error
+}
+
+func (r *CommentRepository) FindByID(ctx context.Context, id uint) (models.Comment, error) {
+	var comment models.Comment
+	err := r.db.WithContext(ctx).Preload("User").Preload("Replies", inOrder).Preload("Replies.User").First(&comment, id).Error
+	return comment, notFound(err)
+}
+
+func (r *CommentRepository) ListByPost(ctx context.Context, postID uint) ([]models.Comment, error) {
+	var comments []models.Comment
+	err := r.db.WithContext(ctx).Preload("User").Preload("Replies", inOrder).Preload("Replies.User").
+		Where("post_id = ? AND parent_id IS NULL", postID).Scopes(inOrder).Find(&comments).Error
+	return comments, err
+}
+
+// inOrder 按发表先后排序，MySQL 的时间只精确到毫秒，相同时间再按 ID 排
+func inOrder(db *gorm.DB) *gorm.DB {
+	return db.Order("created_at ASC, id ASC")
+}
+
+func (r *CommentRepository) Update(ctx context.Context, comment *models.Comment, fields map[string]any) error {
+	// 忽略关联，否则预加载的 User 会把 user_id 改回原作者
+	return r.db.WithContext(ctx).Model(comment).Omit(clause.Associations).Updates(fields).Error
+}
+
+// Delete 逐层找出全部回复后从最深的一层开始删除，评论上的点赞也一并删除。
+// MySQL 逐行检查外键，同一条语句里先删到父评论会失败
+func (r *CommentRepository) Delete(ctx context.Context, comment *models.Comment) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		levels := [][]uint{{comment.ID}}
+		for {
+			var children []uint
+			if err := tx.Model(&models.Comment{}).Where("parent_id IN ?", levels[len(levels)-1]).Pluck("id", &children).Error; err != nil {
+				return err
+			}
+			if len(children) == 0 {
+				break
+			}
+			levels = append(levels, children)
+		}
+		for i := len(levels) - 1; i >= 0; i-- {
+			if err := softDelete(tx).Where("target_type = ? AND target_id IN ?", "comment", levels[i]).Delete(&models.Like{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Delete(&models.Comment{}, levels[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,58 @@
+package gormrepo
+
+import (
+	"context"
+	"goblog/models"
+	"goblog/pkg/analytics"
+
+	"gorm.io/gorm"
+)
+
+// PostExtras 从数据库和浏览量统计中填充文章的收藏数、浏览量和合著者，实现 service.PostExtras
+type PostExtras struct {
+	db *gorm.DB
+}
+
+func NewPostExtras(db *gorm.DB) *PostExtras {
+	return &PostExtras{db: db}
+}
+
+func (e *PostExtras) FillPostExtras(ctx context.Context, posts []models.Post) error {
+	ids := make([]uint, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+	db := e.db.WithContext(ctx)
+
+	bookmarks, err := countBy(db.Model(&models.Bookmark{}).Where("post_id IN ?", ids), "post_id")
+	if err != nil {
+		return err
+	}
+	bookmarkCounts := make(map[uint]int64, len(bookmarks))
+	for _, row := range bookmarks {
+		bookmarkCounts[row.ID] = row.Count
+	}
+
+	viewCounts, err := analytics.TotalViews(ids)
+	if err != nil {
+		return err
+	}
+
+	var coAuthors []models.PostCollaborator
+	if err := db.Preload("User").Where("post_id IN ? AND role = ? AND status = ?", ids, models.CollaboratorCoAuthor, models.InvitationAccepted).
+		Order("accepted_at asc").Find(&coAuthors).Error; err != nil {
+		return err
+	}
+
+	authors := map[uint][]models.User{}
+	for _, ca := range coAuthors {
+		authors[ca.PostID] = append(authors[ca.PostID], ca.User)
+	}
+
+	for i := range posts {
+		posts[i].Authors = append(posts[i].Authors, authors[posts[i].ID]...)
+		posts[i].BookmarkCount = bookmarkCounts[posts[i].ID]
+		posts[i].ViewCount = viewCounts[posts[i].ID]
+	}
+	return nil
+}
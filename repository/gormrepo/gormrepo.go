@@ -0,0 +1,55 @@
+// Package gormrepo 是 repository 接口基于 GORM 的实现
+package gormrepo
+
+import (
+	"errors"
+	"goblog/database"
+	"goblog/repository"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// New 返回使用 db 的全部仓储
+func New(db *gorm.DB) repository.Repositories {
+	return repository.Repositories{
+		Users:    &UserRepository{db: db},
+		Posts:    &PostRepository{db: db},
+		Comments: &CommentRepository{db: db},
+		Likes:    &LikeRepository{db: db},
+		Tags:     &TagRepository{db: db},
+	}
+}
+
+// notFound 把 gorm.ErrRecordNotFound 转换为 repository.ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return repository.ErrNotFound
+	}
+	return err
+}
+
+// likePattern 把 s 转成 LIKE 的包含匹配，% 和 _ 按字面匹配。
+// 用 ! 作转义符，三种数据库对 ESCAPE '\' 的字符串字面量解析不一致
+func likePattern(s string) string {
+	s = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(strings.ToLower(s))
+	return "%" + s + "%"
+}
+
+// limit 把 0 转成 GORM 的 -1，与 memory 实现一样表示不限制条数，GORM 的 Limit(0) 会生成 LIMIT 0
+func limit(n int) int {
+	if n <= 0 {
+		return -1
+	}
+	return n
+}
+
+// softDelete 返回删除 likes、tags 等带唯一索引的表时使用的 db。
+// 支持部分索引的数据库上唯一索引忽略已删除的记录，可以软删除；
+// MySQL 的唯一索引包含软删除的记录，只能直接删除，否则之后无法再插入相同的值
+func softDelete(db *gorm.DB) *gorm.DB {
+	if database.CapabilitiesOf(db).PartialIndex {
+		return db
+	}
+	return db.Unscoped()
+}
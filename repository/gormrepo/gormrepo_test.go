@@ -0,0 +1,81 @@
+package gormrepo_test
+
+import (
+	"context"
+	"goblog/database/databasetest"
+	"goblog/models"
+	"goblog/repository"
+	"goblog/repository/gormrepo"
+	"goblog/repository/repositorytest"
+	"testing"
+)
+
+// TestRepositories 在每种数据库上运行与 memory 相同的用例，每个用例使用刚迁移好的空库
+func TestRepositories(t *testing.T) {
+	databasetest.ForEach(t, func(t *testing.T, driver string) {
+		repositorytest.Run(t, func(t *testing.T) repository.Repositories {
+			return gormrepo.New(databasetest.Open(t, driver))
+		})
+	})
+}
+
+// TestPurgeCompactsSeries 永久删除系列中的文章后，同一系列后面的文章前移，其他系列不受影响
+func TestPurgeCompactsSeries(t *testing.T) {
+	databasetest.ForEach(t, func(t *testing.T, driver string) {
+		db := databasetest.Open(t, driver)
+		repos := gormrepo.New(db)
+		ctx := context.Background()
+
+		author := models.User{Username: "author", Email: "author@example.com", Password: "hash"}
+		if err := db.Create(&author).Error; err != nil {
+			t.Fatal(err)
+		}
+		series, other := models.Series{UserID: author.ID, Title: "系列"}, models.Series{UserID: author.ID, Title: "其他系列"}
+		for _, s := range []*models.Series{&series, &other} {
+			if err := db.Create(s).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+		var posts []models.Post
+		for i := range 5 {
+			post := models.Post{Title: "文章", Content: "正文", UserID: author.ID}
+			if err := db.Create(&post).Error; err != nil {
+				t.Fatal(err)
+			}
+			posts = append(posts, post)
+			entry := models.SeriesPost{SeriesID: series.ID, PostID: post.ID, Position: i + 1}
+			if i >= 3 {
+				entry = models.SeriesPost{SeriesID: other.ID, PostID: post.ID, Position: i - 2}
+			}
+			if err := db.Create(&entry).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := repos.Posts.Purge(ctx, &posts[1]); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, c := range []struct {
+			seriesID uint
+			want     []uint
+		}{
+			{series.ID, []uint{posts[0].ID, posts[2].ID}},
+			{other.ID, []uint{posts[3].ID, posts[4].ID}},
+		} {
+			var entries []models.SeriesPost
+			if err := db.Where("series_id = ?", c.seriesID).Order("position asc").Find(&entries).Error; err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != len(c.want) {
+				t.Fatalf("系列 %d 中有 %d 篇文章，期望 %d", c.seriesID, len(entries), len(c.want))
+			}
+			for i, entry := range entries {
+				if entry.PostID != c.want[i] || entry.Position != i+1 {
+					t.Errorf("系列 %d 第 %d 项为文章 %d、position %d，期望文章 %d、position %d",
+						c.seriesID, i, entry.PostID, entry.Position, c.want[i], i+1)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package gormrepo
+
+import (
+	"context"
+	"goblog/models"
+
+	"gorm.io/gorm"
+)
+
+type LikeRepository struct {
+	db *gorm.DB
+}
+
+func (r *LikeRepository) Create(ctx context.Context, like *models.Like) error {
+	return r.db.WithContext(ctx).Create(like).Error
+}
+
+func (r *LikeRepository) Exists(ctx context.Context, userID, targetID uint, targetType string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Like{}).
+		Where("user_id = ? AND target_id = ? AND target_type = ?", userID, targetID, targetType).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *LikeRepository) Count(ctx context.Context, targetID uint, targetType string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Like{}).Where("target_id = ? AND target_type = ?", targetID, targetType).Count(&count).Error
+	return count, err
+}
+
+func (r *LikeRepository) CountByTargets(ctx context.Context, targetType string, ids []uint) (map[uint]int64, error) {
+	counts := make(map[uint]int64, len(ids))
+	if len(ids) == 0 {
+		return counts, nil
+	}
+	rows, err := countBy(r.db.WithContext(ctx).Model(&models.Like{}).Where("target_type = ? AND target_id IN ?", targetType, ids), "target_id")
+	for _, row := range rows {
+		counts[row.ID] = row.Count
+	}
+	return counts, err
+}
+
+type countRow struct {
+	ID    uint
+	Count int64
+}
+
+// countBy 按 column 分组计数
+func countBy(query *gorm.DB, column string) ([]countRow, error) {
+	var rows []countRow
+	err := query.Select(column + " AS id, COUNT(*) AS count").Group(column).Scan(&rows).Error
+	return rows, err
+}
@@ -0,0 +1,150 @@
+package gormrepo
+
+import (
+	"context"
+	"goblog/models"
+	"goblog/repository"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PostRepository struct {
+	db *gorm.DB
+}
+
+func (r *PostRepository) Create(ctx context.Context, post *models.Post) error {
+	return r.db.WithContext(ctx).Create(post).Error
+}
+
+func (r *PostRepository) FindByID(ctx context.Context, id uint) (models.Post, error) {
+	var post models.Post
+	err := r.db.WithContext(ctx).Preload("User").Preload("Tags").First(&post, id).Error
+	return post, notFound(err)
+}
+
+func (r *PostRepository) List(ctx context.Context, query repository.PostQuery) ([]models.Post, error) {
+	db := r.db.WithContext(ctx).Preload("User").Preload("Tags")
+	if query.Published {
+		db = db.Where("is_draft = ?", false)
+	}
+
+	var posts []models.Post
+	err := db.Order(query.Order).Limit(limit(query.Limit)).Offset(query.Offset).Find(&posts).Error
+	return posts, err
+}
+
+func (r *PostRepository) FindPublished(ctx context.Context, ids []uint) ([]models.Post, error) {
+	var posts []models.Post
+	err := r.db.WithContext(ctx).Preload("User").Preload("Tags").Where("id IN ? AND is_draft = ?", ids, false).Find(&posts).Error
+	return posts, err
+}
+
+func (r *PostRepository) PinnedIDs(ctx context.Context) ([]uint, error) {
+	var ids []uint
+	err := r.db.WithContext(ctx).Model(&models.Post{}).Where("is_top = ? AND is_draft = ?", true, false).
+		Order("created_at desc, id desc").Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (r *PostRepository) ListByTag(ctx context.Context, tagID uint) ([]models.Post, error) {
+	var posts []models.Post
+	err := r.db.WithContext(ctx).Preload("User").Preload("Tags").
+		Joins("JOIN post_tags ON post_tags.post_id = posts.id AND post_tags.tag_id = ?", tagID).
+		Where("posts.is_draft = ?", false).Order("posts.created_at desc, posts.id desc").Find(&posts).Error
+	return posts, err
+}
+
+func (r *PostRepository) Update(ctx context.Context, post *models.Post, fields map[string]any) error {
+	// 忽略关联，否则预加载的 Author 会把 user_id 改回原作者
+	return r.db.WithContext(ctx).Model(post).Omit(clause.Associations).Updates(fields).Error
+}
+
+func (r *PostRepository) Delete(ctx context.Context, post *models.Post) error {
+	return r.db.WithContext(ctx).Delete(post).Error
+}
+
+func (r *PostRepository) ListTrashed(ctx context.Context, userID uint) ([]models.Post, error) {
+	var posts []models.Post
+	err := r.db.WithContext(ctx).Unscoped().Preload("User").Preload("Tags").
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID).Order("deleted_at desc, id desc").Find(&posts).Error
+	return posts, err
+}
+
+func (r *PostRepository) FindTrashed(ctx context.Context, id uint) (models.Post, error) {
+	var post models.Post
+	err := r.db.WithContext(ctx).Unscoped().Preload("User").Preload("Tags").
+		Where("deleted_at IS NOT NULL").First(&post, id).Error
+	return post, notFound(err)
+}
+
+func (r *PostRepository) Restore(ctx context.Context, post *models.Post) error {
+	if err := r.db.WithContext(ctx).Unscoped().Model(post).Update("deleted_at", nil).Error; err != nil {
+		return err
+	}
+	post.DeletedAt = gorm.DeletedAt{}
+	return nil
+}
+
+// postTables 是只属于某篇文章、按 post_id 关联的表，永久删除文章时一起清理
+var postTables = []string{
+	"post_tags", "bookmarks", "post_views", "post_referrers", "post_collaborators",
+	"post_transitions", "review_comments", "series_posts",
+}
+
+func (r *PostRepository) Purge(ctx context.Context, post *models.Post) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("target_type = ? AND target_id IN (?)", "comment",
+			tx.Model(&models.Comment{}).Select("id").Where("post_id = ?", post.ID)).Delete(&models.Like{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("target_type = ? AND target_id = ?", "post", post.ID).Delete(&models.Like{}).Error; err != nil {
+			return err
+		}
+		// 先断开回复关系，MySQL 逐行检查外键，直接删除时可能先删到父评论
+		if err := tx.Model(&models.Comment{}).Where("post_id = ? AND parent_id IS NOT NULL", post.ID).Update("parent_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("post_id = ?", post.ID).Delete(&models.Comment{}).Error; err != nil {
+			return err
+		}
+		var seriesIDs []uint
+		if err := tx.Model(&models.SeriesPost{}).Where("post_id = ?", post.ID).Pluck("series_id", &seriesIDs).Error; err != nil {
+			return err
+		}
+		for _, table := range postTables {
+			if err := tx.Exec("DELETE FROM "+table+" WHERE post_id = ?", post.ID).Error; err != nil {
+				return err
+			}
+		}
+		// 系列中后面的文章依次前移，保持 position 从 1 开始连续
+		for _, seriesID := range seriesIDs {
+			if err := compactSeries(tx, seriesID); err != nil {
+				return err
+			}
+		}
+		return tx.Unscoped().Delete(post).Error
+	})
+}
+
+// compactSeries 按现有顺序把系列中文章的 position 重新编号为 1..n
+func compactSeries(tx *gorm.DB, seriesID uint) error {
+	var ids []uint
+	if err := tx.Model(&models.SeriesPost{}).Where("series_id = ?", seriesID).Order("position asc").Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	for i, id := range ids {
+		if err := tx.Model(&models.SeriesPost{}).Where("id = ?", id).Update("position", i+1).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *PostRepository) ExpiredTrash(ctx context.Context, before time.Time, limit int) ([]models.Post, error) {
+	var posts []models.Post
+	err := r.db.WithContext(ctx).Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Order("deleted_at, id").Limit(limit).Find(&posts).Error
+	return posts, err
+}
@@ -0,0 +1,76 @@
+package gormrepo
+
+import (
+	"context"
+	"goblog/models"
+
+	"gorm.io/gorm"
+)
+
+type TagRepository struct {
+	db *gorm.DB
+}
+
+func (r *TagRepository) FindOrCreate(ctx context.Context, name string) (models.Tag, error) {
+	var tag models.Tag
+	err := r.db.WithContext(ctx).FirstOrCreate(&tag, models.Tag{Name: name}).Error
+	return tag, err
+}
+
+func (r *TagRepository) FindByName(ctx context.Context, name string) (models.Tag, error) {
+	var tag models.Tag
+	err := r.db.WithContext(ctx).First(&tag, "name = ?", name).Error
+	return tag, notFound(err)
+}
+
+func (r *TagRepository) FindByID(ctx context.Context, id uint) (models.Tag, error) {
+	var tag models.Tag
+	err := r.db.WithContext(ctx).First(&tag, id).Error
+	return tag, notFound(err)
+}
+
+func (r *TagRepository) List(ctx context.Context, search string) ([]models.Tag, error) {
+	db := r.db.WithContext(ctx)
+	if search != "" {
+		db = db.Where("LOWER(name) LIKE ? ESCAPE '!'", likePattern(search))
+	}
+	var tags []models.Tag
+	if err := db.Order("name").Find(&tags).Error; err != nil {
+		return nil, err
+	}
+
+	// 已删除的文章还留着 post_tags 关联，不计入文章数
+	var counts []struct {
+		TagID uint
+		Count int64
+	}
+	err := r.db.WithContext(ctx).Table("post_tags").
+		Select("post_tags.tag_id, COUNT(*) AS count").
+		Joins("JOIN posts ON posts.id = post_tags.post_id AND posts.deleted_at IS NULL").
+		Group("post_tags.tag_id").Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	byTag := make(map[uint]int64, len(counts))
+	for _, c := range counts {
+		byTag[c.TagID] = c.Count
+	}
+	for i := range tags {
+		tags[i].PostCount = byTag[tags[i].ID]
+	}
+	return tags, nil
+}
+
+func (r *TagRepository) Update(ctx context.Context, tag *models.Tag, fields map[string]any) error {
+	return r.db.WithContext(ctx).Model(tag).Updates(fields).Error
+}
+
+// Delete 删除标签及其文章关联，删除后可以再建同名标签
+func (r *TagRepository) Delete(ctx context.Context, tag *models.Tag) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM post_tags WHERE tag_id = ?", tag.ID).Error; err != nil {
+			return err
+		}
+		return softDelete(tx).Delete(tag).Error
+	})
+}
@@ -0,0 +1,67 @@
+package gormrepo
+
+import (
+	"context"
+	"goblog/models"
+	"goblog/repository"
+
+	"gorm.io/gorm"
+)
+
+type UserRepository struct {
+	db *gorm.DB
+}
+
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
+}
+
+func (r *UserRepository) FindByID(ctx context.Context, id uint) (models.User, error) {
+	var user models.User
+	err := r.db.WithContext(ctx).First(&user, id).Error
+	return user, notFound(err)
+}
+
+func (r *UserRepository) FindByEmail(ctx context.Context, email string) (models.User, error) {
+	var user models.User
+	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
+	return user, notFound(err)
+}
+
+func (r *UserRepository) ExistsByUsernameOrEmail(ctx context.Context, username, email string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.User{}).Where("username = ? OR email = ?", username, email).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *UserRepository) List(ctx context.Context, query repository.UserQuery) ([]models.User, int64, error) {
+	db := r.db.WithContext(ctx).Model(&models.User{})
+	if query.Search != "" {
+		pattern := likePattern(query.Search)
+		db = db.Where("LOWER(username) LIKE ? ESCAPE '!' OR LOWER(email) LIKE ? ESCAPE '!'", pattern, pattern)
+	}
+	if query.Role != "" {
+		db = db.Where("role = ?", query.Role)
+	}
+	if query.Banned != nil {
+		if *query.Banned {
+			db = db.Where("banned_at IS NOT NULL")
+		} else {
+			db = db.Where("banned_at IS NULL")
+		}
+	}
+	// Count 和 Find 共用筛选条件，Session 之后两次查询互不影响
+	db = db.Session(&gorm.Session{})
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var users []models.User
+	err := db.Order("created_at desc, id desc").Offset(query.Offset).Limit(limit(query.Limit)).Find(&users).Error
+	return users, total, err
+}
+
+func (r *UserRepository) Update(ctx context.Context, user *models.User, fields map[string]any) error {
+	return r.db.WithContext(ctx).Model(user).Updates(fields).Error
+}
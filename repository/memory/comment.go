@@ -0,0 +1,129 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"goblog/models"
+	"goblog/repository"
+	"slices"
+	"sort"
+)
+
+type CommentRepository struct {
+	s *Store
+}
+
+func (r *CommentRepository) Create(ctx context.Context, comment *models.Comment) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	comment.ID = r.s.id("comments")
+	comment.CreatedAt, comment.UpdatedAt = now(), now()
+	stored := *comment
+	stored.User, stored.Post, stored.Replies = models.User{}, models.Post{}, nil
+	r.s.comments[comment.ID] = stored
+	return nil
+}
+
+func (r *CommentRepository) FindByID(ctx context.Context, id uint) (models.Comment, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	comment, ok := r.s.comments[id]
+	if !ok {
+		return models.Comment{}, repository.ErrNotFound
+	}
+	return r.s.loadComment(comment), nil
+}
+
+func (r *CommentRepository) ListByPost(ctx context.Context, postID uint) ([]models.Comment, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var comments []models.Comment
+	for _, comment := range r.s.comments {
+		if comment.PostID == postID && comment.ParentID == nil {
+			comments = append(comments, r.s.loadComment(comment))
+		}
+	}
+	sortComments(comments)
+	return comments, nil
+}
+
+// loadComment 关联作者和直接回复（带作者），与 GORM 实现的预加载层级一致，调用方需持有读锁
+func (s *Store) loadComment(comment models.Comment) models.Comment {
+	comment.User = s.users[comment.UserID]
+	comment.Replies = []models.Comment{}
+	for _, reply := range s.comments {
+		if reply.ParentID != nil && *reply.ParentID == comment.ID {
+			reply.User = s.users[reply.UserID]
+			comment.Replies = append(comment.Replies, reply)
+		}
+	}
+	sortComments(comment.Replies)
+	return comment
+}
+
+func sortComments(comments []models.Comment) {
+	sort.Slice(comments, func(i, j int) bool {
+		if !comments[i].CreatedAt.Equal(comments[j].CreatedAt) {
+			return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+		}
+		return comments[i].ID < comments[j].ID
+	})
+}
+
+func (r *CommentRepository) Update(ctx context.Context, comment *models.Comment, fields map[string]any) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	stored, ok := r.s.comments[comment.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	for column, value := range fields {
+		var ok bool
+		switch column {
+		case "content":
+			stored.Content, ok = value.(string)
+		case "user_id":
+			stored.UserID, ok = value.(uint)
+		}
+		if !ok {
+			return fmt.Errorf("不支持修改 %s = %v", column, value)
+		}
+	}
+	stored.UpdatedAt = now()
+	r.s.comments[comment.ID] = stored
+
+	user, replies := comment.User, comment.Replies
+	*comment = stored
+	comment.User, comment.Replies = user, replies
+	return nil
+}
+
+func (r *CommentRepository) Delete(ctx context.Context, comment *models.Comment) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	ids := []uint{comment.ID}
+	for parents := ids; len(parents) > 0; {
+		var children []uint
+		for _, c := range r.s.comments {
+			if c.ParentID != nil && slices.Contains(parents, *c.ParentID) {
+				children = append(children, c.ID)
+			}
+		}
+		ids = append(ids, children...)
+		parents = children
+	}
+	for _, id := range ids {
+		delete(r.s.comments, id)
+	}
+	for id, like := range r.s.likes {
+		if like.TargetType == "comment" && slices.Contains(ids, like.TargetID) {
+			delete(r.s.likes, id)
+		}
+	}
+	return nil
+}
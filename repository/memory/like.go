@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"context"
+	"goblog/models"
+	"slices"
+)
+
+type LikeRepository struct {
+	s *Store
+}
+
+func (r *LikeRepository) Create(ctx context.Context, like *models.Like) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	like.ID = r.s.id("likes")
+	like.CreatedAt = now()
+	r.s.likes[like.ID] = *like
+	return nil
+}
+
+func (r *LikeRepository) Exists(ctx context.Context, userID, targetID uint, targetType string) (bool, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	for _, like := range r.s.likes {
+		if like.UserID == userID && like.TargetID == targetID && like.TargetType == targetType {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *LikeRepository) Count(ctx context.Context, targetID uint, targetType string) (int64, error) {
+	counts, err := r.CountByTargets(ctx, targetType, []uint{targetID})
+	return counts[targetID], err
+}
+
+func (r *LikeRepository) CountByTargets(ctx context.Context, targetType string, ids []uint) (map[uint]int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	counts := make(map[uint]int64, len(ids))
+	for _, like := range r.s.likes {
+		if like.TargetType == targetType && slices.Contains(ids, like.TargetID) {
+			counts[like.TargetID]++
+		}
+	}
+	return counts, nil
+}
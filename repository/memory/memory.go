@@ -0,0 +1,60 @@
+// Package memory 是 repository 接口的内存实现，数据只保存在进程中，用于本地调试和测试。
+package memory
+
+import (
+	"goblog/models"
+	"goblog/repository"
+	"sync"
+	"time"
+)
+
+// Store 保存所有表的数据，各仓储共用一把锁，以便查询时关联作者、标签等记录
+type Store struct {
+	mu       sync.RWMutex
+	nextIDs  map[string]uint
+	users    map[uint]models.User
+	posts    map[uint]models.Post
+	trash    map[uint]models.Post // 软删除的文章
+	postTags map[uint][]uint
+	comments map[uint]models.Comment
+	likes    map[uint]models.Like
+	tags     map[uint]models.Tag
+}
+
+func NewStore() *Store {
+	return &Store{
+		nextIDs:  map[string]uint{},
+		users:    map[uint]models.User{},
+		posts:    map[uint]models.Post{},
+		trash:    map[uint]models.Post{},
+		postTags: map[uint][]uint{},
+		comments: map[uint]models.Comment{},
+		likes:    map[uint]models.Like{},
+		tags:     map[uint]models.Tag{},
+	}
+}
+
+// New 返回共用一个空 Store 的全部仓储
+func New() repository.Repositories {
+	return NewStore().Repositories()
+}
+
+func (s *Store) Repositories() repository.Repositories {
+	return repository.Repositories{
+		Users:    &UserRepository{s},
+		Posts:    &PostRepository{s},
+		Comments: &CommentRepository{s},
+		Likes:    &LikeRepository{s},
+		Tags:     &TagRepository{s},
+	}
+}
+
+// id 分配 table 的自增主键，调用方需持有写锁
+func (s *Store) id(table string) uint {
+	s.nextIDs[table]++
+	return s.nextIDs[table]
+}
+
+func now() time.Time {
+	return time.Now().UTC()
+}
@@ -0,0 +1,12 @@
+package memory_test
+
+import (
+	"goblog/repository"
+	"goblog/repository/memory"
+	"goblog/repository/repositorytest"
+	"testing"
+)
+
+func TestRepositories(t *testing.T) {
+	repositorytest.Run(t, func(*testing.T) repository.Repositories { return memory.New() })
+}
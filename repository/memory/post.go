@@ -0,0 +1,326 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"goblog/models"
+	"goblog/repository"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PostRepository struct {
+	s *Store
+}
+
+func (r *PostRepository) Create(ctx context.Context, post *models.Post) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	post.ID = r.s.id("posts")
+	post.CreatedAt, post.UpdatedAt = now(), now()
+	tagIDs := make([]uint, 0, len(post.Tags))
+	for _, tag := range post.Tags {
+		tagIDs = append(tagIDs, tag.ID)
+	}
+	r.s.postTags[post.ID] = tagIDs
+
+	stored := *post
+	stored.User, stored.Tags = models.User{}, nil
+	r.s.posts[post.ID] = stored
+	return nil
+}
+
+func (r *PostRepository) FindByID(ctx context.Context, id uint) (models.Post, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	post, ok := r.s.posts[id]
+	if !ok {
+		return models.Post{}, repository.ErrNotFound
+	}
+	return r.s.loadPost(post), nil
+}
+
+func (r *PostRepository) List(ctx context.Context, query repository.PostQuery) ([]models.Post, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	posts := make([]models.Post, 0, len(r.s.posts))
+	for _, post := range r.s.posts {
+		if !query.Published || !post.IsDraft {
+			posts = append(posts, post)
+		}
+	}
+	less, err := orderBy(query.Order)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(posts, func(i, j int) bool { return less(posts[i], posts[j]) })
+
+	start := min(max(query.Offset, 0), len(posts))
+	end := len(posts)
+	if query.Limit > 0 {
+		end = min(start+query.Limit, len(posts))
+	}
+	return r.s.loadPosts(posts[start:end]), nil
+}
+
+func (r *PostRepository) FindPublished(ctx context.Context, ids []uint) ([]models.Post, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var posts []models.Post
+	for _, id := range ids {
+		if post, ok := r.s.posts[id]; ok && !post.IsDraft {
+			posts = append(posts, post)
+		}
+	}
+	return r.s.loadPosts(posts), nil
+}
+
+func (r *PostRepository) PinnedIDs(ctx context.Context) ([]uint, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var posts []models.Post
+	for _, post := range r.s.posts {
+		if post.IsTop && !post.IsDraft {
+			posts = append(posts, post)
+		}
+	}
+	sort.Slice(posts, func(i, j int) bool { return newer(posts[i], posts[j]) })
+
+	ids := make([]uint, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+	return ids, nil
+}
+
+func (r *PostRepository) ListByTag(ctx context.Context, tagID uint) ([]models.Post, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var posts []models.Post
+	for _, post := range r.s.posts {
+		if !post.IsDraft && slices.Contains(r.s.postTags[post.ID], tagID) {
+			posts = append(posts, post)
+		}
+	}
+	sort.Slice(posts, func(i, j int) bool { return newer(posts[i], posts[j]) })
+	return r.s.loadPosts(posts), nil
+}
+
+func (r *PostRepository) Update(ctx context.Context, post *models.Post, fields map[string]any) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	stored, ok := r.s.posts[post.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	for column, value := range fields {
+		var ok bool
+		switch column {
+		case "title":
+			stored.Title, ok = value.(string)
+		case "content":
+			stored.Content, ok = value.(string)
+		case "status":
+			stored.Status, ok = value.(string)
+		case "is_draft":
+			stored.IsDraft, ok = value.(bool)
+		case "is_top":
+			stored.IsTop, ok = value.(bool)
+		case "is_recommend":
+			stored.IsRecommend, ok = value.(bool)
+		case "user_id":
+			stored.UserID, ok = value.(uint)
+		}
+		if !ok {
+			return fmt.Errorf("不支持修改 %s = %v", column, value)
+		}
+	}
+	stored.UpdatedAt = now()
+	r.s.posts[post.ID] = stored
+
+	user, tags := post.User, post.Tags
+	*post = stored
+	post.User, post.Tags = user, tags
+	return nil
+}
+
+func (r *PostRepository) Delete(ctx context.Context, post *models.Post) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	stored, ok := r.s.posts[post.ID]
+	if !ok {
+		return nil
+	}
+	stored.DeletedAt = gorm.DeletedAt{Time: now(), Valid: true}
+	delete(r.s.posts, post.ID)
+	r.s.trash[post.ID] = stored
+	return nil
+}
+
+func (r *PostRepository) ListTrashed(ctx context.Context, userID uint) ([]models.Post, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var posts []models.Post
+	for _, post := range r.s.trash {
+		if post.UserID == userID {
+			posts = append(posts, post)
+		}
+	}
+	sort.Slice(posts, func(i, j int) bool { return posts[i].DeletedAt.Time.After(posts[j].DeletedAt.Time) })
+	return r.s.loadPosts(posts), nil
+}
+
+func (r *PostRepository) FindTrashed(ctx context.Context, id uint) (models.Post, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	post, ok := r.s.trash[id]
+	if !ok {
+		return models.Post{}, repository.ErrNotFound
+	}
+	return r.s.loadPost(post), nil
+}
+
+func (r *PostRepository) Restore(ctx context.Context, post *models.Post) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	stored, ok := r.s.trash[post.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	stored.DeletedAt = gorm.DeletedAt{}
+	delete(r.s.trash, post.ID)
+	r.s.posts[post.ID] = stored
+	post.DeletedAt = stored.DeletedAt
+	return nil
+}
+
+func (r *PostRepository) Purge(ctx context.Context, post *models.Post) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	commentIDs := map[uint]bool{}
+	for id, comment := range r.s.comments {
+		if comment.PostID == post.ID {
+			commentIDs[id] = true
+			delete(r.s.comments, id)
+		}
+	}
+	for id, like := range r.s.likes {
+		if (like.TargetType == "post" && like.TargetID == post.ID) || (like.TargetType == "comment" && commentIDs[like.TargetID]) {
+			delete(r.s.likes, id)
+		}
+	}
+	delete(r.s.posts, post.ID)
+	delete(r.s.trash, post.ID)
+	delete(r.s.postTags, post.ID)
+	return nil
+}
+
+func (r *PostRepository) ExpiredTrash(ctx context.Context, before time.Time, limit int) ([]models.Post, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var posts []models.Post
+	for _, post := range r.s.trash {
+		if post.DeletedAt.Time.Before(before) {
+			posts = append(posts, post)
+		}
+	}
+	sort.Slice(posts, func(i, j int) bool { return posts[i].DeletedAt.Time.Before(posts[j].DeletedAt.Time) })
+	return posts[:min(limit, len(posts))], nil
+}
+
+// loadPost 关联作者和标签，相当于 Preload("User").Preload("Tags")，调用方需持有读锁
+func (s *Store) loadPost(post models.Post) models.Post {
+	post.User = s.users[post.UserID]
+	post.Tags = []*models.Tag{}
+	for _, id := range s.postTags[post.ID] {
+		if tag, ok := s.tags[id]; ok {
+			post.Tags = append(post.Tags, &tag)
+		}
+	}
+	return post
+}
+
+func (s *Store) loadPosts(posts []models.Post) []models.Post {
+	loaded := make([]models.Post, len(posts))
+	for i, post := range posts {
+		loaded[i] = s.loadPost(post)
+	}
+	return loaded
+}
+
+func newer(a, b models.Post) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+	return a.ID > b.ID
+}
+
+// orderBy 解析 PostQuery.Order 中的 "is_top desc, created_at desc" 形式的排序，只支持控制器用到的列
+func orderBy(order string) (func(a, b models.Post) bool, error) {
+	type term struct {
+		column string
+		desc   bool
+	}
+	var terms []term
+	for _, part := range strings.Split(order, ",") {
+		fields := strings.Fields(strings.ToLower(part))
+		if len(fields) == 0 {
+			continue
+		}
+		t := term{column: fields[0], desc: len(fields) > 1 && fields[1] == "desc"}
+		switch t.column {
+		case "id", "created_at", "is_top", "is_recommend":
+		default:
+			return nil, fmt.Errorf("不支持按 %s 排序", t.column)
+		}
+		terms = append(terms, t)
+	}
+
+	return func(a, b models.Post) bool {
+		for _, t := range terms {
+			var c int
+			switch t.column {
+			case "id":
+				c = cmp.Compare(a.ID, b.ID)
+			case "created_at":
+				c = a.CreatedAt.Compare(b.CreatedAt)
+			case "is_top":
+				c = compareBool(a.IsTop, b.IsTop)
+			case "is_recommend":
+				c = compareBool(a.IsRecommend, b.IsRecommend)
+			}
+			if c != 0 {
+				return (c < 0) != t.desc
+			}
+		}
+		return false
+	}, nil
+}
+
+func compareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	}
+	return -1
+}
@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"goblog/models"
+	"goblog/repository"
+	"slices"
+	"sort"
+	"strings"
+)
+
+type TagRepository struct {
+	s *Store
+}
+
+func (r *TagRepository) FindOrCreate(ctx context.Context, name string) (models.Tag, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if tag, ok := r.s.tagByName(name); ok {
+		return tag, nil
+	}
+	tag := models.Tag{ID: r.s.id("tags"), Name: name, CreatedAt: now(), UpdatedAt: now()}
+	r.s.tags[tag.ID] = tag
+	return tag, nil
+}
+
+func (r *TagRepository) FindByName(ctx context.Context, name string) (models.Tag, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	tag, ok := r.s.tagByName(name)
+	if !ok {
+		return models.Tag{}, repository.ErrNotFound
+	}
+	return tag, nil
+}
+
+func (s *Store) tagByName(name string) (models.Tag, bool) {
+	for _, tag := range s.tags {
+		if tag.Name == name {
+			return tag, true
+		}
+	}
+	return models.Tag{}, false
+}
+
+func (r *TagRepository) FindByID(ctx context.Context, id uint) (models.Tag, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	tag, ok := r.s.tags[id]
+	if !ok {
+		return models.Tag{}, repository.ErrNotFound
+	}
+	return tag, nil
+}
+
+func (r *TagRepository) List(ctx context.Context, search string) ([]models.Tag, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	counts := map[uint]int64{}
+	for postID, tagIDs := range r.s.postTags {
+		if _, ok := r.s.posts[postID]; !ok {
+			continue
+		}
+		for _, id := range tagIDs {
+			counts[id]++
+		}
+	}
+
+	search = strings.ToLower(search)
+	tags := []models.Tag{}
+	for _, tag := range r.s.tags {
+		if search != "" && !strings.Contains(strings.ToLower(tag.Name), search) {
+			continue
+		}
+		tag.PostCount = counts[tag.ID]
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags, nil
+}
+
+func (r *TagRepository) Update(ctx context.Context, tag *models.Tag, fields map[string]any) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	stored, ok := r.s.tags[tag.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	for column, value := range fields {
+		var ok bool
+		switch column {
+		case "name":
+			stored.Name, ok = value.(string)
+		}
+		if !ok {
+			return fmt.Errorf("不支持修改 %s = %v", column, value)
+		}
+	}
+	stored.UpdatedAt = now()
+	r.s.tags[tag.ID] = stored
+	*tag = stored
+	return nil
+}
+
+func (r *TagRepository) Delete(ctx context.Context, tag *models.Tag) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	delete(r.s.tags, tag.ID)
+	for postID, tagIDs := range r.s.postTags {
+		r.s.postTags[postID] = slices.DeleteFunc(tagIDs, func(id uint) bool { return id == tag.ID })
+	}
+	return nil
+}
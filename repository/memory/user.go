@@ -0,0 +1,134 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"goblog/models"
+	"goblog/repository"
+	"sort"
+	"strings"
+	"time"
+)
+
+type UserRepository struct {
+	s *Store
+}
+
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, u := range r.s.users {
+		if u.Username == user.Username || u.Email == user.Email {
+			return errors.New("duplicate username or email")
+		}
+	}
+	user.ID = r.s.id("users")
+	if user.Role == "" {
+		user.Role = models.RoleUser
+	}
+	user.CreatedAt, user.UpdatedAt = now(), now()
+	r.s.users[user.ID] = *user
+	return nil
+}
+
+func (r *UserRepository) FindByID(ctx context.Context, id uint) (models.User, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	user, ok := r.s.users[id]
+	if !ok {
+		return models.User{}, repository.ErrNotFound
+	}
+	return user, nil
+}
+
+func (r *UserRepository) FindByEmail(ctx context.Context, email string) (models.User, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	for _, user := range r.s.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return models.User{}, repository.ErrNotFound
+}
+
+func (r *UserRepository) ExistsByUsernameOrEmail(ctx context.Context, username, email string) (bool, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	for _, user := range r.s.users {
+		if user.Username == username || user.Email == email {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *UserRepository) List(ctx context.Context, query repository.UserQuery) ([]models.User, int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	search := strings.ToLower(query.Search)
+	var users []models.User
+	for _, user := range r.s.users {
+		if search != "" && !strings.Contains(strings.ToLower(user.Username), search) && !strings.Contains(strings.ToLower(user.Email), search) {
+			continue
+		}
+		if query.Role != "" && user.Role != query.Role {
+			continue
+		}
+		if query.Banned != nil && *query.Banned != (user.BannedAt != nil) {
+			continue
+		}
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if !users[i].CreatedAt.Equal(users[j].CreatedAt) {
+			return users[i].CreatedAt.After(users[j].CreatedAt)
+		}
+		return users[i].ID > users[j].ID
+	})
+	start := min(max(query.Offset, 0), len(users))
+	end := len(users)
+	if query.Limit > 0 {
+		end = min(start+query.Limit, len(users))
+	}
+	return users[start:end], int64(len(users)), nil
+}
+
+func (r *UserRepository) Update(ctx context.Context, user *models.User, fields map[string]any) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	stored, ok := r.s.users[user.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	for column, value := range fields {
+		var ok bool
+		switch column {
+		case "role":
+			stored.Role, ok = value.(string)
+		case "ban_reason":
+			stored.BanReason, ok = value.(string)
+		case "banned_at":
+			switch v := value.(type) {
+			case nil:
+				stored.BannedAt, ok = nil, true
+			case time.Time:
+				stored.BannedAt, ok = &v, true
+			}
+		}
+		if !ok {
+			return fmt.Errorf("不支持修改 %s = %v", column, value)
+		}
+	}
+	stored.UpdatedAt = now()
+	r.s.users[user.ID] = stored
+	*user = stored
+	return nil
+}
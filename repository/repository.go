@@ -0,0 +1,113 @@
+// Package repository 定义核心数据（用户、文章、评论、点赞、标签）的存取接口。
+// gormrepo 是基于数据库的实现，memory 是不依赖 PostgreSQL 的内存实现，供本地调试和测试使用。
+package repository
+
+import (
+	"context"
+	"errors"
+	"goblog/models"
+	"time"
+)
+
+// ErrNotFound 表示要查找的记录不存在
+var ErrNotFound = errors.New("record not found")
+
+type Repositories struct {
+	Users    UserRepository
+	Posts    PostRepository
+	Comments CommentRepository
+	Likes    LikeRepository
+	Tags     TagRepository
+}
+
+type UserRepository interface {
+	Create(ctx context.Context, user *models.User) error
+	FindByID(ctx context.Context, id uint) (models.User, error)
+	FindByEmail(ctx context.Context, email string) (models.User, error)
+	// ExistsByUsernameOrEmail 用户名或邮箱任一已被占用时返回 true
+	ExistsByUsernameOrEmail(ctx context.Context, username, email string) (bool, error)
+	// List 按条件分页返回用户，新注册的在前，同时返回符合条件的总数
+	List(ctx context.Context, query UserQuery) ([]models.User, int64, error)
+	// Update 修改 user 的部分字段，并把修改写回 user
+	Update(ctx context.Context, user *models.User, fields map[string]any) error
+}
+
+// UserQuery 是管理后台的用户筛选条件，Search 匹配用户名或邮箱，Banned 为空时不按封禁状态筛选
+type UserQuery struct {
+	Search string
+	Role   string
+	Banned *bool
+	Offset int
+	Limit  int
+}
+
+// PostQuery 是文章列表的分页和排序条件，Order 为 SQL 排序表达式，如 "is_top desc, created_at desc"，
+// Published 为 true 时只返回已发布的文章
+type PostQuery struct {
+	Order     string
+	Published bool
+	Offset    int
+	Limit     int
+}
+
+type PostRepository interface {
+	// Create 保存文章及其标签关联
+	Create(ctx context.Context, post *models.Post) error
+	// FindByID 返回文章，带作者和标签
+	FindByID(ctx context.Context, id uint) (models.Post, error)
+	// List 分页返回文章，带作者和标签
+	List(ctx context.Context, query PostQuery) ([]models.Post, error)
+	// FindPublished 返回 ids 中已发布的文章，顺序不保证
+	FindPublished(ctx context.Context, ids []uint) ([]models.Post, error)
+	// PinnedIDs 返回已发布的置顶文章 ID，新的在前
+	PinnedIDs(ctx context.Context) ([]uint, error)
+	// ListByTag 返回带有该标签的已发布文章，新的在前
+	ListByTag(ctx context.Context, tagID uint) ([]models.Post, error)
+	// Update 修改 post 的部分字段，并把修改写回 post
+	Update(ctx context.Context, post *models.Post, fields map[string]any) error
+	// Delete 软删除文章，文章进入回收站
+	Delete(ctx context.Context, post *models.Post) error
+	// ListTrashed 返回作者回收站中的文章，带作者和标签，最近删除的在前
+	ListTrashed(ctx context.Context, userID uint) ([]models.Post, error)
+	// FindTrashed 返回回收站中的文章，带作者和标签，未删除的文章返回 ErrNotFound
+	FindTrashed(ctx context.Context, id uint) (models.Post, error)
+	// Restore 把文章移出回收站
+	Restore(ctx context.Context, post *models.Post) error
+	// Purge 永久删除文章，以及它的评论、点赞、标签关联和其他只属于该文章的数据
+	Purge(ctx context.Context, post *models.Post) error
+	// ExpiredTrash 返回删除时间早于 before 的文章，最多 limit 篇，先删除的在前
+	ExpiredTrash(ctx context.Context, before time.Time, limit int) ([]models.Post, error)
+}
+
+type CommentRepository interface {
+	Create(ctx context.Context, comment *models.Comment) error
+	// FindByID 返回评论，带作者和子评论
+	FindByID(ctx context.Context, id uint) (models.Comment, error)
+	// ListByPost 返回文章的顶层评论，带作者和子评论，按时间先后排序
+	ListByPost(ctx context.Context, postID uint) ([]models.Comment, error)
+	// Update 修改 comment 的部分字段，并把修改写回 comment
+	Update(ctx context.Context, comment *models.Comment, fields map[string]any) error
+	// Delete 删除评论及其回复
+	Delete(ctx context.Context, comment *models.Comment) error
+}
+
+type LikeRepository interface {
+	Create(ctx context.Context, like *models.Like) error
+	Exists(ctx context.Context, userID, targetID uint, targetType string) (bool, error)
+	Count(ctx context.Context, targetID uint, targetType string) (int64, error)
+	// CountByTargets 批量统计点赞数，没有点赞的对象不在结果中
+	CountByTargets(ctx context.Context, targetType string, ids []uint) (map[uint]int64, error)
+}
+
+type TagRepository interface {
+	// FindOrCreate 按名称查找标签，不存在时创建
+	FindOrCreate(ctx context.Context, name string) (models.Tag, error)
+	FindByName(ctx context.Context, name string) (models.Tag, error)
+	FindByID(ctx context.Context, id uint) (models.Tag, error)
+	// List 返回名称包含 search 的标签，带文章数，按名称排序
+	List(ctx context.Context, search string) ([]models.Tag, error)
+	// Update 修改 tag 的部分字段，并把修改写回 tag
+	Update(ctx context.Context, tag *models.Tag, fields map[string]any) error
+	// Delete 删除标签及其文章关联
+	Delete(ctx context.Context, tag *models.Tag) error
+}
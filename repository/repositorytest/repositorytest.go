@@ -0,0 +1,615 @@
+// Package repositorytest 是 repository 接口的行为约定，memory 和 gormrepo 在各自的测试中运行同一套用例，
+// gormrepo 还会在每种数据库上各运行一次，保证换实现或换数据库时接口行为不变。
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"goblog/models"
+	"goblog/repository"
+	"slices"
+	"testing"
+	"time"
+)
+
+// Run 运行全部用例，open 为每个用例返回一组共用同一份空数据的仓储
+func Run(t *testing.T, open func(t *testing.T) repository.Repositories) {
+	cases := []struct {
+		name string
+		fn   func(t *testing.T, r repository.Repositories)
+	}{
+		{"Users", testUsers},
+		{"UserList", testUserList},
+		{"Posts", testPosts},
+		{"PostList", testPostList},
+		{"Trash", testTrash},
+		{"Purge", testPurge},
+		{"Comments", testComments},
+		{"Likes", testLikes},
+		{"Tags", testTags},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) { c.fn(t, open(t)) })
+	}
+}
+
+var ctx = context.Background()
+
+func testUsers(t *testing.T, r repository.Repositories) {
+	alice := newUser(t, r, "alice")
+	if alice.ID == 0 {
+		t.Fatal("创建用户后没有分配 ID")
+	}
+
+	found, err := r.Users.FindByID(ctx, alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.Username != "alice" || found.Role != models.RoleUser {
+		t.Fatalf("FindByID 返回 %+v，期望 alice，角色默认为 user", found)
+	}
+	if found, err = r.Users.FindByEmail(ctx, "alice@example.com"); err != nil || found.ID != alice.ID {
+		t.Fatalf("FindByEmail 返回 %d, %v", found.ID, err)
+	}
+	if _, err := r.Users.FindByID(ctx, alice.ID+100); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("不存在的用户应返回 ErrNotFound，得到 %v", err)
+	}
+	if _, err := r.Users.FindByEmail(ctx, "nobody@example.com"); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("不存在的邮箱应返回 ErrNotFound，得到 %v", err)
+	}
+
+	for _, dup := range []models.User{
+		{Username: "alice", Email: "other@example.com", Password: "x"},
+		{Username: "other", Email: "alice@example.com", Password: "x"},
+	} {
+		if err := r.Users.Create(ctx, &dup); err == nil {
+			t.Fatalf("用户名或邮箱重复时应返回错误: %+v", dup)
+		}
+	}
+	for _, c := range []struct {
+		username, email string
+		want            bool
+	}{
+		{"alice", "new@example.com", true},
+		{"new", "alice@example.com", true},
+		{"new", "new@example.com", false},
+	} {
+		if got, err := r.Users.ExistsByUsernameOrEmail(ctx, c.username, c.email); err != nil || got != c.want {
+			t.Fatalf("ExistsByUsernameOrEmail(%s, %s) = %v, %v，期望 %v", c.username, c.email, got, err, c.want)
+		}
+	}
+
+	bannedAt := time.Now().UTC().Truncate(time.Second)
+	if err := r.Users.Update(ctx, &alice, map[string]any{"role": models.RoleEditor, "banned_at": bannedAt, "ban_reason": "spam"}); err != nil {
+		t.Fatal(err)
+	}
+	if alice.Role != models.RoleEditor || alice.BannedAt == nil || alice.BanReason != "spam" {
+		t.Fatalf("Update 没有把修改写回: %+v", alice)
+	}
+	found, _ = r.Users.FindByID(ctx, alice.ID)
+	if found.Role != models.RoleEditor || found.BannedAt == nil || !found.BannedAt.Equal(bannedAt) || found.BanReason != "spam" {
+		t.Fatalf("Update 后读到 %+v", found)
+	}
+	if err := r.Users.Update(ctx, &alice, map[string]any{"banned_at": nil, "ban_reason": ""}); err != nil {
+		t.Fatal(err)
+	}
+	if found, _ = r.Users.FindByID(ctx, alice.ID); found.BannedAt != nil {
+		t.Fatalf("解封后 banned_at 应为空: %v", found.BannedAt)
+	}
+}
+
+func testUserList(t *testing.T, r repository.Repositories) {
+	alice := newUser(t, r, "Alice")
+	percent := newUser(t, r, "al%ex")
+	bob := newUser(t, r, "bob")
+	if err := r.Users.Update(ctx, &bob, map[string]any{"role": models.RoleAdmin, "banned_at": time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	banned, active := true, false
+	for _, c := range []struct {
+		query repository.UserQuery
+		want  []uint
+		total int64
+	}{
+		{repository.UserQuery{}, []uint{bob.ID, percent.ID, alice.ID}, 3},
+		{repository.UserQuery{Limit: 2}, []uint{bob.ID, percent.ID}, 3},
+		{repository.UserQuery{Offset: 1, Limit: 1}, []uint{percent.ID}, 3},
+		{repository.UserQuery{Search: "AL"}, []uint{percent.ID, alice.ID}, 2},
+		{repository.UserQuery{Search: "%"}, []uint{percent.ID}, 1},
+		{repository.UserQuery{Search: "l_e"}, nil, 0},
+		{repository.UserQuery{Search: "bob@EXAMPLE"}, []uint{bob.ID}, 1},
+		{repository.UserQuery{Role: models.RoleAdmin}, []uint{bob.ID}, 1},
+		{repository.UserQuery{Banned: &banned}, []uint{bob.ID}, 1},
+		{repository.UserQuery{Banned: &active, Limit: 1}, []uint{percent.ID}, 2},
+	} {
+		users, total, err := r.Users.List(ctx, c.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := userIDs(users); !slices.Equal(got, c.want) || total != c.total {
+			t.Errorf("List(%+v) = %v, %d，期望 %v, %d", c.query, got, total, c.want, c.total)
+		}
+	}
+}
+
+func testPosts(t *testing.T, r repository.Repositories) {
+	author := newUser(t, r, "author")
+	post := newPost(t, r, author, false, "go", "sql")
+
+	found, err := r.Posts.FindByID(ctx, post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.Title != post.Title || found.User.Username != "author" || !slices.Equal(tagNames(found.Tags), []string{"go", "sql"}) {
+		t.Fatalf("FindByID 返回 %+v，应带作者和标签", found)
+	}
+	if _, err := r.Posts.FindByID(ctx, post.ID+100); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("不存在的文章应返回 ErrNotFound，得到 %v", err)
+	}
+
+	other := newUser(t, r, "other")
+	if err := r.Posts.Update(ctx, &found, map[string]any{"title": "新标题", "status": models.PostInReview, "is_draft": true, "user_id": other.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if found.Title != "新标题" || found.Status != models.PostInReview || !found.IsDraft || found.UserID != other.ID {
+		t.Fatalf("Update 没有把修改写回: %+v", found)
+	}
+	found, _ = r.Posts.FindByID(ctx, post.ID)
+	if found.Title != "新标题" || found.Status != models.PostInReview || found.UserID != other.ID || found.User.ID != other.ID {
+		t.Fatalf("Update 后读到 %+v", found)
+	}
+	if len(found.Tags) != 2 {
+		t.Fatalf("Update 不应修改标签: %v", tagNames(found.Tags))
+	}
+}
+
+func testPostList(t *testing.T, r repository.Repositories) {
+	author := newUser(t, r, "author")
+	first := newPost(t, r, author, false, "go")
+	draft := newPost(t, r, author, true, "go")
+	pinned := newPost(t, r, author, false, "sql")
+	pinnedDraft := newPost(t, r, author, true)
+	for _, p := range []*models.Post{&pinned, &pinnedDraft} {
+		if err := r.Posts.Update(ctx, p, map[string]any{"is_top": true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, c := range []struct {
+		query repository.PostQuery
+		want  []uint
+	}{
+		{repository.PostQuery{Order: "id desc"}, []uint{pinnedDraft.ID, pinned.ID, draft.ID, first.ID}},
+		{repository.PostQuery{Order: "id desc", Offset: 1, Limit: 2}, []uint{pinned.ID, draft.ID}},
+		{repository.PostQuery{Order: "is_top desc, id asc", Limit: 3}, []uint{pinned.ID, pinnedDraft.ID, first.ID}},
+		{repository.PostQuery{Order: "is_top desc, id asc", Published: true}, []uint{pinned.ID, first.ID}},
+	} {
+		posts, err := r.Posts.List(ctx, c.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := postIDs(posts); !slices.Equal(got, c.want) {
+			t.Errorf("List(%+v) = %v，期望 %v", c.query, got, c.want)
+		}
+		if len(posts) > 0 && posts[0].User.ID != author.ID {
+			t.Errorf("List 应带作者: %+v", posts[0].User)
+		}
+	}
+
+	published, err := r.Posts.FindPublished(ctx, []uint{first.ID, draft.ID, pinned.ID, pinned.ID + 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := postIDs(published)
+	slices.Sort(got)
+	if !slices.Equal(got, []uint{first.ID, pinned.ID}) {
+		t.Errorf("FindPublished 返回 %v，期望 %v", got, []uint{first.ID, pinned.ID})
+	}
+
+	if ids, err := r.Posts.PinnedIDs(ctx); err != nil || !slices.Equal(ids, []uint{pinned.ID}) {
+		t.Errorf("PinnedIDs = %v, %v，期望只有已发布的 %d", ids, err, pinned.ID)
+	}
+
+	tag, err := r.Tags.FindByName(ctx, "go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	byTag, err := r.Posts.ListByTag(ctx, tag.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := postIDs(byTag); !slices.Equal(got, []uint{first.ID}) {
+		t.Errorf("ListByTag = %v，期望 %v", got, []uint{first.ID})
+	}
+	if len(byTag) > 0 && !slices.Equal(tagNames(byTag[0].Tags), []string{"go"}) {
+		t.Errorf("ListByTag 应带标签: %v", tagNames(byTag[0].Tags))
+	}
+}
+
+func testTrash(t *testing.T, r repository.Repositories) {
+	author, other := newUser(t, r, "author"), newUser(t, r, "other")
+	older := newPost(t, r, author, false, "go")
+	newer := newPost(t, r, author, false)
+	live := newPost(t, r, author, false)
+	others := newPost(t, r, other, false)
+
+	for _, p := range []*models.Post{&older, &newer, &others} {
+		if err := r.Posts.Delete(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := r.Posts.FindByID(ctx, older.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("删除后 FindByID 应返回 ErrNotFound，得到 %v", err)
+	}
+	if posts, _ := r.Posts.List(ctx, repository.PostQuery{Order: "id asc"}); !slices.Equal(postIDs(posts), []uint{live.ID}) {
+		t.Fatalf("List 不应包含回收站中的文章: %v", postIDs(posts))
+	}
+
+	trashed, err := r.Posts.ListTrashed(ctx, author.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := postIDs(trashed); !slices.Equal(got, []uint{newer.ID, older.ID}) {
+		t.Fatalf("ListTrashed = %v，期望 %v", got, []uint{newer.ID, older.ID})
+	}
+	if !trashed[1].DeletedAt.Valid || !slices.Equal(tagNames(trashed[1].Tags), []string{"go"}) {
+		t.Fatalf("回收站中的文章应带删除时间和标签: %+v", trashed[1])
+	}
+
+	found, err := r.Posts.FindTrashed(ctx, older.ID)
+	if err != nil || found.User.ID != author.ID {
+		t.Fatalf("FindTrashed 返回 %+v, %v", found, err)
+	}
+	if _, err := r.Posts.FindTrashed(ctx, live.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("未删除的文章 FindTrashed 应返回 ErrNotFound，得到 %v", err)
+	}
+
+	expired, err := r.Posts.ExpiredTrash(ctx, time.Now().Add(time.Hour), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := postIDs(expired); !slices.Equal(got, []uint{older.ID, newer.ID}) {
+		t.Fatalf("ExpiredTrash = %v，期望先删除的 %v", got, []uint{older.ID, newer.ID})
+	}
+	if expired, _ := r.Posts.ExpiredTrash(ctx, time.Now().Add(-time.Hour), 10); len(expired) != 0 {
+		t.Fatalf("删除时间晚于 before 的文章不应过期: %v", postIDs(expired))
+	}
+
+	if err := r.Posts.Restore(ctx, &older); err != nil {
+		t.Fatal(err)
+	}
+	if older.DeletedAt.Valid {
+		t.Fatal("Restore 后 DeletedAt 应为空")
+	}
+	if _, err := r.Posts.FindByID(ctx, older.ID); err != nil {
+		t.Fatalf("恢复后应能找到文章: %v", err)
+	}
+	if _, err := r.Posts.FindTrashed(ctx, older.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("恢复后 FindTrashed 应返回 ErrNotFound，得到 %v", err)
+	}
+}
+
+func testPurge(t *testing.T, r repository.Repositories) {
+	author := newUser(t, r, "author")
+	post := newPost(t, r, author, false, "go")
+	kept := newPost(t, r, author, false, "go")
+	comment := newComment(t, r, author, post, nil)
+	reply := newComment(t, r, author, post, &comment)
+	keptComment := newComment(t, r, author, kept, nil)
+	for _, like := range []models.Like{
+		{UserID: author.ID, TargetID: post.ID, TargetType: "post"},
+		{UserID: author.ID, TargetID: reply.ID, TargetType: "comment"},
+		{UserID: author.ID, TargetID: kept.ID, TargetType: "post"},
+		{UserID: author.ID, TargetID: keptComment.ID, TargetType: "comment"},
+	} {
+		if err := r.Likes.Create(ctx, &like); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := r.Posts.Delete(ctx, &post); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Posts.Purge(ctx, &post); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Posts.FindTrashed(ctx, post.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("永久删除后 FindTrashed 应返回 ErrNotFound，得到 %v", err)
+	}
+	for _, id := range []uint{comment.ID, reply.ID} {
+		if _, err := r.Comments.FindByID(ctx, id); !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("永久删除文章后评论 %d 应被删除，得到 %v", id, err)
+		}
+	}
+	if n, _ := r.Likes.Count(ctx, post.ID, "post"); n != 0 {
+		t.Fatalf("文章的点赞应被删除，还有 %d 个", n)
+	}
+	if n, _ := r.Likes.Count(ctx, reply.ID, "comment"); n != 0 {
+		t.Fatalf("评论的点赞应被删除，还有 %d 个", n)
+	}
+
+	// 其他文章的数据和共用的标签不受影响
+	if _, err := r.Comments.FindByID(ctx, keptComment.ID); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := r.Likes.Count(ctx, kept.ID, "post"); n != 1 {
+		t.Fatalf("其他文章的点赞数为 %d，期望 1", n)
+	}
+	if n, _ := r.Likes.Count(ctx, keptComment.ID, "comment"); n != 1 {
+		t.Fatalf("其他评论的点赞数为 %d，期望 1", n)
+	}
+	tags, err := r.Tags.List(ctx, "go")
+	if err != nil || len(tags) != 1 || tags[0].PostCount != 1 {
+		t.Fatalf("标签 go 应保留且只剩 1 篇文章: %+v, %v", tags, err)
+	}
+}
+
+func testComments(t *testing.T, r repository.Repositories) {
+	author, other := newUser(t, r, "author"), newUser(t, r, "other")
+	post := newPost(t, r, author, false)
+	otherPost := newPost(t, r, author, false)
+
+	first := newComment(t, r, author, post, nil)
+	second := newComment(t, r, other, post, nil)
+	reply := newComment(t, r, other, post, &first)
+	nested := newComment(t, r, author, post, &reply)
+	newComment(t, r, author, otherPost, nil)
+
+	found, err := r.Comments.FindByID(ctx, first.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.User.Username != "author" || !slices.Equal(commentIDs(found.Replies), []uint{reply.ID}) || found.Replies[0].User.Username != "other" {
+		t.Fatalf("FindByID 应带作者和带作者的回复: %+v", found)
+	}
+	if _, err := r.Comments.FindByID(ctx, nested.ID+100); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("不存在的评论应返回 ErrNotFound，得到 %v", err)
+	}
+
+	comments, err := r.Comments.ListByPost(ctx, post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := commentIDs(comments); !slices.Equal(got, []uint{first.ID, second.ID}) {
+		t.Fatalf("ListByPost = %v，期望顶层评论 %v", got, []uint{first.ID, second.ID})
+	}
+	if comments[1].User.Username != "other" || !slices.Equal(commentIDs(comments[0].Replies), []uint{reply.ID}) {
+		t.Fatalf("ListByPost 应带作者和回复: %+v", comments[0])
+	}
+
+	if err := r.Comments.Update(ctx, &found, map[string]any{"content": "修改后"}); err != nil {
+		t.Fatal(err)
+	}
+	if found.Content != "修改后" {
+		t.Fatalf("Update 没有把修改写回: %+v", found)
+	}
+	if found, _ = r.Comments.FindByID(ctx, first.ID); found.Content != "修改后" || found.User.ID != author.ID {
+		t.Fatalf("Update 后读到 %+v", found)
+	}
+
+	like := models.Like{UserID: other.ID, TargetID: nested.ID, TargetType: "comment"}
+	if err := r.Likes.Create(ctx, &like); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Comments.Delete(ctx, &first); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []uint{first.ID, reply.ID, nested.ID} {
+		if _, err := r.Comments.FindByID(ctx, id); !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("删除评论后回复 %d 应一起删除，得到 %v", id, err)
+		}
+	}
+	if n, _ := r.Likes.Count(ctx, nested.ID, "comment"); n != 0 {
+		t.Fatalf("被删除的回复上还有 %d 个点赞", n)
+	}
+	if comments, _ := r.Comments.ListByPost(ctx, post.ID); !slices.Equal(commentIDs(comments), []uint{second.ID}) {
+		t.Fatalf("删除后 ListByPost = %v", commentIDs(comments))
+	}
+}
+
+func testLikes(t *testing.T, r repository.Repositories) {
+	alice, bob := newUser(t, r, "alice"), newUser(t, r, "bob")
+	post := newPost(t, r, alice, false)
+	other := newPost(t, r, alice, false)
+	for _, like := range []models.Like{
+		{UserID: alice.ID, TargetID: post.ID, TargetType: "post"},
+		{UserID: bob.ID, TargetID: post.ID, TargetType: "post"},
+		{UserID: bob.ID, TargetID: other.ID, TargetType: "comment"},
+	} {
+		if err := r.Likes.Create(ctx, &like); err != nil {
+			t.Fatal(err)
+		}
+		if like.ID == 0 {
+			t.Fatal("创建点赞后没有分配 ID")
+		}
+	}
+
+	for _, c := range []struct {
+		user, target uint
+		targetType   string
+		want         bool
+	}{
+		{alice.ID, post.ID, "post", true},
+		{alice.ID, other.ID, "post", false},
+		{bob.ID, other.ID, "comment", true},
+		{bob.ID, other.ID, "post", false},
+	} {
+		if got, err := r.Likes.Exists(ctx, c.user, c.target, c.targetType); err != nil || got != c.want {
+			t.Errorf("Exists(%d, %d, %s) = %v, %v，期望 %v", c.user, c.target, c.targetType, got, err, c.want)
+		}
+	}
+
+	if n, err := r.Likes.Count(ctx, post.ID, "post"); err != nil || n != 2 {
+		t.Errorf("Count = %d, %v，期望 2", n, err)
+	}
+	counts, err := r.Likes.CountByTargets(ctx, "post", []uint{post.ID, other.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[uint]int64{post.ID: 2}; fmt.Sprint(counts) != fmt.Sprint(want) {
+		t.Errorf("CountByTargets = %v，期望 %v", counts, want)
+	}
+	if counts, err := r.Likes.CountByTargets(ctx, "post", nil); err != nil || len(counts) != 0 {
+		t.Errorf("没有 ID 时 CountByTargets = %v, %v", counts, err)
+	}
+}
+
+func testTags(t *testing.T, r repository.Repositories) {
+	author := newUser(t, r, "author")
+	golang, err := r.Tags.FindOrCreate(ctx, "Go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, err := r.Tags.FindOrCreate(ctx, "Go"); err != nil || again.ID != golang.ID {
+		t.Fatalf("FindOrCreate 同名标签应返回已有的 %d，得到 %d, %v", golang.ID, again.ID, err)
+	}
+	if found, err := r.Tags.FindByName(ctx, "Go"); err != nil || found.ID != golang.ID {
+		t.Fatalf("FindByName 返回 %+v, %v", found, err)
+	}
+	if found, err := r.Tags.FindByID(ctx, golang.ID); err != nil || found.Name != "Go" {
+		t.Fatalf("FindByID 返回 %+v, %v", found, err)
+	}
+	if _, err := r.Tags.FindByName(ctx, "none"); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("不存在的标签应返回 ErrNotFound，得到 %v", err)
+	}
+	if _, err := r.Tags.FindByID(ctx, golang.ID+100); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("不存在的标签应返回 ErrNotFound，得到 %v", err)
+	}
+
+	newPost(t, r, author, false, "Go", "go_db")
+	newPost(t, r, author, true, "Go")
+	trashed := newPost(t, r, author, false, "Go", "golang")
+	if err := r.Posts.Delete(ctx, &trashed); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		search string
+		want   map[string]int64
+	}{
+		{"", map[string]int64{"Go": 2, "go_db": 1, "golang": 0}},
+		{"GO", map[string]int64{"Go": 2, "go_db": 1, "golang": 0}},
+		{"_", map[string]int64{"go_db": 1}},
+		{"%", map[string]int64{}},
+	} {
+		tags, err := r.Tags.List(ctx, c.search)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := map[string]int64{}
+		names := make([]string, len(tags))
+		for i, tag := range tags {
+			got[tag.Name] = tag.PostCount
+			names[i] = tag.Name
+		}
+		if fmt.Sprint(got) != fmt.Sprint(c.want) || !slices.IsSorted(names) {
+			t.Errorf("List(%q) = %v，期望按名称排序的 %v", c.search, names, c.want)
+		}
+	}
+
+	if err := r.Tags.Update(ctx, &golang, map[string]any{"name": "Golang2"}); err != nil {
+		t.Fatal(err)
+	}
+	if golang.Name != "Golang2" {
+		t.Fatalf("Update 没有把修改写回: %+v", golang)
+	}
+	if _, err := r.Tags.FindByName(ctx, "Golang2"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Tags.Delete(ctx, &golang); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Tags.FindByID(ctx, golang.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("删除后 FindByID 应返回 ErrNotFound，得到 %v", err)
+	}
+	if posts, _ := r.Posts.ListByTag(ctx, golang.ID); len(posts) != 0 {
+		t.Fatalf("删除后标签下还有文章 %v", postIDs(posts))
+	}
+	// 删除后可以再建同名标签
+	if again, err := r.Tags.FindOrCreate(ctx, "Golang2"); err != nil || again.ID == golang.ID {
+		t.Fatalf("删除后 FindOrCreate 应创建新标签，得到 %+v, %v", again, err)
+	}
+}
+
+func newUser(t *testing.T, r repository.Repositories, name string) models.User {
+	t.Helper()
+
+	user := models.User{Username: name, Email: name + "@example.com", Password: "hash"}
+	if err := r.Users.Create(ctx, &user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// newPost 与 PostService.Create 一样先 FindOrCreate 标签再创建文章
+func newPost(t *testing.T, r repository.Repositories, author models.User, draft bool, tags ...string) models.Post {
+	t.Helper()
+
+	post := models.Post{Title: "文章", Content: "正文", UserID: author.ID}
+	if draft {
+		post.SetStatus(models.PostDraft)
+	} else {
+		post.SetStatus(models.PostPublished)
+	}
+	for _, name := range tags {
+		tag, err := r.Tags.FindOrCreate(ctx, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		post.Tags = append(post.Tags, &tag)
+	}
+	if err := r.Posts.Create(ctx, &post); err != nil {
+		t.Fatal(err)
+	}
+	return post
+}
+
+func newComment(t *testing.T, r repository.Repositories, author models.User, post models.Post, parent *models.Comment) models.Comment {
+	t.Helper()
+
+	comment := models.Comment{Content: "评论", UserID: author.ID, PostID: post.ID}
+	if parent != nil {
+		comment.ParentID = &parent.ID
+	}
+	if err := r.Comments.Create(ctx, &comment); err != nil {
+		t.Fatal(err)
+	}
+	return comment
+}
+
+func userIDs(users []models.User) []uint {
+	ids := make([]uint, len(users))
+	for i, u := range users {
+		ids[i] = u.ID
+	}
+	return ids
+}
+
+func postIDs(posts []models.Post) []uint {
+	ids := make([]uint, len(posts))
+	for i, p := range posts {
+		ids[i] = p.ID
+	}
+	return ids
+}
+
+func commentIDs(comments []models.Comment) []uint {
+	ids := make([]uint, len(comments))
+	for i, c := range comments {
+		ids[i] = c.ID
+	}
+	return ids
+}
+
+func tagNames(tags []*models.Tag) []string {
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.Name
+	}
+	slices.Sort(names)
+	return names
+}
@@ -1,38 +1,181 @@
 package routes
 
 import (
+	"goblog/config"
 	"goblog/controllers"
 	"goblog/middlewares"
+	"goblog/service"
+	"net/http"
+	"slices"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
-func SetupRoutes(r *gin.Engine) {
+// SetupRoutes 注册全部路由，用户、文章、评论、点赞和标签的接口使用 svc 访问数据
+func SetupRoutes(r *gin.Engine, svc *service.Services) {
+	h := controllers.NewHandler(svc)
+	conf := config.AppConfig
+
+	// 让 *gin.Context 作为 context.Context 传给服务层时带上请求里的 span，GORM 和 Redis 的子 span 才能挂到同一条 trace 上
+	r.ContextWithFallback = true
+	r.Use(
+		middlewares.RequestID(),
+		otelgin.Middleware(conf.Tracing.ServiceName, otelgin.WithFilter(traced)),
+		middlewares.Metrics(),
+		middlewares.AccessLog(quietPaths...),
+		middlewares.CORSMiddleware(conf.CORS),
+		middlewares.ErrorHandler(),
+		middlewares.Recovery(),
+	)
+	r.NoRoute(middlewares.NotFoundHandler)
+
+	r.GET("/healthz", controllers.Healthz)
+	r.GET("/readyz", controllers.Readyz)
+	if conf.Metrics.Enabled {
+		r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	}
+
 	api := r.Group("/api")
 
-	api.POST("/register", controllers.Register)
-	api.POST(("/login"), controllers.Login)
-	api.GET("/tags/:name/posts", controllers.GetPostByTag)
+	api.POST("/register", h.Register)
+	api.POST("/login", h.Login)
+	api.GET("/tags/:name/posts", h.GetPostByTag)
 
 	posts := api.Group("/posts")
 	{
-		posts.GET("", controllers.GetPosts)
-		posts.POST("", middlewares.JWTAuthMiddleware(), controllers.CreatePost)
-		posts.GET("/:id", controllers.GetPostByID)
-		posts.PUT("/:id", middlewares.JWTAuthMiddleware(), controllers.UpdataPost)
-		posts.DELETE("/:id", middlewares.JWTAuthMiddleware(), controllers.DeletePost)
+		posts.GET("", h.GetPosts)
+		posts.POST("", middlewares.JWTAuthMiddleware(), h.CreatePost)
+		posts.GET("/:id", middlewares.OptionalJWTAuthMiddleware(), h.GetPostByID)
+		posts.GET("/:id/related", h.GetRelatedPosts)
+		posts.POST("/:id/collaborators", middlewares.JWTAuthMiddleware(), controllers.InviteCollaborator)
+		posts.GET("/:id/collaborators", middlewares.JWTAuthMiddleware(), controllers.GetCollaborators)
+		posts.DELETE("/:id/collaborators/:user_id", middlewares.JWTAuthMiddleware(), controllers.RemoveCollaborator)
+		posts.POST("/:id/transitions", middlewares.JWTAuthMiddleware(), controllers.TransitionPost)
+		posts.GET("/:id/transitions", middlewares.JWTAuthMiddleware(), controllers.GetPostTransitions)
+		posts.POST("/:id/review-comments", middlewares.JWTAuthMiddleware(), controllers.CreateReviewComment)
+		posts.GET("/:id/review-comments", middlewares.JWTAuthMiddleware(), controllers.GetReviewComments)
+		posts.POST("/:id/review-comments/:comment_id/resolve", middlewares.JWTAuthMiddleware(), controllers.ResolveReviewComment)
+		posts.PUT("/:id", middlewares.JWTAuthMiddleware(), h.UpdataPost)
+		posts.DELETE("/:id", middlewares.JWTAuthMiddleware(), h.DeletePost)
+	}
+
+	account := api.Group("/account", middlewares.JWTAuthMiddleware())
+	{
+		account.POST("/exports", controllers.RequestExport)
+		account.GET("/exports", controllers.GetExports)
+		account.GET("/exports/:id/download", controllers.DownloadExport)
+		account.POST("/deletion", controllers.RequestAccountDeletion)
+		account.GET("/deletion", controllers.GetAccountDeletion)
+		account.DELETE("/deletion", controllers.CancelAccountDeletion)
+	}
+
+	trash := api.Group("/trash", middlewares.JWTAuthMiddleware())
+	{
+		trash.GET("", h.GetTrash)
+		trash.POST("/:id/restore", h.RestorePost)
+	}
+
+	invitations := api.Group("/invitations", middlewares.JWTAuthMiddleware())
+	{
+		invitations.GET("", controllers.GetInvitations)
+		invitations.POST("/:id/accept", controllers.AcceptInvitation)
+		invitations.POST("/:id/decline", controllers.DeclineInvitation)
 	}
 
 	comments := api.Group("/comments")
 	{
-		comments.POST("", middlewares.JWTAuthMiddleware(), controllers.CreateComment)
-		comments.GET("", controllers.GetCommentsByPostID)
+		comments.POST("", middlewares.JWTAuthMiddleware(), h.CreateComment)
+		comments.GET("", h.GetCommentsByPostID)
 	}
 
 	likes := api.Group("/likes")
 	{
-		likes.POST("", middlewares.JWTAuthMiddleware(), controllers.Like)
-		likes.GET("/count", controllers.GetLikeCount)
-		likes.GET("/check", middlewares.JWTAuthMiddleware(), controllers.CheckIfLiked)
+		likes.POST("", middlewares.JWTAuthMiddleware(), h.Like)
+		likes.GET("/count", h.GetLikeCount)
+		likes.GET("/check", middlewares.JWTAuthMiddleware(), h.CheckIfLiked)
+	}
+
+	api.GET("/feed", middlewares.JWTAuthMiddleware(), controllers.GetFeed)
+
+	follows := api.Group("/follows", middlewares.JWTAuthMiddleware())
+	{
+		follows.POST("", controllers.Follow)
+		follows.DELETE("", controllers.Unfollow)
+		follows.GET("", controllers.GetFollowing)
+	}
+
+	bookmarks := api.Group("/bookmarks", middlewares.JWTAuthMiddleware())
+	{
+		bookmarks.POST("", controllers.AddBookmark)
+		bookmarks.GET("", controllers.GetBookmarks)
+		bookmarks.DELETE("/:post_id", controllers.RemoveBookmark)
+	}
+
+	collections := api.Group("/collections")
+	{
+		collections.POST("", middlewares.JWTAuthMiddleware(), controllers.CreateCollection)
+		collections.GET("", middlewares.JWTAuthMiddleware(), controllers.GetMyCollections)
+		collections.GET("/:id", middlewares.OptionalJWTAuthMiddleware(), controllers.GetCollection)
+		collections.PUT("/:id", middlewares.JWTAuthMiddleware(), controllers.UpdateCollection)
+		collections.DELETE("/:id", middlewares.JWTAuthMiddleware(), controllers.DeleteCollection)
+	}
+	api.GET("/users/:id/collections", controllers.GetUserCollections)
+
+	series := api.Group("/series")
+	{
+		series.POST("", middlewares.JWTAuthMiddleware(), controllers.CreateSeries)
+		series.GET("/:id", middlewares.OptionalJWTAuthMiddleware(), controllers.GetSeries)
+		series.PUT("/:id", middlewares.JWTAuthMiddleware(), controllers.UpdateSeries)
+		series.DELETE("/:id", middlewares.JWTAuthMiddleware(), controllers.DeleteSeries)
+		series.POST("/:id/posts", middlewares.JWTAuthMiddleware(), controllers.AddSeriesPost)
+		series.PUT("/:id/posts", middlewares.JWTAuthMiddleware(), controllers.ReorderSeries)
+		series.DELETE("/:id/posts/:post_id", middlewares.JWTAuthMiddleware(), controllers.RemoveSeriesPost)
+	}
+	api.GET("/users/:id/series", controllers.GetUserSeries)
+
+	analytics := api.Group("/analytics", middlewares.JWTAuthMiddleware())
+	{
+		analytics.GET("/series", controllers.GetAnalyticsSeries)
+		analytics.GET("/top-posts", controllers.GetTopPosts)
+		analytics.GET("/referrers", controllers.GetReferrers)
+		analytics.GET("/posts/:id", controllers.GetPostAnalytics)
 	}
+
+	webhooks := api.Group("/webhooks", middlewares.JWTAuthMiddleware())
+	{
+		webhooks.POST("", controllers.CreateWebhook)
+		webhooks.GET("", controllers.GetWebhooks)
+		webhooks.DELETE("/:id", controllers.DeleteWebhook)
+		webhooks.GET("/:id/deliveries", controllers.GetWebhookDeliveries)
+		webhooks.POST("/:id/deliveries/:delivery_id/redeliver", controllers.RedeliverWebhook)
+	}
+
+	admin := api.Group("/admin", middlewares.JWTAuthMiddleware(), h.RequireAdmin)
+	{
+		admin.GET("/users", h.AdminListUsers)
+		admin.PUT("/users/:id/role", h.AdminSetUserRole)
+		admin.POST("/users/:id/ban", h.AdminBanUser)
+		admin.DELETE("/users/:id/ban", h.AdminUnbanUser)
+		admin.PUT("/posts/:id", h.AdminUpdatePost)
+		admin.DELETE("/posts/:id", h.AdminDeletePost)
+		admin.DELETE("/trash/:id", h.AdminPurgePost)
+		admin.PUT("/comments/:id", h.AdminUpdateComment)
+		admin.DELETE("/comments/:id", h.AdminDeleteComment)
+		admin.GET("/tags", h.AdminListTags)
+		admin.PUT("/tags/:id", h.AdminRenameTag)
+		admin.DELETE("/tags/:id", h.AdminDeleteTag)
+		admin.GET("/stats", controllers.GetSiteStats)
+		admin.GET("/cache", controllers.GetCacheStats)
+		admin.GET("/audit-logs", controllers.GetAuditLogs)
+		admin.POST("/import", controllers.ImportPosts)
+	}
+}
+
+// quietPaths 是探活和指标抓取的路径，这些请求频繁且没有排查价值，不追踪，访问日志只在 debug 级别记录
+var quietPaths = []string{"/healthz", "/readyz", "/metrics"}
+
+func traced(r *http.Request) bool {
+	return !slices.Contains(quietPaths, r.URL.Path)
 }
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"fmt"
+	"goblog/pkg/cache"
+)
+
+// 读接口的缓存键。写操作通过 BlogEvents 让对应的缓存失效，不经过服务修改文章的地方（如审稿流转）调用 InvalidatePost
+const (
+	postPrefix     = "post:"
+	postListPrefix = "posts:list:"
+	tagPostsPrefix = "tags:"
+)
+
+// PostListKey 是文章列表某一页的缓存键，window 只在 sort=top 时有意义
+func PostListKey(sort, window, page, limit string) string {
+	return fmt.Sprintf("%s%s:%s:page:%s:limit:%s", postListPrefix, sort, window, page, limit)
+}
+
+func PostKey(id uint) string {
+	return fmt.Sprintf("%s%d", postPrefix, id)
+}
+
+func TagPostsKey(name string) string {
+	return tagPostsPrefix + name + ":posts"
+}
+
+func CommentsKey(postID uint) string {
+	return fmt.Sprintf("comments:post:%d", postID)
+}
+
+func LikeCountKey(targetType string, targetID uint) string {
+	return fmt.Sprintf("likes:count:%s:%d", targetType, targetID)
+}
+
+// UserStatusKey 缓存用户是否被封禁或已注销，认证中间件每个请求都要检查
+func UserStatusKey(id uint) string {
+	return fmt.Sprintf("users:status:%d", id)
+}
+
+// InvalidatePost 让文章详情、所有文章列表和标签文章列表的缓存失效
+func InvalidatePost(postID uint) {
+	cache.Del(PostKey(postID))
+	cache.DelPrefix(postListPrefix, tagPostsPrefix)
+}
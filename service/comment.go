@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"goblog/models"
+	"goblog/pkg/apperror"
+	"goblog/repository"
+)
+
+type CommentService struct {
+	comments repository.CommentRepository
+	events   Events
+}
+
+// Create 创建评论或回复，返回带作者信息的评论
+func (s *CommentService) Create(ctx context.Context, userID, postID uint, content string, parentID *uint) (models.Comment, error) {
+	comment := models.Comment{
+		Content:  content,
+		UserID:   userID,
+		PostID:   postID,
+		ParentID: parentID,
+	}
+	if err := s.comments.Create(ctx, &comment); err != nil {
+		return models.Comment{}, apperror.Internal(err)
+	}
+
+	comment, err := s.comments.FindByID(ctx, comment.ID)
+	if err != nil {
+		return models.Comment{}, apperror.Internal(err)
+	}
+	s.events.CommentCreated(comment)
+	return comment, nil
+}
+
+func (s *CommentService) ListByPost(ctx context.Context, postID uint) ([]models.Comment, error) {
+	comments, err := s.comments.ListByPost(ctx, postID)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	return comments, nil
+}
+
+// Find 返回评论，不存在时返回 comment.not_found
+func (s *CommentService) Find(ctx context.Context, id uint) (models.Comment, error) {
+	comment, err := s.comments.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return comment, apperror.NotFound(apperror.CodeCommentNotFound)
+	}
+	if err != nil {
+		return comment, apperror.Internal(err)
+	}
+	return comment, nil
+}
+
+// Update 修改评论的部分字段，权限由调用方检查
+func (s *CommentService) Update(ctx context.Context, comment *models.Comment, fields map[string]any) error {
+	if err := s.comments.Update(ctx, comment, fields); err != nil {
+		return apperror.Internal(err)
+	}
+	s.events.CommentUpdated(*comment)
+	return nil
+}
+
+// Delete 删除评论及其回复，权限由调用方检查，返回被删除的评论
+func (s *CommentService) Delete(ctx context.Context, id uint) (models.Comment, error) {
+	comment, err := s.Find(ctx, id)
+	if err != nil {
+		return comment, err
+	}
+	if err := s.comments.Delete(ctx, &comment); err != nil {
+		return comment, apperror.Internal(err)
+	}
+	s.events.CommentDeleted(comment)
+	return comment, nil
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"goblog/models"
+	"goblog/pkg/cache"
+	"goblog/pkg/feed"
+	"goblog/pkg/lifecycle"
+	"goblog/pkg/ranking"
+	"goblog/pkg/related"
+	"goblog/pkg/telemetry"
+	"goblog/pkg/webhook"
+)
+
+// BlogEvents 把核心数据的变更同步到动态时间线、排行、相关文章、webhook、读接口缓存和业务指标
+type BlogEvents struct{}
+
+func (BlogEvents) PostCreated(post models.Post) {
+	telemetry.PostsCreated.Inc()
+	if !post.IsDraft {
+		lifecycle.Go(func() { feed.FanOut(post) })
+		lifecycle.Go(func() { ranking.Add(post) })
+		lifecycle.Go(func() { webhook.Publish(webhook.EventPostPublished, post) })
+		related.MarkDirty(post.ID)
+	}
+	InvalidatePost(post.ID)
+}
+
+func (BlogEvents) PostUpdated(post models.Post) {
+	InvalidatePost(post.ID)
+}
+
+func (BlogEvents) PostDeleted(post models.Post) {
+	lifecycle.Go(func() { ranking.Remove(post.ID) })
+	related.MarkDirty(post.ID)
+	InvalidatePost(post.ID)
+	cache.Del(CommentsKey(post.ID))
+}
+
+// PostRestored 文章从回收站恢复，重新加入排行和相关文章，不再触发动态和 webhook
+func (BlogEvents) PostRestored(post models.Post) {
+	if !post.IsDraft {
+		lifecycle.Go(func() { ranking.Add(post) })
+	}
+	related.MarkDirty(post.ID)
+	InvalidatePost(post.ID)
+}
+
+func (BlogEvents) CommentCreated(comment models.Comment) {
+	telemetry.CommentsCreated.Inc()
+	cache.Del(CommentsKey(comment.PostID))
+	lifecycle.Go(func() { ranking.Bump(comment.PostID, ranking.WeightComment) })
+	lifecycle.Go(func() { webhook.Publish(webhook.EventCommentCreated, comment) })
+}
+
+func (BlogEvents) CommentUpdated(comment models.Comment) {
+	cache.Del(CommentsKey(comment.PostID))
+}
+
+func (BlogEvents) CommentDeleted(comment models.Comment) {
+	cache.Del(CommentsKey(comment.PostID))
+}
+
+func (BlogEvents) LikeCreated(like models.Like) {
+	telemetry.LikesCreated.WithLabelValues(like.TargetType).Inc()
+	cache.Del(LikeCountKey(like.TargetType, like.TargetID))
+	if like.TargetType == "post" {
+		cache.Del(PostKey(like.TargetID))
+		lifecycle.Go(func() { ranking.Bump(like.TargetID, ranking.WeightLike) })
+		related.MarkDirty(like.TargetID)
+	}
+	lifecycle.Go(func() { webhook.Publish(webhook.EventLikeCreated, like) })
+}
+
+// TagChanged 标签改名或删除后，文章详情和列表中的标签都已过期。
+// 相关文章按标签 ID 计算，改名不影响；删除标签后的变化在相关文章缓存过期后生效
+func (BlogEvents) TagChanged(tag models.Tag) {
+	cache.Del(TagPostsKey(tag.Name))
+	cache.DelPrefix(postPrefix, postListPrefix)
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"goblog/models"
+	"goblog/pkg/apperror"
+	"goblog/repository"
+)
+
+type LikeService struct {
+	likes  repository.LikeRepository
+	events Events
+}
+
+// Like 点赞，已经点过赞时返回 false
+func (s *LikeService) Like(ctx context.Context, userID, targetID uint, targetType string) (bool, error) {
+	liked, err := s.likes.Exists(ctx, userID, targetID, targetType)
+	if err != nil {
+		return false, apperror.Internal(err)
+	}
+	if liked {
+		return false, nil
+	}
+
+	like := models.Like{
+		UserID:     userID,
+		TargetID:   targetID,
+		TargetType: targetType,
+	}
+	if err := s.likes.Create(ctx, &like); err != nil {
+		return false, apperror.Internal(err)
+	}
+	s.events.LikeCreated(like)
+	return true, nil
+}
+
+func (s *LikeService) Count(ctx context.Context, targetID uint, targetType string) (int64, error) {
+	count, err := s.likes.Count(ctx, targetID, targetType)
+	if err != nil {
+		return 0, apperror.Internal(err)
+	}
+	return count, nil
+}
+
+func (s *LikeService) Liked(ctx context.Context, userID, targetID uint, targetType string) (bool, error) {
+	liked, err := s.likes.Exists(ctx, userID, targetID, targetType)
+	if err != nil {
+		return false, apperror.Internal(err)
+	}
+	return liked, nil
+}
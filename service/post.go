@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"goblog/models"
+	"goblog/pkg/apperror"
+	"goblog/repository"
+)
+
+// PostExtras 填充核心仓储之外的文章数据：收藏数、浏览量和合著者
+type PostExtras interface {
+	FillPostExtras(ctx context.Context, posts []models.Post) error
+}
+
+type PostService struct {
+	posts     repository.PostRepository
+	likes     repository.LikeRepository
+	tags      repository.TagRepository
+	events    Events
+	extras    PostExtras
+	editorial bool
+}
+
+type NewPost struct {
+	Title   string
+	Content string
+	IsDraft bool
+	Tags    []string
+}
+
+// Create 创建文章，开启审稿流程时一律保存为草稿
+func (s *PostService) Create(ctx context.Context, userID uint, input NewPost) (models.Post, error) {
+	var tags []*models.Tag
+	for _, name := range input.Tags {
+		tag, err := s.tags.FindOrCreate(ctx, name)
+		if err == nil {
+			tags = append(tags, &tag)
+		}
+	}
+
+	post := models.Post{
+		Title:   input.Title,
+		Content: input.Content,
+		UserID:  userID,
+		Tags:    tags,
+	}
+	if input.IsDraft || s.editorial {
+		post.SetStatus(models.PostDraft)
+	} else {
+		post.SetStatus(models.PostPublished)
+	}
+
+	if err := s.posts.Create(ctx, &post); err != nil {
+		return models.Post{}, apperror.Internal(err)
+	}
+	s.events.PostCreated(post)
+	return post, nil
+}
+
+// Find 返回文章，不填充点赞数等统计数据
+func (s *PostService) Find(ctx context.Context, id uint) (models.Post, error) {
+	post, err := s.posts.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return models.Post{}, apperror.NotFound(apperror.CodePostNotFound)
+	}
+	if err != nil {
+		return models.Post{}, apperror.Internal(err)
+	}
+	return post, nil
+}
+
+// Get 返回带统计数据的文章详情
+func (s *PostService) Get(ctx context.Context, id uint) (models.Post, error) {
+	post, err := s.Find(ctx, id)
+	if err != nil {
+		return post, err
+	}
+	posts := []models.Post{post}
+	if err := s.FillDetails(ctx, posts); err != nil {
+		return models.Post{}, err
+	}
+	return posts[0], nil
+}
+
+func (s *PostService) List(ctx context.Context, query repository.PostQuery) ([]models.Post, error) {
+	posts, err := s.posts.List(ctx, query)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	return posts, nil
+}
+
+// FindPublished 按 ids 的顺序返回其中已发布的文章，已删除或撤回的文章被跳过
+func (s *PostService) FindPublished(ctx context.Context, ids []uint) ([]models.Post, error) {
+	if len(ids) == 0 {
+		return []models.Post{}, nil
+	}
+	found, err := s.posts.FindPublished(ctx, ids)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	byID := make(map[uint]models.Post, len(found))
+	for _, post := range found {
+		byID[post.ID] = post
+	}
+
+	posts := make([]models.Post, 0, len(ids))
+	for _, id := range ids {
+		if post, ok := byID[id]; ok {
+			posts = append(posts, post)
+		}
+	}
+	return posts, nil
+}
+
+func (s *PostService) PinnedIDs(ctx context.Context) ([]uint, error) {
+	ids, err := s.posts.PinnedIDs(ctx)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	return ids, nil
+}
+
+// Update 修改文章的部分字段，权限和审稿状态由调用方检查
+func (s *PostService) Update(ctx context.Context, post *models.Post, fields map[string]any) error {
+	if err := s.posts.Update(ctx, post, fields); err != nil {
+		return apperror.Internal(err)
+	}
+	s.events.PostUpdated(*post)
+	return nil
+}
+
+// Delete 删除文章，只有作者本人可以删除，返回被删除的文章
+func (s *PostService) Delete(ctx context.Context, id, userID uint) (models.Post, error) {
+	post, err := s.Find(ctx, id)
+	if err != nil {
+		return post, err
+	}
+	if post.UserID != userID {
+		return post, apperror.Forbidden(apperror.CodePostDeleteDenied)
+	}
+	if err := s.posts.Delete(ctx, &post); err != nil {
+		return post, apperror.Internal(err)
+	}
+	s.events.PostDeleted(post)
+	return post, nil
+}
+
+// DeleteAny 供管理员删除任意文章，返回被删除的文章
+func (s *PostService) DeleteAny(ctx context.Context, id uint) (models.Post, error) {
+	post, err := s.Find(ctx, id)
+	if err != nil {
+		return post, err
+	}
+	if err := s.posts.Delete(ctx, &post); err != nil {
+		return post, apperror.Internal(err)
+	}
+	s.events.PostDeleted(post)
+	return post, nil
+}
+
+// FillDetails 批量填充文章的作者列表、点赞数、收藏数和浏览量
+func (s *PostService) FillDetails(ctx context.Context, posts []models.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+	likeCounts, err := s.likes.CountByTargets(ctx, "post", ids)
+	if err != nil {
+		return apperror.Internal(err)
+	}
+	for i := range posts {
+		posts[i].Authors = []models.User{posts[i].User}
+		posts[i].LikeCount = likeCounts[posts[i].ID]
+	}
+
+	if s.extras != nil {
+		if err := s.extras.FillPostExtras(ctx, posts); err != nil {
+			return apperror.Internal(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+// Package service 实现用户、文章、评论、点赞和标签的业务规则。
+// 服务只依赖 repository 接口，在 main 中注入数据库实现，测试时可以换成 repository/memory。
+package service
+
+import (
+	"goblog/models"
+	"goblog/repository"
+	"time"
+)
+
+type Services struct {
+	Users    *UserService
+	Posts    *PostService
+	Comments *CommentService
+	Likes    *LikeService
+	Tags     *TagService
+}
+
+// Options 是服务的配置和可选依赖，Events 和 Extras 为空时不触发事件，也不填充收藏数等附加数据
+type Options struct {
+	JWTSecret string
+	JWTExpire time.Duration
+	// EditorialWorkflow 开启后新文章一律保存为草稿
+	EditorialWorkflow bool
+	Events            Events
+	Extras            PostExtras
+}
+
+func New(repos repository.Repositories, opts Options) *Services {
+	if opts.Events == nil {
+		opts.Events = NopEvents{}
+	}
+	posts := &PostService{posts: repos.Posts, likes: repos.Likes, tags: repos.Tags, events: opts.Events, extras: opts.Extras, editorial: opts.EditorialWorkflow}
+	return &Services{
+		Users:    &UserService{users: repos.Users, secret: opts.JWTSecret, expire: opts.JWTExpire},
+		Posts:    posts,
+		Comments: &CommentService{comments: repos.Comments, events: opts.Events},
+		Likes:    &LikeService{likes: repos.Likes, events: opts.Events},
+		Tags:     &TagService{tags: repos.Tags, posts: posts},
+	}
+}
+
+// Events 接收核心数据的变更，用来更新动态、排行、webhook 和缓存等派生数据。
+// 方法在请求处理的 goroutine 中调用，耗时的工作应自行异步执行
+type Events interface {
+	PostCreated(post models.Post)
+	PostUpdated(post models.Post)
+	PostDeleted(post models.Post)
+	PostRestored(post models.Post)
+	CommentCreated(comment models.Comment)
+	CommentUpdated(comment models.Comment)
+	CommentDeleted(comment models.Comment)
+	LikeCreated(like models.Like)
+	// TagChanged 在标签改名或删除后调用，tag 为修改前的标签
+	TagChanged(tag models.Tag)
+}
+
+type NopEvents struct{}
+
+func (NopEvents) PostCreated(models.Post)       {}
+func (NopEvents) PostUpdated(models.Post)       {}
+func (NopEvents) PostDeleted(models.Post)       {}
+func (NopEvents) PostRestored(models.Post)      {}
+func (NopEvents) CommentCreated(models.Comment) {}
+func (NopEvents) CommentUpdated(models.Comment) {}
+func (NopEvents) CommentDeleted(models.Comment) {}
+func (NopEvents) LikeCreated(models.Like)       {}
+func (NopEvents) TagChanged(models.Tag)         {}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"goblog/models"
+	"goblog/pkg/apperror"
+	"goblog/repository"
+)
+
+type TagService struct {
+	tags  repository.TagRepository
+	posts *PostService
+}
+
+// Posts 返回标签及其下的文章，文章带统计数据
+func (s *TagService) Posts(ctx context.Context, name string) (models.Tag, []models.Post, error) {
+	tag, err := s.tags.FindByName(ctx, name)
+	if errors.Is(err, repository.ErrNotFound) {
+		return tag, nil, apperror.NotFound(apperror.CodeTagNotFound)
+	}
+	if err != nil {
+		return tag, nil, apperror.Internal(err)
+	}
+
+	posts, err := s.posts.posts.ListByTag(ctx, tag.ID)
+	if err != nil {
+		return tag, nil, apperror.Internal(err)
+	}
+	if err := s.posts.FillDetails(ctx, posts); err != nil {
+		return tag, nil, err
+	}
+	return tag, posts, nil
+}
+
+// Find 返回标签，不存在时返回 tag.not_found
+func (s *TagService) Find(ctx context.Context, id uint) (models.Tag, error) {
+	tag, err := s.tags.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return tag, apperror.NotFound(apperror.CodeTagNotFound)
+	}
+	if err != nil {
+		return tag, apperror.Internal(err)
+	}
+	return tag, nil
+}
+
+// List 返回名称包含 search 的标签及其文章数
+func (s *TagService) List(ctx context.Context, search string) ([]models.Tag, error) {
+	tags, err := s.tags.List(ctx, search)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	return tags, nil
+}
+
+// Rename 修改标签名，新名称已被其他标签使用时返回 tag.exists。返回改名前的标签
+func (s *TagService) Rename(ctx context.Context, id uint, name string) (models.Tag, error) {
+	tag, err := s.Find(ctx, id)
+	if err != nil {
+		return tag, err
+	}
+	old := tag
+	if other, err := s.tags.FindByName(ctx, name); err == nil && other.ID != id {
+		return old, apperror.Conflict(apperror.CodeTagExists)
+	} else if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return old, apperror.Internal(err)
+	}
+	if err := s.tags.Update(ctx, &tag, map[string]any{"name": name}); err != nil {
+		return old, apperror.Internal(err)
+	}
+	s.posts.events.TagChanged(old)
+	return old, nil
+}
+
+// Delete 删除标签及其文章关联，返回被删除的标签
+func (s *TagService) Delete(ctx context.Context, id uint) (models.Tag, error) {
+	tag, err := s.Find(ctx, id)
+	if err != nil {
+		return tag, err
+	}
+	if err := s.tags.Delete(ctx, &tag); err != nil {
+		return tag, apperror.Internal(err)
+	}
+	s.posts.events.TagChanged(tag)
+	return tag, nil
+}
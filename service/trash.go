@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"goblog/models"
+	"goblog/pkg/apperror"
+	"goblog/pkg/audit"
+	"goblog/repository"
+	"log/slog"
+	"time"
+)
+
+// TrashPurgeInterval 是检查回收站过期文章的间隔
+const TrashPurgeInterval = time.Hour
+
+// purgeBatch 是每次从回收站取出的过期文章数
+const purgeBatch = 100
+
+// Trash 返回作者回收站中的文章
+func (s *PostService) Trash(ctx context.Context, userID uint) ([]models.Post, error) {
+	posts, err := s.posts.ListTrashed(ctx, userID)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	return posts, nil
+}
+
+// FindTrashed 返回回收站中的文章，不在回收站中时返回 post.not_in_trash
+func (s *PostService) FindTrashed(ctx context.Context, id uint) (models.Post, error) {
+	post, err := s.posts.FindTrashed(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return post, apperror.NotFound(apperror.CodeNotInTrash)
+	}
+	if err != nil {
+		return post, apperror.Internal(err)
+	}
+	return post, nil
+}
+
+// Restore 把文章移出回收站，只有作者本人可以恢复
+func (s *PostService) Restore(ctx context.Context, id, userID uint) (models.Post, error) {
+	post, err := s.FindTrashed(ctx, id)
+	if err != nil {
+		return post, err
+	}
+	if post.UserID != userID {
+		return post, apperror.Forbidden(apperror.CodeRestoreDenied)
+	}
+	if err := s.posts.Restore(ctx, &post); err != nil {
+		return post, apperror.Internal(err)
+	}
+	s.events.PostRestored(post)
+	return post, nil
+}
+
+// Purge 永久删除回收站中的文章，权限由调用方检查，返回被删除的文章
+func (s *PostService) Purge(ctx context.Context, id uint) (models.Post, error) {
+	post, err := s.FindTrashed(ctx, id)
+	if err != nil {
+		return post, err
+	}
+	if err := s.posts.Purge(ctx, &post); err != nil {
+		return post, apperror.Internal(err)
+	}
+	return post, nil
+}
+
+// PurgeExpired 永久删除在 before 之前进入回收站的文章，返回已删除的文章。
+// 出错时返回出错前已删除的文章
+func (s *PostService) PurgeExpired(ctx context.Context, before time.Time) ([]models.Post, error) {
+	var purged []models.Post
+	for {
+		posts, err := s.posts.ExpiredTrash(ctx, before, purgeBatch)
+		if err != nil {
+			return purged, err
+		}
+		for _, post := range posts {
+			if err := s.posts.Purge(ctx, &post); err != nil {
+				return purged, err
+			}
+			purged = append(purged, post)
+		}
+		if len(posts) < purgeBatch {
+			return purged, nil
+		}
+	}
+}
+
+// RunTrashPurger 每隔 TrashPurgeInterval 永久删除在回收站中超过 retention 的文章，并写入审计日志。
+// retention 为 0 时不清理
+func RunTrashPurger(ctx context.Context, posts *PostService, retention time.Duration) {
+	if retention == 0 {
+		return
+	}
+	ticker := time.NewTicker(TrashPurgeInterval)
+	defer ticker.Stop()
+	for {
+		purged, err := posts.PurgeExpired(ctx, time.Now().Add(-retention))
+		for _, post := range purged {
+			audit.Record(ctx, audit.Entry{Action: audit.ActionPostPurge, TargetType: "post", TargetID: post.ID, Before: post})
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "清理回收站失败", "err", err)
+		} else if len(purged) > 0 {
+			slog.InfoContext(ctx, "已清理回收站中过期的文章", "count", len(purged))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"goblog/models"
+	"goblog/pkg/apperror"
+	"goblog/repository"
+	"goblog/utils"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+type UserService struct {
+	users  repository.UserRepository
+	secret string
+	expire time.Duration
+}
+
+// Register 创建用户，用户名或邮箱已被占用时返回 user.exists
+func (s *UserService) Register(ctx context.Context, username, email, password string) (models.User, error) {
+	exists, err := s.users.ExistsByUsernameOrEmail(ctx, username, email)
+	if err != nil {
+		return models.User{}, apperror.Internal(err)
+	}
+	if exists {
+		return models.User{}, apperror.Conflict(apperror.CodeUserExists)
+	}
+
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return models.User{}, apperror.Internal(err)
+	}
+
+	user := models.User{
+		Username: username,
+		Email:    email,
+		Password: hashedPassword,
+	}
+	if err := s.users.Create(ctx, &user); err != nil {
+		return models.User{}, apperror.Internal(err)
+	}
+	return user, nil
+}
+
+// Login 校验邮箱和密码，返回签名后的 JWT。邮箱存在时即使登录失败也返回对应的用户，供调用方记录审计日志
+func (s *UserService) Login(ctx context.Context, email, password string) (models.User, string, error) {
+	user, err := s.users.FindByEmail(ctx, email)
+	if errors.Is(err, repository.ErrNotFound) {
+		return models.User{}, "", apperror.Unauthorized(apperror.CodeUserNotFound)
+	}
+	if err != nil {
+		return models.User{}, "", apperror.Internal(err)
+	}
+
+	if !utils.CheckPasswordHash(password, user.Password) {
+		return user, "", apperror.Unauthorized(apperror.CodeWrongPassword)
+	}
+	if user.BannedAt != nil {
+		return user, "", apperror.Forbidden(apperror.CodeUserBanned)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": user.ID,
+		"exp":     time.Now().Add(s.expire).Unix(),
+	})
+	tokenString, err := token.SignedString([]byte(s.secret))
+	if err != nil {
+		return user, "", apperror.Internal(err)
+	}
+	return user, tokenString, nil
+}
+
+// Role 返回用户的站点角色，用户不存在时返回空字符串
+func (s *UserService) Role(ctx context.Context, userID uint) string {
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return ""
+	}
+	return user.Role
+}
+
+// Get 返回用户，不存在时返回 user.not_found
+func (s *UserService) Get(ctx context.Context, id uint) (models.User, error) {
+	user, err := s.users.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return user, apperror.NotFound(apperror.CodeUserNotExist)
+	}
+	if err != nil {
+		return user, apperror.Internal(err)
+	}
+	return user, nil
+}
+
+// List 供管理后台按条件分页查询用户
+func (s *UserService) List(ctx context.Context, query repository.UserQuery) ([]models.User, int64, error) {
+	users, total, err := s.users.List(ctx, query)
+	if err != nil {
+		return nil, 0, apperror.Internal(err)
+	}
+	return users, total, nil
+}
+
+// SetRole 修改用户的站点角色，管理员不能修改自己的角色，避免站点失去最后一个管理员
+func (s *UserService) SetRole(ctx context.Context, actorID, id uint, role string) (models.User, error) {
+	if actorID == id {
+		return models.User{}, apperror.Forbidden(apperror.CodeChangeOwnRole)
+	}
+	user, err := s.Get(ctx, id)
+	if err != nil {
+		return user, err
+	}
+	if err := s.users.Update(ctx, &user, map[string]any{"role": role}); err != nil {
+		return user, apperror.Internal(err)
+	}
+	return user, nil
+}
+
+// Ban 封禁用户，管理员需要先降级才能封禁。已封禁的用户再次封禁只更新原因
+func (s *UserService) Ban(ctx context.Context, id uint, reason string) (models.User, error) {
+	user, err := s.Get(ctx, id)
+	if err != nil {
+		return user, err
+	}
+	if user.Role == models.RoleAdmin {
+		return user, apperror.Forbidden(apperror.CodeBanAdmin)
+	}
+	fields := map[string]any{"ban_reason": reason}
+	if user.BannedAt == nil {
+		fields["banned_at"] = time.Now()
+	}
+	if err := s.users.Update(ctx, &user, fields); err != nil {
+		return user, apperror.Internal(err)
+	}
+	return user, nil
+}
+
+// Unban 解除封禁
+func (s *UserService) Unban(ctx context.Context, id uint) (models.User, error) {
+	user, err := s.Get(ctx, id)
+	if err != nil {
+		return user, err
+	}
+	if err := s.users.Update(ctx, &user, map[string]any{"banned_at": nil, "ban_reason": ""}); err != nil {
+		return user, apperror.Internal(err)
+	}
+	return user, nil
+}